@@ -1,6 +1,7 @@
 package accountinformation
 
 import (
+	"app/blobs"
 	"app/bus"
 	"app/primitives"
 	"app/utils"
@@ -24,22 +25,33 @@ type DocumentsFromBusConsumer struct {
 	handler                  eh.CommandHandler
 	monetaryAccountIDFetcher MonetaryAccountIDFetcher
 	TransactionIDFetcher     TransactionIDFetcher
+	accountChannel           <-chan bus.MonetaryAccountDocument
+	transactionChannel       <-chan bus.TransactionDocument
 }
 
+// NewDocumentsFromBusConsumer subscribes to the accounts and transactions on the bus, Start consumes them until the bus is closed
 func NewDocumentsFromBusConsumer(
 	ctx context.Context,
 	handler eh.CommandHandler,
 	monetaryAccountIDFetcher MonetaryAccountIDFetcher,
 	transactionIDFetcher TransactionIDFetcher,
+	subscriber bus.Subscriber,
 ) DocumentsConsumer {
-	return DocumentsFromBusConsumer{context: ctx, handler: handler, monetaryAccountIDFetcher: monetaryAccountIDFetcher, TransactionIDFetcher: transactionIDFetcher}
+	return DocumentsFromBusConsumer{
+		context:                  ctx,
+		handler:                  handler,
+		monetaryAccountIDFetcher: monetaryAccountIDFetcher,
+		TransactionIDFetcher:     transactionIDFetcher,
+		accountChannel:           subscriber.SubscribeAccounts(bus.DefaultSubscriptionOptions),
+		transactionChannel:       subscriber.SubscribeTransactions(bus.DefaultSubscriptionOptions),
+	}
 }
 
 func (consumer DocumentsFromBusConsumer) Start() {
 	wg := sync.WaitGroup{}
 
-	accountChannel := bus.AccountChannelForReading()
-	transactionChannel := bus.TransactionChannelForReading()
+	accountChannel := consumer.accountChannel
+	transactionChannel := consumer.transactionChannel
 
 	for {
 		select {
@@ -48,7 +60,10 @@ func (consumer DocumentsFromBusConsumer) Start() {
 				accountChannel = nil
 			} else {
 				wg.Add(1)
-				go consumer.handleAccountDocument(&wg, document)
+				go func() {
+					defer wg.Done()
+					consumer.handleAccountDocument(consumer.context, document)
+				}()
 			}
 
 		case document, ok := <-transactionChannel:
@@ -56,7 +71,10 @@ func (consumer DocumentsFromBusConsumer) Start() {
 				transactionChannel = nil
 			} else {
 				wg.Add(1)
-				go consumer.handleTransactionDocument(&wg, document)
+				go func() {
+					defer wg.Done()
+					consumer.handleTransactionDocument(consumer.context, document)
+				}()
 			}
 		}
 
@@ -68,37 +86,81 @@ func (consumer DocumentsFromBusConsumer) Start() {
 	wg.Wait()
 }
 
-func (consumer DocumentsFromBusConsumer) handleAccountDocument(wg *sync.WaitGroup, document bus.MonetaryAccountDocument) {
-	defer wg.Done()
+// DocumentsFromDurableBusName is the name the consumer of a durable bus acknowledges messages under
+const DocumentsFromDurableBusName = "account-information"
+
+// DocumentsFromDurableBusConsumer consumes the accounts and transactions of a durable bus one by one,
+// a document is acknowledged when its commands are handled
+type DocumentsFromDurableBusConsumer struct {
+	DocumentsFromBusConsumer
+	consumer bus.Consumer
+}
+
+// NewDocumentsFromDurableBusConsumer creates a consumer that continues with the documents it did not acknowledge yet
+func NewDocumentsFromDurableBusConsumer(
+	ctx context.Context,
+	handler eh.CommandHandler,
+	monetaryAccountIDFetcher MonetaryAccountIDFetcher,
+	transactionIDFetcher TransactionIDFetcher,
+	consumer bus.Consumer,
+) DocumentsConsumer {
+	return DocumentsFromDurableBusConsumer{
+		DocumentsFromBusConsumer: DocumentsFromBusConsumer{
+			context:                  ctx,
+			handler:                  handler,
+			monetaryAccountIDFetcher: monetaryAccountIDFetcher,
+			TransactionIDFetcher:     transactionIDFetcher,
+		},
+		consumer: consumer,
+	}
+}
+
+// Start consumes until the context is done or the bus is closed
+func (consumer DocumentsFromDurableBusConsumer) Start() {
+	topics := []bus.Topic{bus.AccountsTopic, bus.TransactionsTopic}
+	if err := consumer.consumer.Consume(consumer.context, DocumentsFromDurableBusName, topics, consumer.handleMessage); err != nil {
+		log.Printf("Stopped consuming documents: %v", err)
+	}
+}
 
+func (consumer DocumentsFromDurableBusConsumer) handleMessage(ctx context.Context, message bus.Message) error {
+	switch document := message.Document.(type) {
+	case bus.MonetaryAccountDocument:
+		return consumer.handleAccountDocument(ctx, document)
+	case bus.TransactionDocument:
+		return consumer.handleTransactionDocument(ctx, document)
+	}
+	return fmt.Errorf("unexpected document on %s", message.Topic)
+}
+
+func (consumer DocumentsFromBusConsumer) handleAccountDocument(ctx context.Context, document bus.MonetaryAccountDocument) error {
 	idOrErrorChan := make(chan MonetaryAccountIDOrError)
 	go consumer.monetaryAccountIDFetcher.FetchID(&document.Iban, &document.Institution, &document.InstitutionEntityID, &document.Alias, idOrErrorChan)
 
 	idOrError := <-idOrErrorChan
 
-	// TODO: propagate this error?
 	if idOrError.err != nil {
 		log.Printf("Could not find ID for monetary account: %v", idOrError.err)
-	} else {
-		monetaryAccountID := idOrError.ID
-		cmd := ProcessMonetaryAccountCommand{
-			MonetaryAccountID:   *monetaryAccountID,
-			Iban:                document.Iban,
-			Joint:               document.Joint,
-			OwnerUserID:         document.OwnerUserID,
-			Alias:               document.Alias,
-			Institution:         document.Institution,
-			InstitutionEntityID: document.InstitutionEntityID,
-			Balance:             primitives.NewMoneyForCommand(document.Balance),
-			FetchTimestamp:      document.FetchTimestamp,
-		}
-		consumer.handleCommand(cmd)
+		return idOrError.err
 	}
-}
 
-func (consumer DocumentsFromBusConsumer) handleTransactionDocument(wg *sync.WaitGroup, document bus.TransactionDocument) {
-	defer wg.Done()
+	monetaryAccountID := idOrError.ID
+	cmd := ProcessMonetaryAccountCommand{
+		MonetaryAccountID:   *monetaryAccountID,
+		Iban:                document.Iban,
+		Joint:               document.Joint,
+		OwnerUserID:         document.OwnerUserID,
+		Alias:               document.Alias,
+		Institution:         document.Institution,
+		InstitutionEntityID: document.InstitutionEntityID,
+		Balance:             primitives.NewMoneyForCommand(document.Balance),
+		FetchTimestamp:      document.FetchTimestamp,
+		Imported:            document.Imported,
+	}
+	return consumer.handleCommand(ctx, cmd)
+}
 
+func (consumer DocumentsFromBusConsumer) handleTransactionDocument(ctx context.Context, document bus.TransactionDocument) error {
 	fromIDOrErrorChan := make(chan MonetaryAccountIDOrError)
 	toIDOrErrorChan := make(chan MonetaryAccountIDOrError)
 	transaactionIDOrErrorChan := make(chan TransactionIDOrError)
@@ -111,59 +173,76 @@ func (consumer DocumentsFromBusConsumer) handleTransactionDocument(wg *sync.Wait
 	toIDOrError := <-toIDOrErrorChan
 	transactionIDOrError := <-transaactionIDOrErrorChan
 
-	// TODO: propagate these errors?
 	if fromIDOrError.err != nil {
 		log.Printf("Could not find ID for monetary account: %v", fromIDOrError.err)
+		return fromIDOrError.err
 	} else if toIDOrError.err != nil {
 		log.Printf("Could not find ID for monetary account: %v", toIDOrError.err)
+		return toIDOrError.err
 	} else if transactionIDOrError.err != nil {
 		log.Printf("Could not find ID for transaction: %v", transactionIDOrError.err)
-	} else {
-		fromMonetaryAccountID := fromIDOrError.ID
-		toMonetaryAccountID := toIDOrError.ID
-		transactionID := transactionIDOrError.ID
-
-		baseCommand := ProcessTransactionDocumentCommand{
-			ID:                    *transactionID,
-			Amount:                primitives.NewMoneyForCommand(document.Amount),
-			From:                  NewTransactionParty(document.FromIBAN, document.FromName),
-			FromMonetaryAccountID: *fromMonetaryAccountID,
-			To:                    NewTransactionParty(document.ToIBAN, document.ToName),
-			ToMonetaryAccountID:   *toMonetaryAccountID,
-			Description:           document.Description,
-			InstitutionScheduleID: utils.EmptyStringOrValue(document.InstitutionScheduleID),
-			IsScheduled:           document.InstitutionScheduleID != nil,
-			BalanceAfterMutation:  primitives.NewMoneyForCommand(document.BalanceAfterMutation),
-			TransactionDate:       document.TransactionDate,
-			FetchTimestamp:        document.FetchTimestamp,
-		}
+		return transactionIDOrError.err
+	}
 
-		if document.FromInstitutionEntityID != nil {
-			baseCommand.InstitutionEntityID = *document.FromInstitutionEntityID
-		} else {
-			baseCommand.InstitutionEntityID = *document.ToInstitionEntityID
-		}
+	fromMonetaryAccountID := fromIDOrError.ID
+	toMonetaryAccountID := toIDOrError.ID
+	transactionID := transactionIDOrError.ID
 
-		cmd1 := ProcessTransactionDocumentCommand{}
-		err := copier.Copy(&cmd1, baseCommand)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		cmd1.MonetaryAccountID = *fromMonetaryAccountID
-		consumer.handleCommand(cmd1)
+	baseCommand := ProcessTransactionDocumentCommand{
+		ID:                    *transactionID,
+		Amount:                primitives.NewMoneyForCommand(document.Amount),
+		From:                  NewTransactionParty(document.FromIBAN, document.FromName),
+		FromMonetaryAccountID: *fromMonetaryAccountID,
+		To:                    NewTransactionParty(document.ToIBAN, document.ToName),
+		ToMonetaryAccountID:   *toMonetaryAccountID,
+		Description:           document.Description,
+		InstitutionScheduleID: utils.EmptyStringOrValue(document.InstitutionScheduleID),
+		IsScheduled:           document.InstitutionScheduleID != nil,
+		BalanceAfterMutation:  primitives.NewMoneyForCommand(document.BalanceAfterMutation),
+		TransactionDate:       document.TransactionDate,
+		FetchTimestamp:        document.FetchTimestamp,
+	}
+	if document.Geolocation != nil {
+		baseCommand.Geolocation = Geolocation(*document.Geolocation)
+		baseCommand.HasGeolocation = true
+	}
+	for _, attachment := range document.Attachments {
+		baseCommand.Attachments = append(baseCommand.Attachments, TransactionAttachment{
+			Digest:      blobs.Digest(attachment.Digest),
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		})
+	}
 
-		cmd2 := ProcessTransactionDocumentCommand{}
-		copier.Copy(&cmd2, baseCommand)
-		cmd2.MonetaryAccountID = *toMonetaryAccountID
-		consumer.handleCommand(cmd2)
+	if document.FromInstitutionEntityID != nil {
+		baseCommand.InstitutionEntityID = *document.FromInstitutionEntityID
+	} else {
+		baseCommand.InstitutionEntityID = *document.ToInstitionEntityID
 	}
+
+	cmd1 := ProcessTransactionDocumentCommand{}
+	if err := copier.Copy(&cmd1, baseCommand); err != nil {
+		log.Printf("Could not copy transaction command: %v", err)
+		return err
+	}
+	cmd1.MonetaryAccountID = *fromMonetaryAccountID
+	if err := consumer.handleCommand(ctx, cmd1); err != nil {
+		return err
+	}
+
+	cmd2 := ProcessTransactionDocumentCommand{}
+	copier.Copy(&cmd2, baseCommand)
+	cmd2.MonetaryAccountID = *toMonetaryAccountID
+	return consumer.handleCommand(ctx, cmd2)
 }
 
-func (consumer DocumentsFromBusConsumer) handleCommand(cmd eh.Command) {
-	if err := consumer.handler.HandleCommand(consumer.context, cmd); err != nil {
+func (consumer DocumentsFromBusConsumer) handleCommand(ctx context.Context, cmd eh.Command) error {
+	err := consumer.handler.HandleCommand(ctx, cmd)
+	if err != nil {
 		handleCommandError(cmd, err)
 	}
+	return err
 }
 
 func handleCommandError(cmd eh.Command, err error) {
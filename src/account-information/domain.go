@@ -1,9 +1,12 @@
 package accountinformation
 
 import (
+	"app/blobs"
 	"app/primitives"
 	"app/utils"
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/Rhymond/go-money"
@@ -29,12 +32,63 @@ func NewTransactionParty(iban *iban.IBAN, name *string) TransactionParty {
 	}
 }
 
+// Geolocation where a card payment was made, the radius is the accuracy in meters
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	Radius    float64
+}
+
 // Transaction base transaction
 type Transaction struct {
-	from            TransactionParty
-	to              TransactionParty
-	amount          money.Money
-	transactionTime time.Time
+	from                TransactionParty
+	to                  TransactionParty
+	amount              money.Money
+	description         string
+	transactionTime     time.Time
+	category            primitives.Category
+	manuallyCategorised bool
+	transferID          primitives.TransferID
+	internalTransfer    bool
+	note                string
+	geolocation         Geolocation
+	hasGeolocation      bool
+	attachments         []TransactionAttachment
+	tags                []string
+	splitParts          []TransactionSplitPart
+}
+
+// TransactionSplitPart is the part of the amount of a split transaction that belongs to a category or to a person,
+// like the share of a friend in a shared dinner. Without a category the category of the transaction applies
+type TransactionSplitPart struct {
+	Category primitives.Category
+	Person   string
+	Amount   money.Money
+}
+
+// TransactionSplitPartForCommand is a TransactionSplitPart in a command
+type TransactionSplitPartForCommand struct {
+	Category primitives.Category
+	Person   string
+	Amount   primitives.MoneyForCommand
+}
+
+// TransactionAttachment is a file attached to a transaction, like a receipt. The content is in the blob store
+type TransactionAttachment struct {
+	Digest      blobs.Digest
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+func (transaction Transaction) hasAttachment(digest blobs.Digest) bool {
+	for _, attachment := range transaction.attachments {
+		if attachment.Digest == digest {
+			return true
+		}
+	}
+	return false
 }
 
 // NewTransaction constructs a Transaction
@@ -53,7 +107,9 @@ type monetaryAccountDetails struct {
 	joint       bool
 	institution primitives.Institution
 	alias       string
-	currency    money.Currency
+	// localAlias is the alias the user gave the monetary account, the alias of the institution does not overwrite it
+	localAlias string
+	currency   money.Currency
 }
 
 type balanceHistory struct {
@@ -67,6 +123,7 @@ type MonetaryAccountState struct {
 	Transactions   map[primitives.TransactionID]Transaction
 	BalanceHistory []balanceHistory
 	Owners         map[primitives.UserID]primitives.UserID
+	Shares         map[primitives.UserID]int
 }
 
 func EmptyMonetaryAccountState(ID primitives.MonetaryAccountID) *MonetaryAccountState {
@@ -76,9 +133,20 @@ func EmptyMonetaryAccountState(ID primitives.MonetaryAccountID) *MonetaryAccount
 	res.Transactions = make(map[primitives.TransactionID]Transaction, 0)
 	res.BalanceHistory = make([]balanceHistory, 0)
 	res.Owners = make(map[primitives.UserID]primitives.UserID)
+	res.Shares = make(map[primitives.UserID]int)
 	return res
 }
 
+// balanceAt is the last balance snapshotted at or before the timestamp
+func (state *MonetaryAccountState) balanceAt(timestamp time.Time) (balanceHistory, bool) {
+	for i := len(state.BalanceHistory) - 1; i >= 0; i-- {
+		if !state.BalanceHistory[i].timestamp.After(timestamp) {
+			return state.BalanceHistory[i], true
+		}
+	}
+	return balanceHistory{}, false
+}
+
 type MonetaryAccountEvent interface {
 	appliedTo(state *MonetaryAccountState) *MonetaryAccountState
 }
@@ -87,6 +155,21 @@ type MonetaryAccountCommand interface {
 	applyTo(state *MonetaryAccountState) []MonetaryAccountEvent
 }
 
+// validatedCommand is a command that is rejected when it does not apply to the state, instead of being ignored
+type validatedCommand interface {
+	validate(state *MonetaryAccountState) error
+}
+
+func validateTransaction(state *MonetaryAccountState, transactionID primitives.TransactionID) error {
+	if state == nil || !state.Details.initialized {
+		return primitives.NewValidationError("MonetaryAccountID", "unknown monetary account")
+	}
+	if _, hasTransaction := state.Transactions[transactionID]; !hasTransaction {
+		return primitives.NewValidationError("TransactionID", "unknown transaction")
+	}
+	return nil
+}
+
 type ProcessMonetaryAccountCommand struct {
 	MonetaryAccountID   primitives.MonetaryAccountID
 	Iban                iban.IBAN
@@ -97,6 +180,19 @@ type ProcessMonetaryAccountCommand struct {
 	InstitutionEntityID string
 	Balance             primitives.MoneyForCommand
 	FetchTimestamp      time.Time
+	Imported            bool
+}
+
+// validate rejects an imported account that exists and is not owned by the importing user,
+// otherwise a statement with the IBAN of someone else would make the importer an owner
+func (cmd ProcessMonetaryAccountCommand) validate(state *MonetaryAccountState) error {
+	if !cmd.Imported || state == nil || !state.Details.initialized {
+		return nil
+	}
+	if _, hasOwner := state.Owners[cmd.OwnerUserID]; !hasOwner {
+		return primitives.NewValidationError("Iban", "imported account is owned by another user")
+	}
+	return nil
 }
 
 func (cmd ProcessMonetaryAccountCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
@@ -119,8 +215,9 @@ func (cmd ProcessMonetaryAccountCommand) applyTo(state *MonetaryAccountState) []
 		events = append(events, newMonetaryAccountBecameJoint(cmd))
 	}
 
-	lastBalance := state.BalanceHistory[len(state.BalanceHistory)-1]
-	if lastBalance.balance.Amount() != cmd.Balance.Amount || lastBalance.timestamp.Add(time.Hour*1).Before(cmd.FetchTimestamp) {
+	// imported balances can be older than the last balance, so compare with the balance at the time of the command
+	balance, hasBalance := state.balanceAt(cmd.FetchTimestamp)
+	if !hasBalance || balance.balance.Amount() != cmd.Balance.Amount || balance.timestamp.Add(time.Hour*1).Before(cmd.FetchTimestamp) {
 		events = append(events, newBalanceHistorySnapshotted(cmd))
 	}
 
@@ -150,6 +247,9 @@ type ProcessTransactionDocumentCommand struct {
 	InstitutionScheduleID string `eh:"optional"`
 	IsScheduled           bool
 	BalanceAfterMutation  primitives.MoneyForCommand
+	Geolocation           Geolocation             `eh:"optional"`
+	HasGeolocation        bool                    `eh:"optional"`
+	Attachments           []TransactionAttachment `eh:"optional"`
 	TransactionDate       time.Time
 	FetchTimestamp        time.Time
 }
@@ -161,13 +261,354 @@ func (cmd ProcessTransactionDocumentCommand) applyTo(state *MonetaryAccountState
 
 	var events []MonetaryAccountEvent
 
-	_, hasTransaction := state.Transactions[cmd.ID]
+	transaction, hasTransaction := state.Transactions[cmd.ID]
 	if !hasTransaction {
 		events = append(events, newNewTransactionFound(cmd))
 	}
+
+	// attachments of the institution can be added after the transaction was found
+	added := make(map[blobs.Digest]bool)
+	for _, attachment := range cmd.Attachments {
+		if !transaction.hasAttachment(attachment.Digest) && !added[attachment.Digest] {
+			added[attachment.Digest] = true
+			events = append(events, newTransactionAttachmentAdded(cmd.MonetaryAccountID, cmd.ID, attachment))
+		}
+	}
 	return events
 }
 
+type CategoriseTransactionCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Category          primitives.Category
+	RuleID            primitives.CategorisationRuleID `eh:"optional"`
+}
+
+func (cmd CategoriseTransactionCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || transaction.manuallyCategorised || transaction.category == cmd.Category {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionCategorised(cmd.MonetaryAccountID, cmd.TransactionID, cmd.Category, cmd.RuleID, false)}
+}
+
+type OverrideTransactionCategoryCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Category          primitives.Category
+}
+
+func (cmd OverrideTransactionCategoryCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || (transaction.manuallyCategorised && transaction.category == cmd.Category) {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionCategorised(cmd.MonetaryAccountID, cmd.TransactionID, cmd.Category, primitives.CategorisationRuleID{}, true)}
+}
+
+func (cmd OverrideTransactionCategoryCommand) validate(state *MonetaryAccountState) error {
+	return validateTransaction(state, cmd.TransactionID)
+}
+
+// AddTransactionAttachmentCommand attaches a file that is in the blob store to a transaction
+type AddTransactionAttachmentCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Digest            blobs.Digest
+	FileName          string `eh:"optional"`
+	ContentType       string
+	Size              int64 `eh:"optional"`
+}
+
+func (cmd AddTransactionAttachmentCommand) validate(state *MonetaryAccountState) error {
+	if _, err := blobs.ParseDigest(string(cmd.Digest)); err != nil {
+		return primitives.NewValidationError("Digest", err.Error())
+	}
+	return validateTransaction(state, cmd.TransactionID)
+}
+
+func (cmd AddTransactionAttachmentCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || transaction.hasAttachment(cmd.Digest) {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionAttachmentAdded(cmd.MonetaryAccountID, cmd.TransactionID, TransactionAttachment{
+		Digest:      cmd.Digest,
+		FileName:    cmd.FileName,
+		ContentType: cmd.ContentType,
+		Size:        cmd.Size,
+	})}
+}
+
+// SetTransactionNoteCommand sets the note of the user on a transaction, an empty note removes it
+type SetTransactionNoteCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Note              string `eh:"optional"`
+}
+
+func (cmd SetTransactionNoteCommand) validate(state *MonetaryAccountState) error {
+	return validateTransaction(state, cmd.TransactionID)
+}
+
+func (cmd SetTransactionNoteCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || transaction.note == cmd.Note {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionNoteSet(cmd)}
+}
+
+// maxTagLength is the longest tag a user can put on a transaction
+const maxTagLength = 50
+
+// normalisedTags are the tags without surrounding whitespace, in lower case, sorted and without duplicates
+func normalisedTags(tags []string) []string {
+	seen := make(map[string]bool)
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			res = append(res, tag)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func equalTags(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTransactionTagsCommand sets the tags of the user on a transaction, without tags the tags are removed
+type SetTransactionTagsCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Tags              []string `eh:"optional"`
+}
+
+func (cmd SetTransactionTagsCommand) validate(state *MonetaryAccountState) error {
+	for _, tag := range normalisedTags(cmd.Tags) {
+		if len(tag) > maxTagLength {
+			return primitives.NewValidationError("Tags", fmt.Sprintf("tag %s is longer than %d characters", tag, maxTagLength))
+		}
+	}
+	return validateTransaction(state, cmd.TransactionID)
+}
+
+func (cmd SetTransactionTagsCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	tags := normalisedTags(cmd.Tags)
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || equalTags(transaction.tags, tags) {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionTagsSet(cmd.MonetaryAccountID, cmd.TransactionID, tags)}
+}
+
+// SplitTransactionCommand splits a transaction into parts per category or person, the amounts of the parts
+// sum to the amount of the transaction. Without parts the split is removed
+type SplitTransactionCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Parts             []TransactionSplitPartForCommand `eh:"optional"`
+}
+
+func (cmd SplitTransactionCommand) validate(state *MonetaryAccountState) error {
+	if err := validateTransaction(state, cmd.TransactionID); err != nil {
+		return err
+	}
+	if len(cmd.Parts) == 0 {
+		return nil
+	}
+	if len(cmd.Parts) == 1 {
+		return primitives.NewValidationError("Parts", "a split has at least two parts")
+	}
+
+	transactionAmount := state.Transactions[cmd.TransactionID].amount
+	amount := transactionAmount.Absolute()
+	total := money.New(0, amount.Currency().Code)
+	for _, part := range cmd.Parts {
+		partAmount := part.Amount.ToMoney()
+		if !partAmount.SameCurrency(amount) {
+			return primitives.NewValidationError("Parts", fmt.Sprintf("amount of a part is not in %s, the currency of the transaction", amount.Currency().Code))
+		}
+		if !partAmount.IsPositive() {
+			return primitives.NewValidationError("Parts", "amount of a part is not positive")
+		}
+		if part.Category == "" && strings.TrimSpace(part.Person) == "" {
+			return primitives.NewValidationError("Parts", "a part has a category or a person")
+		}
+		total, _ = total.Add(&partAmount)
+	}
+
+	if equal, _ := total.Equals(amount); !equal {
+		return primitives.NewValidationError("Parts", fmt.Sprintf("parts sum to %s instead of %s", total.Display(), amount.Display()))
+	}
+	return nil
+}
+
+func (cmd SplitTransactionCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction {
+		return nil
+	}
+
+	parts := make([]TransactionSplitPart, 0, len(cmd.Parts))
+	for _, part := range cmd.Parts {
+		parts = append(parts, TransactionSplitPart{
+			Category: part.Category,
+			Person:   strings.TrimSpace(part.Person),
+			Amount:   part.Amount.ToMoney(),
+		})
+	}
+	if equalSplitParts(transaction.splitParts, parts) {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newTransactionSplit(cmd.MonetaryAccountID, cmd.TransactionID, parts)}
+}
+
+func equalSplitParts(a []TransactionSplitPart, b []TransactionSplitPart) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Category != b[i].Category || a[i].Person != b[i].Person {
+			return false
+		}
+		if equal, err := a[i].Amount.Equals(&b[i].Amount); err != nil || !equal {
+			return false
+		}
+	}
+	return true
+}
+
+type MarkInternalTransferCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	TransferID        primitives.TransferID
+}
+
+func (cmd MarkInternalTransferCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	transaction, hasTransaction := state.Transactions[cmd.TransactionID]
+	if !hasTransaction || (transaction.internalTransfer && transaction.transferID == cmd.TransferID) {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newInternalTransferDetected(cmd)}
+}
+
+// SetMonetaryAccountShareCommand sets the percentage of a joint monetary account that belongs to one of its owners
+type SetMonetaryAccountShareCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	UserID            primitives.UserID
+	Percentage        int `eh:"optional"`
+}
+
+func (cmd SetMonetaryAccountShareCommand) validate(state *MonetaryAccountState) error {
+	if state == nil {
+		return primitives.NewValidationError("MonetaryAccountID", "unknown monetary account")
+	}
+	if cmd.Percentage < 0 || cmd.Percentage > 100 {
+		return primitives.NewValidationError("Percentage", "not between 0 and 100")
+	}
+	if _, hasOwner := state.Owners[cmd.UserID]; !hasOwner {
+		return primitives.NewValidationError("UserID", "not an owner of the monetary account")
+	}
+
+	total := cmd.Percentage
+	for userID, percentage := range state.Shares {
+		if userID != cmd.UserID {
+			total += percentage
+		}
+	}
+	if total > 100 {
+		return primitives.NewValidationError("Percentage", fmt.Sprintf("shares of the owners add up to %d percent", total))
+	}
+	return nil
+}
+
+func (cmd SetMonetaryAccountShareCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil {
+		return nil
+	}
+
+	if percentage, hasShare := state.Shares[cmd.UserID]; hasShare && percentage == cmd.Percentage {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newMonetaryAccountShareSet(cmd)}
+}
+
+// maxAliasLength is the longest alias a user can give a monetary account
+const maxAliasLength = 100
+
+// RenameMonetaryAccountCommand gives a monetary account the alias of the user, an empty alias falls back to the alias of the institution
+type RenameMonetaryAccountCommand struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	Alias             string `eh:"optional"`
+}
+
+func (cmd RenameMonetaryAccountCommand) validate(state *MonetaryAccountState) error {
+	if state == nil || !state.Details.initialized {
+		return primitives.NewValidationError("MonetaryAccountID", "unknown monetary account")
+	}
+	if len(cmd.Alias) > maxAliasLength {
+		return primitives.NewValidationError("Alias", fmt.Sprintf("longer than %d characters", maxAliasLength))
+	}
+	return nil
+}
+
+func (cmd RenameMonetaryAccountCommand) applyTo(state *MonetaryAccountState) []MonetaryAccountEvent {
+	if state == nil || state.Details.localAlias == cmd.Alias {
+		return nil
+	}
+
+	return []MonetaryAccountEvent{newMonetaryAccountRenamed(cmd)}
+}
+
 type UpdateBalanceForNonAutomatedAccountCommand struct {
 	MonetaryAccountID primitives.MonetaryAccountID
 }
@@ -178,15 +619,43 @@ func (cmd UpdateBalanceForNonAutomatedAccountCommand) applyTo(state *MonetaryAcc
 }
 
 type NewTransactionFound struct {
-	ID primitives.TransactionID
+	ID                    primitives.TransactionID
+	MonetaryAccountID     primitives.MonetaryAccountID
+	FromMonetaryAccountID primitives.MonetaryAccountID
+	From                  TransactionParty
+	ToMonetaryAccountID   primitives.MonetaryAccountID
+	To                    TransactionParty
+	Amount                money.Money
+	Description           string
+	Geolocation           Geolocation
+	HasGeolocation        bool
+	TransactionDate       time.Time
 }
 
 func newNewTransactionFound(cmd ProcessTransactionDocumentCommand) NewTransactionFound {
 	res := new(NewTransactionFound)
-	// TODO
+	res.ID = cmd.ID
+	res.MonetaryAccountID = cmd.MonetaryAccountID
+	res.FromMonetaryAccountID = cmd.FromMonetaryAccountID
+	res.From = cmd.From
+	res.ToMonetaryAccountID = cmd.ToMonetaryAccountID
+	res.To = cmd.To
+	res.Amount = cmd.Amount.ToMoney()
+	res.Description = cmd.Description
+	res.Geolocation = cmd.Geolocation
+	res.HasGeolocation = cmd.HasGeolocation
+	res.TransactionDate = cmd.TransactionDate
 	return *res
 }
 
+// Counterparty returns the party on the other side of the transaction, as seen from the monetary account
+func (event NewTransactionFound) Counterparty() TransactionParty {
+	if event.MonetaryAccountID == event.FromMonetaryAccountID {
+		return event.To
+	}
+	return event.From
+}
+
 func (event NewTransactionFound) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
 	_, hasTransaction := state.Transactions[event.ID]
 	if hasTransaction {
@@ -196,12 +665,197 @@ func (event NewTransactionFound) appliedTo(state *MonetaryAccountState) *Monetar
 	res := MonetaryAccountState{}
 	copier.Copy(&res, &state)
 
-	// TODO
-	res.Transactions[event.ID] = Transaction{}
+	transaction := NewTransaction(event.From, event.To, event.Amount, event.TransactionDate)
+	transaction.description = event.Description
+	transaction.category = primitives.Uncategorised
+	transaction.geolocation = event.Geolocation
+	transaction.hasGeolocation = event.HasGeolocation
+	res.Transactions[event.ID] = transaction
 
 	return &res
 }
 
+type TransactionCategorised struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	Category      primitives.Category
+	RuleID        primitives.CategorisationRuleID
+	Manual        bool
+}
+
+func newTransactionCategorised(id primitives.MonetaryAccountID, transactionID primitives.TransactionID, category primitives.Category, ruleID primitives.CategorisationRuleID, manual bool) TransactionCategorised {
+	res := new(TransactionCategorised)
+	res.ID = id
+	res.TransactionID = transactionID
+	res.Category = category
+	res.RuleID = ruleID
+	res.Manual = manual
+	return *res
+}
+
+func (event TransactionCategorised) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	transaction.category = event.Category
+	transaction.manuallyCategorised = transaction.manuallyCategorised || event.Manual
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
+// TransactionNoteSet is the note of the user on a transaction
+type TransactionNoteSet struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	Note          string
+}
+
+func newTransactionNoteSet(cmd SetTransactionNoteCommand) TransactionNoteSet {
+	res := new(TransactionNoteSet)
+	res.ID = cmd.MonetaryAccountID
+	res.TransactionID = cmd.TransactionID
+	res.Note = cmd.Note
+	return *res
+}
+
+func (event TransactionNoteSet) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	transaction.note = event.Note
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
+// TransactionAttachmentAdded is a file attached to a transaction
+type TransactionAttachmentAdded struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	Attachment    TransactionAttachment
+}
+
+func newTransactionAttachmentAdded(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID, attachment TransactionAttachment) TransactionAttachmentAdded {
+	res := new(TransactionAttachmentAdded)
+	res.ID = monetaryAccountID
+	res.TransactionID = transactionID
+	res.Attachment = attachment
+	return *res
+}
+
+func (event TransactionAttachmentAdded) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	attachments := make([]TransactionAttachment, 0, len(transaction.attachments)+1)
+	transaction.attachments = append(append(attachments, transaction.attachments...), event.Attachment)
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
+// TransactionTagsSet are the tags of the user on a transaction, normalised to lower case and sorted
+type TransactionTagsSet struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	Tags          []string
+}
+
+func newTransactionTagsSet(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID, tags []string) TransactionTagsSet {
+	res := new(TransactionTagsSet)
+	res.ID = monetaryAccountID
+	res.TransactionID = transactionID
+	res.Tags = tags
+	return *res
+}
+
+func (event TransactionTagsSet) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	transaction.tags = append([]string{}, event.Tags...)
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
+// TransactionSplit are the parts a transaction is split into, without parts the transaction is no longer split
+type TransactionSplit struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	Parts         []TransactionSplitPart
+}
+
+func newTransactionSplit(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID, parts []TransactionSplitPart) TransactionSplit {
+	res := new(TransactionSplit)
+	res.ID = monetaryAccountID
+	res.TransactionID = transactionID
+	res.Parts = parts
+	return *res
+}
+
+func (event TransactionSplit) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	transaction.splitParts = append([]TransactionSplitPart{}, event.Parts...)
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
+// InternalTransferDetected marks a transaction as one leg of a transfer between accounts of the same user,
+// both legs share the TransferID
+type InternalTransferDetected struct {
+	ID            primitives.MonetaryAccountID
+	TransactionID primitives.TransactionID
+	TransferID    primitives.TransferID
+}
+
+func newInternalTransferDetected(cmd MarkInternalTransferCommand) InternalTransferDetected {
+	res := new(InternalTransferDetected)
+	res.ID = cmd.MonetaryAccountID
+	res.TransactionID = cmd.TransactionID
+	res.TransferID = cmd.TransferID
+	return *res
+}
+
+func (event InternalTransferDetected) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	transaction, hasTransaction := state.Transactions[event.TransactionID]
+	if !hasTransaction {
+		return state
+	}
+
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	transaction.transferID = event.TransferID
+	transaction.internalTransfer = true
+	res.Transactions[event.TransactionID] = transaction
+	return &res
+}
+
 type NewMonetaryAccountFound struct {
 	ID           primitives.MonetaryAccountID
 	Iban         iban.IBAN
@@ -292,6 +946,27 @@ func (event MonetaryAccountAliasUpdated) appliedTo(state *MonetaryAccountState)
 	return &res
 }
 
+// MonetaryAccountRenamed is the alias the user gave a monetary account, empty when the alias of the institution is used
+type MonetaryAccountRenamed struct {
+	ID    primitives.MonetaryAccountID
+	Alias string
+}
+
+func newMonetaryAccountRenamed(cmd RenameMonetaryAccountCommand) MonetaryAccountRenamed {
+	res := new(MonetaryAccountRenamed)
+	res.ID = cmd.MonetaryAccountID
+	res.Alias = cmd.Alias
+	return *res
+}
+
+func (event MonetaryAccountRenamed) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	res.Details.localAlias = event.Alias
+	return &res
+}
+
 type MonetaryAccountUserAdded struct {
 	ID     primitives.MonetaryAccountID
 	UserID primitives.UserID
@@ -336,3 +1011,25 @@ func (event MonetaryAccountBalanceSnapshotted) appliedTo(state *MonetaryAccountS
 	})
 	return &res
 }
+
+type MonetaryAccountShareSet struct {
+	ID         primitives.MonetaryAccountID
+	UserID     primitives.UserID
+	Percentage int
+}
+
+func newMonetaryAccountShareSet(cmd SetMonetaryAccountShareCommand) MonetaryAccountShareSet {
+	res := new(MonetaryAccountShareSet)
+	res.ID = cmd.MonetaryAccountID
+	res.UserID = cmd.UserID
+	res.Percentage = cmd.Percentage
+	return *res
+}
+
+func (event MonetaryAccountShareSet) appliedTo(state *MonetaryAccountState) *MonetaryAccountState {
+	res := MonetaryAccountState{}
+	copier.Copy(&res, &state)
+
+	res.Shares[event.UserID] = event.Percentage
+	return &res
+}
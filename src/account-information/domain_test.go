@@ -3,6 +3,7 @@ package accountinformation
 import (
 	"app/primitives"
 	"app/utils"
+	"errors"
 	"testing"
 	"time"
 
@@ -115,3 +116,265 @@ func Test_ProcessMonetaryAccountCommand_Apply_NoChanges(t *testing.T) {
 		t.Errorf("Expected zero event, found %d", len(events))
 	}
 }
+
+func Test_ProcessMonetaryAccountCommand_ImportNeverAddsOwner(t *testing.T) {
+	state := newStateAfter(EmptyMonetaryAccountState(monetaryAccountID), ProcessMonetaryAccountCommand{
+		OwnerUserID: primitives.UserID(uuid.New()),
+		Balance:     primitives.NewMoneyForCommand(*money.New(0, "EUR")),
+	})
+	cmd := ProcessMonetaryAccountCommand{
+		OwnerUserID: primitives.UserID(uuid.New()),
+		Balance:     primitives.NewMoneyForCommand(*money.New(0, "EUR")),
+		Imported:    true,
+	}
+
+	if err := cmd.validate(state); err == nil {
+		t.Errorf("Expected an imported account of another user to be rejected")
+	}
+	if err := cmd.validate(EmptyMonetaryAccountState(monetaryAccountID)); err != nil {
+		t.Errorf("Expected a new imported account to be accepted, got %v", err)
+	}
+}
+
+func Test_ProcessMonetaryAccountCommand_OldBalanceIsNotCurrent(t *testing.T) {
+	now := time.Now()
+	state := newStateAfter(EmptyMonetaryAccountState(monetaryAccountID), ProcessMonetaryAccountCommand{
+		Balance:        primitives.NewMoneyForCommand(*money.New(5000, "EUR")),
+		FetchTimestamp: now,
+	})
+	imported := ProcessMonetaryAccountCommand{
+		Balance:        primitives.NewMoneyForCommand(*money.New(1000, "EUR")),
+		FetchTimestamp: now.AddDate(0, -1, 0),
+		Imported:       true,
+	}
+
+	state = newStateAfter(state, imported)
+	last := state.BalanceHistory[len(state.BalanceHistory)-1]
+	if len(state.BalanceHistory) != 2 || last.balance.Amount() != 5000 {
+		t.Errorf("Expected the old balance to be history and the current balance to remain, got %+v", state.BalanceHistory)
+	}
+	if events := imported.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events when importing the statement again, found %d", len(events))
+	}
+}
+
+func stateWithTransaction(transactionID primitives.TransactionID) *MonetaryAccountState {
+	state := EmptyMonetaryAccountState(monetaryAccountID)
+	event := NewTransactionFound{ID: transactionID, MonetaryAccountID: monetaryAccountID, Amount: *money.New(-1000, "EUR")}
+	return event.appliedTo(state)
+}
+
+func Test_NewTransactionFound_IsUncategorised(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+
+	result := stateWithTransaction(transactionID)
+
+	if result.Transactions[transactionID].category != primitives.Uncategorised {
+		t.Errorf("New transaction is not uncategorised")
+	}
+}
+
+func Test_ProcessTransactionDocumentCommand_KeepsGeolocation(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	geolocation := Geolocation{Latitude: 52.3731, Longitude: 4.8926, Radius: 10}
+	cmd := ProcessTransactionDocumentCommand{ID: transactionID, MonetaryAccountID: monetaryAccountID, Geolocation: geolocation, HasGeolocation: true}
+
+	events := cmd.applyTo(EmptyMonetaryAccountState(monetaryAccountID))
+	if len(events) != 1 {
+		t.Fatalf("Expected the transaction to be found, got %v", events)
+	}
+	event := events[0].(NewTransactionFound)
+	if !event.HasGeolocation || event.Geolocation != geolocation {
+		t.Errorf("Expected the geolocation on the event, got %+v", event)
+	}
+	if transaction := event.appliedTo(EmptyMonetaryAccountState(monetaryAccountID)).Transactions[transactionID]; !transaction.hasGeolocation || transaction.geolocation != geolocation {
+		t.Errorf("Expected the geolocation on the transaction, got %+v", transaction)
+	}
+}
+
+func Test_CategoriseTransactionCommand_Categorises(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	cmd := CategoriseTransactionCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Category: "Groceries"}
+
+	state = newStateAfter(state, cmd)
+
+	if state.Transactions[transactionID].category != "Groceries" {
+		t.Errorf("Transaction not categorised")
+	}
+	if events := cmd.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events, found %d", len(events))
+	}
+}
+
+func Test_OverrideTransactionCategoryCommand_TakesPrecedence(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	override := OverrideTransactionCategoryCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Category: "Gifts"}
+	categorise := CategoriseTransactionCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Category: "Groceries"}
+
+	state = newStateAfter(newStateAfter(state, override), categorise)
+
+	if state.Transactions[transactionID].category != "Gifts" {
+		t.Errorf("Manual category was overwritten by a rule")
+	}
+}
+
+func Test_MarkInternalTransferCommand_IsIdempotent(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	cmd := MarkInternalTransferCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, TransferID: NewTransferID(transactionID)}
+
+	state = newStateAfter(state, cmd)
+
+	transaction := state.Transactions[transactionID]
+	if !transaction.internalTransfer || transaction.transferID != NewTransferID(transactionID) {
+		t.Errorf("Transaction not marked as internal transfer")
+	}
+	if events := cmd.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events, found %d", len(events))
+	}
+}
+
+func Test_MarkInternalTransferCommand_IgnoresUnknownTransaction(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	otherTransactionID := primitives.TransactionID(uuid.New())
+	cmd := MarkInternalTransferCommand{MonetaryAccountID: monetaryAccountID, TransactionID: otherTransactionID, TransferID: NewTransferID(otherTransactionID)}
+
+	if events := cmd.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events, found %d", len(events))
+	}
+}
+
+func Test_SetMonetaryAccountShareCommand_RejectsMoreThanEverything(t *testing.T) {
+	user1 := primitives.UserID(uuid.New())
+	user2 := primitives.UserID(uuid.New())
+	state := EmptyMonetaryAccountState(monetaryAccountID)
+	state = MonetaryAccountUserAdded{ID: monetaryAccountID, UserID: user1}.appliedTo(state)
+	state = MonetaryAccountUserAdded{ID: monetaryAccountID, UserID: user2}.appliedTo(state)
+
+	state = newStateAfter(state, SetMonetaryAccountShareCommand{MonetaryAccountID: monetaryAccountID, UserID: user1, Percentage: 70})
+	err := SetMonetaryAccountShareCommand{MonetaryAccountID: monetaryAccountID, UserID: user2, Percentage: 40}.validate(state)
+
+	if state.Shares[user1] != 70 {
+		t.Errorf("Share not set")
+	}
+	var validationError primitives.ValidationError
+	if !errors.As(err, &validationError) || validationError.Field != "Percentage" {
+		t.Errorf("Expected shares over 100 percent to be invalid, got %v", err)
+	}
+}
+
+func Test_SetMonetaryAccountShareCommand_RejectsNonOwners(t *testing.T) {
+	state := EmptyMonetaryAccountState(monetaryAccountID)
+	cmd := SetMonetaryAccountShareCommand{MonetaryAccountID: monetaryAccountID, UserID: primitives.UserID(uuid.New()), Percentage: 50}
+
+	var validationError primitives.ValidationError
+	if err := cmd.validate(state); !errors.As(err, &validationError) || validationError.Field != "UserID" {
+		t.Errorf("Expected a user that does not own the account to be invalid, got %v", err)
+	}
+}
+
+func Test_RenameMonetaryAccountCommand_KeepsAliasOfInstitution(t *testing.T) {
+	state := EmptyMonetaryAccountState(monetaryAccountID)
+	if err := (RenameMonetaryAccountCommand{MonetaryAccountID: monetaryAccountID, Alias: "Groceries"}).validate(state); err == nil {
+		t.Fatalf("Expected an unknown monetary account to be rejected")
+	}
+
+	state.Details.initialized = true
+	state.Details.alias = "Bunq"
+	cmd := RenameMonetaryAccountCommand{MonetaryAccountID: monetaryAccountID, Alias: "Groceries"}
+	if err := cmd.validate(state); err != nil {
+		t.Fatalf("Expected the account to be renamed, got %v", err)
+	}
+
+	events := cmd.applyTo(state)
+	if len(events) != 1 {
+		t.Fatalf("Expected the account to be renamed, got %v", events)
+	}
+	result := events[0].appliedTo(state)
+	if result.Details.localAlias != "Groceries" || result.Details.alias != "Bunq" {
+		t.Errorf("Expected the alias of the institution to be kept, got %+v", result.Details)
+	}
+	if events := cmd.applyTo(result); len(events) != 0 {
+		t.Errorf("Expected renaming to the same alias to be ignored, got %v", events)
+	}
+}
+
+func Test_SetTransactionNoteCommand_RejectsUnknownTransaction(t *testing.T) {
+	state := EmptyMonetaryAccountState(monetaryAccountID)
+	state.Details.initialized = true
+	transactionID := primitives.TransactionID(uuid.New())
+	cmd := SetTransactionNoteCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Note: "Dinner"}
+
+	err := cmd.validate(state)
+	if validationError, ok := err.(primitives.ValidationError); !ok || validationError.Field != "TransactionID" {
+		t.Fatalf("Expected the transaction to be unknown, got %v", err)
+	}
+
+	state.Transactions[transactionID] = Transaction{}
+	events := cmd.applyTo(state)
+	if len(events) != 1 {
+		t.Fatalf("Expected the note to be set, got %v", events)
+	}
+	if result := events[0].appliedTo(state); result.Transactions[transactionID].note != "Dinner" {
+		t.Errorf("Expected the note to be set, got %+v", result.Transactions[transactionID])
+	}
+}
+
+func Test_SetTransactionTagsCommand_NormalisesTags(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	cmd := SetTransactionTagsCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Tags: []string{" Holiday", "tax", "holiday ", ""}}
+
+	result := newStateAfter(state, cmd)
+	if tags := result.Transactions[transactionID].tags; len(tags) != 2 || tags[0] != "holiday" || tags[1] != "tax" {
+		t.Fatalf("Expected the tags to be normalised, got %v", tags)
+	}
+	if events := (SetTransactionTagsCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Tags: []string{"tax", "HOLIDAY"}}).applyTo(result); len(events) != 0 {
+		t.Errorf("Expected the same tags to be ignored, got %v", events)
+	}
+	if result = newStateAfter(result, SetTransactionTagsCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID}); len(result.Transactions[transactionID].tags) != 0 {
+		t.Errorf("Expected the tags to be removed, got %v", result.Transactions[transactionID].tags)
+	}
+}
+
+func Test_SplitTransactionCommand_RequiresPartsToSumToAmount(t *testing.T) {
+	transactionID := primitives.TransactionID(uuid.New())
+	state := stateWithTransaction(transactionID)
+	state.Details.initialized = true
+	part := func(category primitives.Category, person string, amount int64, currency string) TransactionSplitPartForCommand {
+		return TransactionSplitPartForCommand{Category: category, Person: person, Amount: primitives.NewMoneyForCommand(*money.New(amount, currency))}
+	}
+
+	invalid := [][]TransactionSplitPartForCommand{
+		{part("Restaurants", "", 1000, "EUR")},
+		{part("Restaurants", "", 600, "EUR"), part("", "Alice", 300, "EUR")},
+		{part("Restaurants", "", 600, "EUR"), part("", "Alice", 400, "USD")},
+		{part("Restaurants", "", 1200, "EUR"), part("", "Alice", -200, "EUR")},
+		{part("Restaurants", "", 600, "EUR"), part("", " ", 400, "EUR")},
+	}
+	for _, parts := range invalid {
+		err := SplitTransactionCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Parts: parts}.validate(state)
+		if validationError, ok := err.(primitives.ValidationError); !ok || validationError.Field != "Parts" {
+			t.Errorf("Expected the parts %v to be invalid, got %v", parts, err)
+		}
+	}
+
+	cmd := SplitTransactionCommand{MonetaryAccountID: monetaryAccountID, TransactionID: transactionID, Parts: []TransactionSplitPartForCommand{
+		part("Restaurants", "", 600, "EUR"),
+		part("", "Alice", 400, "EUR"),
+	}}
+	if err := cmd.validate(state); err != nil {
+		t.Fatalf("Expected the split to be valid, got %v", err)
+	}
+
+	result := newStateAfter(state, cmd)
+	if parts := result.Transactions[transactionID].splitParts; len(parts) != 2 || parts[1].Person != "Alice" || parts[1].Amount.Amount() != 400 {
+		t.Fatalf("Expected the transaction to be split, got %+v", parts)
+	}
+	if events := cmd.applyTo(result); len(events) != 0 {
+		t.Errorf("Expected the same split to be ignored, got %v", events)
+	}
+}
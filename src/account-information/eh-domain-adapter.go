@@ -42,6 +42,15 @@ type Aggregate struct {
 const EhProcessMonetaryAccountCommand = eh.CommandType("monetaryaccount:proces")
 const EhProcessTransactionDocumentCommand = eh.CommandType("monetaryaccount:proces-tx")
 const EhUpdateBalanceForNonAutomatedAccountCommand = eh.CommandType("monetaryaccount:update-balance-non-automated")
+const EhCategoriseTransactionCommand = eh.CommandType("monetaryaccount:categorise-tx")
+const EhOverrideTransactionCategoryCommand = eh.CommandType("monetaryaccount:override-tx-category")
+const EhMarkInternalTransferCommand = eh.CommandType("monetaryaccount:mark-internal-transfer")
+const EhSetMonetaryAccountShareCommand = eh.CommandType("monetaryaccount:set-share")
+const EhRenameMonetaryAccountCommand = eh.CommandType("monetaryaccount:rename")
+const EhSetTransactionNoteCommand = eh.CommandType("monetaryaccount:set-tx-note")
+const EhAddTransactionAttachmentCommand = eh.CommandType("monetaryaccount:add-tx-attachment")
+const EhSetTransactionTagsCommand = eh.CommandType("monetaryaccount:set-tx-tags")
+const EhSplitTransactionCommand = eh.CommandType("monetaryaccount:split-tx")
 
 const EhNewMonetaryAccountFound = eh.EventType("monetaryaccount:new-found")
 const EhMonetaryAccountBecameJoint = eh.EventType("monetaryaccount:became-joint")
@@ -50,6 +59,32 @@ const EhMonetaryAccountAliasUpdated = eh.EventType("monetaryaccount:alias-update
 const EhNewTransactionFound = eh.EventType("monetaryaccount:new-tx")
 const EhMonetaryAccountBalanceSnapshotted = eh.EventType("monetaryaccount:balance-snapshotted")
 const EhMonetaryAccountUserAdded = eh.EventType("monetaryaccount:user-added")
+const EhTransactionCategorised = eh.EventType("monetaryaccount:tx-categorised")
+const EhInternalTransferDetected = eh.EventType("monetaryaccount:internal-transfer-detected")
+const EhMonetaryAccountShareSet = eh.EventType("monetaryaccount:share-set")
+const EhMonetaryAccountRenamed = eh.EventType("monetaryaccount:renamed")
+const EhTransactionNoteSet = eh.EventType("monetaryaccount:tx-note-set")
+const EhTransactionAttachmentAdded = eh.EventType("monetaryaccount:tx-attachment-added")
+const EhTransactionTagsSet = eh.EventType("monetaryaccount:tx-tags-set")
+const EhTransactionSplit = eh.EventType("monetaryaccount:tx-split")
+
+// CommandTypes are all command types handled by the monetary account aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhProcessMonetaryAccountCommand,
+		EhProcessTransactionDocumentCommand,
+		EhUpdateBalanceForNonAutomatedAccountCommand,
+		EhCategoriseTransactionCommand,
+		EhOverrideTransactionCategoryCommand,
+		EhMarkInternalTransferCommand,
+		EhSetMonetaryAccountShareCommand,
+		EhRenameMonetaryAccountCommand,
+		EhSetTransactionNoteCommand,
+		EhAddTransactionAttachmentCommand,
+		EhSetTransactionTagsCommand,
+		EhSplitTransactionCommand,
+	}
+}
 
 func (cmd ProcessMonetaryAccountCommand) AggregateID() uuid.UUID {
 	return uuid.UUID(cmd.MonetaryAccountID)
@@ -87,6 +122,114 @@ func (cmd UpdateBalanceForNonAutomatedAccountCommand) CommandType() eh.CommandTy
 	return EhUpdateBalanceForNonAutomatedAccountCommand
 }
 
+func (cmd CategoriseTransactionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd CategoriseTransactionCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd CategoriseTransactionCommand) CommandType() eh.CommandType {
+	return EhCategoriseTransactionCommand
+}
+
+func (cmd OverrideTransactionCategoryCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd OverrideTransactionCategoryCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd OverrideTransactionCategoryCommand) CommandType() eh.CommandType {
+	return EhOverrideTransactionCategoryCommand
+}
+
+func (cmd MarkInternalTransferCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd MarkInternalTransferCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd MarkInternalTransferCommand) CommandType() eh.CommandType {
+	return EhMarkInternalTransferCommand
+}
+
+func (cmd SetMonetaryAccountShareCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd SetMonetaryAccountShareCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd SetMonetaryAccountShareCommand) CommandType() eh.CommandType {
+	return EhSetMonetaryAccountShareCommand
+}
+
+func (cmd RenameMonetaryAccountCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd RenameMonetaryAccountCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd RenameMonetaryAccountCommand) CommandType() eh.CommandType {
+	return EhRenameMonetaryAccountCommand
+}
+
+func (cmd SetTransactionNoteCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd SetTransactionNoteCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd SetTransactionNoteCommand) CommandType() eh.CommandType {
+	return EhSetTransactionNoteCommand
+}
+
+func (cmd AddTransactionAttachmentCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd AddTransactionAttachmentCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd AddTransactionAttachmentCommand) CommandType() eh.CommandType {
+	return EhAddTransactionAttachmentCommand
+}
+
+func (cmd SetTransactionTagsCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd SetTransactionTagsCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd SetTransactionTagsCommand) CommandType() eh.CommandType {
+	return EhSetTransactionTagsCommand
+}
+
+func (cmd SplitTransactionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.MonetaryAccountID)
+}
+
+func (cmd SplitTransactionCommand) AggregateType() eh.AggregateType {
+	return MonetaryAccountAggregateType
+}
+
+func (cmd SplitTransactionCommand) CommandType() eh.CommandType {
+	return EhSplitTransactionCommand
+}
+
 func init() {
 	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
 		return &Aggregate{
@@ -121,6 +264,38 @@ func init() {
 	eh.RegisterEventData(EhMonetaryAccountBalanceSnapshotted, func() eh.EventData {
 		return &MonetaryAccountBalanceSnapshotted{}
 	})
+
+	eh.RegisterEventData(EhTransactionCategorised, func() eh.EventData {
+		return &TransactionCategorised{}
+	})
+
+	eh.RegisterEventData(EhInternalTransferDetected, func() eh.EventData {
+		return &InternalTransferDetected{}
+	})
+
+	eh.RegisterEventData(EhMonetaryAccountShareSet, func() eh.EventData {
+		return &MonetaryAccountShareSet{}
+	})
+
+	eh.RegisterEventData(EhMonetaryAccountRenamed, func() eh.EventData {
+		return &MonetaryAccountRenamed{}
+	})
+
+	eh.RegisterEventData(EhTransactionNoteSet, func() eh.EventData {
+		return &TransactionNoteSet{}
+	})
+
+	eh.RegisterEventData(EhTransactionAttachmentAdded, func() eh.EventData {
+		return &TransactionAttachmentAdded{}
+	})
+
+	eh.RegisterEventData(EhTransactionTagsSet, func() eh.EventData {
+		return &TransactionTagsSet{}
+	})
+
+	eh.RegisterEventData(EhTransactionSplit, func() eh.EventData {
+		return &TransactionSplit{}
+	})
 }
 
 // HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
@@ -130,6 +305,12 @@ func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
 		return err
 	}
 
+	if validated, ok := domainCommand.(validatedCommand); ok {
+		if err := validated.validate(a.MonetaryAccountState); err != nil {
+			return err
+		}
+	}
+
 	events := domainCommand.applyTo(a.MonetaryAccountState)
 	for _, event := range events {
 		eventType, err := mapToEhEventType(event)
@@ -169,6 +350,22 @@ func mapToDomainEvent(event eh.Event) (MonetaryAccountEvent, error) {
 		return event.Data().(MonetaryAccountEvent), nil
 	case EhMonetaryAccountBalanceSnapshotted:
 		return event.Data().(MonetaryAccountEvent), nil
+	case EhTransactionCategorised:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhInternalTransferDetected:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhMonetaryAccountShareSet:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhMonetaryAccountRenamed:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhTransactionNoteSet:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhTransactionAttachmentAdded:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhTransactionTagsSet:
+		return event.Data().(MonetaryAccountEvent), nil
+	case EhTransactionSplit:
+		return event.Data().(MonetaryAccountEvent), nil
 	default:
 		return nil, fmt.Errorf("unable to understand evnt %v", event)
 	}
@@ -190,6 +387,22 @@ func mapToEhEventType(event MonetaryAccountEvent) (eh.EventType, error) {
 		return EhMonetaryAccountUserAdded, nil
 	case MonetaryAccountBalanceSnapshotted:
 		return EhMonetaryAccountBalanceSnapshotted, nil
+	case TransactionCategorised:
+		return EhTransactionCategorised, nil
+	case InternalTransferDetected:
+		return EhInternalTransferDetected, nil
+	case MonetaryAccountShareSet:
+		return EhMonetaryAccountShareSet, nil
+	case MonetaryAccountRenamed:
+		return EhMonetaryAccountRenamed, nil
+	case TransactionNoteSet:
+		return EhTransactionNoteSet, nil
+	case TransactionAttachmentAdded:
+		return EhTransactionAttachmentAdded, nil
+	case TransactionTagsSet:
+		return EhTransactionTagsSet, nil
+	case TransactionSplit:
+		return EhTransactionSplit, nil
 	}
 	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
 }
@@ -202,6 +415,24 @@ func mapToDomainCommand(cmd eh.Command) (MonetaryAccountCommand, error) {
 		return cmd, nil
 	case UpdateBalanceForNonAutomatedAccountCommand:
 		return cmd, nil
+	case CategoriseTransactionCommand:
+		return cmd, nil
+	case OverrideTransactionCategoryCommand:
+		return cmd, nil
+	case MarkInternalTransferCommand:
+		return cmd, nil
+	case SetMonetaryAccountShareCommand:
+		return cmd, nil
+	case RenameMonetaryAccountCommand:
+		return cmd, nil
+	case SetTransactionNoteCommand:
+		return cmd, nil
+	case AddTransactionAttachmentCommand:
+		return cmd, nil
+	case SetTransactionTagsCommand:
+		return cmd, nil
+	case SplitTransactionCommand:
+		return cmd, nil
 
 	default:
 		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
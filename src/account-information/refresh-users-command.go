@@ -4,8 +4,8 @@ import (
 	"app/primitives"
 	"context"
 	"fmt"
-
-	"github.com/google/uuid"
+	"log"
+	"sync"
 )
 
 type RefreshUsersCommand interface {
@@ -21,34 +21,137 @@ type usersRepository interface {
 	FetchUserIds(out chan<- fetchUserIdsResult)
 }
 
-type inMemoryUserRepository struct {
+// ActiveUsers are the users whose connections are refreshed
+type ActiveUsers interface {
+	ActiveUserIDs() []primitives.UserID
+}
+
+type activeUsersRepository struct {
+	users ActiveUsers
 }
 
-func NewInMemoryUserRepository() inMemoryUserRepository {
-	return inMemoryUserRepository{}
+// NewActiveUsersRepository creates a repository of the users that are signed up and not deactivated
+func NewActiveUsersRepository(users ActiveUsers) activeUsersRepository {
+	return activeUsersRepository{users: users}
 }
 
-func (repo inMemoryUserRepository) FetchUserIds(out chan<- fetchUserIdsResult) {
-	userID := primitives.UserID(uuid.New())
-	out <- fetchUserIdsResult{userID: &userID}
+func (repo activeUsersRepository) FetchUserIds(out chan<- fetchUserIdsResult) {
+	for _, userID := range repo.users.ActiveUserIDs() {
+		userID := userID
+		out <- fetchUserIdsResult{userID: &userID}
+	}
+	close(out)
+}
+
+// Connection a user has with an institution, the connector of the institution refreshes it
+type Connection struct {
+	UserID      primitives.UserID
+	Institution primitives.Institution
+}
+
+type fetchConnectionsResult struct {
+	connection *Connection
+	err        error
+}
+
+type connectionsRepository interface {
+	FetchConnectionsOf(userID primitives.UserID, out chan<- fetchConnectionsResult)
+}
+
+// InMemoryConnectionRepository keeps the connections of users, every user is connected to the default institutions
+// unless the user disconnected from it
+type InMemoryConnectionRepository struct {
+	mu           *sync.Mutex
+	defaults     *[]primitives.Institution
+	connections  map[primitives.UserID]map[primitives.Institution]bool
+	disconnected map[primitives.UserID]map[primitives.Institution]bool
+}
+
+// NewInMemoryConnectionRepository creates an InMemoryConnectionRepository where every user is connected to the default institutions
+func NewInMemoryConnectionRepository(defaults ...primitives.Institution) InMemoryConnectionRepository {
+	return InMemoryConnectionRepository{
+		mu:           new(sync.Mutex),
+		defaults:     &defaults,
+		connections:  make(map[primitives.UserID]map[primitives.Institution]bool),
+		disconnected: make(map[primitives.UserID]map[primitives.Institution]bool),
+	}
+}
+
+// ConnectEveryone connects every user to the institution
+func (repo InMemoryConnectionRepository) ConnectEveryone(institution primitives.Institution) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	*repo.defaults = append(*repo.defaults, institution)
+}
+
+// Connect connects the user to the institution
+func (repo InMemoryConnectionRepository) Connect(userID primitives.UserID, institution primitives.Institution) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	connections, ok := repo.connections[userID]
+	if !ok {
+		connections = make(map[primitives.Institution]bool)
+		repo.connections[userID] = connections
+	}
+	connections[institution] = true
+	delete(repo.disconnected[userID], institution)
+}
+
+// Disconnect disconnects the user from the institution, also when it is one of the default institutions
+func (repo InMemoryConnectionRepository) Disconnect(userID primitives.UserID, institution primitives.Institution) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.connections[userID], institution)
+	disconnected, ok := repo.disconnected[userID]
+	if !ok {
+		disconnected = make(map[primitives.Institution]bool)
+		repo.disconnected[userID] = disconnected
+	}
+	disconnected[institution] = true
+}
+
+func (repo InMemoryConnectionRepository) FetchConnectionsOf(userID primitives.UserID, out chan<- fetchConnectionsResult) {
+	repo.mu.Lock()
+	institutions := make(map[primitives.Institution]bool, len(*repo.defaults)+len(repo.connections[userID]))
+	for _, institution := range *repo.defaults {
+		institutions[institution] = true
+	}
+	for institution := range repo.connections[userID] {
+		institutions[institution] = true
+	}
+	for institution := range repo.disconnected[userID] {
+		delete(institutions, institution)
+	}
+	repo.mu.Unlock()
+
+	for institution := range institutions {
+		out <- fetchConnectionsResult{connection: &Connection{UserID: userID, Institution: institution}}
+	}
 	close(out)
 }
 
 type RefreshUsersFromRepositoryCommand struct {
-	context            context.Context
-	usersRepository    usersRepository
-	refreshUserCommand StartUserRefreshCommand
+	context               context.Context
+	usersRepository       usersRepository
+	connectionsRepository connectionsRepository
+	refreshUserCommands   map[primitives.Institution]StartUserRefreshCommand
 }
 
 type StartUserRefreshCommand interface {
 	Refresh(userID primitives.UserID)
 }
 
-func NewRefreshUsersFromRepositoryCommand(ctx context.Context, repo usersRepository, refreshUserCommand StartUserRefreshCommand) RefreshUsersFromRepositoryCommand {
+// NewRefreshUsersFromRepositoryCommand creates a RefreshUsersFromRepositoryCommand that refreshes every connection of a user
+// with the command of the institution of the connection
+func NewRefreshUsersFromRepositoryCommand(ctx context.Context, repo usersRepository, connections connectionsRepository, refreshUserCommands map[primitives.Institution]StartUserRefreshCommand) RefreshUsersFromRepositoryCommand {
 	return RefreshUsersFromRepositoryCommand{
-		context:            ctx,
-		usersRepository:    repo,
-		refreshUserCommand: refreshUserCommand,
+		context:               ctx,
+		usersRepository:       repo,
+		connectionsRepository: connections,
+		refreshUserCommands:   refreshUserCommands,
 	}
 }
 
@@ -69,8 +172,47 @@ func (cmd RefreshUsersFromRepositoryCommand) StartRefresh() {
 				// TODO:
 				fmt.Println(userID.err)
 			} else {
-				go cmd.refreshUserCommand.Refresh(*userID.userID)
+				go cmd.refreshConnectionsOf(*userID.userID)
+			}
+		}
+	}
+}
+
+// RefreshUser refreshes every connection of the user right away, instead of at the next refresh of all users
+func (cmd RefreshUsersFromRepositoryCommand) RefreshUser(userID primitives.UserID) {
+	cmd.refreshConnectionsOf(userID)
+}
+
+// Refreshes tells whether there is a connector to refresh the connections of users to the institution
+func (cmd RefreshUsersFromRepositoryCommand) Refreshes(institution primitives.Institution) bool {
+	_, ok := cmd.refreshUserCommands[institution]
+	return ok
+}
+
+func (cmd RefreshUsersFromRepositoryCommand) refreshConnectionsOf(userID primitives.UserID) {
+	connections := make(chan fetchConnectionsResult, 10)
+	go cmd.connectionsRepository.FetchConnectionsOf(userID, connections)
+
+	for {
+		select {
+		case <-cmd.context.Done():
+			return
+		case connection, ok := <-connections:
+			if !ok {
+				return
+			}
+
+			if connection.err != nil {
+				log.Printf("Could not fetch connections of user %s: %v", userID, connection.err)
+				continue
+			}
+
+			refreshUserCommand, ok := cmd.refreshUserCommands[connection.connection.Institution]
+			if !ok {
+				log.Printf("No connector for %s to refresh user %s", connection.connection.Institution, userID)
+				continue
 			}
+			go refreshUserCommand.Refresh(userID)
 		}
 	}
 }
@@ -0,0 +1,87 @@
+package accountinformation
+
+import (
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fixedUsersRepository []primitives.UserID
+
+func (repo fixedUsersRepository) FetchUserIds(out chan<- fetchUserIdsResult) {
+	for i := range repo {
+		out <- fetchUserIdsResult{userID: &repo[i]}
+	}
+	close(out)
+}
+
+type recordingRefreshCommand chan primitives.UserID
+
+func (cmd recordingRefreshCommand) Refresh(userID primitives.UserID) {
+	cmd <- userID
+}
+
+func Test_RefreshUsers_DispatchesPerConnection(t *testing.T) {
+	bunqUser := primitives.UserID(uuid.New())
+	ingUser := primitives.UserID(uuid.New())
+	connections := NewInMemoryConnectionRepository(primitives.Bunq)
+	connections.Connect(ingUser, primitives.ING)
+	bunq := make(recordingRefreshCommand, 2)
+	ing := make(recordingRefreshCommand, 2)
+
+	cmd := NewRefreshUsersFromRepositoryCommand(context.Background(), fixedUsersRepository{bunqUser, ingUser}, connections, map[primitives.Institution]StartUserRefreshCommand{
+		primitives.Bunq: bunq,
+		primitives.ING:  ing,
+	})
+	cmd.StartRefresh()
+
+	refreshed := make(map[primitives.UserID]int)
+	for i := 0; i < 2; i++ {
+		select {
+		case userID := <-bunq:
+			refreshed[userID]++
+		case <-time.After(time.Second):
+			t.Fatalf("Expected both users to be refreshed at bunq")
+		}
+	}
+	if refreshed[bunqUser] != 1 || refreshed[ingUser] != 1 {
+		t.Errorf("Expected both users to be refreshed at bunq once, got %v", refreshed)
+	}
+
+	select {
+	case userID := <-ing:
+		if userID != ingUser {
+			t.Errorf("Expected only %s to be refreshed at ING, got %s", ingUser, userID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the connection to ING to be refreshed")
+	}
+}
+
+func Test_InMemoryConnectionRepository_DisconnectsFromDefaults(t *testing.T) {
+	userID := primitives.UserID(uuid.New())
+	connections := NewInMemoryConnectionRepository(primitives.Bunq)
+	connections.Connect(userID, primitives.ING)
+	connections.Disconnect(userID, primitives.Bunq)
+
+	fetched := make(chan fetchConnectionsResult, 10)
+	connections.FetchConnectionsOf(userID, fetched)
+
+	var institutions []primitives.Institution
+	for result := range fetched {
+		institutions = append(institutions, result.connection.Institution)
+	}
+	if len(institutions) != 1 || institutions[0] != primitives.ING {
+		t.Errorf("Expected only the connection to ING, got %v", institutions)
+	}
+
+	connections.Connect(userID, primitives.Bunq)
+	fetched = make(chan fetchConnectionsResult, 10)
+	connections.FetchConnectionsOf(userID, fetched)
+	if count := len(fetched); count != 2 {
+		t.Errorf("Expected the user to be connected to bunq again, got %d connections", count)
+	}
+}
@@ -0,0 +1,137 @@
+package accountinformation
+
+import (
+	"app/primitives"
+	"context"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// NewTransferID derives the id shared by both legs of an internal transfer
+func NewTransferID(transactionID primitives.TransactionID) primitives.TransferID {
+	return primitives.TransferID(uuid.NewMD5(parentUUID, []byte("transfer-"+transactionID.String())))
+}
+
+type transferLegs struct {
+	from primitives.MonetaryAccountID
+	to   primitives.MonetaryAccountID
+}
+
+// TransferDetector marks transactions between monetary accounts that share an owner as internal transfers.
+// Both legs of the transfer are recognised when they are found, or when an account gets an owner in common later on
+type TransferDetector struct {
+	handler eh.CommandHandler
+
+	mu           sync.Mutex
+	owners       map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	transactions map[primitives.MonetaryAccountID]map[primitives.TransactionID]transferLegs
+}
+
+// NewTransferDetector creates a TransferDetector that dispatches its commands to the handler
+func NewTransferDetector(handler eh.CommandHandler) *TransferDetector {
+	return &TransferDetector{
+		handler:      handler,
+		owners:       make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool),
+		transactions: make(map[primitives.MonetaryAccountID]map[primitives.TransactionID]transferLegs),
+	}
+}
+
+// Matcher matches all events the detector is interested in
+func (detector *TransferDetector) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		EhNewTransactionFound,
+		EhMonetaryAccountUserAdded,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (detector *TransferDetector) HandlerType() eh.EventHandlerType {
+	return "internal-transfer-detector"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (detector *TransferDetector) HandleEvent(ctx context.Context, event eh.Event) error {
+	var commands []eh.Command
+
+	switch data := event.Data().(type) {
+	case *NewTransactionFound:
+		commands = detector.transactionFound(*data)
+	case *MonetaryAccountUserAdded:
+		commands = detector.ownerAdded(data.ID, data.UserID)
+	}
+
+	var firstErr error
+	for _, cmd := range commands {
+		if err := detector.handler.HandleCommand(ctx, cmd); err != nil {
+			log.Printf("Could not mark internal transfer: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (detector *TransferDetector) transactionFound(event NewTransactionFound) []eh.Command {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	legs := transferLegs{from: event.FromMonetaryAccountID, to: event.ToMonetaryAccountID}
+
+	transactions, ok := detector.transactions[event.MonetaryAccountID]
+	if !ok {
+		transactions = make(map[primitives.TransactionID]transferLegs)
+		detector.transactions[event.MonetaryAccountID] = transactions
+	}
+	transactions[event.ID] = legs
+
+	if !detector.sharesOwner(legs) {
+		return nil
+	}
+	return []eh.Command{markInternalTransfer(event.MonetaryAccountID, event.ID)}
+}
+
+func (detector *TransferDetector) ownerAdded(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) []eh.Command {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	owners, ok := detector.owners[monetaryAccountID]
+	if !ok {
+		owners = make(map[primitives.UserID]bool)
+		detector.owners[monetaryAccountID] = owners
+	}
+	owners[userID] = true
+
+	var commands []eh.Command
+	for accountID, transactions := range detector.transactions {
+		for transactionID, legs := range transactions {
+			if (legs.from == monetaryAccountID || legs.to == monetaryAccountID) && detector.sharesOwner(legs) {
+				commands = append(commands, markInternalTransfer(accountID, transactionID))
+			}
+		}
+	}
+	return commands
+}
+
+func (detector *TransferDetector) sharesOwner(legs transferLegs) bool {
+	if legs.from == legs.to {
+		return false
+	}
+	for userID := range detector.owners[legs.from] {
+		if detector.owners[legs.to][userID] {
+			return true
+		}
+	}
+	return false
+}
+
+func markInternalTransfer(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) MarkInternalTransferCommand {
+	return MarkInternalTransferCommand{
+		MonetaryAccountID: monetaryAccountID,
+		TransactionID:     transactionID,
+		TransferID:        NewTransferID(transactionID),
+	}
+}
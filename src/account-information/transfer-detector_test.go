@@ -0,0 +1,102 @@
+package accountinformation
+
+import (
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type recordingCommandHandler struct {
+	commands []eh.Command
+}
+
+func (handler *recordingCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	handler.commands = append(handler.commands, cmd)
+	return nil
+}
+
+func ownerAdded(detector *TransferDetector, monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) {
+	detector.HandleEvent(context.Background(), eh.NewEvent(EhMonetaryAccountUserAdded, &MonetaryAccountUserAdded{ID: monetaryAccountID, UserID: userID}, time.Now()))
+}
+
+func transferFound(detector *TransferDetector, from primitives.MonetaryAccountID, to primitives.MonetaryAccountID) primitives.TransactionID {
+	transactionID := primitives.TransactionID(uuid.New())
+	detector.HandleEvent(context.Background(), eh.NewEvent(EhNewTransactionFound, &NewTransactionFound{
+		ID:                    transactionID,
+		MonetaryAccountID:     from,
+		FromMonetaryAccountID: from,
+		ToMonetaryAccountID:   to,
+	}, time.Now()))
+	return transactionID
+}
+
+func Test_TransferDetector_MarksTransferBetweenAccountsOfOwner(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	detector := NewTransferDetector(commands)
+	userID := primitives.UserID(uuid.New())
+	checking, savings := primitives.MonetaryAccountID(uuid.New()), primitives.MonetaryAccountID(uuid.New())
+	ownerAdded(detector, checking, userID)
+	ownerAdded(detector, savings, userID)
+
+	transactionID := transferFound(detector, checking, savings)
+
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected one command, got %+v", commands.commands)
+	}
+	cmd, ok := commands.commands[0].(MarkInternalTransferCommand)
+	if !ok || cmd.MonetaryAccountID != checking || cmd.TransactionID != transactionID || cmd.TransferID != NewTransferID(transactionID) {
+		t.Errorf("Expected the transfer to be marked, got %+v", commands.commands[0])
+	}
+}
+
+func Test_TransferDetector_MarksTransferWhenOwnerIsAddedLater(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	detector := NewTransferDetector(commands)
+	userID := primitives.UserID(uuid.New())
+	checking, joint := primitives.MonetaryAccountID(uuid.New()), primitives.MonetaryAccountID(uuid.New())
+	ownerAdded(detector, checking, userID)
+
+	transactionID := transferFound(detector, checking, joint)
+	if len(commands.commands) != 0 {
+		t.Fatalf("Expected no transfer before the accounts share an owner, got %+v", commands.commands)
+	}
+
+	ownerAdded(detector, joint, userID)
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected one command, got %+v", commands.commands)
+	}
+	if cmd, ok := commands.commands[0].(MarkInternalTransferCommand); !ok || cmd.MonetaryAccountID != checking || cmd.TransactionID != transactionID {
+		t.Errorf("Expected the transfer to be marked, got %+v", commands.commands[0])
+	}
+}
+
+func Test_TransferDetector_IgnoresTransferToSameAccount(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	detector := NewTransferDetector(commands)
+	checking := primitives.MonetaryAccountID(uuid.New())
+	ownerAdded(detector, checking, primitives.UserID(uuid.New()))
+
+	transferFound(detector, checking, checking)
+
+	if len(commands.commands) != 0 {
+		t.Errorf("Expected a transaction within one account not to be a transfer, got %+v", commands.commands)
+	}
+}
+
+func Test_TransferDetector_IgnoresAccountsOfDifferentUsers(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	detector := NewTransferDetector(commands)
+	mine, theirs := primitives.MonetaryAccountID(uuid.New()), primitives.MonetaryAccountID(uuid.New())
+	ownerAdded(detector, mine, primitives.UserID(uuid.New()))
+	ownerAdded(detector, theirs, primitives.UserID(uuid.New()))
+
+	transferFound(detector, mine, theirs)
+
+	if len(commands.commands) != 0 {
+		t.Errorf("Expected a payment to another user not to be a transfer, got %+v", commands.commands)
+	}
+}
@@ -0,0 +1,117 @@
+package accountinformation
+
+import (
+	"app/primitives"
+	"app/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// ConnectionsAggregateType is the aggregate type of the commands about the connections of a user,
+// they are handled by the UserCommandHandler instead of an aggregate
+const ConnectionsAggregateType = eh.AggregateType("connections")
+
+const EhRefreshUserCommand = eh.CommandType("connections:refresh")
+const EhConnectInstitutionCommand = eh.CommandType("connections:connect-institution")
+const EhDisconnectInstitutionCommand = eh.CommandType("connections:disconnect-institution")
+
+// UserCommandTypes are all command types handled by the UserCommandHandler
+func UserCommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhRefreshUserCommand,
+		EhConnectInstitutionCommand,
+		EhDisconnectInstitutionCommand,
+	}
+}
+
+// RefreshUserCommand refreshes every connection of a user right away
+type RefreshUserCommand struct {
+	UserID primitives.UserID
+}
+
+// ConnectInstitutionCommand connects a user to an institution, so the accounts of the user at the institution are refreshed
+type ConnectInstitutionCommand struct {
+	UserID      primitives.UserID
+	Institution primitives.Institution
+}
+
+// DisconnectInstitutionCommand disconnects a user from an institution, the accounts at the institution are no longer refreshed
+type DisconnectInstitutionCommand struct {
+	UserID      primitives.UserID
+	Institution primitives.Institution
+}
+
+func (cmd RefreshUserCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd RefreshUserCommand) AggregateType() eh.AggregateType {
+	return ConnectionsAggregateType
+}
+
+func (cmd RefreshUserCommand) CommandType() eh.CommandType {
+	return EhRefreshUserCommand
+}
+
+func (cmd ConnectInstitutionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd ConnectInstitutionCommand) AggregateType() eh.AggregateType {
+	return ConnectionsAggregateType
+}
+
+func (cmd ConnectInstitutionCommand) CommandType() eh.CommandType {
+	return EhConnectInstitutionCommand
+}
+
+func (cmd DisconnectInstitutionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd DisconnectInstitutionCommand) AggregateType() eh.AggregateType {
+	return ConnectionsAggregateType
+}
+
+func (cmd DisconnectInstitutionCommand) CommandType() eh.CommandType {
+	return EhDisconnectInstitutionCommand
+}
+
+// UserCommandHandler refreshes users and keeps their connections in the connection repository
+type UserCommandHandler struct {
+	refresh     RefreshUsersFromRepositoryCommand
+	connections InMemoryConnectionRepository
+}
+
+// NewUserCommandHandler creates a UserCommandHandler, users can only connect to institutions the refresh command has a connector for
+func NewUserCommandHandler(refresh RefreshUsersFromRepositoryCommand, connections InMemoryConnectionRepository) UserCommandHandler {
+	res := new(UserCommandHandler)
+	res.refresh = refresh
+	res.connections = connections
+	return *res
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (handler UserCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	if err := eh.CheckCommand(cmd); err != nil {
+		return err
+	}
+
+	switch cmd := cmd.(type) {
+	case RefreshUserCommand:
+		handler.refresh.RefreshUser(cmd.UserID)
+	case ConnectInstitutionCommand:
+		if !handler.refresh.Refreshes(cmd.Institution) {
+			return primitives.NewValidationError("Institution", fmt.Sprintf("no connector for %s", cmd.Institution))
+		}
+		handler.connections.Connect(cmd.UserID, cmd.Institution)
+	case DisconnectInstitutionCommand:
+		handler.connections.Disconnect(cmd.UserID, cmd.Institution)
+	default:
+		return fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+	return nil
+}
@@ -2,100 +2,221 @@ package main
 
 import (
 	accountinformation "app/account-information"
-	bunqconnector "app/bunq-connector"
+	"app/attachments"
+	"app/auth"
+	"app/blobs"
 	"app/bus"
+	"app/export"
 	graphqladapter "app/graphql-adapter"
+	"app/households"
 	"app/primitives"
+	spendingmap "app/spending-map"
+	statementimport "app/statement-import"
+	syncstatus "app/sync-status"
+	"app/users"
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
-	"github.com/Rhymond/go-money"
-
-	"github.com/almerlucke/go-iban/iban"
 	"github.com/google/uuid"
-
 	"github.com/gorilla/mux"
 )
 
-type fakeStartUserRefreshCommand struct {
-	ctx            context.Context
-	updatesChannel chan<- bus.Update
-	accountChannel chan<- bus.MonetaryAccountDocument
-}
-
-func newFakeStartUserRefreshCommand(ctx context.Context, updatesChannel chan<- bus.Update, accountChannel chan<- bus.MonetaryAccountDocument) fakeStartUserRefreshCommand {
-	r := new(fakeStartUserRefreshCommand)
-	r.ctx = ctx
-	r.updatesChannel = updatesChannel
-	r.accountChannel = accountChannel
-	return *r
-}
+// publicPaths are the path prefixes that are served without authentication: health checks and the redirect back from bunq
+var publicPaths = []string{"/health/", "/bunq/authorize"}
 
-func (cmd fakeStartUserRefreshCommand) Refresh(userID primitives.UserID) {
-	ticker := time.NewTicker(1000 * time.Millisecond)
+// sessionValidity is how long a session cookie is valid
+const sessionValidity = 7 * 24 * time.Hour
 
-	go func() {
-		for {
-			select {
-			case <-cmd.ctx.Done():
-				return
-
-			case <-ticker.C:
-				iban, _ := iban.NewIBAN("")
-				user, _ := uuid.FromBytes([]byte("69359037-9599-48e7-b8f2-48393c019135"))
-				cmd.accountChannel <- bus.MonetaryAccountDocument{
-					Iban:                *iban,
-					Joint:               false,
-					OwnerUserID:         primitives.UserID(user),
-					Alias:               "d",
-					Institution:         primitives.Bunq,
-					InstitutionEntityID: "x",
-					Balance:             *money.New(1200, "EUR"),
-					FetchTimestamp:      time.Now(),
-				}
-			}
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import-csv" {
+		if err := runImportCSVCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
-	}()
-}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExportCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runTokenCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	authKey, err := authKeyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer := auth.NewSigner(authKey)
 
-func main() {
 	osSignalled := make(chan os.Signal, 1)
 	signal.Notify(osSignalled, os.Interrupt)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	usersRepository := accountinformation.NewInMemoryUserRepository()
+	documentBus, durableBus, err := openDocumentBus()
+	if err != nil {
+		log.Fatal(err)
+	}
+	operators, err := busOperators()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	startUserRefreshCommand := bunqconnector.NewStartUserRefreshCommand(ctx)
-	// startUserRefreshCommand := newFakeStartUserRefreshCommand(ctx, bus.UpdatesChannelForWriting(), bus.AccountChannelForWriting())
-	refreshUsersCommand := accountinformation.NewRefreshUsersFromRepositoryCommand(ctx, usersRepository, startUserRefreshCommand)
+	blobStore, err := openBlobStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// only the active users that signed up are refreshed
+	userDirectory := users.NewDirectory()
+	usersRepository := accountinformation.NewActiveUsersRepository(userDirectory)
+
+	connectionsRepository := accountinformation.NewInMemoryConnectionRepository()
+
+	connectorRegistry, err := newConnectorRegistry(ctx, connectionsRepository, documentBus, blobStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	refreshUsersCommand := accountinformation.NewRefreshUsersFromRepositoryCommand(ctx, usersRepository, connectionsRepository, connectorRegistry.RefreshCommands())
 
 	go func() {
 		<-osSignalled
 		cancel()
 	}()
 
-	go refreshUsersCommand.StartRefresh()
-
-	handler, err := NewHandler()
+	handler, err := NewHandler(userDirectory, accountinformation.NewUserCommandHandler(refreshUsersCommand, connectionsRepository))
 	if err != nil {
 		log.Println(err)
 	}
 
 	monetaryAccountIDFetcher := accountinformation.NewInMemoryMonetaryAccountIDFetcher()
 	transactionIDFetcher := accountinformation.NewInMemoryTransactionIDFetcher()
-	documentsConsumer := accountinformation.NewDocumentsFromBusConsumer(ctx, handler.CommandHandler, monetaryAccountIDFetcher, transactionIDFetcher)
-	go documentsConsumer.Start()
+	var documentsConsumer accountinformation.DocumentsConsumer
+	if durableBus != nil {
+		documentsConsumer = accountinformation.NewDocumentsFromDurableBusConsumer(ctx, handler.CommandHandler, monetaryAccountIDFetcher, transactionIDFetcher, durableBus)
+	} else {
+		documentsConsumer = accountinformation.NewDocumentsFromBusConsumer(ctx, handler.CommandHandler, monetaryAccountIDFetcher, transactionIDFetcher, documentBus)
+	}
+	documentsConsumed := make(chan struct{})
+	go func() {
+		documentsConsumer.Start()
+		close(documentsConsumed)
+	}()
 
-	muxes := make([]func(r *mux.Router) error, 3)
-	muxes[0] = registerHealthchecks
-	muxes[1] = graphqladapter.RegisterGraphql
-	muxes[2] = bunqconnector.RegisterOAuthController
+	syncTracker := syncstatus.NewTracker(documentBus, syncstatus.DefaultOptions)
+	go syncTracker.Start()
+
+	subscriptionFeed := graphqladapter.NewFeed(documentBus)
+	if err := handler.EventBus.AddHandler(subscriptionFeed.Matcher(), subscriptionFeed); err != nil {
+		log.Fatal(err)
+	}
+	go subscriptionFeed.Start()
+
+	go refreshUsersCommand.StartRefresh()
 
-	if err := ServeHttp(ctx, muxes); err != nil {
+	muxes := make([]func(r *mux.Router) error, 4)
+	muxes[0] = registerHealthchecks(connectorRegistry, syncTracker)
+	muxes[1] = graphqladapter.RegisterGraphql(graphqladapter.Repositories{
+		Budgets:    handler.Budgets,
+		Goals:      handler.SavingsGoals,
+		Reports:    handler.Reports,
+		NetWorth:   handler.NetWorth,
+		Syncs:      syncTracker,
+		Search:     handler.Search,
+		Map:        handler.SpendingMap,
+		Feed:       subscriptionFeed,
+		Commands:   handler.CommandHandler,
+		Owners:     handler.AccountOwners,
+		Joint:      handler.AccountOwners,
+		Payers:     handler.Payers,
+		Users:      handler.Users,
+		Households: handler.Households,
+
+		SharedExpenses: handler.SharedExpenses,
+		Transactions:   handler.AccountOwners,
+	}, allowedOrigins())
+	muxes[2] = statementimport.RegisterImportController(documentBus, handler.AccountOwners)
+	muxes[3] = export.RegisterExportController(handler.Exporter)
+	muxes = append(muxes, spendingmap.RegisterSpendingMapController(handler.SpendingMap))
+	// every member of a household sees the attachments of the accounts shared with it, only the owners of the household add them
+	readers := households.NewSharedAccountOwners(handler.AccountOwners, handler.Households, households.Viewer)
+	writers := households.NewSharedAccountOwners(handler.AccountOwners, handler.Households, households.Owner)
+	muxes = append(muxes, attachments.RegisterAttachmentController(blobStore, handler.CommandHandler, handler.AccountOwners, readers, writers))
+	muxes = append(muxes, connectorRegistry.Muxes()...)
+	if durableBus != nil && len(operators) > 0 {
+		muxes = append(muxes, bus.RegisterBusController(durableBus, operators))
+	}
+
+	muxes = append(muxes, auth.RegisterSessionController(signer, sessionValidity))
+
+	if err := ServeHttp(ctx, auth.Middleware(signer, publicPaths...), muxes); err != nil {
 		log.Printf("failed to serve:+%v\n", err)
 	}
+
+	documentBus.Close()
+	<-documentsConsumed
+}
+
+// openDocumentBus opens a durable bus in BUS_DIRECTORY, so documents survive a crash and can be replayed,
+// without it documents are only passed on in memory
+func openDocumentBus() (bus.Bus, *bus.DurableBus, error) {
+	directory, ok := os.LookupEnv("BUS_DIRECTORY")
+	if !ok {
+		return bus.NewInMemoryBus(), nil, nil
+	}
+
+	durableBus, err := bus.OpenDurableBus(directory, bus.DefaultDurableOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return durableBus, durableBus, nil
+}
+
+// busOperators are the users in BUS_OPERATORS, a comma separated list of user ids, that may replay messages on the
+// durable bus and see its dead letters. Without operators the bus is not exposed over http
+func busOperators() ([]primitives.UserID, error) {
+	var operators []primitives.UserID
+	for _, value := range strings.Split(os.Getenv("BUS_OPERATORS"), ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		id, err := uuid.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %s in BUS_OPERATORS: %w", value, err)
+		}
+		operators = append(operators, primitives.UserID(id))
+	}
+	return operators, nil
+}
+
+// allowedOrigins are the comma separated origins in ALLOWED_ORIGINS of the web apps that open subscriptions
+func allowedOrigins() []string {
+	var origins []string
+	for _, value := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin := strings.TrimSpace(value); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// openBlobStore opens a store in BLOB_DIRECTORY for attachments of transactions,
+// without it attachments are only kept in memory
+func openBlobStore() (blobs.Store, error) {
+	directory, ok := os.LookupEnv("BLOB_DIRECTORY")
+	if !ok {
+		return blobs.NewMemoryStore(), nil
+	}
+	return blobs.NewLocalStore(directory)
 }
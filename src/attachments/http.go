@@ -0,0 +1,235 @@
+package attachments
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	"app/blobs"
+	"app/primitives"
+	"app/reporting"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// maxAttachmentSize is the largest file that can be attached, large enough for a scanned receipt or invoice
+const maxAttachmentSize = 20 << 20
+
+// sniffLength is the number of bytes the content type of a file is detected from
+const sniffLength = 512
+
+// AccountOwners tells whether a user may see or change the transactions of a monetary account
+type AccountOwners interface {
+	IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool
+}
+
+// Transactions gives access to the transactions files are attached to
+type Transactions interface {
+	TransactionOf(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) (reporting.ReportedTransaction, bool)
+}
+
+// attachmentResponse is an attachment as json
+type attachmentResponse struct {
+	Digest      string `json:"digest"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+func responseOf(attachment accountinformation.TransactionAttachment) attachmentResponse {
+	return attachmentResponse{
+		Digest:      string(attachment.Digest),
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+	}
+}
+
+// allowedContentType tells whether files of the content type can be attached: images and PDFs
+func allowedContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") || contentType == "application/pdf"
+}
+
+// authorizedTransaction is the transaction of the path, only when the authenticated user of the request is one of the owners of its account
+func authorizedTransaction(res http.ResponseWriter, req *http.Request, owners AccountOwners, transactions Transactions) (reporting.ReportedTransaction, bool) {
+	vars := mux.Vars(req)
+	accountID, err := uuid.Parse(vars["accountId"])
+	if err != nil {
+		http.Error(res, "accountId should be a valid uuid", http.StatusBadRequest)
+		return reporting.ReportedTransaction{}, false
+	}
+	transactionID, err := uuid.Parse(vars["transactionId"])
+	if err != nil {
+		http.Error(res, "transactionId should be a valid uuid", http.StatusBadRequest)
+		return reporting.ReportedTransaction{}, false
+	}
+
+	userID, ok := auth.UserIDFrom(req.Context())
+	if !ok {
+		http.Error(res, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+		return reporting.ReportedTransaction{}, false
+	}
+	if !owners.IsOwner(primitives.MonetaryAccountID(accountID), userID) {
+		http.Error(res, auth.ErrForbidden.Error(), http.StatusForbidden)
+		return reporting.ReportedTransaction{}, false
+	}
+
+	transaction, ok := transactions.TransactionOf(primitives.MonetaryAccountID(accountID), primitives.TransactionID(transactionID))
+	if !ok {
+		http.Error(res, "unknown transaction", http.StatusNotFound)
+		return reporting.ReportedTransaction{}, false
+	}
+	return transaction, true
+}
+
+// uploadedFile is the file of the request, either as file part of a multipart form or as raw body with a fileName query parameter
+func uploadedFile(res http.ResponseWriter, req *http.Request) (io.ReadCloser, string, error) {
+	req.Body = http.MaxBytesReader(res, req.Body, maxAttachmentSize)
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := req.ParseMultipartForm(maxAttachmentSize); err != nil {
+			return nil, "", err
+		}
+		part, header, err := req.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("missing file part")
+		}
+		return part, header.Filename, nil
+	}
+	return req.Body, req.URL.Query().Get("fileName"), nil
+}
+
+func uploadHandler(store blobs.Store, commands eh.CommandHandler, transactions Transactions, writers AccountOwners) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		transaction, ok := authorizedTransaction(res, req, writers, transactions)
+		if !ok {
+			return
+		}
+
+		file, fileName, err := uploadedFile(res, req)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		head := make([]byte, sniffLength)
+		read, err := io.ReadFull(file, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		head = head[:read]
+		contentType := http.DetectContentType(head)
+		if !allowedContentType(contentType) {
+			http.Error(res, fmt.Sprintf("files of type %s can not be attached, only images and PDFs", contentType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		digest, size, err := store.Put(io.MultiReader(bytes.NewReader(head), file))
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if fileName != "" {
+			fileName = filepath.Base(fileName)
+		}
+		attachment := accountinformation.TransactionAttachment{
+			Digest:      digest,
+			FileName:    fileName,
+			ContentType: contentType,
+			Size:        size,
+		}
+		err = commands.HandleCommand(req.Context(), accountinformation.AddTransactionAttachmentCommand{
+			MonetaryAccountID: transaction.MonetaryAccountID,
+			TransactionID:     transaction.ID,
+			Digest:            attachment.Digest,
+			FileName:          attachment.FileName,
+			ContentType:       attachment.ContentType,
+			Size:              attachment.Size,
+		})
+		var validationError primitives.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		json.NewEncoder(res).Encode(responseOf(attachment))
+	}
+}
+
+func listHandler(transactions Transactions, readers AccountOwners) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		transaction, ok := authorizedTransaction(res, req, readers, transactions)
+		if !ok {
+			return
+		}
+
+		attachments := make([]attachmentResponse, 0, len(transaction.Attachments))
+		for _, attachment := range transaction.Attachments {
+			attachments = append(attachments, responseOf(attachment))
+		}
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(attachments)
+	}
+}
+
+// downloadHandler serves the content of an attachment, only of attachments of the transaction
+func downloadHandler(store blobs.Store, transactions Transactions, readers AccountOwners) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		transaction, ok := authorizedTransaction(res, req, readers, transactions)
+		if !ok {
+			return
+		}
+
+		digest := blobs.Digest(mux.Vars(req)["digest"])
+		for _, attachment := range transaction.Attachments {
+			if attachment.Digest != digest {
+				continue
+			}
+
+			blob, err := store.Open(digest)
+			if err != nil {
+				http.Error(res, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer blob.Close()
+
+			res.Header().Set("Content-Type", attachment.ContentType)
+			res.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+			if attachment.FileName != "" {
+				res.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+			}
+			io.Copy(res, blob)
+			return
+		}
+		http.Error(res, "unknown attachment", http.StatusNotFound)
+	}
+}
+
+// RegisterAttachmentController will register a http controller to attach images and PDFs to transactions,
+// like receipts for tax and warranty, and to download them again. Readers see the attachments, writers also attach files
+func RegisterAttachmentController(store blobs.Store, commands eh.CommandHandler, transactions Transactions, readers AccountOwners, writers AccountOwners) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix("/attachments/{accountId}/{transactionId}").Subrouter()
+		controller.Methods("POST").Path("").HandlerFunc(uploadHandler(store, commands, transactions, writers))
+		controller.Methods("GET").Path("").HandlerFunc(listHandler(transactions, readers))
+		controller.Methods("GET").Path("/{digest}").HandlerFunc(downloadHandler(store, transactions, readers))
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package attachments
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	"app/blobs"
+	"app/primitives"
+	"app/reporting"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	eh "github.com/looplab/eventhorizon"
+)
+
+var (
+	owner         = primitives.UserID(uuid.New())
+	accountID     = primitives.MonetaryAccountID(uuid.New())
+	transactionID = primitives.TransactionID(uuid.New())
+	receipt       = "\x89PNG\r\n\x1a\n a scanned receipt"
+)
+
+type fakeTransactions struct {
+	transaction reporting.ReportedTransaction
+}
+
+func (transactions *fakeTransactions) IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool {
+	return monetaryAccountID == accountID && userID == owner
+}
+
+func (transactions *fakeTransactions) TransactionOf(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) (reporting.ReportedTransaction, bool) {
+	return transactions.transaction, transactions.transaction.ID == transactionID
+}
+
+type attachingCommandHandler struct {
+	transactions *fakeTransactions
+}
+
+func (handler attachingCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	command := cmd.(accountinformation.AddTransactionAttachmentCommand)
+	handler.transactions.transaction.Attachments = append(handler.transactions.transaction.Attachments, accountinformation.TransactionAttachment{
+		Digest:      command.Digest,
+		FileName:    command.FileName,
+		ContentType: command.ContentType,
+		Size:        command.Size,
+	})
+	return nil
+}
+
+func serve(store blobs.Store, transactions *fakeTransactions, userID primitives.UserID, req *http.Request) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	RegisterAttachmentController(store, attachingCommandHandler{transactions}, transactions, transactions, transactions)(r)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req.WithContext(auth.WithUserID(req.Context(), userID)))
+	return res
+}
+
+func Test_AttachmentController_StoresAndServesReceipts(t *testing.T) {
+	store := blobs.NewMemoryStore()
+	transactions := &fakeTransactions{transaction: reporting.ReportedTransaction{ID: transactionID, MonetaryAccountID: accountID}}
+	path := "/attachments/" + uuid.UUID(accountID).String() + "/" + uuid.UUID(transactionID).String()
+
+	res := serve(store, transactions, owner, httptest.NewRequest(http.MethodPost, path+"?fileName=receipt.png", strings.NewReader(receipt)))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("Expected the receipt to be attached, got %d %s", res.Code, res.Body.String())
+	}
+	attachments := transactions.transaction.Attachments
+	if len(attachments) != 1 || attachments[0].ContentType != "image/png" || attachments[0].FileName != "receipt.png" || attachments[0].Size != int64(len(receipt)) {
+		t.Fatalf("Expected a png attachment, got %v", attachments)
+	}
+
+	res = serve(store, transactions, owner, httptest.NewRequest(http.MethodGet, path+"/"+string(attachments[0].Digest), nil))
+	if content, _ := ioutil.ReadAll(res.Body); res.Code != http.StatusOK || string(content) != receipt || res.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected the receipt to be served, got %d %q", res.Code, content)
+	}
+
+	res = serve(store, transactions, owner, httptest.NewRequest(http.MethodGet, path+"/"+strings.Repeat("0", 64), nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected an attachment of another transaction not to be found, got %d", res.Code)
+	}
+}
+
+func Test_AttachmentController_RejectsOtherUsersAndFiles(t *testing.T) {
+	store := blobs.NewMemoryStore()
+	transactions := &fakeTransactions{transaction: reporting.ReportedTransaction{ID: transactionID, MonetaryAccountID: accountID}}
+	path := "/attachments/" + uuid.UUID(accountID).String() + "/" + uuid.UUID(transactionID).String()
+
+	res := serve(store, transactions, primitives.UserID(uuid.New()), httptest.NewRequest(http.MethodPost, path, strings.NewReader(receipt)))
+	if res.Code != http.StatusForbidden {
+		t.Errorf("Expected another user to be forbidden, got %d", res.Code)
+	}
+
+	res = serve(store, transactions, owner, httptest.NewRequest(http.MethodPost, path, strings.NewReader("#!/bin/sh\necho not a receipt")))
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected a script to be rejected, got %d", res.Code)
+	}
+
+	res = serve(store, transactions, owner, httptest.NewRequest(http.MethodPost, "/attachments/"+uuid.UUID(accountID).String()+"/"+uuid.New().String(), strings.NewReader(receipt)))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown transaction not to be found, got %d", res.Code)
+	}
+
+	if len(transactions.transaction.Attachments) != 0 {
+		t.Errorf("Expected nothing to be attached, got %v", transactions.transaction.Attachments)
+	}
+}
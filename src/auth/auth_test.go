@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"app/primitives"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var (
+	userID = primitives.UserID(uuid.New())
+	now    = time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newTestSigner(key string) Signer {
+	signer := NewSigner([]byte(key))
+	signer.now = func() time.Time { return now }
+	return signer
+}
+
+func Test_Signer_VerifiesSignedTokens(t *testing.T) {
+	signer := newTestSigner("0123456789abcdef0123456789abcdef")
+	token := signer.Sign(userID, now.Add(time.Hour))
+
+	verified, err := signer.Verify(token)
+	if err != nil || verified != userID {
+		t.Fatalf("Expected the token to be of the user, got %s %v", verified, err)
+	}
+
+	if _, err := newTestSigner("another key of at least 32 bytes").Verify(token); err != ErrInvalidToken {
+		t.Errorf("Expected a token of another key to be invalid, got %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	forged := newTestSigner("another key of at least 32 bytes").Sign(primitives.UserID(uuid.New()), now.Add(time.Hour))
+	if _, err := signer.Verify(strings.Split(forged, ".")[0] + "." + parts[1]); err != ErrInvalidToken {
+		t.Errorf("Expected a token with changed claims to be invalid, got %v", err)
+	}
+
+	if _, err := signer.Verify(signer.Sign(userID, now)); err != ErrExpiredToken {
+		t.Errorf("Expected the token to be expired, got %v", err)
+	}
+}
+
+func serve(signer Signer, req *http.Request) (*httptest.ResponseRecorder, primitives.UserID) {
+	var authenticated primitives.UserID
+	r := mux.NewRouter()
+	r.Use(Middleware(signer, "/health/"))
+	r.PathPrefix("/").HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		authenticated, _ = UserIDFrom(req.Context())
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res, authenticated
+}
+
+func Test_Middleware_AuthenticatesRequests(t *testing.T) {
+	signer := newTestSigner("0123456789abcdef0123456789abcdef")
+	token := signer.Sign(userID, now.Add(time.Hour))
+
+	req := httptest.NewRequest(http.MethodGet, "/export/transactions?userId="+userID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if res, authenticated := serve(signer, req); res.Code != http.StatusOK || authenticated != userID {
+		t.Errorf("Expected the bearer token to authenticate the user, got %d %s", res.Code, authenticated)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/graphql/", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: token})
+	if res, authenticated := serve(signer, req); res.Code != http.StatusOK || authenticated != userID {
+		t.Errorf("Expected the session cookie to authenticate the user, got %d %s", res.Code, authenticated)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/export/transactions?userId="+uuid.New().String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if res, _ := serve(signer, req); res.Code != http.StatusForbidden {
+		t.Errorf("Expected the data of another user to be forbidden, got %d", res.Code)
+	}
+
+	if res, _ := serve(signer, httptest.NewRequest(http.MethodGet, "/graphql/", nil)); res.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a request without token to be unauthorized, got %d", res.Code)
+	}
+
+	if res, _ := serve(signer, httptest.NewRequest(http.MethodGet, "/health/ready", nil)); res.Code != http.StatusOK {
+		t.Errorf("Expected the health checks to be public, got %d", res.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"app/primitives"
+	"context"
+	"errors"
+)
+
+// ErrUnauthenticated is returned when there is no user in the context
+var ErrUnauthenticated = errors.New("not authenticated")
+
+// ErrForbidden is returned when the user in the context is not allowed to see the data of another user or account
+var ErrForbidden = errors.New("forbidden")
+
+type contextKey int
+
+const userIDKey contextKey = iota
+
+// WithUserID returns a context with the authenticated user
+func WithUserID(ctx context.Context, userID primitives.UserID) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFrom returns the authenticated user of the context
+func UserIDFrom(ctx context.Context) (primitives.UserID, bool) {
+	if ctx == nil {
+		return primitives.UserID{}, false
+	}
+	userID, ok := ctx.Value(userIDKey).(primitives.UserID)
+	return userID, ok
+}
+
+// Authorize checks that the data of the user is requested by the user itself
+func Authorize(ctx context.Context, userID primitives.UserID) error {
+	authenticated, ok := UserIDFrom(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if authenticated != userID {
+		return ErrForbidden
+	}
+	return nil
+}
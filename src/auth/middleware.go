@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"app/primitives"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// SessionCookie is the cookie with the token of a session, for browsers that cannot send an Authorization header like with websockets
+const SessionCookie = "session"
+
+// Middleware authenticates every request, except those to the public path prefixes, with the bearer token in the Authorization
+// header or the token in the session cookie. The user is put in the context of the request, and a userId query parameter
+// of another user is forbidden
+func Middleware(signer Signer, public ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			for _, prefix := range public {
+				if strings.HasPrefix(req.URL.Path, prefix) {
+					next.ServeHTTP(res, req)
+					return
+				}
+			}
+
+			userID, err := signer.Verify(tokenOf(req))
+			if err != nil {
+				res.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(res, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if requested := req.URL.Query().Get("userId"); requested != "" {
+				if id, err := uuid.Parse(requested); err == nil && primitives.UserID(id) != userID {
+					http.Error(res, ErrForbidden.Error(), http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(res, req.WithContext(WithUserID(req.Context(), userID)))
+		})
+	}
+}
+
+func tokenOf(req *http.Request) string {
+	if header := req.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if cookie, err := req.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// RegisterSessionController registers the endpoint that turns the bearer token of a request into a session cookie
+// that expires after the validity
+func RegisterSessionController(signer Signer, validity time.Duration) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix("/auth").Subrouter()
+		controller.Methods("POST").Path("/session").HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			userID, ok := UserIDFrom(req.Context())
+			if !ok {
+				http.Error(res, ErrUnauthenticated.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			expires := signer.now().Add(validity)
+			http.SetCookie(res, &http.Cookie{
+				Name:     SessionCookie,
+				Value:    signer.Sign(userID, expires),
+				Path:     "/",
+				Expires:  expires,
+				HttpOnly: true,
+				Secure:   true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			res.WriteHeader(http.StatusNoContent)
+		})
+		controller.Methods("DELETE").Path("/session").HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			http.SetCookie(res, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1, HttpOnly: true, Secure: true})
+			res.WriteHeader(http.StatusNoContent)
+		})
+		return nil
+	}
+}
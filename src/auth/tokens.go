@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"app/primitives"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed or not signed with the key
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrExpiredToken is returned for tokens that are signed with the key, but are no longer valid
+var ErrExpiredToken = errors.New("expired token")
+
+// claims are signed in a token, the token is the claims and their signature, both encoded as base64 url
+type claims struct {
+	UserID  uuid.UUID `json:"sub"`
+	Expires int64     `json:"exp"`
+}
+
+// Signer signs and verifies the tokens of users with a local key
+type Signer struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewSigner creates a Signer with the key, the key should be at least 32 random bytes
+func NewSigner(key []byte) Signer {
+	res := new(Signer)
+	res.key = key
+	res.now = time.Now
+	return *res
+}
+
+// Sign creates a token for the user that is valid until expires
+func (signer Signer) Sign(userID primitives.UserID, expires time.Time) string {
+	payload, _ := json.Marshal(claims{UserID: uuid.UUID(userID), Expires: expires.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signer.signature(encoded))
+}
+
+// Verify returns the user of the token when it is signed with the key and has not expired
+func (signer Signer) Verify(token string) (primitives.UserID, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return primitives.UserID{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signer.signature(parts[0])) {
+		return primitives.UserID{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return primitives.UserID{}, ErrInvalidToken
+	}
+	var signed claims
+	if err := json.Unmarshal(payload, &signed); err != nil || signed.UserID == uuid.Nil {
+		return primitives.UserID{}, ErrInvalidToken
+	}
+	if !signer.now().Before(time.Unix(signed.Expires, 0)) {
+		return primitives.UserID{}, ErrExpiredToken
+	}
+	return primitives.UserID(signed.UserID), nil
+}
+
+func (signer Signer) signature(payload string) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
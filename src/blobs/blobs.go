@@ -0,0 +1,139 @@
+package blobs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound is returned for a digest that is not in the store
+var ErrNotFound = errors.New("blob not found")
+
+// Digest is the hex encoded SHA-256 of the content of a blob, blobs are stored by their digest
+// so the same content is only stored once
+type Digest string
+
+// ParseDigest checks that the digest is a hex encoded SHA-256
+func ParseDigest(digest string) (Digest, error) {
+	decoded, err := hex.DecodeString(digest)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid digest %s", digest)
+	}
+	return Digest(digest), nil
+}
+
+// Store keeps blobs by the digest of their content
+type Store interface {
+	// Put stores the content of the reader and returns its digest and size
+	Put(content io.Reader) (Digest, int64, error)
+	// Open returns the content of the blob, or ErrNotFound
+	Open(digest Digest) (io.ReadCloser, error)
+}
+
+// LocalStore stores blobs as files in a directory, in sub directories of the first two characters of their digest
+type LocalStore struct {
+	directory string
+}
+
+// NewLocalStore creates a LocalStore in the directory, the directory is created when it does not exist
+func NewLocalStore(directory string) (LocalStore, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return LocalStore{}, fmt.Errorf("could not create blob directory: %w", err)
+	}
+	return LocalStore{directory: directory}, nil
+}
+
+func (store LocalStore) path(digest Digest) string {
+	return filepath.Join(store.directory, string(digest[:2]), string(digest))
+}
+
+// Put writes the content to a temporary file while it is hashed, and moves it to the path of its digest
+func (store LocalStore) Put(content io.Reader) (Digest, int64, error) {
+	file, err := ioutil.TempFile(store.directory, "upload-")
+	if err != nil {
+		return "", 0, fmt.Errorf("could not create blob: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("could not write blob: %w", err)
+	}
+
+	digest := Digest(hex.EncodeToString(hash.Sum(nil)))
+	path := store.path(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", 0, fmt.Errorf("could not create blob directory: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("could not store blob: %w", err)
+	}
+	return digest, size, nil
+}
+
+// Open opens the file of the blob
+func (store LocalStore) Open(digest Digest) (io.ReadCloser, error) {
+	if _, err := ParseDigest(string(digest)); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(store.path(digest))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// MemoryStore keeps blobs in memory, the blobs are lost on a restart
+type MemoryStore struct {
+	mu    *sync.RWMutex
+	blobs map[Digest][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() MemoryStore {
+	res := new(MemoryStore)
+	res.mu = new(sync.RWMutex)
+	res.blobs = make(map[Digest][]byte)
+	return *res
+}
+
+// Put reads all of the content in memory
+func (store MemoryStore) Put(content io.Reader) (Digest, int64, error) {
+	read, err := ioutil.ReadAll(content)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not read blob: %w", err)
+	}
+	hash := sha256.Sum256(read)
+	digest := Digest(hex.EncodeToString(hash[:]))
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.blobs[digest] = read
+	return digest, int64(len(read)), nil
+}
+
+// Open returns a reader on the blob
+func (store MemoryStore) Open(digest Digest) (io.ReadCloser, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	content, ok := store.blobs[digest]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
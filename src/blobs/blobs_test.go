@@ -0,0 +1,44 @@
+package blobs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_LocalStore_StoresByDigestOfContent(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "blobs")
+	defer os.RemoveAll(directory)
+	store, err := NewLocalStore(directory)
+	if err != nil {
+		t.Fatalf("Could not create store: %v", err)
+	}
+
+	digest, size, err := store.Put(strings.NewReader("receipt"))
+	if err != nil || size != 7 {
+		t.Fatalf("Expected the blob to be stored, got %d %v", size, err)
+	}
+	if digest != "6f32860910ca0fb2a20c7fda143666b09dbf8db5238195c90a586fb542ff0cad" {
+		t.Errorf("Expected a SHA-256 digest, got %s", digest)
+	}
+	if again, _, _ := store.Put(strings.NewReader("receipt")); again != digest {
+		t.Errorf("Expected the same content to have the same digest, got %s and %s", digest, again)
+	}
+
+	blob, err := store.Open(digest)
+	if err != nil {
+		t.Fatalf("Expected the blob to open, got %v", err)
+	}
+	defer blob.Close()
+	if content, _ := ioutil.ReadAll(blob); string(content) != "receipt" {
+		t.Errorf("Expected the content of the blob, got %q", content)
+	}
+
+	if _, err := store.Open(Digest(strings.Repeat("0", 64))); err != ErrNotFound {
+		t.Errorf("Expected an unknown blob not to be found, got %v", err)
+	}
+	if _, err := store.Open("../../etc/passwd"); err == nil {
+		t.Errorf("Expected an invalid digest to be rejected")
+	}
+}
@@ -0,0 +1,118 @@
+package budgeting
+
+import (
+	"app/primitives"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/eventhandler/projector"
+	"github.com/looplab/eventhorizon/repo/memory"
+)
+
+// BudgetOverview is the read model of a budget
+type BudgetOverview struct {
+	ID       primitives.BudgetID
+	UserID   primitives.UserID
+	Category primitives.Category
+	Ledger   Ledger
+}
+
+// EntityID implements the EntityID method of the eventhorizon.Entity interface.
+func (overview *BudgetOverview) EntityID() uuid.UUID {
+	return uuid.UUID(overview.ID)
+}
+
+// BudgetRepository gives access to the budgets of users
+type BudgetRepository interface {
+	FindForUser(ctx context.Context, userID primitives.UserID) ([]BudgetOverview, error)
+}
+
+type readRepoBudgetRepository struct {
+	repo eh.ReadRepo
+}
+
+func (repository readRepoBudgetRepository) FindForUser(ctx context.Context, userID primitives.UserID) ([]BudgetOverview, error) {
+	entities, err := repository.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets []BudgetOverview
+	for _, entity := range entities {
+		overview, ok := entity.(*BudgetOverview)
+		if ok && overview.UserID == userID {
+			budgets = append(budgets, *overview)
+		}
+	}
+	return budgets, nil
+}
+
+// SetupReadModel projects the budget events into an in memory repository
+func SetupReadModel(eventBus eh.EventBus) (BudgetRepository, error) {
+	repo := memory.NewRepo()
+	repo.SetEntityFactory(func() eh.Entity { return &BudgetOverview{} })
+
+	budgetProjector := projector.NewEventHandler(&Projector{}, repo)
+	budgetProjector.SetEntityFactory(func() eh.Entity { return &BudgetOverview{} })
+
+	err := eventBus.AddHandler(eh.MatchAnyEventOf(
+		EhBudgetSet,
+		EhBudgetChanged,
+		EhBudgetSpendingRecorded,
+		EhBudgetSpendingRemoved,
+		EhBudgetRemoved,
+	), budgetProjector)
+	if err != nil {
+		return nil, fmt.Errorf("could not add budget projector: %w", err)
+	}
+
+	return readRepoBudgetRepository{repo: repo}, nil
+}
+
+// Projector projects budget events onto a BudgetOverview
+type Projector struct{}
+
+// ProjectorType implements the ProjectorType method of the eventhorizon.Projector interface.
+func (p *Projector) ProjectorType() projector.Type {
+	return "budget"
+}
+
+// Project implements the Project method of the eventhorizon.Projector interface.
+func (p *Projector) Project(ctx context.Context, event eh.Event, entity eh.Entity) (eh.Entity, error) {
+	overview, ok := entity.(*BudgetOverview)
+	if !ok {
+		return nil, fmt.Errorf("model is of incorrect type")
+	}
+
+	res := *overview
+	res.Ledger.Spendings = make(map[primitives.TransactionID]Spending, len(overview.Ledger.Spendings))
+	for id, spending := range overview.Ledger.Spendings {
+		res.Ledger.Spendings[id] = spending
+	}
+
+	switch data := event.Data().(type) {
+	case *BudgetSet:
+		res.ID = data.ID
+		res.UserID = data.UserID
+		res.Category = data.Category
+		res.Ledger.Limit = data.MonthlyLimit
+		res.Ledger.Rollover = data.Rollover
+		res.Ledger.Since = data.Since
+	case *BudgetChanged:
+		res.Ledger.Limit = data.MonthlyLimit
+		res.Ledger.Rollover = data.Rollover
+		res.Ledger.Since = data.Since
+	case *BudgetSpendingRecorded:
+		res.Ledger.Spendings[data.TransactionID] = Spending{Month: data.Month, Amount: data.Amount}
+	case *BudgetSpendingRemoved:
+		delete(res.Ledger.Spendings, data.TransactionID)
+	case *BudgetRemoved:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("could not project event: %s", event.EventType())
+	}
+
+	return &res, nil
+}
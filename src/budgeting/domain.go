@@ -0,0 +1,414 @@
+package budgeting
+
+import (
+	"app/primitives"
+	"fmt"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+// parentUUID is the namespace of budget ids, set before package variables that derive ids are initialised
+var parentUUID = uuid.MustParse("3a1c07e2-5d1f-4c8e-9b55-a0c6f1d27e44")
+
+// NewBudgetID derives the id of the budget of a user for a category
+func NewBudgetID(userID primitives.UserID, category primitives.Category) primitives.BudgetID {
+	return primitives.BudgetID(uuid.NewMD5(parentUUID, []byte(userID.String()+"-"+string(category))))
+}
+
+// Thresholds are the percentages of the available amount at which a BudgetThresholdReached is emitted
+var Thresholds = []int{80, 100}
+
+// Month a calendar month, formatted as 2006-01
+type Month string
+
+const monthLayout = "2006-01"
+
+// MonthOf returns the month the time is in
+func MonthOf(t time.Time) Month {
+	return Month(t.Format(monthLayout))
+}
+
+// ParseMonth parses a month formatted as 2006-01
+func ParseMonth(s string) (Month, error) {
+	t, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return "", fmt.Errorf("invalid month %s, expected format yyyy-mm", s)
+	}
+	return MonthOf(t), nil
+}
+
+func (month Month) next() Month {
+	t, _ := time.Parse(monthLayout, string(month))
+	return MonthOf(t.AddDate(0, 1, 0))
+}
+
+// Spending a transaction counted against a budget, refunds have a negative amount
+type Spending struct {
+	Month  Month
+	Amount int64
+}
+
+// Ledger keeps the spending of a budget, to compute what is available in a month
+type Ledger struct {
+	Limit     money.Money
+	Rollover  bool
+	Since     Month
+	Spendings map[primitives.TransactionID]Spending
+}
+
+func newLedger() Ledger {
+	return Ledger{Spendings: make(map[primitives.TransactionID]Spending)}
+}
+
+// SpentIn is the total of the spendings in the month
+func (ledger Ledger) SpentIn(month Month) money.Money {
+	return *money.New(ledger.spentIn(month), ledger.Limit.Currency().Code)
+}
+
+// AvailableIn is the monthly limit, plus what is left from previous months when the budget rolls over
+func (ledger Ledger) AvailableIn(month Month) money.Money {
+	return *money.New(ledger.availableIn(month), ledger.Limit.Currency().Code)
+}
+
+// RemainingIn is what is available minus what is spent in the month
+func (ledger Ledger) RemainingIn(month Month) money.Money {
+	return *money.New(ledger.availableIn(month)-ledger.spentIn(month), ledger.Limit.Currency().Code)
+}
+
+// PercentageSpentIn is the percentage of the available amount that is spent in the month
+func (ledger Ledger) PercentageSpentIn(month Month) float64 {
+	available := ledger.availableIn(month)
+	spent := ledger.spentIn(month)
+	if available <= 0 {
+		if spent > 0 {
+			return 100
+		}
+		return 0
+	}
+	return float64(spent) * 100 / float64(available)
+}
+
+func (ledger Ledger) spentIn(month Month) int64 {
+	var spent int64
+	for _, spending := range ledger.Spendings {
+		if spending.Month == month {
+			spent += spending.Amount
+		}
+	}
+	return spent
+}
+
+func (ledger Ledger) availableIn(month Month) int64 {
+	limit := ledger.Limit.Amount()
+	if !ledger.Rollover || month <= ledger.Since {
+		return limit
+	}
+
+	available := limit
+	for m := ledger.Since; m < month; m = m.next() {
+		available = available - ledger.spentIn(m) + limit
+	}
+	return available
+}
+
+type budgetState struct {
+	ID                primitives.BudgetID
+	initialized       bool
+	removed           bool
+	userID            primitives.UserID
+	category          primitives.Category
+	ledger            Ledger
+	thresholdsReached map[Month]int
+}
+
+func emptyBudgetState(id primitives.BudgetID) *budgetState {
+	res := new(budgetState)
+	res.ID = id
+	res.ledger = newLedger()
+	res.thresholdsReached = make(map[Month]int)
+	return res
+}
+
+func (state *budgetState) copy() *budgetState {
+	res := *state
+	res.ledger.Spendings = make(map[primitives.TransactionID]Spending, len(state.ledger.Spendings))
+	for id, spending := range state.ledger.Spendings {
+		res.ledger.Spendings[id] = spending
+	}
+	res.thresholdsReached = make(map[Month]int, len(state.thresholdsReached))
+	for month, threshold := range state.thresholdsReached {
+		res.thresholdsReached[month] = threshold
+	}
+	return &res
+}
+
+type BudgetEvent interface {
+	appliedTo(state *budgetState) *budgetState
+}
+
+type BudgetCommand interface {
+	applyTo(state *budgetState) ([]BudgetEvent, error)
+}
+
+type SetBudgetCommand struct {
+	BudgetID     primitives.BudgetID
+	UserID       primitives.UserID
+	Category     primitives.Category
+	MonthlyLimit primitives.MoneyForCommand
+	Rollover     bool
+	Since        time.Time
+}
+
+func (cmd SetBudgetCommand) applyTo(state *budgetState) ([]BudgetEvent, error) {
+	if cmd.BudgetID != NewBudgetID(cmd.UserID, cmd.Category) {
+		return nil, fmt.Errorf("budget id %s does not belong to user %s and category %s", cmd.BudgetID, cmd.UserID, cmd.Category)
+	}
+
+	if cmd.MonthlyLimit.Amount < 0 {
+		return nil, fmt.Errorf("monthly limit of a budget can not be negative")
+	}
+
+	if money.GetCurrency(cmd.MonthlyLimit.CurrencyCode) == nil {
+		return nil, fmt.Errorf("unknown currency %s", cmd.MonthlyLimit.CurrencyCode)
+	}
+
+	if state == nil || !state.initialized || state.removed {
+		return []BudgetEvent{newBudgetSet(cmd)}, nil
+	}
+
+	if state.ledger.Limit.Currency().Code != cmd.MonthlyLimit.CurrencyCode {
+		return nil, fmt.Errorf("currency of budget can not be changed from %s to %s", state.ledger.Limit.Currency().Code, cmd.MonthlyLimit.CurrencyCode)
+	}
+
+	if state.ledger.Limit.Amount() == cmd.MonthlyLimit.Amount && state.ledger.Rollover == cmd.Rollover && state.ledger.Since == MonthOf(cmd.Since) {
+		return nil, nil
+	}
+
+	return []BudgetEvent{newBudgetChanged(cmd)}, nil
+}
+
+type RemoveBudgetCommand struct {
+	BudgetID primitives.BudgetID
+	UserID   primitives.UserID
+}
+
+func (cmd RemoveBudgetCommand) applyTo(state *budgetState) ([]BudgetEvent, error) {
+	if state == nil || !state.initialized || state.removed {
+		return nil, nil
+	}
+
+	if state.userID != cmd.UserID {
+		return nil, primitives.NewValidationError("BudgetID", fmt.Sprintf("budget %s belongs to another user", cmd.BudgetID))
+	}
+
+	return []BudgetEvent{newBudgetRemoved(state)}, nil
+}
+
+type RecordBudgetSpendingCommand struct {
+	BudgetID        primitives.BudgetID
+	TransactionID   primitives.TransactionID
+	Amount          primitives.MoneyForCommand
+	TransactionDate time.Time
+}
+
+func (cmd RecordBudgetSpendingCommand) applyTo(state *budgetState) ([]BudgetEvent, error) {
+	if state == nil || !state.initialized || state.removed {
+		return nil, fmt.Errorf("budget %s does not exist", cmd.BudgetID)
+	}
+
+	if state.ledger.Limit.Currency().Code != cmd.Amount.CurrencyCode {
+		return nil, fmt.Errorf("spending in %s can not be recorded on budget %s in %s", cmd.Amount.CurrencyCode, cmd.BudgetID, state.ledger.Limit.Currency().Code)
+	}
+
+	month := MonthOf(cmd.TransactionDate)
+	spending, hasSpending := state.ledger.Spendings[cmd.TransactionID]
+	if hasSpending && spending.Month == month && spending.Amount == cmd.Amount.Amount {
+		return nil, nil
+	}
+
+	recorded := newBudgetSpendingRecorded(cmd.BudgetID, cmd.TransactionID, month, cmd.Amount.Amount)
+	events := []BudgetEvent{recorded}
+	return append(events, thresholdsReachedAfter(state, recorded)...), nil
+}
+
+type RemoveBudgetSpendingCommand struct {
+	BudgetID      primitives.BudgetID
+	TransactionID primitives.TransactionID
+}
+
+func (cmd RemoveBudgetSpendingCommand) applyTo(state *budgetState) ([]BudgetEvent, error) {
+	if state == nil || !state.initialized || state.removed {
+		return nil, nil
+	}
+
+	if _, hasSpending := state.ledger.Spendings[cmd.TransactionID]; !hasSpending {
+		return nil, nil
+	}
+
+	return []BudgetEvent{newBudgetSpendingRemoved(cmd.BudgetID, cmd.TransactionID)}, nil
+}
+
+func thresholdsReachedAfter(state *budgetState, event BudgetEvent) []BudgetEvent {
+	recorded, ok := event.(BudgetSpendingRecorded)
+	if !ok {
+		return nil
+	}
+
+	after := event.appliedTo(state)
+	percentage := after.ledger.PercentageSpentIn(recorded.Month)
+
+	var events []BudgetEvent
+	for _, threshold := range Thresholds {
+		if float64(threshold) <= percentage && threshold > state.thresholdsReached[recorded.Month] {
+			events = append(events, newBudgetThresholdReached(after, recorded.Month, threshold))
+		}
+	}
+	return events
+}
+
+type BudgetSet struct {
+	ID           primitives.BudgetID
+	UserID       primitives.UserID
+	Category     primitives.Category
+	MonthlyLimit money.Money
+	Rollover     bool
+	Since        Month
+}
+
+func newBudgetSet(cmd SetBudgetCommand) BudgetSet {
+	res := new(BudgetSet)
+	res.ID = cmd.BudgetID
+	res.UserID = cmd.UserID
+	res.Category = cmd.Category
+	res.MonthlyLimit = cmd.MonthlyLimit.ToMoney()
+	res.Rollover = cmd.Rollover
+	res.Since = MonthOf(cmd.Since)
+	return *res
+}
+
+func (event BudgetSet) appliedTo(state *budgetState) *budgetState {
+	res := emptyBudgetState(event.ID)
+	res.initialized = true
+	res.userID = event.UserID
+	res.category = event.Category
+	res.ledger.Limit = event.MonthlyLimit
+	res.ledger.Rollover = event.Rollover
+	res.ledger.Since = event.Since
+	return res
+}
+
+type BudgetChanged struct {
+	ID           primitives.BudgetID
+	MonthlyLimit money.Money
+	Rollover     bool
+	Since        Month
+}
+
+func newBudgetChanged(cmd SetBudgetCommand) BudgetChanged {
+	res := new(BudgetChanged)
+	res.ID = cmd.BudgetID
+	res.MonthlyLimit = cmd.MonthlyLimit.ToMoney()
+	res.Rollover = cmd.Rollover
+	res.Since = MonthOf(cmd.Since)
+	return *res
+}
+
+func (event BudgetChanged) appliedTo(state *budgetState) *budgetState {
+	res := state.copy()
+	res.ledger.Limit = event.MonthlyLimit
+	res.ledger.Rollover = event.Rollover
+	res.ledger.Since = event.Since
+	return res
+}
+
+type BudgetRemoved struct {
+	ID       primitives.BudgetID
+	UserID   primitives.UserID
+	Category primitives.Category
+}
+
+func newBudgetRemoved(state *budgetState) BudgetRemoved {
+	res := new(BudgetRemoved)
+	res.ID = state.ID
+	res.UserID = state.userID
+	res.Category = state.category
+	return *res
+}
+
+func (event BudgetRemoved) appliedTo(state *budgetState) *budgetState {
+	res := state.copy()
+	res.removed = true
+	return res
+}
+
+type BudgetSpendingRecorded struct {
+	ID            primitives.BudgetID
+	TransactionID primitives.TransactionID
+	Month         Month
+	Amount        int64
+}
+
+func newBudgetSpendingRecorded(id primitives.BudgetID, transactionID primitives.TransactionID, month Month, amount int64) BudgetSpendingRecorded {
+	res := new(BudgetSpendingRecorded)
+	res.ID = id
+	res.TransactionID = transactionID
+	res.Month = month
+	res.Amount = amount
+	return *res
+}
+
+func (event BudgetSpendingRecorded) appliedTo(state *budgetState) *budgetState {
+	res := state.copy()
+	res.ledger.Spendings[event.TransactionID] = Spending{Month: event.Month, Amount: event.Amount}
+	return res
+}
+
+type BudgetSpendingRemoved struct {
+	ID            primitives.BudgetID
+	TransactionID primitives.TransactionID
+}
+
+func newBudgetSpendingRemoved(id primitives.BudgetID, transactionID primitives.TransactionID) BudgetSpendingRemoved {
+	res := new(BudgetSpendingRemoved)
+	res.ID = id
+	res.TransactionID = transactionID
+	return *res
+}
+
+func (event BudgetSpendingRemoved) appliedTo(state *budgetState) *budgetState {
+	res := state.copy()
+	delete(res.ledger.Spendings, event.TransactionID)
+	return res
+}
+
+type BudgetThresholdReached struct {
+	ID        primitives.BudgetID
+	UserID    primitives.UserID
+	Category  primitives.Category
+	Month     Month
+	Threshold int
+	Spent     money.Money
+	Available money.Money
+}
+
+func newBudgetThresholdReached(state *budgetState, month Month, threshold int) BudgetThresholdReached {
+	res := new(BudgetThresholdReached)
+	res.ID = state.ID
+	res.UserID = state.userID
+	res.Category = state.category
+	res.Month = month
+	res.Threshold = threshold
+	res.Spent = state.ledger.SpentIn(month)
+	res.Available = state.ledger.AvailableIn(month)
+	return *res
+}
+
+func (event BudgetThresholdReached) appliedTo(state *budgetState) *budgetState {
+	res := state.copy()
+	if event.Threshold > res.thresholdsReached[event.Month] {
+		res.thresholdsReached[event.Month] = event.Threshold
+	}
+	return res
+}
@@ -0,0 +1,167 @@
+package budgeting
+
+import (
+	"app/primitives"
+	"app/utils"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+var budgetID = NewBudgetID(userID, "Groceries")
+var january = time.Date(2020, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+func newStateAfter(state *budgetState, cmd BudgetCommand) *budgetState {
+	events, _ := cmd.applyTo(state)
+	for i := 0; i < len(events); i++ {
+		state = events[i].appliedTo(state)
+	}
+	return state
+}
+
+func setBudget(rollover bool) SetBudgetCommand {
+	return SetBudgetCommand{
+		BudgetID:     budgetID,
+		UserID:       userID,
+		Category:     "Groceries",
+		MonthlyLimit: primitives.NewMoneyForCommand(*money.New(10000, "EUR")),
+		Rollover:     rollover,
+		Since:        january,
+	}
+}
+
+func spend(amount int64, date time.Time) RecordBudgetSpendingCommand {
+	return RecordBudgetSpendingCommand{
+		BudgetID:        budgetID,
+		TransactionID:   primitives.TransactionID(uuid.New()),
+		Amount:          primitives.NewMoneyForCommand(*money.New(amount, "EUR")),
+		TransactionDate: date,
+	}
+}
+
+func Test_SetBudgetCommand_RejectsForeignBudgetID(t *testing.T) {
+	cmd := setBudget(false)
+	cmd.BudgetID = primitives.BudgetID(uuid.New())
+
+	if _, err := cmd.applyTo(nil); err == nil {
+		t.Errorf("Expected an error for a budget id that does not match user and category")
+	}
+}
+
+func Test_SetBudgetCommand_ChangesSince(t *testing.T) {
+	state := newStateAfter(nil, setBudget(true))
+	cmd := setBudget(true)
+	cmd.Since = january.AddDate(0, 2, 0)
+
+	events, _ := cmd.applyTo(state)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, found %d", len(events))
+	}
+	if changed, ok := events[0].(BudgetChanged); !ok || changed.Since != "2020-03" {
+		t.Errorf("Expected the budget to change since March, got %+v", events[0])
+	}
+	if state = newStateAfter(state, cmd); state.ledger.Since != "2020-03" {
+		t.Errorf("Expected the ledger to start in March, got %s", state.ledger.Since)
+	}
+}
+
+func Test_RemoveBudgetCommand_OnlyByOwner(t *testing.T) {
+	state := newStateAfter(nil, setBudget(false))
+
+	if events, err := (RemoveBudgetCommand{BudgetID: budgetID, UserID: primitives.UserID(uuid.New())}).applyTo(state); err == nil || len(events) != 0 {
+		t.Errorf("Expected an error when another user removes the budget, got %v", events)
+	}
+
+	state = newStateAfter(state, RemoveBudgetCommand{BudgetID: budgetID, UserID: userID})
+	if !state.removed {
+		t.Fatalf("Budget not removed")
+	}
+	if _, err := spend(1000, january).applyTo(state); err == nil {
+		t.Errorf("Expected no spending to be recorded on a removed budget")
+	}
+	if events, _ := setBudget(false).applyTo(state); len(events) != 1 {
+		t.Errorf("Expected the removed budget to be set again, found %d events", len(events))
+	}
+}
+
+func Test_RecordBudgetSpendingCommand_IsIdempotent(t *testing.T) {
+	state := newStateAfter(nil, setBudget(false))
+	cmd := spend(1000, january)
+
+	state = newStateAfter(state, cmd)
+	events, _ := cmd.applyTo(state)
+
+	if len(events) != 0 {
+		t.Errorf("Expected zero events, found %d", len(events))
+	}
+	if spent := state.ledger.SpentIn("2020-01"); spent.Amount() != 1000 {
+		t.Errorf("Expected 1000 spent, found %d", spent.Amount())
+	}
+}
+
+func Test_RecordBudgetSpendingCommand_RejectsOtherCurrency(t *testing.T) {
+	state := newStateAfter(nil, setBudget(false))
+	cmd := spend(1000, january)
+	cmd.Amount = primitives.NewMoneyForCommand(*money.New(1000, "USD"))
+
+	if _, err := cmd.applyTo(state); err == nil {
+		t.Errorf("Expected an error for spending in another currency than the budget")
+	}
+}
+
+func Test_RecordBudgetSpendingCommand_ReachesThresholdsOnce(t *testing.T) {
+	state := newStateAfter(nil, setBudget(false))
+
+	events, _ := spend(8500, january).applyTo(state)
+	expectedEventTypes := []string{"BudgetSpendingRecorded", "BudgetThresholdReached"}
+	if len(events) != len(expectedEventTypes) {
+		t.Fatalf("Expected %d events, found %d", len(expectedEventTypes), len(events))
+	}
+	for i := 0; i < len(expectedEventTypes); i++ {
+		if eventType := utils.TypeNameOf(events[i]); eventType != expectedEventTypes[i] {
+			t.Errorf("Event %d is not %s, found %s", i, expectedEventTypes[i], eventType)
+		}
+	}
+	for _, event := range events {
+		state = event.appliedTo(state)
+	}
+
+	events, _ = spend(500, january).applyTo(state)
+	if len(events) != 1 {
+		t.Errorf("Expected 80%% threshold not to be reached again, found %d events", len(events))
+	}
+
+	events, _ = spend(2000, january).applyTo(state)
+	if len(events) != 2 || events[1].(BudgetThresholdReached).Threshold != 100 {
+		t.Errorf("Expected 100%% threshold to be reached")
+	}
+}
+
+func Test_RemoveBudgetSpendingCommand_RemovesSpending(t *testing.T) {
+	state := newStateAfter(nil, setBudget(false))
+	record := spend(1000, january)
+	remove := RemoveBudgetSpendingCommand{BudgetID: budgetID, TransactionID: record.TransactionID}
+
+	state = newStateAfter(newStateAfter(state, record), remove)
+
+	if spent := state.ledger.SpentIn("2020-01"); spent.Amount() != 0 {
+		t.Errorf("Expected nothing spent, found %d", spent.Amount())
+	}
+}
+
+func Test_Ledger_Rollover(t *testing.T) {
+	state := newStateAfter(nil, setBudget(true))
+	state = newStateAfter(state, spend(4000, january))
+
+	if available := state.ledger.AvailableIn("2020-02"); available.Amount() != 16000 {
+		t.Errorf("Expected 16000 available in february, found %d", available.Amount())
+	}
+
+	withoutRollover := newStateAfter(newStateAfter(nil, setBudget(false)), spend(4000, january))
+	if available := withoutRollover.ledger.AvailableIn("2020-02"); available.Amount() != 10000 {
+		t.Errorf("Expected 10000 available in february, found %d", available.Amount())
+	}
+}
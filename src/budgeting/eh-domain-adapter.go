@@ -0,0 +1,229 @@
+package budgeting
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(BudgetAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// BudgetAggregateType is the aggregate type for the budget
+const BudgetAggregateType = eh.AggregateType("budget")
+
+// Aggregate is an aggregate for a budget
+type Aggregate struct {
+	*events.AggregateBase
+	*budgetState
+}
+
+const EhSetBudgetCommand = eh.CommandType("budget:set")
+const EhRecordBudgetSpendingCommand = eh.CommandType("budget:record-spending")
+const EhRemoveBudgetSpendingCommand = eh.CommandType("budget:remove-spending")
+const EhRemoveBudgetCommand = eh.CommandType("budget:remove")
+
+const EhBudgetSet = eh.EventType("budget:set")
+const EhBudgetChanged = eh.EventType("budget:changed")
+const EhBudgetSpendingRecorded = eh.EventType("budget:spending-recorded")
+const EhBudgetSpendingRemoved = eh.EventType("budget:spending-removed")
+const EhBudgetThresholdReached = eh.EventType("budget:threshold-reached")
+const EhBudgetRemoved = eh.EventType("budget:removed")
+
+// CommandTypes are all command types handled by the budget aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhSetBudgetCommand,
+		EhRecordBudgetSpendingCommand,
+		EhRemoveBudgetSpendingCommand,
+		EhRemoveBudgetCommand,
+	}
+}
+
+func (cmd SetBudgetCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.BudgetID)
+}
+
+func (cmd SetBudgetCommand) AggregateType() eh.AggregateType {
+	return BudgetAggregateType
+}
+
+func (cmd SetBudgetCommand) CommandType() eh.CommandType {
+	return EhSetBudgetCommand
+}
+
+func (cmd RecordBudgetSpendingCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.BudgetID)
+}
+
+func (cmd RecordBudgetSpendingCommand) AggregateType() eh.AggregateType {
+	return BudgetAggregateType
+}
+
+func (cmd RecordBudgetSpendingCommand) CommandType() eh.CommandType {
+	return EhRecordBudgetSpendingCommand
+}
+
+func (cmd RemoveBudgetSpendingCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.BudgetID)
+}
+
+func (cmd RemoveBudgetSpendingCommand) AggregateType() eh.AggregateType {
+	return BudgetAggregateType
+}
+
+func (cmd RemoveBudgetSpendingCommand) CommandType() eh.CommandType {
+	return EhRemoveBudgetSpendingCommand
+}
+
+func (cmd RemoveBudgetCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.BudgetID)
+}
+
+func (cmd RemoveBudgetCommand) AggregateType() eh.AggregateType {
+	return BudgetAggregateType
+}
+
+func (cmd RemoveBudgetCommand) CommandType() eh.CommandType {
+	return EhRemoveBudgetCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(BudgetAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhBudgetSet, func() eh.EventData {
+		return &BudgetSet{}
+	})
+
+	eh.RegisterEventData(EhBudgetChanged, func() eh.EventData {
+		return &BudgetChanged{}
+	})
+
+	eh.RegisterEventData(EhBudgetSpendingRecorded, func() eh.EventData {
+		return &BudgetSpendingRecorded{}
+	})
+
+	eh.RegisterEventData(EhBudgetSpendingRemoved, func() eh.EventData {
+		return &BudgetSpendingRemoved{}
+	})
+
+	eh.RegisterEventData(EhBudgetThresholdReached, func() eh.EventData {
+		return &BudgetThresholdReached{}
+	})
+
+	eh.RegisterEventData(EhBudgetRemoved, func() eh.EventData {
+		return &BudgetRemoved{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.budgetState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.budgetState = eventInDomain.appliedTo(a.budgetState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (BudgetEvent, error) {
+	switch event.EventType() {
+	case EhBudgetSet:
+		return event.Data().(BudgetEvent), nil
+	case EhBudgetChanged:
+		return event.Data().(BudgetEvent), nil
+	case EhBudgetSpendingRecorded:
+		return event.Data().(BudgetEvent), nil
+	case EhBudgetSpendingRemoved:
+		return event.Data().(BudgetEvent), nil
+	case EhBudgetThresholdReached:
+		return event.Data().(BudgetEvent), nil
+	case EhBudgetRemoved:
+		return event.Data().(BudgetEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event BudgetEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case BudgetSet:
+		return EhBudgetSet, nil
+	case BudgetChanged:
+		return EhBudgetChanged, nil
+	case BudgetSpendingRecorded:
+		return EhBudgetSpendingRecorded, nil
+	case BudgetSpendingRemoved:
+		return EhBudgetSpendingRemoved, nil
+	case BudgetThresholdReached:
+		return EhBudgetThresholdReached, nil
+	case BudgetRemoved:
+		return EhBudgetRemoved, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (BudgetCommand, error) {
+	switch cmd := cmd.(type) {
+	case SetBudgetCommand:
+		return cmd, nil
+	case RecordBudgetSpendingCommand:
+		return cmd, nil
+	case RemoveBudgetSpendingCommand:
+		return cmd, nil
+	case RemoveBudgetCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
@@ -0,0 +1,227 @@
+package budgeting
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type trackedTransaction struct {
+	amount          money.Money
+	outgoing        bool
+	transactionDate time.Time
+	category        primitives.Category
+	transfer        bool
+}
+
+// spending is the amount counted against a budget, outgoing transactions count as spending and incoming ones as refunds
+func (transaction trackedTransaction) spending() primitives.MoneyForCommand {
+	absolute := transaction.amount.Absolute()
+	if transaction.outgoing {
+		return primitives.NewMoneyForCommand(*absolute)
+	}
+	return primitives.NewMoneyForCommand(*absolute.Negative())
+}
+
+// SpendingTracker records categorised transactions of the account-information domain on the budgets of the account owners,
+// internal transfers between accounts of a user are never counted
+type SpendingTracker struct {
+	handler eh.CommandHandler
+
+	mu           sync.Mutex
+	budgets      map[primitives.UserID]map[primitives.Category]primitives.BudgetID
+	owners       map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	transactions map[primitives.MonetaryAccountID]map[primitives.TransactionID]trackedTransaction
+}
+
+// NewSpendingTracker creates a SpendingTracker that dispatches its commands to the handler
+func NewSpendingTracker(handler eh.CommandHandler) *SpendingTracker {
+	return &SpendingTracker{
+		handler:      handler,
+		budgets:      make(map[primitives.UserID]map[primitives.Category]primitives.BudgetID),
+		owners:       make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool),
+		transactions: make(map[primitives.MonetaryAccountID]map[primitives.TransactionID]trackedTransaction),
+	}
+}
+
+// Matcher matches all events the tracker is interested in
+func (tracker *SpendingTracker) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhTransactionCategorised,
+		accountinformation.EhMonetaryAccountUserAdded,
+		accountinformation.EhInternalTransferDetected,
+		EhBudgetSet,
+		EhBudgetRemoved,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (tracker *SpendingTracker) HandlerType() eh.EventHandlerType {
+	return "budget-spending-tracker"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (tracker *SpendingTracker) HandleEvent(ctx context.Context, event eh.Event) error {
+	var commands []eh.Command
+
+	switch data := event.Data().(type) {
+	case *accountinformation.NewTransactionFound:
+		tracker.transactionFound(*data)
+	case *accountinformation.TransactionCategorised:
+		commands = tracker.transactionCategorised(data.ID, data.TransactionID, data.Category)
+	case *accountinformation.MonetaryAccountUserAdded:
+		commands = tracker.ownerAdded(data.ID, data.UserID)
+	case *accountinformation.InternalTransferDetected:
+		commands = tracker.transferDetected(data.ID, data.TransactionID)
+	case *BudgetSet:
+		commands = tracker.budgetSet(data.ID, data.UserID, data.Category)
+	case *BudgetRemoved:
+		tracker.budgetRemoved(data.UserID, data.Category)
+	}
+
+	var firstErr error
+	for _, cmd := range commands {
+		if err := tracker.handler.HandleCommand(ctx, cmd); err != nil {
+			log.Printf("Could not track budget spending: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (tracker *SpendingTracker) transactionFound(event accountinformation.NewTransactionFound) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	transactions, ok := tracker.transactions[event.MonetaryAccountID]
+	if !ok {
+		transactions = make(map[primitives.TransactionID]trackedTransaction)
+		tracker.transactions[event.MonetaryAccountID] = transactions
+	}
+
+	transactions[event.ID] = trackedTransaction{
+		amount:          event.Amount,
+		outgoing:        event.MonetaryAccountID == event.FromMonetaryAccountID,
+		transactionDate: event.TransactionDate,
+		category:        primitives.Uncategorised,
+	}
+}
+
+func (tracker *SpendingTracker) transactionCategorised(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID, category primitives.Category) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	transaction, ok := tracker.transactions[monetaryAccountID][transactionID]
+	if !ok || transaction.transfer {
+		return nil
+	}
+
+	previousCategory := transaction.category
+	transaction.category = category
+	tracker.transactions[monetaryAccountID][transactionID] = transaction
+
+	var commands []eh.Command
+	for userID := range tracker.owners[monetaryAccountID] {
+		if budgetID, ok := tracker.budgets[userID][previousCategory]; ok && previousCategory != category {
+			commands = append(commands, RemoveBudgetSpendingCommand{BudgetID: budgetID, TransactionID: transactionID})
+		}
+		if budgetID, ok := tracker.budgets[userID][category]; ok {
+			commands = append(commands, recordSpending(budgetID, transactionID, transaction))
+		}
+	}
+	return commands
+}
+
+func (tracker *SpendingTracker) ownerAdded(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	owners, ok := tracker.owners[monetaryAccountID]
+	if !ok {
+		owners = make(map[primitives.UserID]bool)
+		tracker.owners[monetaryAccountID] = owners
+	}
+	owners[userID] = true
+
+	var commands []eh.Command
+	for transactionID, transaction := range tracker.transactions[monetaryAccountID] {
+		if transaction.transfer {
+			continue
+		}
+		if budgetID, ok := tracker.budgets[userID][transaction.category]; ok {
+			commands = append(commands, recordSpending(budgetID, transactionID, transaction))
+		}
+	}
+	return commands
+}
+
+func (tracker *SpendingTracker) transferDetected(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	transaction, ok := tracker.transactions[monetaryAccountID][transactionID]
+	if !ok || transaction.transfer {
+		return nil
+	}
+
+	transaction.transfer = true
+	tracker.transactions[monetaryAccountID][transactionID] = transaction
+
+	var commands []eh.Command
+	for userID := range tracker.owners[monetaryAccountID] {
+		if budgetID, ok := tracker.budgets[userID][transaction.category]; ok {
+			commands = append(commands, RemoveBudgetSpendingCommand{BudgetID: budgetID, TransactionID: transactionID})
+		}
+	}
+	return commands
+}
+
+func (tracker *SpendingTracker) budgetSet(budgetID primitives.BudgetID, userID primitives.UserID, category primitives.Category) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	budgets, ok := tracker.budgets[userID]
+	if !ok {
+		budgets = make(map[primitives.Category]primitives.BudgetID)
+		tracker.budgets[userID] = budgets
+	}
+	budgets[category] = budgetID
+
+	var commands []eh.Command
+	for monetaryAccountID, owners := range tracker.owners {
+		if !owners[userID] {
+			continue
+		}
+		for transactionID, transaction := range tracker.transactions[monetaryAccountID] {
+			if transaction.category == category && !transaction.transfer {
+				commands = append(commands, recordSpending(budgetID, transactionID, transaction))
+			}
+		}
+	}
+	return commands
+}
+
+func (tracker *SpendingTracker) budgetRemoved(userID primitives.UserID, category primitives.Category) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	delete(tracker.budgets[userID], category)
+}
+
+func recordSpending(budgetID primitives.BudgetID, transactionID primitives.TransactionID, transaction trackedTransaction) RecordBudgetSpendingCommand {
+	return RecordBudgetSpendingCommand{
+		BudgetID:        budgetID,
+		TransactionID:   transactionID,
+		Amount:          transaction.spending(),
+		TransactionDate: transaction.transactionDate,
+	}
+}
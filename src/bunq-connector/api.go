@@ -2,13 +2,17 @@ package bunqconnector
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"math"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/rickb777/date/period"
 
 	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
 
 	"github.com/OGKevin/go-bunq/bunq"
 	"github.com/Rhymond/go-money"
@@ -18,6 +22,7 @@ type bunqAccountID int
 type bunqTransactionID int
 type bunqDirectDebitTransactionID int
 type bunqScheduleID int
+type bunqAttachmentID int
 
 type apiAuth struct{}
 
@@ -26,6 +31,7 @@ type bunqAPI interface {
 	fetchTransactions(ctx context.Context, bunqAccountID bunqAccountID, newerThan time.Time, out chan<- apiTransactionOrError)
 	fetchDirectDebitTransactions(ctx context.Context, bunqAccountID bunqAccountID, out chan<- apiDirectDebitTransactionOrError)
 	fetchSchedules(ctx context.Context, bunqAccountID bunqAccountID, out chan<- apiScheduleOrError)
+	fetchAttachment(ctx context.Context, bunqAccountID bunqAccountID, bunqAttachmentID bunqAttachmentID, out chan<- apiAttachmentOrError)
 }
 
 type realBunqAPI struct {
@@ -101,6 +107,7 @@ type apiTransaction struct {
 	institutionScheduleID *string
 	balanceAfterMutation  money.Money
 	geolocation           *apiTransactionGeolocation
+	attachmentIDs         []bunqAttachmentID
 	transactionDate       time.Time
 	fetchTimestamp        time.Time
 }
@@ -156,6 +163,66 @@ func (api realBunqAPI) fetchTransactions(ctx context.Context, bunqAccountID bunq
 	}
 }
 
+type apiAttachment struct {
+	bunqAttachmentID bunqAttachmentID
+	content          []byte
+}
+
+type apiAttachmentOrError struct {
+	apiAttachment
+	err error
+}
+
+func (api realBunqAPI) fetchAttachment(ctx context.Context, bunqAccountID bunqAccountID, bunqAttachmentID bunqAttachmentID, out chan<- apiAttachmentOrError) {
+	defer func() { close(out) }()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-api.rateLimiter.forGet():
+		content, err := api.fetchAttachmentContent(ctx, bunqAccountID, bunqAttachmentID)
+		if err != nil {
+			out <- apiAttachmentOrError{err: err}
+			return
+		}
+
+		out <- apiAttachmentOrError{apiAttachment: apiAttachment{bunqAttachmentID: bunqAttachmentID, content: content}}
+	}
+}
+
+// fetchAttachmentContent gets the content of an attachment of a monetary account from the api directly,
+// the client only covers public attachments. The request is authenticated with the session of the client
+func (api realBunqAPI) fetchAttachmentContent(ctx context.Context, bunqAccountID bunqAccountID, bunqAttachmentID bunqAttachmentID) ([]byte, error) {
+	clientContext, err := api.client.ExportClientContext()
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%suser/%d/monetary-account/%d/attachment/%d/content", clientContext.BaseURL, clientContext.UserID, bunqAccountID, bunqAttachmentID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("User-Agent", "expenses")
+	req.Header.Set("X-Bunq-Language", "en_US")
+	req.Header.Set("X-Bunq-Region", "nl_NL")
+	req.Header.Set("X-Bunq-Geolocation", "0 0 0 0 NL")
+	req.Header.Set("X-Bunq-Client-Request-Id", uuid.New().String())
+	req.Header.Set("X-Bunq-Client-Authentication", clientContext.SessionServerContext.Token.Token)
+
+	res, err := api.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get content of attachment %d: %s", bunqAttachmentID, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
 type apiDirectDebitTransaction struct {
 	bunqDirectDebitTransactionID bunqDirectDebitTransactionID
 	amount                       money.Money
@@ -389,6 +456,11 @@ func mapTransaction(tx bunq.Payment) (*apiTransaction, error) {
 		radius:    tx.Geolocation.Radius,
 	}
 
+	attachmentIDs := make([]bunqAttachmentID, 0, len(tx.Attachment))
+	for _, attachment := range tx.Attachment {
+		attachmentIDs = append(attachmentIDs, bunqAttachmentID(attachment.ID))
+	}
+
 	aliasIban, _ := iban.NewIBAN(tx.Alias.IBAN)
 	counterpartyIban, _ := iban.NewIBAN(tx.CounterpartyAlias.IBAN)
 
@@ -403,6 +475,7 @@ func mapTransaction(tx bunq.Payment) (*apiTransaction, error) {
 		institutionScheduleID: scheduleID,
 		balanceAfterMutation:  *balanceAfterMutation,
 		geolocation:           geolocation,
+		attachmentIDs:         attachmentIDs,
 		transactionDate:       transactionDate,
 		fetchTimestamp:        time.Now(),
 	}, nil
@@ -9,41 +9,6 @@ import (
 	"github.com/almerlucke/go-iban/iban"
 )
 
-type integrationChannels interface {
-	updatesChannel() chan<- bus.Update
-	accountChannel() chan<- bus.MonetaryAccountDocument
-	transactionChannel() chan<- bus.TransactionDocument
-	scheduleChannel() chan<- bus.ScheduleDocument
-	directDebitChannel() chan<- bus.DirectDebitTransactionDocument
-}
-
-type busChannels struct {
-}
-
-func newBusChannels() busChannels {
-	return busChannels{}
-}
-
-func (b busChannels) updatesChannel() chan<- bus.Update {
-	return bus.UpdatesChannelForWriting()
-}
-
-func (b busChannels) accountChannel() chan<- bus.MonetaryAccountDocument {
-	return bus.AccountChannelForWriting()
-}
-
-func (b busChannels) transactionChannel() chan<- bus.TransactionDocument {
-	return bus.TransactionChannelForWriting()
-}
-
-func (b busChannels) scheduleChannel() chan<- bus.ScheduleDocument {
-	return bus.ScheduleChannelForWriting()
-}
-
-func (b busChannels) directDebitChannel() chan<- bus.DirectDebitTransactionDocument {
-	return bus.DirectDebitChannelForWriting()
-}
-
 func (account apiAccount) mapToDocument(userID primitives.UserID) bus.MonetaryAccountDocument {
 	return bus.MonetaryAccountDocument{
 		Iban:                account.iban,
@@ -195,7 +160,3 @@ func (directDebit apiDirectDebitTransaction) mapToDocument() bus.DirectDebitTran
 		FetchTimestamp:      directDebit.fetchTimestamp,
 	}
 }
-
-func newDoneUpdate(start bus.StartRefreshUpdate) bus.DoneRefreshingUpdate {
-	return bus.NewDoneRefreshingUpdateFrom(start)
-}
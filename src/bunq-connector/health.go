@@ -0,0 +1,30 @@
+package bunqconnector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/OGKevin/go-bunq/bunq"
+)
+
+// HealthCheck tells whether the api of bunq can be reached
+func HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, bunq.BaseURLProduction, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("bunq can not be reached: %w", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("bunq responded with status %d", res.StatusCode)
+	}
+	return nil
+}
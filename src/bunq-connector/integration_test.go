@@ -1,6 +1,7 @@
 package bunqconnector
 
 import (
+	"app/blobs"
 	"app/bus"
 	"app/primitives"
 	"context"
@@ -35,6 +36,10 @@ func (m *fakeBunqAPI) fetchSchedules(ctx context.Context, bunqAccountID bunqAcco
 	m.Called(ctx, bunqAccountID, out)
 }
 
+func (m *fakeBunqAPI) fetchAttachment(ctx context.Context, bunqAccountID bunqAccountID, bunqAttachmentID bunqAttachmentID, out chan<- apiAttachmentOrError) {
+	m.Called(ctx, bunqAccountID, bunqAttachmentID, out)
+}
+
 type fakeAuthRepository struct {
 	mock.Mock
 }
@@ -59,33 +64,58 @@ func (m *fakeRefreshTimestampRepository) saveLastRefresh(accountID bunqAccountID
 	m.Called(accountID, out)
 }
 
-type fakeIntegrationChannels struct {
-	mock.Mock
+// fakePublisher publishes on the channels of the test, so it can wait for documents
+type fakePublisher struct {
+	updatesBus     chan bus.Update
+	accountsBus    chan bus.MonetaryAccountDocument
+	transactionBus chan bus.TransactionDocument
+	scheduleBus    chan bus.ScheduleDocument
+	directDebitBus chan bus.DirectDebitTransactionDocument
 }
 
-func (m *fakeIntegrationChannels) updatesChannel() chan<- bus.Update {
-	args := m.Called()
-	return args.Get(0).(chan bus.Update)
+func (p fakePublisher) PublishUpdate(ctx context.Context, update bus.Update) error {
+	select {
+	case p.updatesBus <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *fakeIntegrationChannels) accountChannel() chan<- bus.MonetaryAccountDocument {
-	args := m.Called()
-	return args.Get(0).(chan bus.MonetaryAccountDocument)
+func (p fakePublisher) PublishAccount(ctx context.Context, document bus.MonetaryAccountDocument) error {
+	select {
+	case p.accountsBus <- document:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *fakeIntegrationChannels) transactionChannel() chan<- bus.TransactionDocument {
-	args := m.Called()
-	return args.Get(0).(chan bus.TransactionDocument)
+func (p fakePublisher) PublishTransaction(ctx context.Context, document bus.TransactionDocument) error {
+	select {
+	case p.transactionBus <- document:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *fakeIntegrationChannels) scheduleChannel() chan<- bus.ScheduleDocument {
-	args := m.Called()
-	return args.Get(0).(chan bus.ScheduleDocument)
+func (p fakePublisher) PublishSchedule(ctx context.Context, document bus.ScheduleDocument) error {
+	select {
+	case p.scheduleBus <- document:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (m *fakeIntegrationChannels) directDebitChannel() chan<- bus.DirectDebitTransactionDocument {
-	args := m.Called()
-	return args.Get(0).(chan bus.DirectDebitTransactionDocument)
+func (p fakePublisher) PublishDirectDebit(ctx context.Context, document bus.DirectDebitTransactionDocument) error {
+	select {
+	case p.directDebitBus <- document:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 type fakeAPIFactory struct {
@@ -103,7 +133,6 @@ type testScope struct {
 	api                        *fakeBunqAPI
 	authRepository             *fakeAuthRepository
 	refreshTimestampRepository *fakeRefreshTimestampRepository
-	channels                   *fakeIntegrationChannels
 	cmd                        StartUserRefreshCommand
 
 	updatesBus     chan bus.Update
@@ -123,30 +152,27 @@ func newTestScope() *testScope {
 	s.api = new(fakeBunqAPI)
 	s.authRepository = new(fakeAuthRepository)
 	s.refreshTimestampRepository = new(fakeRefreshTimestampRepository)
-	s.channels = new(fakeIntegrationChannels)
-	s.cmd = StartUserRefreshCommand{
-		limiter:                    newDefaultRateLimiter(s.ctx),
-		refreshTimestampRepository: s.refreshTimestampRepository,
-		authRepository:             s.authRepository,
-		channels:                   s.channels,
-		apiFactory:                 fakeAPIFactory{api: s.api},
-		context:                    s.ctx,
-	}
-
 	s.updatesBus = make(chan bus.Update)
-	s.channels.On("updatesChannel").Return(s.updatesBus)
-
 	s.accountsBus = make(chan bus.MonetaryAccountDocument)
-	s.channels.On("accountChannel").Return(s.accountsBus)
-
 	s.transactionBus = make(chan bus.TransactionDocument)
-	s.channels.On("transactionChannel").Return(s.transactionBus)
-
 	s.scheduleBus = make(chan bus.ScheduleDocument)
-	s.channels.On("scheduleChannel").Return(s.scheduleBus)
-
 	s.directDebitBus = make(chan bus.DirectDebitTransactionDocument)
-	s.channels.On("directDebitChannel").Return(s.directDebitBus)
+
+	s.cmd = StartUserRefreshCommand{
+		limiter:                    newDefaultRateLimiter(s.ctx),
+		refreshTimestampRepository: s.refreshTimestampRepository,
+		authRepository:             s.authRepository,
+		publisher: fakePublisher{
+			updatesBus:     s.updatesBus,
+			accountsBus:    s.accountsBus,
+			transactionBus: s.transactionBus,
+			scheduleBus:    s.scheduleBus,
+			directDebitBus: s.directDebitBus,
+		},
+		blobs:      blobs.NewMemoryStore(),
+		apiFactory: fakeAPIFactory{api: s.api},
+		context:    s.ctx,
+	}
 
 	return s
 }
@@ -1,6 +1,8 @@
 package bunqconnector
 
 import (
+	"app/blobs"
+	"app/bus"
 	"app/primitives"
 	"context"
 	"encoding/json"
@@ -18,18 +20,21 @@ type StartUserRefreshCommand struct {
 	limiter                    rateLimiter
 	refreshTimestampRepository refreshTimestampRepository
 	authRepository             authRepository
-	channels                   integrationChannels
+	publisher                  bus.Publisher
+	blobs                      blobs.Store
 	apiFactory                 apiFactory
 	context                    context.Context
 }
 
-// NewStartUserRefreshCommand creates a new StartUserRefreshCommand with bunq production values
-func NewStartUserRefreshCommand(ctx context.Context) StartUserRefreshCommand {
+// NewStartUserRefreshCommand creates a new StartUserRefreshCommand with bunq production values, that publishes on the bus
+// and stores attachments of transactions in the blob store
+func NewStartUserRefreshCommand(ctx context.Context, publisher bus.Publisher, blobStore blobs.Store) StartUserRefreshCommand {
 	cmd := new(StartUserRefreshCommand)
 	cmd.limiter = newDefaultRateLimiter(ctx)
 	cmd.refreshTimestampRepository = newInMemoryRefreshTimestampRepository()
 	cmd.authRepository = newInMemoryAuthRepository()
-	cmd.channels = newBusChannels()
+	cmd.publisher = publisher
+	cmd.blobs = blobStore
 	cmd.apiFactory = bunqAPIFactory{}
 	cmd.context = ctx
 	return *cmd
@@ -85,7 +90,7 @@ func (cmd StartUserRefreshCommand) startRefreshForAuth(userID primitives.UserID,
 			}()
 
 		} else {
-			refresher := createUserRefresherWithBusIntegration(cmd.context, client.bunqAPI, cmd.refreshTimestampRepository, cmd.channels)
+			refresher := createUserRefresherWithBusIntegration(cmd.context, client.bunqAPI, cmd.refreshTimestampRepository, cmd.publisher, cmd.blobs)
 			go refresher.refresh(userID)
 		}
 	}
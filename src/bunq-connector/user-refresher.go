@@ -1,10 +1,13 @@
 package bunqconnector
 
 import (
+	"app/blobs"
 	"app/bus"
 	"app/primitives"
+	"bytes"
 	"context"
 	"log"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -20,20 +23,23 @@ type userRefresherWithBusIntegration struct {
 	context                    context.Context
 	api                        bunqAPI
 	refreshTimestampRepository refreshTimestampRepository
-	busChannels                integrationChannels
+	publisher                  bus.Publisher
+	blobs                      blobs.Store
 }
 
 func createUserRefresherWithBusIntegration(
 	ctx context.Context,
 	api bunqAPI,
 	refreshTimestampRepository refreshTimestampRepository,
-	busChannels integrationChannels,
+	publisher bus.Publisher,
+	blobStore blobs.Store,
 ) userRefresher {
 	return userRefresherWithBusIntegration{
 		context:                    ctx,
 		api:                        api,
 		refreshTimestampRepository: refreshTimestampRepository,
-		busChannels:                busChannels,
+		publisher:                  publisher,
+		blobs:                      blobStore,
 	}
 }
 
@@ -115,42 +121,48 @@ func (refresher userRefresherWithBusIntegration) syncAccount(userID primitives.U
 			return
 		}
 
-		startUpdate := newStartRefreshUpdateFor(account)
-		defer refresher.doneSyncing(account, startUpdate)
+		refresh, err := bus.StartRefresh(refresher.context, refresher.publisher, newStartRefreshUpdateFor(account))
+		if err != nil {
+			refresher.published(err)
+			return
+		}
+		defer refresher.doneSyncing(account, refresh)
 
-		refresher.busChannels.updatesChannel() <- startUpdate
-		refresher.busChannels.accountChannel() <- account.mapToDocument(account.userID)
+		refresher.published(refresh.PublishAccount(refresher.context, account.mapToDocument(account.userID)))
 
 		wg := sync.WaitGroup{}
 		wg.Add(3)
 
-		// go refresher.syncTransactions(&wg, account)
-		// go refresher.syncSchedules(&wg, account)
-		go refresher.syncDirectDebits(&wg, account)
+		go refresher.syncTransactions(&wg, refresh, account)
+		go refresher.syncSchedules(&wg, refresh, account)
+		go refresher.syncDirectDebits(&wg, refresh, account)
 
 		wg.Wait()
 	}
 }
 
-func (refresher userRefresherWithBusIntegration) doneSyncing(account accountToRefresh, startUpdate bus.StartRefreshUpdate) {
-	defer func() { refresher.busChannels.updatesChannel() <- newDoneUpdate(startUpdate) }()
+func (refresher userRefresherWithBusIntegration) doneSyncing(account accountToRefresh, refresh *bus.RefreshPublisher) {
+	defer func() {
+		refresher.published(refresh.Done(refresher.context, nil))
+	}()
 
 	select {
 	case <-refresher.context.Done():
 		break
 	default:
 		result := make(chan saveLastRefreshResult)
-		go refresher.refreshTimestampRepository.saveLastRefresh(account.bunqAccountID, startUpdate.Started, result)
+		go refresher.refreshTimestampRepository.saveLastRefresh(account.bunqAccountID, refresh.Started().Started, result)
 
 		for r := range result {
 			if r.err != nil {
 				log.Printf("Unable to save last refresh time for account %d, due to %s", account.bunqAccountID, r.err)
+				refresh.Failed(r.err)
 			}
 		}
 	}
 }
 
-func (refresher userRefresherWithBusIntegration) syncTransactions(wg *sync.WaitGroup, account accountToRefresh) {
+func (refresher userRefresherWithBusIntegration) syncTransactions(wg *sync.WaitGroup, refresh *bus.RefreshPublisher, account accountToRefresh) {
 	defer wg.Done()
 
 	transactions := make(chan apiTransactionOrError, 50)
@@ -176,14 +188,48 @@ func (refresher userRefresherWithBusIntegration) syncTransactions(wg *sync.WaitG
 
 			if tx.err != nil {
 				log.Printf("Error syncing tx: %s", tx.err)
+				refresh.Failed(tx.err)
 			} else {
-				refresher.busChannels.transactionChannel() <- tx.apiTransaction.mapToDocument()
+				document := tx.apiTransaction.mapToDocument()
+				document.Attachments = refresher.syncAttachments(refresh, account, tx.attachmentIDs)
+				refresher.published(refresh.PublishTransaction(refresher.context, document))
 			}
 		}
 	}
 }
 
-func (refresher userRefresherWithBusIntegration) syncSchedules(wg *sync.WaitGroup, account accountToRefresh) {
+// syncAttachments stores the attachments of a transaction, like receipts, in the blob store
+func (refresher userRefresherWithBusIntegration) syncAttachments(refresh *bus.RefreshPublisher, account accountToRefresh, attachmentIDs []bunqAttachmentID) []bus.Attachment {
+	var attachments []bus.Attachment
+	for _, attachmentID := range attachmentIDs {
+		fetched := make(chan apiAttachmentOrError, 1)
+		go refresher.api.fetchAttachment(refresher.context, account.bunqAccountID, attachmentID, fetched)
+
+		for attachment := range fetched {
+			if attachment.err != nil {
+				log.Printf("Error syncing attachment %d: %s", attachmentID, attachment.err)
+				refresh.Failed(attachment.err)
+				continue
+			}
+
+			digest, size, err := refresher.blobs.Put(bytes.NewReader(attachment.content))
+			if err != nil {
+				log.Printf("Could not store attachment %d: %s", attachmentID, err)
+				refresh.Failed(err)
+				continue
+			}
+
+			attachments = append(attachments, bus.Attachment{
+				Digest:      string(digest),
+				ContentType: http.DetectContentType(attachment.content),
+				Size:        size,
+			})
+		}
+	}
+	return attachments
+}
+
+func (refresher userRefresherWithBusIntegration) syncSchedules(wg *sync.WaitGroup, refresh *bus.RefreshPublisher, account accountToRefresh) {
 	defer wg.Done()
 
 	schedules := make(chan apiScheduleOrError, 50)
@@ -201,14 +247,15 @@ func (refresher userRefresherWithBusIntegration) syncSchedules(wg *sync.WaitGrou
 
 			if schedule.err != nil {
 				log.Printf("Error syncing schedules: %s", schedule.err)
+				refresh.Failed(schedule.err)
 			} else {
-				refresher.busChannels.scheduleChannel() <- schedule.apiSchedule.mapToDocument()
+				refresher.published(refresh.PublishSchedule(refresher.context, schedule.apiSchedule.mapToDocument()))
 			}
 		}
 	}
 }
 
-func (refresher userRefresherWithBusIntegration) syncDirectDebits(wg *sync.WaitGroup, account accountToRefresh) {
+func (refresher userRefresherWithBusIntegration) syncDirectDebits(wg *sync.WaitGroup, refresh *bus.RefreshPublisher, account accountToRefresh) {
 	defer wg.Done()
 
 	directDebits := make(chan apiDirectDebitTransactionOrError, 50)
@@ -225,13 +272,20 @@ func (refresher userRefresherWithBusIntegration) syncDirectDebits(wg *sync.WaitG
 			}
 			if directDebit.err != nil {
 				log.Printf("Error syncing directDebit: %s", directDebit.err)
+				refresh.Failed(directDebit.err)
 			} else {
-				refresher.busChannels.directDebitChannel() <- directDebit.apiDirectDebitTransaction.mapToDocument()
+				refresher.published(refresh.PublishDirectDebit(refresher.context, directDebit.apiDirectDebitTransaction.mapToDocument()))
 			}
 		}
 	}
 }
 
+func (refresher userRefresherWithBusIntegration) published(err error) {
+	if err != nil {
+		log.Printf("Could not publish on bus: %v", err)
+	}
+}
+
 func newStartRefreshUpdateFor(account accountToRefresh) bus.StartRefreshUpdate {
 	return bus.StartRefreshUpdate{
 		UserID:              account.userID,
@@ -1,78 +1,78 @@
 package bus
 
-var channels channelsStore
-var started bool = false
+import (
+	"context"
+	"errors"
+)
 
-type channelsStore struct {
-	updatesChannel     chan Update
-	accountChannel     chan MonetaryAccountDocument
-	transactionChannel chan TransactionDocument
-	scheduleChannel    chan ScheduleDocument
-	directDebitChannel chan DirectDebitTransactionDocument
-}
+// Topic a kind of message on the bus, every subscriber of a topic gets every message published on it
+type Topic string
 
-func UpdatesChannelForReading() <-chan Update {
-	ensureStarted()
-	return channels.updatesChannel
-}
+// Topic enum
+const (
+	UpdatesTopic      Topic = "updates"
+	AccountsTopic     Topic = "accounts"
+	TransactionsTopic Topic = "transactions"
+	SchedulesTopic    Topic = "schedules"
+	DirectDebitsTopic Topic = "direct-debits"
+)
 
-func UpdatesChannelForWriting() chan<- Update {
-	ensureStarted()
-	return channels.updatesChannel
-}
+// ErrClosed is returned when publishing on a bus that is closed
+var ErrClosed = errors.New("bus is closed")
 
-func AccountChannelForReading() <-chan MonetaryAccountDocument {
-	ensureStarted()
-	return channels.accountChannel
-}
+// BackPressure what happens when a message is published while the buffer of a subscriber is full
+type BackPressure int
 
-func AccountChannelForWriting() chan<- MonetaryAccountDocument {
-	ensureStarted()
-	return channels.accountChannel
-}
-
-func TransactionChannelForReading() <-chan TransactionDocument {
-	ensureStarted()
-	return channels.transactionChannel
-}
+// BackPressure enum
+const (
+	// Block lets the publisher wait until the subscriber has room, or until the context of the publisher is done
+	Block BackPressure = iota
+	// DropNewest drops the published message for the subscriber
+	DropNewest
+	// DropOldest drops the oldest buffered message of the subscriber to make room
+	DropOldest
+)
 
-func TransactionChannelForWriting() chan<- TransactionDocument {
-	ensureStarted()
-	return channels.transactionChannel
+// SubscriptionOptions how messages are buffered for a subscriber
+type SubscriptionOptions struct {
+	Buffer       int
+	BackPressure BackPressure
 }
 
-func ScheduleChannelForReading() <-chan ScheduleDocument {
-	ensureStarted()
-	return channels.scheduleChannel
+// bufferSize is the buffer of the channel of a subscriber, messages are only dropped from a buffer so subscribers
+// that drop messages get a buffer of at least one
+func (options SubscriptionOptions) bufferSize() int {
+	if options.BackPressure != Block && options.Buffer < 1 {
+		return 1
+	}
+	return options.Buffer
 }
 
-func ScheduleChannelForWriting() chan<- ScheduleDocument {
-	ensureStarted()
-	return channels.scheduleChannel
-}
+// DefaultSubscriptionOptions buffers like the channels of the bus used to, and lets publishers wait for the subscriber
+var DefaultSubscriptionOptions = SubscriptionOptions{Buffer: 50, BackPressure: Block}
 
-func DirectDebitChannelForReading() <-chan DirectDebitTransactionDocument {
-	ensureStarted()
-	return channels.directDebitChannel
+// Publisher publishes the documents of institutions
+type Publisher interface {
+	PublishUpdate(ctx context.Context, update Update) error
+	PublishAccount(ctx context.Context, document MonetaryAccountDocument) error
+	PublishTransaction(ctx context.Context, document TransactionDocument) error
+	PublishSchedule(ctx context.Context, document ScheduleDocument) error
+	PublishDirectDebit(ctx context.Context, document DirectDebitTransactionDocument) error
 }
 
-func DirectDebitChannelForWriting() chan<- DirectDebitTransactionDocument {
-	ensureStarted()
-	return channels.directDebitChannel
+// Subscriber subscribes to the documents of institutions, the channels are closed when the bus closes
+type Subscriber interface {
+	SubscribeUpdates(options SubscriptionOptions) <-chan Update
+	SubscribeAccounts(options SubscriptionOptions) <-chan MonetaryAccountDocument
+	SubscribeTransactions(options SubscriptionOptions) <-chan TransactionDocument
+	SubscribeSchedules(options SubscriptionOptions) <-chan ScheduleDocument
+	SubscribeDirectDebits(options SubscriptionOptions) <-chan DirectDebitTransactionDocument
 }
 
-func ensureStarted() {
-	if started {
-		return
-	}
-
-	channels = channelsStore{
-		updatesChannel:     make(chan Update, 50),
-		accountChannel:     make(chan MonetaryAccountDocument, 50),
-		transactionChannel: make(chan TransactionDocument, 50),
-		directDebitChannel: make(chan DirectDebitTransactionDocument, 50),
-		scheduleChannel:    make(chan ScheduleDocument, 50),
-	}
-
-	started = true
+// Bus connects the connectors of institutions to the domains
+type Bus interface {
+	Publisher
+	Subscriber
+	// Close stops publishing and closes the channels of all subscribers
+	Close()
 }
@@ -25,6 +25,10 @@ type DoneRefreshingUpdate struct {
 	SyncID                primitives.SyncID
 	Started               time.Time
 	Finished              time.Time
+	// Documents is the number of documents that were published during the refresh
+	Documents int
+	// Errors are the errors the refresh ran into, a refresh can finish with some of its documents missing
+	Errors []string
 }
 
 func NewDoneRefreshingUpdateFrom(startUpdate StartRefreshUpdate) DoneRefreshingUpdate {
@@ -44,6 +48,14 @@ type Geolocation struct {
 	Radius    float64
 }
 
+// Attachment is a file of the institution attached to a transaction, the connector puts its content in the blob store
+type Attachment struct {
+	Digest      string
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
 type TransactionDocument struct {
 	Amount money.Money
 
@@ -61,6 +73,7 @@ type TransactionDocument struct {
 	InstitutionScheduleID *string
 	BalanceAfterMutation  money.Money
 	Geolocation           *Geolocation
+	Attachments           []Attachment
 	TransactionDate       time.Time
 	FetchTimestamp        time.Time
 }
@@ -74,6 +87,8 @@ type MonetaryAccountDocument struct {
 	InstitutionEntityID string
 	Balance             money.Money
 	FetchTimestamp      time.Time
+	// Imported accounts come from statement files, which anyone can write, so they never add an owner to an existing account
+	Imported bool
 }
 
 type ScheduleDocument struct {
@@ -0,0 +1,397 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Message is an entry of the log of a DurableBus with the document as it was published
+type Message struct {
+	Offset    uint64
+	Topic     Topic
+	Published time.Time
+	Document  interface{}
+}
+
+// Handler handles a message for a consumer, the message is acknowledged when no error is returned
+type Handler func(ctx context.Context, message Message) error
+
+// Consumer consumes messages with acknowledgements, a message that is not acknowledged is delivered again
+type Consumer interface {
+	// Consume delivers the messages of the topics that the named consumer has not acknowledged yet, and keeps delivering
+	// new messages until the context is done or the bus is closed
+	Consume(ctx context.Context, name string, topics []Topic, handle Handler) error
+}
+
+// DeadLetter is a message a consumer gave up on
+type DeadLetter struct {
+	Consumer string
+	Entry    Entry
+	Error    string
+	Attempts int
+	Failed   time.Time
+}
+
+// DurableOptions how often a message is delivered before it is a dead letter
+type DurableOptions struct {
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, it doubles with every attempt after that
+	Backoff time.Duration
+}
+
+// DefaultDurableOptions gives a failing message about a second to succeed
+var DefaultDurableOptions = DurableOptions{MaxAttempts: 5, Backoff: 100 * time.Millisecond}
+
+type registeredConsumer struct {
+	topics []Topic
+	handle Handler
+}
+
+// DurableBus is a Bus that writes every message to a log on disk before it is published, and a Consumer that
+// remembers the offset every consumer acknowledged. Messages are delivered at least once to consumers, after a crash
+// the messages that were not acknowledged are delivered again.
+// Subscribers get messages like they get them from the InMemoryBus, without acknowledgements
+type DurableBus struct {
+	memory      *InMemoryBus
+	log         *fileLog
+	deadLetters *fileLog
+	directory   string
+	options     DurableOptions
+
+	mu        sync.Mutex
+	appended  chan struct{}
+	offsets   map[string]uint64
+	consumers map[string]registeredConsumer
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	consuming sync.WaitGroup
+}
+
+// OpenDurableBus opens the log, offsets and dead letters in directory, creating them when they do not exist
+func OpenDurableBus(directory string, options DurableOptions) (*DurableBus, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("could not create bus directory: %w", err)
+	}
+
+	messages, err := openFileLog(filepath.Join(directory, "messages.log"))
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := openFileLog(filepath.Join(directory, "dead-letters.log"))
+	if err != nil {
+		messages.close()
+		return nil, err
+	}
+
+	offsets := make(map[string]uint64)
+	stored, err := ioutil.ReadFile(filepath.Join(directory, "offsets.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read consumer offsets: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(stored, &offsets); err != nil {
+			return nil, fmt.Errorf("could not decode consumer offsets: %w", err)
+		}
+	}
+
+	return &DurableBus{
+		memory:      NewInMemoryBus(),
+		log:         messages,
+		deadLetters: deadLetters,
+		directory:   directory,
+		options:     options,
+		appended:    make(chan struct{}),
+		offsets:     offsets,
+		consumers:   make(map[string]registeredConsumer),
+		closing:     make(chan struct{}),
+	}, nil
+}
+
+func (b *DurableBus) publish(ctx context.Context, topic Topic, message interface{}) error {
+	if message == nil {
+		return fmt.Errorf("can not publish nil on %s", topic)
+	}
+	select {
+	case <-b.closing:
+		return ErrClosed
+	default:
+	}
+
+	if _, err := b.log.append(topic, message); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	close(b.appended)
+	b.appended = make(chan struct{})
+	b.mu.Unlock()
+
+	return b.memory.publish(ctx, topic, message)
+}
+
+// Consume implements the Consume method of the Consumer interface.
+// A message that keeps failing is a dead letter after MaxAttempts, and is acknowledged so the consumer can continue
+func (b *DurableBus) Consume(ctx context.Context, name string, topics []Topic, handle Handler) error {
+	b.mu.Lock()
+	select {
+	case <-b.closing:
+		b.mu.Unlock()
+		return nil
+	default:
+	}
+	if _, ok := b.consumers[name]; ok {
+		b.mu.Unlock()
+		return fmt.Errorf("consumer %s is already consuming", name)
+	}
+	b.consumers[name] = registeredConsumer{topics: topics, handle: handle}
+	b.consuming.Add(1)
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.consumers, name)
+		b.mu.Unlock()
+		b.consuming.Done()
+	}()
+
+	for {
+		b.mu.Lock()
+		offset := b.offsets[name]
+		appended := b.appended
+		b.mu.Unlock()
+
+		if offset == b.log.length() {
+			select {
+			case <-appended:
+				continue
+			case <-ctx.Done():
+				return nil
+			case <-b.closing:
+				return nil
+			}
+		}
+
+		entry, err := b.log.read(offset)
+		if err != nil {
+			return err
+		}
+		if subscribed(topics, entry.Topic) {
+			err := b.deliver(ctx, name, entry, handle)
+			if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+				return nil
+			}
+			if err != nil && !errors.Is(err, errDeadLetter) {
+				return err
+			}
+		}
+
+		if err := b.acknowledge(name, offset+1); err != nil {
+			return err
+		}
+	}
+}
+
+// Replay delivers the messages published within [from, to) again to the named consumer, without touching its offset.
+// It returns the number of messages that were handled and the number of dead letters
+func (b *DurableBus) Replay(ctx context.Context, name string, from time.Time, to time.Time) (int, int, error) {
+	b.mu.Lock()
+	consumer, ok := b.consumers[name]
+	b.mu.Unlock()
+	if !ok {
+		return 0, 0, fmt.Errorf("consumer %s is not consuming", name)
+	}
+
+	replayed, deadLetters := 0, 0
+	length := b.log.length()
+	for offset := uint64(0); offset < length; offset++ {
+		entry, err := b.log.read(offset)
+		if err != nil {
+			return replayed, deadLetters, err
+		}
+		if entry.Published.Before(from) || !entry.Published.Before(to) || !subscribed(consumer.topics, entry.Topic) {
+			continue
+		}
+
+		if err := b.deliver(ctx, name, entry, consumer.handle); err != nil {
+			if ctx.Err() != nil {
+				return replayed, deadLetters, ctx.Err()
+			}
+			if errors.Is(err, errDeadLetter) {
+				deadLetters++
+				continue
+			}
+			return replayed, deadLetters, err
+		}
+		replayed++
+	}
+	return replayed, deadLetters, nil
+}
+
+var errDeadLetter = errors.New("message is a dead letter")
+
+// deadLettersTopic is the topic of the entries in the log of dead letters, nothing is published on it
+const deadLettersTopic Topic = "dead-letters"
+
+// deliver hands the entry to the consumer until it is handled, the context is done, or the attempts run out.
+// Running out of attempts writes a dead letter and returns errDeadLetter
+func (b *DurableBus) deliver(ctx context.Context, name string, entry Entry, handle Handler) error {
+	document, err := entry.decode()
+	if err == nil {
+		message := Message{Offset: entry.Offset, Topic: entry.Topic, Published: entry.Published, Document: document}
+
+		backoff := b.options.Backoff
+		for attempt := 1; attempt <= b.options.MaxAttempts; attempt++ {
+			if err = handle(ctx, message); err == nil {
+				return nil
+			}
+			if attempt == b.options.MaxAttempts {
+				break
+			}
+
+			log.Printf("Consumer %s could not handle message at offset %d, attempt %d: %v", name, entry.Offset, attempt, err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-b.closing:
+				return ErrClosed
+			}
+			backoff *= 2
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	log.Printf("Consumer %s gave up on message at offset %d: %v", name, entry.Offset, err)
+	deadLetter := DeadLetter{Consumer: name, Entry: entry, Error: err.Error(), Attempts: b.options.MaxAttempts, Failed: time.Now()}
+	if _, err := b.deadLetters.append(deadLettersTopic, deadLetter); err != nil {
+		return fmt.Errorf("could not write dead letter: %w", err)
+	}
+	return errDeadLetter
+}
+
+// acknowledge stores the offset of the next message for the consumer, the offsets file is replaced so a crash
+// leaves either the old or the new offsets
+func (b *DurableBus) acknowledge(name string, offset uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.offsets[name] = offset
+	encoded, err := json.Marshal(b.offsets)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(b.directory, "offsets.json")
+	if err := ioutil.WriteFile(path+".tmp", encoded, 0600); err != nil {
+		return fmt.Errorf("could not write consumer offsets: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("could not replace consumer offsets: %w", err)
+	}
+	return nil
+}
+
+// DeadLetters are the messages that consumers gave up on, oldest first
+func (b *DurableBus) DeadLetters() ([]DeadLetter, error) {
+	length := b.deadLetters.length()
+	deadLetters := make([]DeadLetter, 0, length)
+	for offset := uint64(0); offset < length; offset++ {
+		entry, err := b.deadLetters.read(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		var deadLetter DeadLetter
+		if err := json.Unmarshal(entry.Message, &deadLetter); err != nil {
+			return nil, fmt.Errorf("could not decode dead letter %d: %w", offset, err)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+// Close stops publishing, waits for the consumers to finish the message they are handling, and closes the log.
+// The channels of subscribers are closed like the InMemoryBus closes them
+func (b *DurableBus) Close() {
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		close(b.closing)
+		b.mu.Unlock()
+
+		b.memory.Close()
+		b.consuming.Wait()
+
+		b.log.close()
+		b.deadLetters.close()
+	})
+}
+
+func subscribed(topics []Topic, topic Topic) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishUpdate implements the PublishUpdate method of the Publisher interface.
+func (b *DurableBus) PublishUpdate(ctx context.Context, update Update) error {
+	return b.publish(ctx, UpdatesTopic, update)
+}
+
+// PublishAccount implements the PublishAccount method of the Publisher interface.
+func (b *DurableBus) PublishAccount(ctx context.Context, document MonetaryAccountDocument) error {
+	return b.publish(ctx, AccountsTopic, document)
+}
+
+// PublishTransaction implements the PublishTransaction method of the Publisher interface.
+func (b *DurableBus) PublishTransaction(ctx context.Context, document TransactionDocument) error {
+	return b.publish(ctx, TransactionsTopic, document)
+}
+
+// PublishSchedule implements the PublishSchedule method of the Publisher interface.
+func (b *DurableBus) PublishSchedule(ctx context.Context, document ScheduleDocument) error {
+	return b.publish(ctx, SchedulesTopic, document)
+}
+
+// PublishDirectDebit implements the PublishDirectDebit method of the Publisher interface.
+func (b *DurableBus) PublishDirectDebit(ctx context.Context, document DirectDebitTransactionDocument) error {
+	return b.publish(ctx, DirectDebitsTopic, document)
+}
+
+// SubscribeUpdates implements the SubscribeUpdates method of the Subscriber interface.
+func (b *DurableBus) SubscribeUpdates(options SubscriptionOptions) <-chan Update {
+	return b.memory.SubscribeUpdates(options)
+}
+
+// SubscribeAccounts implements the SubscribeAccounts method of the Subscriber interface.
+func (b *DurableBus) SubscribeAccounts(options SubscriptionOptions) <-chan MonetaryAccountDocument {
+	return b.memory.SubscribeAccounts(options)
+}
+
+// SubscribeTransactions implements the SubscribeTransactions method of the Subscriber interface.
+func (b *DurableBus) SubscribeTransactions(options SubscriptionOptions) <-chan TransactionDocument {
+	return b.memory.SubscribeTransactions(options)
+}
+
+// SubscribeSchedules implements the SubscribeSchedules method of the Subscriber interface.
+func (b *DurableBus) SubscribeSchedules(options SubscriptionOptions) <-chan ScheduleDocument {
+	return b.memory.SubscribeSchedules(options)
+}
+
+// SubscribeDirectDebits implements the SubscribeDirectDebits method of the Subscriber interface.
+func (b *DurableBus) SubscribeDirectDebits(options SubscriptionOptions) <-chan DirectDebitTransactionDocument {
+	return b.memory.SubscribeDirectDebits(options)
+}
@@ -0,0 +1,172 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+var testDurableOptions = DurableOptions{MaxAttempts: 3, Backoff: time.Millisecond}
+
+func openTestBus(t *testing.T, directory string) *DurableBus {
+	b, err := OpenDurableBus(directory, testDurableOptions)
+	if err != nil {
+		t.Fatalf("Could not open bus: %v", err)
+	}
+	return b
+}
+
+func publishAliases(t *testing.T, b *DurableBus, aliases ...string) {
+	for _, alias := range aliases {
+		if err := b.PublishAccount(context.Background(), MonetaryAccountDocument{Alias: alias, Balance: *money.New(100, "EUR")}); err != nil {
+			t.Fatalf("Could not publish: %v", err)
+		}
+	}
+}
+
+// consumeAliases consumes until count messages are handled, handle decides whether a message is acknowledged
+func consumeAliases(t *testing.T, b *DurableBus, count int, handle func(alias string) error) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var aliases []string
+	err := b.Consume(ctx, "test", []Topic{AccountsTopic}, func(ctx context.Context, message Message) error {
+		alias := message.Document.(MonetaryAccountDocument).Alias
+		aliases = append(aliases, alias)
+		if len(aliases) == count {
+			cancel()
+		}
+		return handle(alias)
+	})
+	if err != nil {
+		t.Fatalf("Could not consume: %v", err)
+	}
+	return aliases
+}
+
+func acknowledge(alias string) error {
+	return nil
+}
+
+func Test_Consume_DeliversUnacknowledgedMessagesAfterReopening(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "bus")
+	defer os.RemoveAll(directory)
+
+	b := openTestBus(t, directory)
+	publishAliases(t, b, "first", "second", "third")
+	consumed := consumeAliases(t, b, 2, func(alias string) error {
+		if alias == "second" {
+			return context.Canceled
+		}
+		return nil
+	})
+	b.Close()
+
+	if len(consumed) != 2 {
+		t.Fatalf("Expected two messages to be consumed, got %v", consumed)
+	}
+
+	b = openTestBus(t, directory)
+	defer b.Close()
+	consumed = consumeAliases(t, b, 2, acknowledge)
+
+	if len(consumed) != 2 || consumed[0] != "second" || consumed[1] != "third" {
+		t.Errorf("Expected the unacknowledged messages to be delivered again, got %v", consumed)
+	}
+}
+
+func Test_Consume_WritesDeadLetterAndContinues(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "bus")
+	defer os.RemoveAll(directory)
+
+	b := openTestBus(t, directory)
+	defer b.Close()
+	publishAliases(t, b, "broken", "fine")
+
+	consumed := consumeAliases(t, b, 4, func(alias string) error {
+		if alias == "broken" {
+			return errors.New("can not handle this")
+		}
+		return nil
+	})
+
+	if len(consumed) != 4 || consumed[3] != "fine" {
+		t.Errorf("Expected three attempts of the broken message before the next one, got %v", consumed)
+	}
+
+	deadLetters, err := b.DeadLetters()
+	if err != nil {
+		t.Fatalf("Could not read dead letters: %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Entry.Offset != 0 || deadLetters[0].Attempts != 3 || deadLetters[0].Error != "can not handle this" {
+		t.Errorf("Expected the broken message to be a dead letter, got %+v", deadLetters)
+	}
+}
+
+func Test_Replay_DeliversTimeRangeToConsumer(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "bus")
+	defer os.RemoveAll(directory)
+
+	b := openTestBus(t, directory)
+	defer b.Close()
+
+	publishAliases(t, b, "before")
+	time.Sleep(5 * time.Millisecond)
+	from := time.Now()
+	publishAliases(t, b, "during")
+	b.PublishUpdate(context.Background(), StartRefreshUpdate{Started: time.Now()})
+	to := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	publishAliases(t, b, "after")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replayedAliases := make(chan string, 10)
+	go b.Consume(ctx, "test", []Topic{AccountsTopic}, func(ctx context.Context, message Message) error {
+		replayedAliases <- message.Document.(MonetaryAccountDocument).Alias
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		<-replayedAliases
+	}
+
+	replayed, deadLetters, err := b.Replay(context.Background(), "test", from, to)
+	if err != nil {
+		t.Fatalf("Could not replay: %v", err)
+	}
+	if replayed != 1 || deadLetters != 0 {
+		t.Errorf("Expected one message to be replayed, got %d and %d dead letters", replayed, deadLetters)
+	}
+	if alias := <-replayedAliases; alias != "during" {
+		t.Errorf("Expected the message within the range to be replayed, got %s", alias)
+	}
+}
+
+func Test_OpenDurableBus_CutsOffPartiallyWrittenEntry(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "bus")
+	defer os.RemoveAll(directory)
+
+	b := openTestBus(t, directory)
+	publishAliases(t, b, "first")
+	b.Close()
+
+	messages, _ := os.OpenFile(filepath.Join(directory, "messages.log"), os.O_APPEND|os.O_WRONLY, 0600)
+	messages.WriteString(`{"Offset":1,"Topic":"accou`)
+	messages.Close()
+
+	b = openTestBus(t, directory)
+	defer b.Close()
+	publishAliases(t, b, "second")
+
+	consumed := consumeAliases(t, b, 2, acknowledge)
+	if len(consumed) != 2 || consumed[1] != "second" {
+		t.Errorf("Expected the partial entry to be replaced, got %v", consumed)
+	}
+}
@@ -0,0 +1,105 @@
+package bus
+
+import (
+	"app/auth"
+	"app/primitives"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ReplayResult is what a replay did
+type ReplayResult struct {
+	Replayed    int
+	DeadLetters int
+}
+
+func parseReplayTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func replayHandler(b *DurableBus) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		consumer := query.Get("consumer")
+		if consumer == "" {
+			http.Error(res, "a consumer is needed to replay", http.StatusBadRequest)
+			return
+		}
+
+		from, err := parseReplayTime(query.Get("from"))
+		if err != nil {
+			http.Error(res, fmt.Sprintf("invalid from %s, expected format yyyy-mm-dd or RFC 3339", query.Get("from")), http.StatusBadRequest)
+			return
+		}
+		to := time.Now()
+		if query.Get("to") != "" {
+			if to, err = parseReplayTime(query.Get("to")); err != nil {
+				http.Error(res, fmt.Sprintf("invalid to %s, expected format yyyy-mm-dd or RFC 3339", query.Get("to")), http.StatusBadRequest)
+				return
+			}
+		}
+
+		replayed, deadLetters, err := b.Replay(req.Context(), consumer, from, to)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(ReplayResult{Replayed: replayed, DeadLetters: deadLetters})
+	}
+}
+
+func deadLettersHandler(b *DurableBus) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		deadLetters, err := b.DeadLetters()
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(deadLetters)
+	}
+}
+
+// operatorsOnly forbids requests of users that are not operators
+func operatorsOnly(operators []primitives.UserID) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			userID, ok := auth.UserIDFrom(req.Context())
+			if !ok {
+				http.Error(res, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+				return
+			}
+			for _, operator := range operators {
+				if operator == userID {
+					next.ServeHTTP(res, req)
+					return
+				}
+			}
+			http.Error(res, auth.ErrForbidden.Error(), http.StatusForbidden)
+		})
+	}
+}
+
+// RegisterBusController will register a http controller to replay messages published between from and to
+// (yyyy-mm-dd or RFC 3339, to defaults to now) into a consumer, and to list the dead letters. Dead letters hold the
+// documents of all users, so only the operators can use the controller
+func RegisterBusController(b *DurableBus, operators []primitives.UserID) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix("/bus").Subrouter()
+		controller.Use(operatorsOnly(operators))
+		controller.Methods("POST").Path("/replay").HandlerFunc(replayHandler(b))
+		controller.Methods("GET").Path("/dead-letters").HandlerFunc(deadLettersHandler(b))
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package bus
+
+import (
+	"app/auth"
+	"app/primitives"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+func Test_BusController_OnlyForOperators(t *testing.T) {
+	directory, _ := ioutil.TempDir("", "bus")
+	defer os.RemoveAll(directory)
+
+	b := openTestBus(t, directory)
+	defer b.Close()
+
+	operator := primitives.UserID(uuid.New())
+	router := mux.NewRouter()
+	RegisterBusController(b, []primitives.UserID{operator})(router)
+
+	requestAs := func(method string, path string, userID primitives.UserID) int {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	user := primitives.UserID(uuid.New())
+	if code := requestAs("GET", "/bus/dead-letters", user); code != http.StatusForbidden {
+		t.Errorf("Expected the dead letters to be forbidden for users, got %d", code)
+	}
+	if code := requestAs("POST", "/bus/replay?consumer=documents&from=2020-01-01", user); code != http.StatusForbidden {
+		t.Errorf("Expected replays to be forbidden for users, got %d", code)
+	}
+	if code := requestAs("GET", "/bus/dead-letters", operator); code != http.StatusOK {
+		t.Errorf("Expected the dead letters to be served to operators, got %d", code)
+	}
+}
@@ -0,0 +1,190 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Entry is a message as it is stored in the log of a DurableBus
+type Entry struct {
+	Offset    uint64
+	Topic     Topic
+	Kind      string
+	Published time.Time
+	Message   json.RawMessage
+}
+
+type span struct {
+	position int64
+	length   int
+}
+
+// fileLog is an append only file of entries, one json document per line.
+// Only the positions of the entries are kept in memory, entries are read from the file when they are consumed
+type fileLog struct {
+	mu      sync.RWMutex
+	file    *os.File
+	size    int64
+	entries []span
+}
+
+// openFileLog opens or creates the log at path. A partially written entry at the end, from a crash while appending, is cut off
+func openFileLog(path string) (*fileLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open log %s: %w", path, err)
+	}
+
+	log := &fileLog{file: file}
+	if err := log.index(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return log, nil
+}
+
+func (log *fileLog) index() error {
+	reader := bufio.NewReader(log.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read log: %w", err)
+		}
+
+		var entry Entry
+		if json.Unmarshal(line, &entry) != nil || entry.Offset != uint64(len(log.entries)) {
+			break
+		}
+		log.entries = append(log.entries, span{position: log.size, length: len(line)})
+		log.size += int64(len(line))
+	}
+
+	if err := log.file.Truncate(log.size); err != nil {
+		return fmt.Errorf("could not cut off the end of the log: %w", err)
+	}
+	_, err := log.file.Seek(log.size, io.SeekStart)
+	return err
+}
+
+// append writes the message to the log and waits for it to be on disk
+func (log *fileLog) append(topic Topic, message interface{}) (Entry, error) {
+	encoded, err := encode(message)
+	if err != nil {
+		return Entry{}, fmt.Errorf("could not encode message for %s: %w", topic, err)
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	entry := Entry{
+		Offset:    uint64(len(log.entries)),
+		Topic:     topic,
+		Kind:      reflect.TypeOf(message).Name(),
+		Published: time.Now(),
+		Message:   encoded,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	line = append(line, '\n')
+
+	if _, err := log.file.Write(line); err != nil {
+		log.file.Truncate(log.size)
+		log.file.Seek(log.size, io.SeekStart)
+		return Entry{}, fmt.Errorf("could not append to log: %w", err)
+	}
+	if err := log.file.Sync(); err != nil {
+		return Entry{}, fmt.Errorf("could not sync log: %w", err)
+	}
+
+	log.entries = append(log.entries, span{position: log.size, length: len(line)})
+	log.size += int64(len(line))
+	return entry, nil
+}
+
+// encode marshals the message, money.Money panics when it is marshalled without an amount
+func encode(message interface{}) (encoded []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+	return json.Marshal(message)
+}
+
+// length is the offset the next entry will get
+func (log *fileLog) length() uint64 {
+	log.mu.RLock()
+	defer log.mu.RUnlock()
+
+	return uint64(len(log.entries))
+}
+
+func (log *fileLog) read(offset uint64) (Entry, error) {
+	log.mu.RLock()
+	if offset >= uint64(len(log.entries)) {
+		log.mu.RUnlock()
+		return Entry{}, fmt.Errorf("no entry at offset %d", offset)
+	}
+	at := log.entries[offset]
+	log.mu.RUnlock()
+
+	line := make([]byte, at.length)
+	if _, err := log.file.ReadAt(line, at.position); err != nil {
+		return Entry{}, fmt.Errorf("could not read entry at offset %d: %w", offset, err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return Entry{}, fmt.Errorf("could not decode entry at offset %d: %w", offset, err)
+	}
+	return entry, nil
+}
+
+func (log *fileLog) close() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return log.file.Close()
+}
+
+// decode gives the message of the entry as the type it was published with
+func (entry Entry) decode() (interface{}, error) {
+	var message interface{}
+	switch entry.Topic {
+	case UpdatesTopic:
+		switch entry.Kind {
+		case "StartRefreshUpdate":
+			message = new(StartRefreshUpdate)
+		case "DoneRefreshingUpdate":
+			message = new(DoneRefreshingUpdate)
+		default:
+			return nil, fmt.Errorf("unknown update %s at offset %d", entry.Kind, entry.Offset)
+		}
+	case AccountsTopic:
+		message = new(MonetaryAccountDocument)
+	case TransactionsTopic:
+		message = new(TransactionDocument)
+	case SchedulesTopic:
+		message = new(ScheduleDocument)
+	case DirectDebitsTopic:
+		message = new(DirectDebitTransactionDocument)
+	default:
+		return nil, fmt.Errorf("unknown topic %s at offset %d", entry.Topic, entry.Offset)
+	}
+
+	if err := json.Unmarshal(entry.Message, message); err != nil {
+		return nil, fmt.Errorf("could not decode %s at offset %d: %w", entry.Kind, entry.Offset, err)
+	}
+	return reflect.ValueOf(message).Elem().Interface(), nil
+}
@@ -0,0 +1,168 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type subscription struct {
+	channel reflect.Value
+	options SubscriptionOptions
+}
+
+// deliver sends the message on the channel of the subscriber, honouring its back-pressure
+func (s subscription) deliver(ctx context.Context, closing <-chan struct{}, message interface{}) error {
+	value := reflect.ValueOf(message)
+
+	switch s.options.BackPressure {
+	case DropNewest:
+		s.channel.TrySend(value)
+		return nil
+	case DropOldest:
+		for !s.channel.TrySend(value) {
+			s.channel.TryRecv()
+		}
+		return nil
+	}
+
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: s.channel, Send: value},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(closing)},
+	})
+	switch chosen {
+	case 1:
+		return ctx.Err()
+	case 2:
+		return ErrClosed
+	}
+	return nil
+}
+
+// InMemoryBus is a Bus within the process
+type InMemoryBus struct {
+	mu            sync.RWMutex
+	closeOnce     sync.Once
+	closing       chan struct{}
+	closed        bool
+	subscriptions map[Topic][]subscription
+}
+
+// NewInMemoryBus creates an InMemoryBus without subscribers
+func NewInMemoryBus() *InMemoryBus {
+	return &InMemoryBus{
+		closing:       make(chan struct{}),
+		subscriptions: make(map[Topic][]subscription),
+	}
+}
+
+func (b *InMemoryBus) publish(ctx context.Context, topic Topic, message interface{}) error {
+	if message == nil {
+		return fmt.Errorf("can not publish nil on %s", topic)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	for _, s := range b.subscriptions[topic] {
+		if err := s.deliver(ctx, b.closing, message); err != nil {
+			return fmt.Errorf("could not publish on %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+func (b *InMemoryBus) subscribe(topic Topic, channel interface{}, options SubscriptionOptions) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := subscription{channel: reflect.ValueOf(channel), options: options}
+	if b.closed {
+		s.channel.Close()
+		return
+	}
+	b.subscriptions[topic] = append(b.subscriptions[topic], s)
+}
+
+// Close stops publishing, publishers that wait for a subscriber get ErrClosed, and closes the channels of all subscribers
+func (b *InMemoryBus) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closing)
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.closed = true
+		for _, subscriptions := range b.subscriptions {
+			for _, s := range subscriptions {
+				s.channel.Close()
+			}
+		}
+	})
+}
+
+// PublishUpdate implements the PublishUpdate method of the Publisher interface.
+func (b *InMemoryBus) PublishUpdate(ctx context.Context, update Update) error {
+	return b.publish(ctx, UpdatesTopic, update)
+}
+
+// PublishAccount implements the PublishAccount method of the Publisher interface.
+func (b *InMemoryBus) PublishAccount(ctx context.Context, document MonetaryAccountDocument) error {
+	return b.publish(ctx, AccountsTopic, document)
+}
+
+// PublishTransaction implements the PublishTransaction method of the Publisher interface.
+func (b *InMemoryBus) PublishTransaction(ctx context.Context, document TransactionDocument) error {
+	return b.publish(ctx, TransactionsTopic, document)
+}
+
+// PublishSchedule implements the PublishSchedule method of the Publisher interface.
+func (b *InMemoryBus) PublishSchedule(ctx context.Context, document ScheduleDocument) error {
+	return b.publish(ctx, SchedulesTopic, document)
+}
+
+// PublishDirectDebit implements the PublishDirectDebit method of the Publisher interface.
+func (b *InMemoryBus) PublishDirectDebit(ctx context.Context, document DirectDebitTransactionDocument) error {
+	return b.publish(ctx, DirectDebitsTopic, document)
+}
+
+// SubscribeUpdates implements the SubscribeUpdates method of the Subscriber interface.
+func (b *InMemoryBus) SubscribeUpdates(options SubscriptionOptions) <-chan Update {
+	channel := make(chan Update, options.bufferSize())
+	b.subscribe(UpdatesTopic, channel, options)
+	return channel
+}
+
+// SubscribeAccounts implements the SubscribeAccounts method of the Subscriber interface.
+func (b *InMemoryBus) SubscribeAccounts(options SubscriptionOptions) <-chan MonetaryAccountDocument {
+	channel := make(chan MonetaryAccountDocument, options.bufferSize())
+	b.subscribe(AccountsTopic, channel, options)
+	return channel
+}
+
+// SubscribeTransactions implements the SubscribeTransactions method of the Subscriber interface.
+func (b *InMemoryBus) SubscribeTransactions(options SubscriptionOptions) <-chan TransactionDocument {
+	channel := make(chan TransactionDocument, options.bufferSize())
+	b.subscribe(TransactionsTopic, channel, options)
+	return channel
+}
+
+// SubscribeSchedules implements the SubscribeSchedules method of the Subscriber interface.
+func (b *InMemoryBus) SubscribeSchedules(options SubscriptionOptions) <-chan ScheduleDocument {
+	channel := make(chan ScheduleDocument, options.bufferSize())
+	b.subscribe(SchedulesTopic, channel, options)
+	return channel
+}
+
+// SubscribeDirectDebits implements the SubscribeDirectDebits method of the Subscriber interface.
+func (b *InMemoryBus) SubscribeDirectDebits(options SubscriptionOptions) <-chan DirectDebitTransactionDocument {
+	channel := make(chan DirectDebitTransactionDocument, options.bufferSize())
+	b.subscribe(DirectDebitsTopic, channel, options)
+	return channel
+}
@@ -0,0 +1,104 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Publish_FansOutToAllSubscribers(t *testing.T) {
+	bus := NewInMemoryBus()
+	first := bus.SubscribeAccounts(DefaultSubscriptionOptions)
+	second := bus.SubscribeAccounts(DefaultSubscriptionOptions)
+	updates := bus.SubscribeUpdates(DefaultSubscriptionOptions)
+
+	if err := bus.PublishAccount(context.Background(), MonetaryAccountDocument{Alias: "Main"}); err != nil {
+		t.Fatalf("Could not publish: %v", err)
+	}
+
+	if (<-first).Alias != "Main" || (<-second).Alias != "Main" {
+		t.Errorf("Expected both subscribers to get the account")
+	}
+	if len(updates) != 0 {
+		t.Errorf("Expected subscribers of other topics not to get the account")
+	}
+}
+
+func Test_Publish_BackPressure(t *testing.T) {
+	bus := NewInMemoryBus()
+	dropNewest := bus.SubscribeTransactions(SubscriptionOptions{Buffer: 1, BackPressure: DropNewest})
+	dropOldest := bus.SubscribeTransactions(SubscriptionOptions{Buffer: 1, BackPressure: DropOldest})
+
+	bus.PublishTransaction(context.Background(), TransactionDocument{Description: "first"})
+	bus.PublishTransaction(context.Background(), TransactionDocument{Description: "second"})
+
+	if document := <-dropNewest; document.Description != "first" {
+		t.Errorf("Expected the newest document to be dropped, got %s", document.Description)
+	}
+	if document := <-dropOldest; document.Description != "second" {
+		t.Errorf("Expected the oldest document to be dropped, got %s", document.Description)
+	}
+}
+
+func Test_Publish_DroppingSubscriberWithoutBuffer(t *testing.T) {
+	bus := NewInMemoryBus()
+	dropOldest := bus.SubscribeTransactions(SubscriptionOptions{Buffer: 0, BackPressure: DropOldest})
+	dropNewest := bus.SubscribeTransactions(SubscriptionOptions{Buffer: 0, BackPressure: DropNewest})
+
+	published := make(chan struct{})
+	go func() {
+		bus.PublishTransaction(context.Background(), TransactionDocument{Description: "first"})
+		bus.PublishTransaction(context.Background(), TransactionDocument{Description: "second"})
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected publishing to a subscriber without buffer not to block")
+	}
+	if document := <-dropOldest; document.Description != "second" {
+		t.Errorf("Expected the oldest document to be dropped, got %s", document.Description)
+	}
+	if document := <-dropNewest; document.Description != "first" {
+		t.Errorf("Expected the newest document to be dropped, got %s", document.Description)
+	}
+}
+
+func Test_Publish_BlockingSubscriberHonoursContext(t *testing.T) {
+	bus := NewInMemoryBus()
+	bus.SubscribeUpdates(SubscriptionOptions{Buffer: 0, BackPressure: Block})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := bus.PublishUpdate(ctx, StartRefreshUpdate{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the publish to time out, got %v", err)
+	}
+}
+
+func Test_Close_StopsWaitingPublishersAndClosesSubscribers(t *testing.T) {
+	bus := NewInMemoryBus()
+	schedules := bus.SubscribeSchedules(SubscriptionOptions{Buffer: 0, BackPressure: Block})
+
+	published := make(chan error)
+	go func() {
+		published <- bus.PublishSchedule(context.Background(), ScheduleDocument{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	bus.Close()
+
+	if err := <-published; !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected the waiting publisher to get ErrClosed, got %v", err)
+	}
+	if _, ok := <-schedules; ok {
+		t.Errorf("Expected the channel of the subscriber to be closed")
+	}
+	if err := bus.PublishSchedule(context.Background(), ScheduleDocument{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected publishing on a closed bus to fail, got %v", err)
+	}
+	if _, ok := <-bus.SubscribeDirectDebits(DefaultSubscriptionOptions); ok {
+		t.Errorf("Expected subscribing to a closed bus to give a closed channel")
+	}
+}
@@ -0,0 +1,90 @@
+package bus
+
+import (
+	"context"
+	"sync"
+)
+
+// RefreshPublisher publishes the documents of one refresh of an account, between its StartRefreshUpdate and its
+// DoneRefreshingUpdate. It counts the documents and collects the errors of the refresh for the DoneRefreshingUpdate
+type RefreshPublisher struct {
+	publisher Publisher
+	start     StartRefreshUpdate
+
+	mu        sync.Mutex
+	documents int
+	errors    []string
+}
+
+// StartRefresh publishes the StartRefreshUpdate
+func StartRefresh(ctx context.Context, publisher Publisher, start StartRefreshUpdate) (*RefreshPublisher, error) {
+	if err := publisher.PublishUpdate(ctx, start); err != nil {
+		return nil, err
+	}
+	return &RefreshPublisher{publisher: publisher, start: start}, nil
+}
+
+// Started is the StartRefreshUpdate of the refresh
+func (refresh *RefreshPublisher) Started() StartRefreshUpdate {
+	return refresh.start
+}
+
+// Failed records an error of the refresh, nil is ignored
+func (refresh *RefreshPublisher) Failed(err error) {
+	if err == nil {
+		return
+	}
+
+	refresh.mu.Lock()
+	defer refresh.mu.Unlock()
+
+	refresh.errors = append(refresh.errors, err.Error())
+}
+
+// Done publishes the DoneRefreshingUpdate, err is recorded when the refresh ended with an error
+func (refresh *RefreshPublisher) Done(ctx context.Context, err error) error {
+	refresh.Failed(err)
+
+	refresh.mu.Lock()
+	update := NewDoneRefreshingUpdateFrom(refresh.start)
+	update.Documents = refresh.documents
+	update.Errors = refresh.errors
+	refresh.mu.Unlock()
+
+	return refresh.publisher.PublishUpdate(ctx, update)
+}
+
+func (refresh *RefreshPublisher) published(err error) error {
+	refresh.mu.Lock()
+	defer refresh.mu.Unlock()
+
+	if err == nil {
+		refresh.documents++
+	}
+	return err
+}
+
+// PublishUpdate implements the PublishUpdate method of the Publisher interface, updates are not counted as documents.
+func (refresh *RefreshPublisher) PublishUpdate(ctx context.Context, update Update) error {
+	return refresh.publisher.PublishUpdate(ctx, update)
+}
+
+// PublishAccount implements the PublishAccount method of the Publisher interface.
+func (refresh *RefreshPublisher) PublishAccount(ctx context.Context, document MonetaryAccountDocument) error {
+	return refresh.published(refresh.publisher.PublishAccount(ctx, document))
+}
+
+// PublishTransaction implements the PublishTransaction method of the Publisher interface.
+func (refresh *RefreshPublisher) PublishTransaction(ctx context.Context, document TransactionDocument) error {
+	return refresh.published(refresh.publisher.PublishTransaction(ctx, document))
+}
+
+// PublishSchedule implements the PublishSchedule method of the Publisher interface.
+func (refresh *RefreshPublisher) PublishSchedule(ctx context.Context, document ScheduleDocument) error {
+	return refresh.published(refresh.publisher.PublishSchedule(ctx, document))
+}
+
+// PublishDirectDebit implements the PublishDirectDebit method of the Publisher interface.
+func (refresh *RefreshPublisher) PublishDirectDebit(ctx context.Context, document DirectDebitTransactionDocument) error {
+	return refresh.published(refresh.publisher.PublishDirectDebit(ctx, document))
+}
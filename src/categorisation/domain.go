@@ -0,0 +1,274 @@
+package categorisation
+
+import (
+	"app/primitives"
+	"fmt"
+	"regexp"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+// AmountRange an inclusive range of absolute transaction amounts
+type AmountRange struct {
+	Min    primitives.MoneyForCommand
+	HasMin bool
+	Max    primitives.MoneyForCommand
+	HasMax bool
+}
+
+// RuleCriteria all criteria of a rule, a transaction has to satisfy every criterium that is set
+type RuleCriteria struct {
+	CounterpartyIBAN     iban.IBAN
+	HasCounterpartyIBAN  bool
+	DescriptionPattern   string
+	MonetaryAccountID    primitives.MonetaryAccountID
+	HasMonetaryAccountID bool
+	AmountRange          AmountRange
+}
+
+func (criteria RuleCriteria) isEmpty() bool {
+	return !criteria.HasCounterpartyIBAN &&
+		criteria.DescriptionPattern == "" &&
+		!criteria.HasMonetaryAccountID &&
+		!criteria.AmountRange.HasMin &&
+		!criteria.AmountRange.HasMax
+}
+
+func (criteria RuleCriteria) validate() error {
+	if criteria.isEmpty() {
+		return fmt.Errorf("a categorisation rule needs at least one criterium")
+	}
+
+	if _, err := regexp.Compile(criteria.DescriptionPattern); err != nil {
+		return fmt.Errorf("invalid description pattern: %w", err)
+	}
+
+	amountRange := criteria.AmountRange
+	if amountRange.HasMin && amountRange.HasMax {
+		if amountRange.Min.CurrencyCode != amountRange.Max.CurrencyCode {
+			return fmt.Errorf("amount range has different currencies %s and %s", amountRange.Min.CurrencyCode, amountRange.Max.CurrencyCode)
+		}
+		if amountRange.Min.Amount > amountRange.Max.Amount {
+			return fmt.Errorf("amount range minimum is larger than the maximum")
+		}
+	}
+
+	return nil
+}
+
+// CandidateTransaction a transaction as seen from one of its monetary accounts, to be categorised
+type CandidateTransaction struct {
+	MonetaryAccountID   primitives.MonetaryAccountID
+	CounterpartyIBAN    iban.IBAN
+	HasCounterpartyIBAN bool
+	Amount              money.Money
+	Description         string
+}
+
+// Rule a categorisation rule that can be evaluated against transactions
+type Rule struct {
+	ID       primitives.CategorisationRuleID
+	UserID   primitives.UserID
+	Category primitives.Category
+	Priority int
+	Criteria RuleCriteria
+	pattern  *regexp.Regexp
+}
+
+// NewRule constructs a Rule, compiling its description pattern
+func NewRule(id primitives.CategorisationRuleID, userID primitives.UserID, category primitives.Category, priority int, criteria RuleCriteria) (Rule, error) {
+	if err := criteria.validate(); err != nil {
+		return Rule{}, err
+	}
+
+	rule := Rule{
+		ID:       id,
+		UserID:   userID,
+		Category: category,
+		Priority: priority,
+		Criteria: criteria,
+	}
+	if criteria.DescriptionPattern != "" {
+		rule.pattern = regexp.MustCompile(criteria.DescriptionPattern)
+	}
+	return rule, nil
+}
+
+// Matches tells if the transaction satisfies all criteria of the rule
+func (rule Rule) Matches(transaction CandidateTransaction) bool {
+	criteria := rule.Criteria
+
+	if criteria.HasMonetaryAccountID && criteria.MonetaryAccountID != transaction.MonetaryAccountID {
+		return false
+	}
+
+	if criteria.HasCounterpartyIBAN && (!transaction.HasCounterpartyIBAN || criteria.CounterpartyIBAN.Code != transaction.CounterpartyIBAN.Code) {
+		return false
+	}
+
+	if rule.pattern != nil && !rule.pattern.MatchString(transaction.Description) {
+		return false
+	}
+
+	return criteria.AmountRange.contains(transaction.Amount)
+}
+
+func (amountRange AmountRange) contains(amount money.Money) bool {
+	if (amount == money.Money{}) {
+		return !amountRange.HasMin && !amountRange.HasMax
+	}
+
+	absolute := amount.Absolute()
+	if amountRange.HasMin {
+		if absolute.Currency().Code != amountRange.Min.CurrencyCode || absolute.Amount() < amountRange.Min.Amount {
+			return false
+		}
+	}
+
+	if amountRange.HasMax {
+		if absolute.Currency().Code != amountRange.Max.CurrencyCode || absolute.Amount() > amountRange.Max.Amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+type categorisationRuleState struct {
+	ID          primitives.CategorisationRuleID
+	initialized bool
+	removed     bool
+	userID      primitives.UserID
+	category    primitives.Category
+	priority    int
+	criteria    RuleCriteria
+}
+
+type CategorisationRuleEvent interface {
+	appliedTo(state *categorisationRuleState) *categorisationRuleState
+}
+
+type CategorisationRuleCommand interface {
+	applyTo(state *categorisationRuleState) ([]CategorisationRuleEvent, error)
+}
+
+type DefineCategorisationRuleCommand struct {
+	RuleID   primitives.CategorisationRuleID
+	UserID   primitives.UserID
+	Category primitives.Category
+	Priority int
+	Criteria RuleCriteria `eh:"optional"`
+}
+
+func (cmd DefineCategorisationRuleCommand) applyTo(state *categorisationRuleState) ([]CategorisationRuleEvent, error) {
+	if err := cmd.Criteria.validate(); err != nil {
+		return nil, err
+	}
+
+	if state == nil || !state.initialized || state.removed {
+		return []CategorisationRuleEvent{newCategorisationRuleDefined(cmd)}, nil
+	}
+
+	if state.userID != cmd.UserID {
+		return nil, primitives.NewValidationError("RuleID", fmt.Sprintf("categorisation rule %s belongs to another user", cmd.RuleID))
+	}
+
+	if state.category == cmd.Category && state.priority == cmd.Priority && state.criteria == cmd.Criteria {
+		return nil, nil
+	}
+
+	return []CategorisationRuleEvent{newCategorisationRuleChanged(cmd)}, nil
+}
+
+type RemoveCategorisationRuleCommand struct {
+	RuleID primitives.CategorisationRuleID
+	UserID primitives.UserID
+}
+
+func (cmd RemoveCategorisationRuleCommand) applyTo(state *categorisationRuleState) ([]CategorisationRuleEvent, error) {
+	if state == nil || !state.initialized || state.removed {
+		return nil, nil
+	}
+
+	if state.userID != cmd.UserID {
+		return nil, primitives.NewValidationError("RuleID", fmt.Sprintf("categorisation rule %s belongs to another user", cmd.RuleID))
+	}
+
+	return []CategorisationRuleEvent{newCategorisationRuleRemoved(state.ID, state.userID)}, nil
+}
+
+type CategorisationRuleDefined struct {
+	ID       primitives.CategorisationRuleID
+	UserID   primitives.UserID
+	Category primitives.Category
+	Priority int
+	Criteria RuleCriteria
+}
+
+func newCategorisationRuleDefined(cmd DefineCategorisationRuleCommand) CategorisationRuleDefined {
+	res := new(CategorisationRuleDefined)
+	res.ID = cmd.RuleID
+	res.UserID = cmd.UserID
+	res.Category = cmd.Category
+	res.Priority = cmd.Priority
+	res.Criteria = cmd.Criteria
+	return *res
+}
+
+func (event CategorisationRuleDefined) appliedTo(state *categorisationRuleState) *categorisationRuleState {
+	res := new(categorisationRuleState)
+	res.ID = event.ID
+	res.initialized = true
+	res.userID = event.UserID
+	res.category = event.Category
+	res.priority = event.Priority
+	res.criteria = event.Criteria
+	return res
+}
+
+type CategorisationRuleChanged struct {
+	ID       primitives.CategorisationRuleID
+	UserID   primitives.UserID
+	Category primitives.Category
+	Priority int
+	Criteria RuleCriteria
+}
+
+func newCategorisationRuleChanged(cmd DefineCategorisationRuleCommand) CategorisationRuleChanged {
+	res := new(CategorisationRuleChanged)
+	res.ID = cmd.RuleID
+	res.UserID = cmd.UserID
+	res.Category = cmd.Category
+	res.Priority = cmd.Priority
+	res.Criteria = cmd.Criteria
+	return *res
+}
+
+func (event CategorisationRuleChanged) appliedTo(state *categorisationRuleState) *categorisationRuleState {
+	res := *state
+
+	res.category = event.Category
+	res.priority = event.Priority
+	res.criteria = event.Criteria
+	return &res
+}
+
+type CategorisationRuleRemoved struct {
+	ID     primitives.CategorisationRuleID
+	UserID primitives.UserID
+}
+
+func newCategorisationRuleRemoved(id primitives.CategorisationRuleID, userID primitives.UserID) CategorisationRuleRemoved {
+	res := new(CategorisationRuleRemoved)
+	res.ID = id
+	res.UserID = userID
+	return *res
+}
+
+func (event CategorisationRuleRemoved) appliedTo(state *categorisationRuleState) *categorisationRuleState {
+	res := *state
+
+	res.removed = true
+	return &res
+}
@@ -0,0 +1,153 @@
+package categorisation
+
+import (
+	"app/primitives"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+var ruleID = primitives.CategorisationRuleID(uuid.New())
+var userID = primitives.UserID(uuid.New())
+
+func newStateAfter(state *categorisationRuleState, cmd CategorisationRuleCommand) *categorisationRuleState {
+	events, _ := cmd.applyTo(state)
+	for i := 0; i < len(events); i++ {
+		state = events[i].appliedTo(state)
+	}
+	return state
+}
+
+func Test_DefineCategorisationRuleCommand_RequiresCriteria(t *testing.T) {
+	cmd := DefineCategorisationRuleCommand{RuleID: ruleID, UserID: userID, Category: "Groceries"}
+
+	_, err := cmd.applyTo(nil)
+
+	if err == nil {
+		t.Errorf("Expected an error for a rule without criteria")
+	}
+}
+
+func Test_DefineCategorisationRuleCommand_RejectsInvalidPattern(t *testing.T) {
+	cmd := DefineCategorisationRuleCommand{RuleID: ruleID, UserID: userID, Category: "Groceries", Criteria: RuleCriteria{DescriptionPattern: "(albert"}}
+
+	_, err := cmd.applyTo(nil)
+
+	if err == nil {
+		t.Errorf("Expected an error for an invalid description pattern")
+	}
+}
+
+func Test_DefineCategorisationRuleCommand_DefinesThenChanges(t *testing.T) {
+	cmd := DefineCategorisationRuleCommand{RuleID: ruleID, UserID: userID, Category: "Groceries", Criteria: RuleCriteria{DescriptionPattern: "(?i)albert heijn"}}
+
+	events, _ := cmd.applyTo(nil)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, found %d", len(events))
+	}
+	if _, ok := events[0].(CategorisationRuleDefined); !ok {
+		t.Errorf("Expected CategorisationRuleDefined")
+	}
+
+	state := newStateAfter(nil, cmd)
+	if events, _ := cmd.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events for an unchanged rule, found %d", len(events))
+	}
+
+	cmd.Category = "Food"
+	events, _ = cmd.applyTo(state)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, found %d", len(events))
+	}
+	if _, ok := events[0].(CategorisationRuleChanged); !ok {
+		t.Errorf("Expected CategorisationRuleChanged")
+	}
+}
+
+func Test_RemoveCategorisationRuleCommand_RemovesOnce(t *testing.T) {
+	define := DefineCategorisationRuleCommand{RuleID: ruleID, UserID: userID, Category: "Groceries", Criteria: RuleCriteria{DescriptionPattern: "jumbo"}}
+	remove := RemoveCategorisationRuleCommand{RuleID: ruleID, UserID: userID}
+
+	state := newStateAfter(newStateAfter(nil, define), remove)
+
+	if !state.removed {
+		t.Errorf("Rule not removed")
+	}
+	if events, _ := remove.applyTo(state); len(events) != 0 {
+		t.Errorf("Expected zero events for an already removed rule, found %d", len(events))
+	}
+}
+
+func Test_RemoveCategorisationRuleCommand_OnlyByOwner(t *testing.T) {
+	define := DefineCategorisationRuleCommand{RuleID: ruleID, UserID: userID, Category: "Groceries", Criteria: RuleCriteria{DescriptionPattern: "jumbo"}}
+	remove := RemoveCategorisationRuleCommand{RuleID: ruleID, UserID: primitives.UserID(uuid.New())}
+
+	events, err := remove.applyTo(newStateAfter(nil, define))
+
+	if err == nil || len(events) != 0 {
+		t.Errorf("Expected an error when another user removes the rule, got %v", events)
+	}
+}
+
+func Test_Rule_Matches(t *testing.T) {
+	landlord, _ := iban.NewIBAN("NL91ABNA0417164300")
+	other, _ := iban.NewIBAN("NL20INGB0001234567")
+
+	rule, err := NewRule(ruleID, userID, "Rent", 0, RuleCriteria{
+		CounterpartyIBAN:    *landlord,
+		HasCounterpartyIBAN: true,
+		DescriptionPattern:  "(?i)huur",
+		AmountRange: AmountRange{
+			Min:    primitives.NewMoneyForCommand(*money.New(50000, "EUR")),
+			HasMin: true,
+			Max:    primitives.NewMoneyForCommand(*money.New(150000, "EUR")),
+			HasMax: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := CandidateTransaction{
+		CounterpartyIBAN:    *landlord,
+		HasCounterpartyIBAN: true,
+		Amount:              *money.New(-95000, "EUR"),
+		Description:         "Huur januari",
+	}
+
+	if !rule.Matches(transaction) {
+		t.Errorf("Expected rule to match")
+	}
+
+	otherCounterparty := transaction
+	otherCounterparty.CounterpartyIBAN = *other
+	if rule.Matches(otherCounterparty) {
+		t.Errorf("Expected rule not to match another counterparty")
+	}
+
+	tooLarge := transaction
+	tooLarge.Amount = *money.New(-250000, "EUR")
+	if rule.Matches(tooLarge) {
+		t.Errorf("Expected rule not to match an amount out of range")
+	}
+
+	otherCurrency := transaction
+	otherCurrency.Amount = *money.New(-95000, "USD")
+	if rule.Matches(otherCurrency) {
+		t.Errorf("Expected rule not to match another currency")
+	}
+}
+
+func Test_SortByPriority(t *testing.T) {
+	low := Rule{ID: primitives.CategorisationRuleID(uuid.New()), Priority: 10}
+	high := Rule{ID: primitives.CategorisationRuleID(uuid.New()), Priority: 1}
+	rules := []Rule{low, high}
+
+	sortByPriority(rules)
+
+	if rules[0].ID != high.ID {
+		t.Errorf("Rules not sorted by priority")
+	}
+}
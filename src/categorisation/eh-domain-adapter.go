@@ -0,0 +1,170 @@
+package categorisation
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(CategorisationRuleAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// CategorisationRuleAggregateType is the aggregate type for the categorisation rule
+const CategorisationRuleAggregateType = eh.AggregateType("categorisationrule")
+
+// Aggregate is an aggregate for a categorisation rule
+type Aggregate struct {
+	*events.AggregateBase
+	*categorisationRuleState
+}
+
+const EhDefineCategorisationRuleCommand = eh.CommandType("categorisationrule:define")
+const EhRemoveCategorisationRuleCommand = eh.CommandType("categorisationrule:remove")
+
+const EhCategorisationRuleDefined = eh.EventType("categorisationrule:defined")
+const EhCategorisationRuleChanged = eh.EventType("categorisationrule:changed")
+const EhCategorisationRuleRemoved = eh.EventType("categorisationrule:removed")
+
+// CommandTypes are all command types handled by the categorisation rule aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhDefineCategorisationRuleCommand,
+		EhRemoveCategorisationRuleCommand,
+	}
+}
+
+func (cmd DefineCategorisationRuleCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.RuleID)
+}
+
+func (cmd DefineCategorisationRuleCommand) AggregateType() eh.AggregateType {
+	return CategorisationRuleAggregateType
+}
+
+func (cmd DefineCategorisationRuleCommand) CommandType() eh.CommandType {
+	return EhDefineCategorisationRuleCommand
+}
+
+func (cmd RemoveCategorisationRuleCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.RuleID)
+}
+
+func (cmd RemoveCategorisationRuleCommand) AggregateType() eh.AggregateType {
+	return CategorisationRuleAggregateType
+}
+
+func (cmd RemoveCategorisationRuleCommand) CommandType() eh.CommandType {
+	return EhRemoveCategorisationRuleCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(CategorisationRuleAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhCategorisationRuleDefined, func() eh.EventData {
+		return &CategorisationRuleDefined{}
+	})
+
+	eh.RegisterEventData(EhCategorisationRuleChanged, func() eh.EventData {
+		return &CategorisationRuleChanged{}
+	})
+
+	eh.RegisterEventData(EhCategorisationRuleRemoved, func() eh.EventData {
+		return &CategorisationRuleRemoved{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.categorisationRuleState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.categorisationRuleState = eventInDomain.appliedTo(a.categorisationRuleState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (CategorisationRuleEvent, error) {
+	switch event.EventType() {
+	case EhCategorisationRuleDefined:
+		return event.Data().(CategorisationRuleEvent), nil
+	case EhCategorisationRuleChanged:
+		return event.Data().(CategorisationRuleEvent), nil
+	case EhCategorisationRuleRemoved:
+		return event.Data().(CategorisationRuleEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event CategorisationRuleEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case CategorisationRuleDefined:
+		return EhCategorisationRuleDefined, nil
+	case CategorisationRuleChanged:
+		return EhCategorisationRuleChanged, nil
+	case CategorisationRuleRemoved:
+		return EhCategorisationRuleRemoved, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (CategorisationRuleCommand, error) {
+	switch cmd := cmd.(type) {
+	case DefineCategorisationRuleCommand:
+		return cmd, nil
+	case RemoveCategorisationRuleCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
@@ -0,0 +1,201 @@
+package categorisation
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// TransactionCategoriser evaluates the categorisation rules of the owners of a monetary account
+// whenever a transaction is found, and re-evaluates the history of a user when one of its rules changes
+type TransactionCategoriser struct {
+	handler eh.CommandHandler
+
+	mu           sync.Mutex
+	rules        map[primitives.UserID]map[primitives.CategorisationRuleID]Rule
+	owners       map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	transactions map[primitives.MonetaryAccountID]map[primitives.TransactionID]CandidateTransaction
+}
+
+// NewTransactionCategoriser creates a TransactionCategoriser that dispatches its categorisations to the handler
+func NewTransactionCategoriser(handler eh.CommandHandler) *TransactionCategoriser {
+	return &TransactionCategoriser{
+		handler:      handler,
+		rules:        make(map[primitives.UserID]map[primitives.CategorisationRuleID]Rule),
+		owners:       make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool),
+		transactions: make(map[primitives.MonetaryAccountID]map[primitives.TransactionID]CandidateTransaction),
+	}
+}
+
+// Matcher matches all events the categoriser is interested in
+func (categoriser *TransactionCategoriser) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhMonetaryAccountUserAdded,
+		EhCategorisationRuleDefined,
+		EhCategorisationRuleChanged,
+		EhCategorisationRuleRemoved,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (categoriser *TransactionCategoriser) HandlerType() eh.EventHandlerType {
+	return "transaction-categoriser"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (categoriser *TransactionCategoriser) HandleEvent(ctx context.Context, event eh.Event) error {
+	var commands []eh.Command
+
+	switch data := event.Data().(type) {
+	case *accountinformation.NewTransactionFound:
+		commands = categoriser.transactionFound(*data)
+	case *accountinformation.MonetaryAccountUserAdded:
+		commands = categoriser.ownerAdded(data.ID, data.UserID)
+	case *CategorisationRuleDefined:
+		commands = categoriser.ruleChanged(data.ID, data.UserID, data.Category, data.Priority, data.Criteria)
+	case *CategorisationRuleChanged:
+		commands = categoriser.ruleChanged(data.ID, data.UserID, data.Category, data.Priority, data.Criteria)
+	case *CategorisationRuleRemoved:
+		commands = categoriser.ruleRemoved(data.ID, data.UserID)
+	}
+
+	var firstErr error
+	for _, cmd := range commands {
+		if err := categoriser.handler.HandleCommand(ctx, cmd); err != nil {
+			log.Printf("Could not categorise transaction: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (categoriser *TransactionCategoriser) transactionFound(event accountinformation.NewTransactionFound) []eh.Command {
+	categoriser.mu.Lock()
+	defer categoriser.mu.Unlock()
+
+	counterparty := event.Counterparty()
+	transaction := CandidateTransaction{
+		MonetaryAccountID:   event.MonetaryAccountID,
+		CounterpartyIBAN:    counterparty.IBAN,
+		HasCounterpartyIBAN: counterparty.HasIBAN,
+		Amount:              event.Amount,
+		Description:         event.Description,
+	}
+
+	transactions, ok := categoriser.transactions[event.MonetaryAccountID]
+	if !ok {
+		transactions = make(map[primitives.TransactionID]CandidateTransaction)
+		categoriser.transactions[event.MonetaryAccountID] = transactions
+	}
+	transactions[event.ID] = transaction
+
+	return []eh.Command{categoriser.categorise(event.ID, transaction)}
+}
+
+func (categoriser *TransactionCategoriser) ownerAdded(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) []eh.Command {
+	categoriser.mu.Lock()
+	defer categoriser.mu.Unlock()
+
+	owners, ok := categoriser.owners[monetaryAccountID]
+	if !ok {
+		owners = make(map[primitives.UserID]bool)
+		categoriser.owners[monetaryAccountID] = owners
+	}
+	owners[userID] = true
+
+	return categoriser.categoriseAccount(monetaryAccountID)
+}
+
+func (categoriser *TransactionCategoriser) ruleChanged(id primitives.CategorisationRuleID, userID primitives.UserID, category primitives.Category, priority int, criteria RuleCriteria) []eh.Command {
+	rule, err := NewRule(id, userID, category, priority, criteria)
+	if err != nil {
+		log.Printf("Ignoring invalid categorisation rule %s: %v", id, err)
+		return nil
+	}
+
+	categoriser.mu.Lock()
+	defer categoriser.mu.Unlock()
+
+	rules, ok := categoriser.rules[userID]
+	if !ok {
+		rules = make(map[primitives.CategorisationRuleID]Rule)
+		categoriser.rules[userID] = rules
+	}
+	rules[id] = rule
+
+	return categoriser.categoriseUser(userID)
+}
+
+func (categoriser *TransactionCategoriser) ruleRemoved(id primitives.CategorisationRuleID, userID primitives.UserID) []eh.Command {
+	categoriser.mu.Lock()
+	defer categoriser.mu.Unlock()
+
+	delete(categoriser.rules[userID], id)
+
+	return categoriser.categoriseUser(userID)
+}
+
+func (categoriser *TransactionCategoriser) categoriseUser(userID primitives.UserID) []eh.Command {
+	var commands []eh.Command
+	for monetaryAccountID, owners := range categoriser.owners {
+		if owners[userID] {
+			commands = append(commands, categoriser.categoriseAccount(monetaryAccountID)...)
+		}
+	}
+	return commands
+}
+
+func (categoriser *TransactionCategoriser) categoriseAccount(monetaryAccountID primitives.MonetaryAccountID) []eh.Command {
+	var commands []eh.Command
+	for transactionID, transaction := range categoriser.transactions[monetaryAccountID] {
+		commands = append(commands, categoriser.categorise(transactionID, transaction))
+	}
+	return commands
+}
+
+func (categoriser *TransactionCategoriser) categorise(transactionID primitives.TransactionID, transaction CandidateTransaction) eh.Command {
+	cmd := accountinformation.CategoriseTransactionCommand{
+		MonetaryAccountID: transaction.MonetaryAccountID,
+		TransactionID:     transactionID,
+		Category:          primitives.Uncategorised,
+	}
+
+	for _, rule := range categoriser.rulesFor(transaction.MonetaryAccountID) {
+		if rule.Matches(transaction) {
+			cmd.Category = rule.Category
+			cmd.RuleID = rule.ID
+			break
+		}
+	}
+
+	return cmd
+}
+
+func (categoriser *TransactionCategoriser) rulesFor(monetaryAccountID primitives.MonetaryAccountID) []Rule {
+	var rules []Rule
+	for userID := range categoriser.owners[monetaryAccountID] {
+		for _, rule := range categoriser.rules[userID] {
+			rules = append(rules, rule)
+		}
+	}
+
+	sortByPriority(rules)
+	return rules
+}
+
+func sortByPriority(rules []Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].ID.String() < rules[j].ID.String()
+	})
+}
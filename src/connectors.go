@@ -0,0 +1,121 @@
+package main
+
+import (
+	accountinformation "app/account-information"
+	"app/blobs"
+	bunqconnector "app/bunq-connector"
+	"app/bus"
+	"app/connectors"
+	"app/primitives"
+	psd2connector "app/psd2-connector"
+	simulatedconnector "app/simulated-connector"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// newConnectorRegistry registers the connectors of all institutions, a new institution only has to be registered here
+func newConnectorRegistry(ctx context.Context, connections accountinformation.InMemoryConnectionRepository, publisher bus.Publisher, blobStore blobs.Store) (*connectors.Registry, error) {
+	registry := connectors.NewRegistry()
+
+	bunq := connectors.Connector{
+		Institution:      primitives.Bunq,
+		RefreshCommand:   bunqconnector.NewStartUserRefreshCommand(ctx, publisher, blobStore),
+		Routes:           bunqconnector.RegisterOAuthController,
+		HealthCheck:      bunqconnector.HealthCheck,
+		Capabilities:     []connectors.Capability{connectors.Accounts, connectors.Transactions, connectors.Schedules, connectors.DirectDebits},
+		ConnectEveryUser: true,
+	}
+	if os.Getenv("BUNQ_CONNECTOR") == "simulated" {
+		config, err := simulationConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		bunq = connectors.Connector{
+			Institution:      primitives.Bunq,
+			RefreshCommand:   simulatedconnector.NewStartUserRefreshCommand(ctx, config, publisher),
+			Capabilities:     []connectors.Capability{connectors.Accounts, connectors.Transactions, connectors.Schedules, connectors.DirectDebits},
+			ConnectEveryUser: true,
+		}
+	}
+	if err := registry.Register(bunq); err != nil {
+		return nil, fmt.Errorf("could not register bunq connector: %w", err)
+	}
+
+	if psd2RefreshCommand, ok := psd2RefreshCommandFromEnv(ctx, publisher); ok {
+		err := registry.Register(connectors.Connector{
+			Institution:    psd2RefreshCommand.Institution(),
+			RefreshCommand: psd2RefreshCommand,
+			Routes:         psd2connector.RegisterConsentController(psd2RefreshCommand, connections),
+			HealthCheck:    psd2RefreshCommand.HealthCheck,
+			Capabilities:   []connectors.Capability{connectors.Accounts, connectors.Transactions},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not register psd2 connector: %w", err)
+		}
+	}
+
+	for _, connector := range registry.Connectors() {
+		if connector.ConnectEveryUser {
+			connections.ConnectEveryone(connector.Institution)
+		}
+	}
+	return registry, nil
+}
+
+// simulationConfigFromEnv reads the simulation of bunq from SIMULATION_SEED, SIMULATION_START (yyyy-mm-dd),
+// SIMULATION_STEP and SIMULATION_TICK (durations like 24h and 1s), what is not set is taken from the default config
+func simulationConfigFromEnv() (simulatedconnector.Config, error) {
+	config := simulatedconnector.DefaultConfig()
+
+	if seed := os.Getenv("SIMULATION_SEED"); seed != "" {
+		parsed, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid SIMULATION_SEED %s", seed)
+		}
+		config.Seed = parsed
+	}
+	if start := os.Getenv("SIMULATION_START"); start != "" {
+		parsed, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return config, fmt.Errorf("invalid SIMULATION_START %s, expected format yyyy-mm-dd", start)
+		}
+		config.Start = parsed
+	}
+	if step := os.Getenv("SIMULATION_STEP"); step != "" {
+		parsed, err := time.ParseDuration(step)
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("invalid SIMULATION_STEP %s", step)
+		}
+		config.Step = parsed
+	}
+	if tick := os.Getenv("SIMULATION_TICK"); tick != "" {
+		parsed, err := time.ParseDuration(tick)
+		if err != nil {
+			return config, fmt.Errorf("invalid SIMULATION_TICK %s", tick)
+		}
+		config.Tick = parsed
+	}
+	return config, nil
+}
+
+// psd2RefreshCommandFromEnv creates the connector of the ASPSP in PSD2_BASE_URL, that is refreshed as the institution in PSD2_INSTITUTION
+func psd2RefreshCommandFromEnv(ctx context.Context, publisher bus.Publisher) (psd2connector.StartUserRefreshCommand, bool) {
+	baseURL := os.Getenv("PSD2_BASE_URL")
+	if baseURL == "" {
+		return psd2connector.StartUserRefreshCommand{}, false
+	}
+
+	institution := primitives.Institution(os.Getenv("PSD2_INSTITUTION"))
+	if institution == "" {
+		institution = primitives.OtherInstitution
+	}
+
+	return psd2connector.NewStartUserRefreshCommand(ctx, psd2connector.ASPSP{
+		Institution: institution,
+		BaseURL:     baseURL,
+		RedirectURL: os.Getenv("PSD2_REDIRECT_URL"),
+	}, publisher), true
+}
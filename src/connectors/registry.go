@@ -0,0 +1,152 @@
+package connectors
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Capability what a connector can fetch from its institution
+type Capability string
+
+// Capability enum
+const (
+	Accounts     Capability = "Accounts"
+	Transactions Capability = "Transactions"
+	Schedules    Capability = "Schedules"
+	DirectDebits Capability = "DirectDebits"
+)
+
+// Connector connects the accounts of users at an institution
+type Connector struct {
+	Institution primitives.Institution
+	// RefreshCommand refreshes the accounts of a user that is connected to the institution
+	RefreshCommand accountinformation.StartUserRefreshCommand
+	// Routes registers the http routes a user connects with, like an OAuth flow, it is optional
+	Routes func(r *mux.Router) error
+	// HealthCheck tells whether the institution can be reached, it is optional
+	HealthCheck  func(ctx context.Context) error
+	Capabilities []Capability
+	// ConnectEveryUser refreshes every user with the connector, for connectors that know themselves which users they can refresh
+	ConnectEveryUser bool
+}
+
+// Supports tells whether the connector has the capability
+func (connector Connector) Supports(capability Capability) bool {
+	for _, c := range connector.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry keeps the connectors per institution, so institutions can be added without changing how they are refreshed
+type Registry struct {
+	mu         sync.RWMutex
+	connectors map[primitives.Institution]Connector
+	order      []primitives.Institution
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{connectors: make(map[primitives.Institution]Connector)}
+}
+
+// Register adds the connector of an institution, an institution can only have one connector
+func (registry *Registry) Register(connector Connector) error {
+	if connector.Institution == "" {
+		return fmt.Errorf("a connector needs an institution")
+	}
+	if connector.RefreshCommand == nil {
+		return fmt.Errorf("connector of %s needs a refresh command", connector.Institution)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.connectors[connector.Institution]; ok {
+		return fmt.Errorf("a connector of %s is already registered", connector.Institution)
+	}
+	registry.connectors[connector.Institution] = connector
+	registry.order = append(registry.order, connector.Institution)
+	return nil
+}
+
+// Get returns the connector of the institution
+func (registry *Registry) Get(institution primitives.Institution) (Connector, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	connector, ok := registry.connectors[institution]
+	return connector, ok
+}
+
+// Connectors returns all connectors in the order they were registered
+func (registry *Registry) Connectors() []Connector {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	res := make([]Connector, 0, len(registry.order))
+	for _, institution := range registry.order {
+		res = append(res, registry.connectors[institution])
+	}
+	return res
+}
+
+// RefreshCommands returns the refresh command per institution
+func (registry *Registry) RefreshCommands() map[primitives.Institution]accountinformation.StartUserRefreshCommand {
+	res := make(map[primitives.Institution]accountinformation.StartUserRefreshCommand)
+	for _, connector := range registry.Connectors() {
+		res[connector.Institution] = connector.RefreshCommand
+	}
+	return res
+}
+
+// Muxes returns the http routes of all connectors
+func (registry *Registry) Muxes() []func(r *mux.Router) error {
+	var res []func(r *mux.Router) error
+	for _, connector := range registry.Connectors() {
+		if connector.Routes != nil {
+			res = append(res, connector.Routes)
+		}
+	}
+	return res
+}
+
+// Health is the outcome of the health check of a connector
+type Health struct {
+	Institution  primitives.Institution `json:"institution"`
+	Capabilities []Capability           `json:"capabilities"`
+	Healthy      bool                   `json:"healthy"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// CheckHealth runs the health checks of all connectors, a connector without a health check is healthy
+func (registry *Registry) CheckHealth(ctx context.Context) []Health {
+	connectors := registry.Connectors()
+	res := make([]Health, len(connectors))
+
+	wg := sync.WaitGroup{}
+	for i, connector := range connectors {
+		res[i] = Health{Institution: connector.Institution, Capabilities: connector.Capabilities, Healthy: true}
+		if connector.HealthCheck == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, connector Connector) {
+			defer wg.Done()
+			if err := connector.HealthCheck(ctx); err != nil {
+				res[i].Healthy = false
+				res[i].Error = err.Error()
+			}
+		}(i, connector)
+	}
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,66 @@
+package connectors
+
+import (
+	"app/primitives"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type noopRefreshCommand struct{}
+
+func (cmd noopRefreshCommand) Refresh(userID primitives.UserID) {}
+
+func Test_Register_RejectsSecondConnectorOfInstitution(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.Register(Connector{Institution: primitives.ING, RefreshCommand: noopRefreshCommand{}}); err != nil {
+		t.Fatalf("Expected the first connector to be registered, got %v", err)
+	}
+	if err := registry.Register(Connector{Institution: primitives.ING, RefreshCommand: noopRefreshCommand{}}); err == nil {
+		t.Errorf("Expected the second connector of ING to be rejected")
+	}
+	if err := registry.Register(Connector{Institution: primitives.Bunq}); err == nil {
+		t.Errorf("Expected a connector without a refresh command to be rejected")
+	}
+}
+
+func Test_Registry_BuildsRefreshCommandsAndRoutes(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Connector{
+		Institution:    primitives.Bunq,
+		RefreshCommand: noopRefreshCommand{},
+		Routes:         func(r *mux.Router) error { return nil },
+		Capabilities:   []Capability{Accounts, Transactions, Schedules, DirectDebits},
+	})
+	registry.Register(Connector{Institution: "Knab", RefreshCommand: noopRefreshCommand{}, Capabilities: []Capability{Accounts}})
+
+	commands := registry.RefreshCommands()
+	if len(commands) != 2 || commands["Knab"] == nil {
+		t.Errorf("Expected a refresh command per institution, got %v", commands)
+	}
+	if len(registry.Muxes()) != 1 {
+		t.Errorf("Expected only the routes of bunq, got %d", len(registry.Muxes()))
+	}
+	if connector, _ := registry.Get("Knab"); connector.Supports(Transactions) {
+		t.Errorf("Expected Knab to only support accounts")
+	}
+}
+
+func Test_CheckHealth(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Connector{Institution: primitives.Bunq, RefreshCommand: noopRefreshCommand{}})
+	registry.Register(Connector{
+		Institution:    primitives.ING,
+		RefreshCommand: noopRefreshCommand{},
+		HealthCheck:    func(ctx context.Context) error { return fmt.Errorf("unreachable") },
+	})
+
+	health := registry.CheckHealth(context.Background())
+
+	if !health[0].Healthy || health[1].Healthy || health[1].Error != "unreachable" {
+		t.Errorf("Unexpected health %+v", health)
+	}
+}
@@ -0,0 +1,40 @@
+package exchangerates
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Converter converts money between currencies with the rates of a RateProvider
+type Converter struct {
+	provider RateProvider
+}
+
+// NewConverter creates a Converter on the rates of the provider
+func NewConverter(provider RateProvider) Converter {
+	return Converter{provider: provider}
+}
+
+// Convert converts the amount into the currency with the rate on the date, rounded to the minor unit of the currency
+func (converter Converter) Convert(amount money.Money, currencyCode string, date time.Time) (money.Money, error) {
+	from := amount.Currency()
+	if from.Code == currencyCode {
+		return amount, nil
+	}
+
+	to := money.GetCurrency(currencyCode)
+	if to == nil {
+		return money.Money{}, fmt.Errorf("unknown currency %s", currencyCode)
+	}
+
+	rate, err := converter.provider.RateOn(from.Code, to.Code, date)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	minorUnits := float64(amount.Amount()) * rate * math.Pow10(to.Fraction-from.Fraction)
+	return *money.New(int64(math.Round(minorUnits)), to.Code), nil
+}
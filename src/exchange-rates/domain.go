@@ -0,0 +1,75 @@
+package exchangerates
+
+import (
+	"app/primitives"
+	"fmt"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var parentUUID uuid.UUID
+
+func init() {
+	parentUUID = uuid.MustParse("c4e2a9d0-6b3f-4f71-8e25-91d0b7a6f3c8")
+}
+
+// reportingCurrencyIDOf derives the id of the reporting currency aggregate of a user, which differs from the id of the
+// user aggregate because aggregates share the event store
+func reportingCurrencyIDOf(userID primitives.UserID) uuid.UUID {
+	return uuid.NewMD5(parentUUID, []byte("reporting-currency-"+userID.String()))
+}
+
+// DefaultReportingCurrency is the reporting currency of users that did not choose one
+const DefaultReportingCurrency = "EUR"
+
+type reportingCurrencyState struct {
+	ID           primitives.UserID
+	initialized  bool
+	currencyCode string
+}
+
+type ReportingCurrencyEvent interface {
+	appliedTo(state *reportingCurrencyState) *reportingCurrencyState
+}
+
+type ReportingCurrencyCommand interface {
+	applyTo(state *reportingCurrencyState) ([]ReportingCurrencyEvent, error)
+}
+
+type SetReportingCurrencyCommand struct {
+	UserID       primitives.UserID
+	CurrencyCode string
+}
+
+func (cmd SetReportingCurrencyCommand) applyTo(state *reportingCurrencyState) ([]ReportingCurrencyEvent, error) {
+	if money.GetCurrency(cmd.CurrencyCode) == nil {
+		return nil, primitives.NewValidationError("CurrencyCode", fmt.Sprintf("unknown currency %s", cmd.CurrencyCode))
+	}
+
+	if state != nil && state.initialized && state.currencyCode == cmd.CurrencyCode {
+		return nil, nil
+	}
+
+	return []ReportingCurrencyEvent{newReportingCurrencySet(cmd)}, nil
+}
+
+type ReportingCurrencySet struct {
+	ID           primitives.UserID
+	CurrencyCode string
+}
+
+func newReportingCurrencySet(cmd SetReportingCurrencyCommand) ReportingCurrencySet {
+	res := new(ReportingCurrencySet)
+	res.ID = cmd.UserID
+	res.CurrencyCode = cmd.CurrencyCode
+	return *res
+}
+
+func (event ReportingCurrencySet) appliedTo(state *reportingCurrencyState) *reportingCurrencyState {
+	res := new(reportingCurrencyState)
+	res.ID = event.ID
+	res.initialized = true
+	res.currencyCode = event.CurrencyCode
+	return res
+}
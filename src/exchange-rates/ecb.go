@@ -0,0 +1,73 @@
+package exchangerates
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ECBBaseCurrency is the currency the reference rates of the European Central Bank are published against
+const ECBBaseCurrency = "EUR"
+
+// LoadECBFile loads the historical reference rates from a csv file as published by the European Central Bank (eurofxref-hist.csv)
+func LoadECBFile(path string) (*HistoricalRates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open exchange rates file: %w", err)
+	}
+	defer file.Close()
+
+	return LoadECBCSV(file)
+}
+
+// LoadECBCSV loads historical reference rates in the csv format of the European Central Bank,
+// a header row with Date followed by currency codes and a row of rates per date. Missing rates are N/A
+func LoadECBCSV(reader io.Reader) (*HistoricalRates, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header of exchange rates: %w", err)
+	}
+	if len(header) == 0 || strings.TrimSpace(header[0]) != "Date" {
+		return nil, fmt.Errorf("exchange rates should start with a Date column")
+	}
+
+	rates := NewHistoricalRates(ECBBaseCurrency)
+	for line := 2; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read exchange rates on line %d: %w", line, err)
+		}
+
+		date, err := time.Parse(dateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date on line %d: %w", line, err)
+		}
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			currency := strings.TrimSpace(header[i])
+			value := strings.TrimSpace(record[i])
+			if currency == "" || value == "" || value == "N/A" {
+				continue
+			}
+
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("invalid rate %s for %s on line %d", value, currency, line)
+			}
+			rates.Add(currency, date, rate)
+		}
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,136 @@
+package exchangerates
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(ReportingCurrencyAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// ReportingCurrencyAggregateType is the aggregate type for the reporting currency of a user
+const ReportingCurrencyAggregateType = eh.AggregateType("reportingcurrency")
+
+// Aggregate is an aggregate for a reporting currency of a user
+type Aggregate struct {
+	*events.AggregateBase
+	*reportingCurrencyState
+}
+
+const EhSetReportingCurrencyCommand = eh.CommandType("reportingcurrency:set")
+
+const EhReportingCurrencySet = eh.EventType("reportingcurrency:set")
+
+// CommandTypes are all command types handled by the reporting currency of a user aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhSetReportingCurrencyCommand,
+	}
+}
+
+func (cmd SetReportingCurrencyCommand) AggregateID() uuid.UUID {
+	return reportingCurrencyIDOf(cmd.UserID)
+}
+
+func (cmd SetReportingCurrencyCommand) AggregateType() eh.AggregateType {
+	return ReportingCurrencyAggregateType
+}
+
+func (cmd SetReportingCurrencyCommand) CommandType() eh.CommandType {
+	return EhSetReportingCurrencyCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(ReportingCurrencyAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhReportingCurrencySet, func() eh.EventData {
+		return &ReportingCurrencySet{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.reportingCurrencyState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.reportingCurrencyState = eventInDomain.appliedTo(a.reportingCurrencyState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (ReportingCurrencyEvent, error) {
+	switch event.EventType() {
+	case EhReportingCurrencySet:
+		return event.Data().(ReportingCurrencyEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event ReportingCurrencyEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case ReportingCurrencySet:
+		return EhReportingCurrencySet, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (ReportingCurrencyCommand, error) {
+	switch cmd := cmd.(type) {
+	case SetReportingCurrencyCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
@@ -0,0 +1,47 @@
+package exchangerates
+
+import (
+	"app/primitives"
+	"app/users"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	eventbus "github.com/looplab/eventhorizon/eventbus/local"
+	eventstore "github.com/looplab/eventhorizon/eventstore/memory"
+)
+
+func Test_SetupDomain_SharesTheEventStoreWithUsers(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.NewEventStore()
+	bus := eventbus.NewEventBus(nil)
+
+	usersHandler, err := users.SetupDomain(store, bus)
+	if err != nil {
+		t.Fatalf("Could not setup users: %v", err)
+	}
+	currencyHandler, err := SetupDomain(store, bus)
+	if err != nil {
+		t.Fatalf("Could not setup reporting currencies: %v", err)
+	}
+
+	userID := primitives.UserID(uuid.New())
+	otherUserID := primitives.UserID(uuid.New())
+	commands := []struct {
+		handler eh.CommandHandler
+		cmd     eh.Command
+	}{
+		{usersHandler, users.SignUpCommand{UserID: userID, Name: "Sam", Email: "sam@example.com", SignedUp: time.Now()}},
+		{currencyHandler, SetReportingCurrencyCommand{UserID: userID, CurrencyCode: "USD"}},
+		{currencyHandler, SetReportingCurrencyCommand{UserID: otherUserID, CurrencyCode: "GBP"}},
+		{usersHandler, users.SignUpCommand{UserID: otherUserID, Name: "Alex", Email: "alex@example.com", SignedUp: time.Now()}},
+	}
+
+	for _, c := range commands {
+		if err := c.handler.HandleCommand(ctx, c.cmd); err != nil {
+			t.Fatalf("Expected %T to be handled, got %v", c.cmd, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package exchangerates
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RateProvider provides the rate to convert an amount from one currency into another on a date
+type RateProvider interface {
+	RateOn(from string, to string, date time.Time) (float64, error)
+}
+
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// HistoricalRates keeps the reference rates of currencies against a base currency per date.
+// The rate on a date is the most recent rate published on or before that date, so weekends and holidays use the rate of the last business day
+type HistoricalRates struct {
+	mu    sync.RWMutex
+	base  string
+	rates map[string][]datedRate
+}
+
+// NewHistoricalRates creates HistoricalRates without any rates against the base currency
+func NewHistoricalRates(base string) *HistoricalRates {
+	return &HistoricalRates{base: base, rates: make(map[string][]datedRate)}
+}
+
+// Add adds the rate of one unit of the base currency in the currency on the date
+func (rates *HistoricalRates) Add(currency string, date time.Time, rate float64) {
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+
+	day := truncateToDay(date)
+	history := rates.rates[currency]
+	i := sort.Search(len(history), func(i int) bool { return !history[i].date.Before(day) })
+	if i < len(history) && history[i].date.Equal(day) {
+		history[i].rate = rate
+		return
+	}
+
+	history = append(history, datedRate{})
+	copy(history[i+1:], history[i:])
+	history[i] = datedRate{date: day, rate: rate}
+	rates.rates[currency] = history
+}
+
+// RateOn implements the RateOn method of the RateProvider interface, currencies are converted via the base currency
+func (rates *HistoricalRates) RateOn(from string, to string, date time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	rates.mu.RLock()
+	defer rates.mu.RUnlock()
+
+	fromRate, err := rates.baseRateOn(from, date)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := rates.baseRateOn(to, date)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+func (rates *HistoricalRates) baseRateOn(currency string, date time.Time) (float64, error) {
+	if currency == rates.base {
+		return 1, nil
+	}
+
+	day := truncateToDay(date)
+	history := rates.rates[currency]
+	i := sort.Search(len(history), func(i int) bool { return history[i].date.After(day) })
+	if i == 0 {
+		return 0, fmt.Errorf("no exchange rate for %s on %s", currency, day.Format(dateLayout))
+	}
+	return history[i-1].rate, nil
+}
+
+const dateLayout = "2006-01-02"
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,69 @@
+package exchangerates
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+const ecbRates = `Date,USD,JPY,GBP,
+2020-01-03,1.1147,120.52,0.85208,
+2020-01-02,1.1193,121.75,N/A,
+`
+
+func loadRates(t *testing.T) *HistoricalRates {
+	rates, err := LoadECBCSV(strings.NewReader(ecbRates))
+	if err != nil {
+		t.Fatalf("Could not load rates: %v", err)
+	}
+	return rates
+}
+
+func Test_LoadECBCSV_UsesLastRateOnOrBeforeDate(t *testing.T) {
+	rates := loadRates(t)
+
+	saturday := time.Date(2020, time.January, 4, 15, 0, 0, 0, time.UTC)
+	rate, err := rates.RateOn("EUR", "USD", saturday)
+
+	if err != nil || rate != 1.1147 {
+		t.Errorf("Expected rate of friday 1.1147, got %v (%v)", rate, err)
+	}
+}
+
+func Test_LoadECBCSV_SkipsMissingRates(t *testing.T) {
+	rates := loadRates(t)
+
+	if _, err := rates.RateOn("EUR", "GBP", time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Expected no rate for GBP before it was published")
+	}
+}
+
+func Test_LoadECBCSV_RejectsInvalidHeader(t *testing.T) {
+	if _, err := LoadECBCSV(strings.NewReader("USD,JPY\n1.1,120\n")); err == nil {
+		t.Errorf("Expected an error for rates without a Date column")
+	}
+}
+
+func Test_Converter_ConvertsBetweenCurrencyFractions(t *testing.T) {
+	converter := NewConverter(loadRates(t))
+	date := time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC)
+
+	converted, err := converter.Convert(*money.New(1000, "EUR"), "JPY", date)
+
+	if err != nil || converted.Currency().Code != "JPY" || converted.Amount() != 1205 {
+		t.Errorf("Expected 1205 JPY, got %d %s (%v)", converted.Amount(), converted.Currency().Code, err)
+	}
+}
+
+func Test_Converter_ConvertsViaBaseCurrency(t *testing.T) {
+	converter := NewConverter(loadRates(t))
+	date := time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC)
+
+	converted, err := converter.Convert(*money.New(11147, "USD"), "GBP", date)
+
+	if err != nil || converted.Amount() != 8521 {
+		t.Errorf("Expected 8521 GBP, got %d (%v)", converted.Amount(), err)
+	}
+}
@@ -0,0 +1,75 @@
+package exchangerates
+
+import (
+	"app/primitives"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// ReportingCurrencies keeps the currency each user wants to see totals in
+type ReportingCurrencies struct {
+	mu         sync.RWMutex
+	currencies map[primitives.UserID]string
+}
+
+// NewReportingCurrencies creates ReportingCurrencies where every user reports in the DefaultReportingCurrency
+func NewReportingCurrencies() *ReportingCurrencies {
+	return &ReportingCurrencies{currencies: make(map[primitives.UserID]string)}
+}
+
+// Matcher matches all events the reporting currencies are interested in
+func (currencies *ReportingCurrencies) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(EhReportingCurrencySet)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (currencies *ReportingCurrencies) HandlerType() eh.EventHandlerType {
+	return "reporting-currencies"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (currencies *ReportingCurrencies) HandleEvent(ctx context.Context, event eh.Event) error {
+	if data, ok := event.Data().(*ReportingCurrencySet); ok {
+		currencies.mu.Lock()
+		defer currencies.mu.Unlock()
+
+		currencies.currencies[data.ID] = data.CurrencyCode
+	}
+	return nil
+}
+
+// ReportingCurrencyOf returns the reporting currency of the user
+func (currencies *ReportingCurrencies) ReportingCurrencyOf(userID primitives.UserID) string {
+	currencies.mu.RLock()
+	defer currencies.mu.RUnlock()
+
+	if currency, ok := currencies.currencies[userID]; ok {
+		return currency
+	}
+	return DefaultReportingCurrency
+}
+
+// ReportingConverter converts amounts into the reporting currency of users
+type ReportingConverter struct {
+	converter  Converter
+	currencies *ReportingCurrencies
+}
+
+// NewReportingConverter creates a ReportingConverter
+func NewReportingConverter(converter Converter, currencies *ReportingCurrencies) ReportingConverter {
+	return ReportingConverter{converter: converter, currencies: currencies}
+}
+
+// ReportingCurrencyOf returns the reporting currency of the user
+func (converter ReportingConverter) ReportingCurrencyOf(userID primitives.UserID) string {
+	return converter.currencies.ReportingCurrencyOf(userID)
+}
+
+// Convert converts the amount into the currency with the rate on the date
+func (converter ReportingConverter) Convert(amount money.Money, currencyCode string, date time.Time) (money.Money, error) {
+	return converter.converter.Convert(amount, currencyCode, date)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// runExportCommand downloads the transactions of a user from the export endpoint of a running server, with the token in AUTH_TOKEN
+func runExportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	userID := flags.String("user", "", "id of the user to export the transactions of")
+	format := flags.String("format", "csv", "format of the export: csv, ofx or ndjson")
+	account := flags.String("account", "", "only export the transactions of this monetary account")
+	from := flags.String("from", "", "only export transactions on or after this date, as yyyy-mm-dd")
+	to := flags.String("to", "", "only export transactions before this date, as yyyy-mm-dd")
+	category := flags.String("category", "", "only export transactions of this category")
+	output := flags.String("o", "", "file to write the export to, defaults to stdout")
+	server := flags.String("server", "http://localhost:9000", "address of the server to export from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return fmt.Errorf("a user is needed to export")
+	}
+
+	query := url.Values{}
+	query.Set("userId", *userID)
+	query.Set("format", *format)
+	for name, value := range map[string]string{"account": *account, "from": *from, "to": *to, "category": *category} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, *server+"/export/transactions?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(authorized(req))
+	if err != nil {
+		return fmt.Errorf("could not export transactions: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("export failed with status %d: %s", res.StatusCode, message)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("could not create %s: %w", *output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	_, err = io.Copy(out, res.Body)
+	return err
+}
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"app/reporting"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"Date", "Account", "Account alias", "Counterparty name", "Counterparty IBAN",
+	"Description", "Category", "Amount", "Currency", "Internal transfer", "Transaction ID",
+}
+
+func (exporter Exporter) writeCSV(writer io.Writer, transactions []reporting.ReportedTransaction) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		amount := transaction.SignedAmount()
+		record := []string{
+			transaction.TransactionDate.Format("2006-01-02"),
+			exporter.accountNumberOf(transaction.MonetaryAccountID),
+			exporter.source.AliasOf(transaction.MonetaryAccountID),
+			transaction.Counterparty.Name,
+			transaction.Counterparty.IBAN.Code,
+			transaction.Description,
+			string(transaction.Category),
+			formatAmount(amount),
+			amount.Currency().Code,
+			strconv.FormatBool(transaction.InternalTransfer),
+			transaction.ID.String(),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
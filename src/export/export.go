@@ -0,0 +1,109 @@
+package export
+
+import (
+	"app/primitives"
+	"app/reporting"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+// Format a file format transactions can be exported to
+type Format string
+
+// Format enum
+const (
+	CSV    Format = "csv"
+	OFX    Format = "ofx"
+	NDJSON Format = "ndjson"
+)
+
+// ContentType is the media type of files in the format
+func (format Format) ContentType() string {
+	switch format {
+	case CSV:
+		return "text/csv"
+	case OFX:
+		return "application/x-ofx"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Filter selects the transactions to export, a zero filter selects all transactions of the user
+type Filter struct {
+	MonetaryAccountID    primitives.MonetaryAccountID
+	HasMonetaryAccountID bool
+	Period               reporting.Period
+	Category             primitives.Category
+	HasCategory          bool
+}
+
+func (filter Filter) matches(transaction reporting.ReportedTransaction) bool {
+	if filter.HasMonetaryAccountID && transaction.MonetaryAccountID != filter.MonetaryAccountID {
+		return false
+	}
+	if filter.HasCategory && transaction.Category != filter.Category {
+		return false
+	}
+	return filter.Period.Contains(transaction.TransactionDate)
+}
+
+// TransactionSource gives access to the transactions to export and the accounts they are on
+type TransactionSource interface {
+	TransactionsOf(userID primitives.UserID) []reporting.ReportedTransaction
+	AliasOf(monetaryAccountID primitives.MonetaryAccountID) string
+	IBANOf(monetaryAccountID primitives.MonetaryAccountID) (iban.IBAN, bool)
+}
+
+// Exporter writes the transactions of users to files
+type Exporter struct {
+	source TransactionSource
+}
+
+// NewExporter creates an Exporter on the transactions of the source
+func NewExporter(source TransactionSource) Exporter {
+	return Exporter{source: source}
+}
+
+// Export writes the transactions of the user that match the filter in the format, oldest first
+func (exporter Exporter) Export(writer io.Writer, userID primitives.UserID, filter Filter, format Format) error {
+	var transactions []reporting.ReportedTransaction
+	for _, transaction := range exporter.source.TransactionsOf(userID) {
+		if filter.matches(transaction) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].TransactionDate.Equal(transactions[j].TransactionDate) {
+			return transactions[i].TransactionDate.Before(transactions[j].TransactionDate)
+		}
+		return transactions[i].ID.String() < transactions[j].ID.String()
+	})
+
+	switch format {
+	case CSV:
+		return exporter.writeCSV(writer, transactions)
+	case OFX:
+		return exporter.writeOFX(writer, transactions)
+	case NDJSON:
+		return exporter.writeNDJSON(writer, transactions)
+	default:
+		return fmt.Errorf("unknown export format %s", format)
+	}
+}
+
+func (exporter Exporter) accountNumberOf(monetaryAccountID primitives.MonetaryAccountID) string {
+	if accountIBAN, ok := exporter.source.IBANOf(monetaryAccountID); ok && accountIBAN.Code != "" {
+		return accountIBAN.Code
+	}
+	return monetaryAccountID.String()
+}
+
+// formatAmount formats the amount as a plain decimal in the minor units of its currency, like -12.50
+func formatAmount(amount money.Money) string {
+	return money.NewFormatter(amount.Currency().Fraction, ".", "", "", "1").Format(amount.Amount())
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"app/reporting"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+var accountID = primitives.MonetaryAccountID(uuid.New())
+
+type fakeSource []reporting.ReportedTransaction
+
+func (source fakeSource) TransactionsOf(userID primitives.UserID) []reporting.ReportedTransaction {
+	return source
+}
+
+func (source fakeSource) AliasOf(monetaryAccountID primitives.MonetaryAccountID) string {
+	return "Main"
+}
+
+func (source fakeSource) IBANOf(monetaryAccountID primitives.MonetaryAccountID) (iban.IBAN, bool) {
+	accountIBAN, _ := iban.NewIBAN("NL91ABNA0417164300")
+	return *accountIBAN, true
+}
+
+func transaction(amount int64, currency string, outgoing bool, category primitives.Category, date time.Time) reporting.ReportedTransaction {
+	return reporting.ReportedTransaction{
+		ID:                primitives.TransactionID(uuid.New()),
+		MonetaryAccountID: accountID,
+		Counterparty:      accountinformation.TransactionParty{Name: "Bakery", HasName: true},
+		Amount:            *money.New(amount, currency),
+		Outgoing:          outgoing,
+		Category:          category,
+		Description:       "Bread",
+		TransactionDate:   date,
+	}
+}
+
+var source = fakeSource{
+	transaction(1250, "EUR", true, "Groceries", time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)),
+	transaction(100000, "EUR", false, "Salary", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)),
+	transaction(500, "JPY", true, "Travel", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)),
+}
+
+func Test_Export_CSV_FormatsAmountsInAccountCurrency(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	err := NewExporter(source).Export(out, userID, Filter{}, CSV)
+
+	records, _ := csv.NewReader(out).ReadAll()
+	if err != nil || len(records) != 4 {
+		t.Fatalf("Expected a header and 3 rows, got %d (%v)", len(records), err)
+	}
+	if records[1][7] != "1000.00" || records[2][7] != "-12.50" || records[3][7] != "-500" || records[3][8] != "JPY" {
+		t.Errorf("Unexpected amounts %v %v %v", records[1][7], records[2][7], records[3][7])
+	}
+}
+
+func Test_Export_NDJSON_Filters(t *testing.T) {
+	out := new(bytes.Buffer)
+	filter := Filter{Category: "Groceries", HasCategory: true}
+
+	err := NewExporter(source).Export(out, userID, filter, NDJSON)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("Expected one line, got %d (%v)", len(lines), err)
+	}
+	var exported jsonTransaction
+	if err := json.Unmarshal([]byte(lines[0]), &exported); err != nil || exported.AmountMinorUnits != -1250 || exported.Category != "Groceries" {
+		t.Errorf("Unexpected transaction %+v (%v)", exported, err)
+	}
+}
+
+func Test_Export_OFX_StatementPerCurrency(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	err := NewExporter(source).Export(out, userID, Filter{}, OFX)
+
+	ofx := out.String()
+	if err != nil || strings.Count(ofx, "<STMTRS>") != 2 || !strings.Contains(ofx, "<TRNAMT>-12.50</TRNAMT>") || !strings.Contains(ofx, "<BANKID>ABNA</BANKID>") {
+		t.Errorf("Unexpected OFX document (%v):\n%s", err, ofx)
+	}
+}
+
+func Test_ParseQuery(t *testing.T) {
+	query := map[string][]string{"userId": {userID.String()}, "format": {"ofx"}, "from": {"2020-01-01"}}
+
+	parsedUserID, format, filter, err := ParseQuery(query)
+
+	if err != nil || parsedUserID != userID || format != OFX || filter.Period.From.IsZero() {
+		t.Errorf("Unexpected query %v %v %+v (%v)", parsedUserID, format, filter, err)
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"app/primitives"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ParseQuery reads the user, format and filter of an export from query parameters:
+// userId, format (csv, ofx or ndjson), account, category and from/to as yyyy-mm-dd where to is exclusive
+func ParseQuery(query url.Values) (primitives.UserID, Format, Filter, error) {
+	var filter Filter
+
+	userID, err := uuid.Parse(query.Get("userId"))
+	if err != nil {
+		return primitives.UserID{}, "", filter, fmt.Errorf("userId should be a valid uuid")
+	}
+
+	format := Format(query.Get("format"))
+	switch format {
+	case "":
+		format = CSV
+	case CSV, OFX, NDJSON:
+	default:
+		return primitives.UserID{}, "", filter, fmt.Errorf("unknown export format %s", format)
+	}
+
+	if account := query.Get("account"); account != "" {
+		monetaryAccountID, err := uuid.Parse(account)
+		if err != nil {
+			return primitives.UserID{}, "", filter, fmt.Errorf("account should be a valid uuid")
+		}
+		filter.MonetaryAccountID = primitives.MonetaryAccountID(monetaryAccountID)
+		filter.HasMonetaryAccountID = true
+	}
+
+	if category := query.Get("category"); category != "" {
+		filter.Category = primitives.Category(category)
+		filter.HasCategory = true
+	}
+
+	if from := query.Get("from"); from != "" {
+		if filter.Period.From, err = time.Parse("2006-01-02", from); err != nil {
+			return primitives.UserID{}, "", filter, fmt.Errorf("invalid from date %s, expected format yyyy-mm-dd", from)
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.Period.To, err = time.Parse("2006-01-02", to); err != nil {
+			return primitives.UserID{}, "", filter, fmt.Errorf("invalid to date %s, expected format yyyy-mm-dd", to)
+		}
+	}
+
+	return primitives.UserID(userID), format, filter, nil
+}
+
+func exportHandler(exporter Exporter) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, format, filter, err := ParseQuery(req.URL.Query())
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res.Header().Set("Content-Type", format.ContentType())
+		res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"transactions.%s\"", format))
+
+		if err := exporter.Export(res, userID, filter, format); err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// RegisterExportController will register a http controller that exports the transactions of a user
+func RegisterExportController(exporter Exporter) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix("/export").Subrouter()
+		controller.Methods("GET").Path("/transactions").HandlerFunc(exportHandler(exporter))
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"app/reporting"
+	"encoding/json"
+	"io"
+)
+
+type jsonTransaction struct {
+	ID                string `json:"id"`
+	Date              string `json:"date"`
+	Account           string `json:"account"`
+	AccountAlias      string `json:"accountAlias"`
+	CounterpartyName  string `json:"counterpartyName,omitempty"`
+	CounterpartyIBAN  string `json:"counterpartyIban,omitempty"`
+	Description       string `json:"description"`
+	Category          string `json:"category"`
+	Amount            string `json:"amount"`
+	AmountMinorUnits  int64  `json:"amountMinorUnits"`
+	Currency          string `json:"currency"`
+	InternalTransfer  bool   `json:"internalTransfer"`
+	MonetaryAccountID string `json:"monetaryAccountId"`
+}
+
+func (exporter Exporter) writeNDJSON(writer io.Writer, transactions []reporting.ReportedTransaction) error {
+	encoder := json.NewEncoder(writer)
+	for _, transaction := range transactions {
+		amount := transaction.SignedAmount()
+		err := encoder.Encode(jsonTransaction{
+			ID:                transaction.ID.String(),
+			Date:              transaction.TransactionDate.Format("2006-01-02T15:04:05Z07:00"),
+			Account:           exporter.accountNumberOf(transaction.MonetaryAccountID),
+			AccountAlias:      exporter.source.AliasOf(transaction.MonetaryAccountID),
+			CounterpartyName:  transaction.Counterparty.Name,
+			CounterpartyIBAN:  transaction.Counterparty.IBAN.Code,
+			Description:       transaction.Description,
+			Category:          string(transaction.Category),
+			Amount:            formatAmount(amount),
+			AmountMinorUnits:  amount.Amount(),
+			Currency:          amount.Currency().Code,
+			InternalTransfer:  transaction.InternalTransfer,
+			MonetaryAccountID: transaction.MonetaryAccountID.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
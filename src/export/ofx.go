@@ -0,0 +1,156 @@
+package export
+
+import (
+	"app/primitives"
+	"app/reporting"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+const ofxDateLayout = "20060102150405"
+
+// maxOFXNameLength is the maximum length of the NAME of a transaction in OFX
+const maxOFXNameLength = 32
+
+type ofxDocument struct {
+	XMLName    xml.Name               `xml:"OFX"`
+	SignOn     ofxSignOn              `xml:"SIGNONMSGSRSV1>SONRS"`
+	Statements []ofxStatementResponse `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	Server   string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStatementResponse struct {
+	TransactionUID string       `xml:"TRNUID"`
+	Status         ofxStatus    `xml:"STATUS"`
+	Statement      ofxStatement `xml:"STMTRS"`
+}
+
+type ofxStatement struct {
+	Currency     string             `xml:"CURDEF"`
+	Account      ofxBankAccount     `xml:"BANKACCTFROM"`
+	Transactions ofxTransactionList `xml:"BANKTRANLIST"`
+}
+
+type ofxBankAccount struct {
+	BankID      string `xml:"BANKID"`
+	AccountID   string `xml:"ACCTID"`
+	AccountType string `xml:"ACCTTYPE"`
+}
+
+type ofxTransactionList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	ID     string `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxAccountKey struct {
+	monetaryAccountID primitives.MonetaryAccountID
+	currency          string
+}
+
+// writeOFX writes an OFX 2.2 document with a bank statement per account and currency
+func (exporter Exporter) writeOFX(writer io.Writer, transactions []reporting.ReportedTransaction) error {
+	statements := make(map[ofxAccountKey]*ofxStatement)
+	var order []ofxAccountKey
+
+	for _, transaction := range transactions {
+		amount := transaction.SignedAmount()
+		key := ofxAccountKey{monetaryAccountID: transaction.MonetaryAccountID, currency: amount.Currency().Code}
+
+		statement, ok := statements[key]
+		if !ok {
+			statement = exporter.newOFXStatement(key)
+			statements[key] = statement
+			order = append(order, key)
+		}
+
+		posted := transaction.TransactionDate.UTC().Format(ofxDateLayout)
+		if statement.Transactions.Start == "" || posted < statement.Transactions.Start {
+			statement.Transactions.Start = posted
+		}
+		if posted > statement.Transactions.End {
+			statement.Transactions.End = posted
+		}
+
+		ofxType := "CREDIT"
+		if transaction.Outgoing {
+			ofxType = "DEBIT"
+		}
+		if transaction.InternalTransfer {
+			ofxType = "XFER"
+		}
+
+		statement.Transactions.Transactions = append(statement.Transactions.Transactions, ofxTransaction{
+			Type:   ofxType,
+			Posted: posted,
+			Amount: formatAmount(amount),
+			ID:     transaction.ID.String(),
+			Name:   truncate(transaction.Counterparty.Name, maxOFXNameLength),
+			Memo:   transaction.Description,
+		})
+	}
+
+	document := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			Server:   time.Now().UTC().Format(ofxDateLayout),
+			Language: "ENG",
+		},
+	}
+	for i, key := range order {
+		document.Statements = append(document.Statements, ofxStatementResponse{
+			TransactionUID: strconv.Itoa(i + 1),
+			Status:         ofxStatus{Code: 0, Severity: "INFO"},
+			Statement:      *statements[key],
+		})
+	}
+
+	if _, err := io.WriteString(writer, ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
+
+func (exporter Exporter) newOFXStatement(key ofxAccountKey) *ofxStatement {
+	account := ofxBankAccount{BankID: "0", AccountID: exporter.accountNumberOf(key.monetaryAccountID), AccountType: "CHECKING"}
+	if accountIBAN, ok := exporter.source.IBANOf(key.monetaryAccountID); ok && len(accountIBAN.BBAN) >= 4 {
+		account.BankID = accountIBAN.BBAN[:4]
+	}
+
+	return &ofxStatement{Currency: key.currency, Account: account}
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
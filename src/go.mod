@@ -7,6 +7,7 @@ require (
 	github.com/almerlucke/go-iban v0.0.0-20170112082528-316a6e2335b3
 	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/graphql-go/graphql v0.7.9
 	github.com/graphql-go/handler v0.2.3
 	github.com/jinzhu/copier v0.0.0-20190924061706-b57f9002281a
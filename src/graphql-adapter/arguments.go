@@ -0,0 +1,68 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/primitives"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+// AccountOwners tells whether a user is one of the owners of a monetary account
+type AccountOwners interface {
+	IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool
+}
+
+func userIDArgument(args map[string]interface{}) (primitives.UserID, error) {
+	value, _ := args["userId"].(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return primitives.UserID{}, fmt.Errorf("invalid userId %s", value)
+	}
+	return primitives.UserID(id), nil
+}
+
+// authenticatedUser is the authenticated user of the request
+func authenticatedUser(p graphql.ResolveParams) (primitives.UserID, error) {
+	userID, ok := auth.UserIDFrom(p.Context)
+	if !ok {
+		return primitives.UserID{}, authorizationError(auth.ErrUnauthenticated)
+	}
+	return userID, nil
+}
+
+// authorizedUserIDArgument is the userId argument, only when it is the authenticated user of the request
+func authorizedUserIDArgument(p graphql.ResolveParams) (primitives.UserID, error) {
+	userID, err := userIDArgument(p.Args)
+	if err != nil {
+		return primitives.UserID{}, invalidArgument("userId", err)
+	}
+	if err := auth.Authorize(p.Context, userID); err != nil {
+		return primitives.UserID{}, authorizationError(err)
+	}
+	return userID, nil
+}
+
+// authorizedAccountIDArgument is the accountId argument, only when the authenticated user of the request owns the account
+func authorizedAccountIDArgument(p graphql.ResolveParams, owners AccountOwners) (primitives.MonetaryAccountID, error) {
+	id, err := accountIDArgument(p.Args)
+	if err != nil {
+		return primitives.MonetaryAccountID{}, invalidArgument("accountId", err)
+	}
+
+	accountID := primitives.MonetaryAccountID(id)
+	userID, ok := auth.UserIDFrom(p.Context)
+	if !ok {
+		return primitives.MonetaryAccountID{}, authorizationError(auth.ErrUnauthenticated)
+	}
+	if !owners.IsOwner(accountID, userID) {
+		return primitives.MonetaryAccountID{}, authorizationError(auth.ErrForbidden)
+	}
+	return accountID, nil
+}
+
+func stringArgument(args map[string]interface{}, name string) (string, bool) {
+	value, ok := args[name].(string)
+	return value, ok && value != ""
+}
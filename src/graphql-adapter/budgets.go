@@ -0,0 +1,185 @@
+package graphqladapter
+
+import (
+	"app/budgeting"
+	"app/primitives"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+const monthLayout = "2006-01"
+
+type budgetInMonth struct {
+	budgeting.BudgetOverview
+	month budgeting.Month
+}
+
+var budgetType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Budget",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(budgetInMonth).ID.String(), nil
+			},
+		},
+		"category": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(budgetInMonth).Category), nil
+			},
+		},
+		"month": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(budgetInMonth).month), nil
+			},
+		},
+		"monthlyLimit": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(budgetInMonth).Ledger.Limit, nil
+			},
+		},
+		"rollover": &graphql.Field{
+			Type: graphql.Boolean,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(budgetInMonth).Ledger.Rollover, nil
+			},
+		},
+		"available": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				budget := p.Source.(budgetInMonth)
+				return budget.Ledger.AvailableIn(budget.month), nil
+			},
+		},
+		"spent": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				budget := p.Source.(budgetInMonth)
+				return budget.Ledger.SpentIn(budget.month), nil
+			},
+		},
+		"remaining": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				budget := p.Source.(budgetInMonth)
+				return budget.Ledger.RemainingIn(budget.month), nil
+			},
+		},
+		"percentageSpent": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				budget := p.Source.(budgetInMonth)
+				return budget.Ledger.PercentageSpentIn(budget.month), nil
+			},
+		},
+	},
+})
+
+func budgetFields(repository budgeting.BudgetRepository) graphql.Fields {
+	return graphql.Fields{
+		"budgets": &graphql.Field{
+			Type:        graphql.NewList(budgetType),
+			Description: "Budgets of a user with the spending in a month, the current month to date by default",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"month":  &graphql.ArgumentConfig{Type: graphql.String, Description: "Month formatted as yyyy-mm"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				month := budgeting.MonthOf(time.Now())
+				if value, ok := stringArgument(p.Args, "month"); ok {
+					if month, err = budgeting.ParseMonth(value); err != nil {
+						return nil, err
+					}
+				}
+
+				budgets, err := repository.FindForUser(p.Context, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				res := make([]budgetInMonth, 0, len(budgets))
+				for _, budget := range budgets {
+					res = append(res, budgetInMonth{BudgetOverview: budget, month: month})
+				}
+				return res, nil
+			},
+		},
+	}
+}
+
+func budgetMutationFields(commands eh.CommandHandler) graphql.Fields {
+	return graphql.Fields{
+		"setBudget": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Sets the monthly budget of the authenticated user for a category, or changes it when the category already has one. Resolves to the id of the budget",
+			Args: graphql.FieldConfigArgument{
+				"category":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"monthlyLimit": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int), Description: "Amount in minor units of the currency"},
+				"currency":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"rollover":     &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false, Description: "Whether what is left of a month is available in the next month"},
+				"since":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "First month of the budget, formatted as yyyy-mm"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+
+				value, _ := stringArgument(p.Args, "since")
+				since, err := time.Parse(monthLayout, value)
+				if err != nil {
+					return nil, invalidArgument("since", fmt.Errorf("invalid since %s, expected format yyyy-mm", value))
+				}
+				category, _ := stringArgument(p.Args, "category")
+				monthlyLimit, _ := p.Args["monthlyLimit"].(int)
+				currency, _ := stringArgument(p.Args, "currency")
+				rollover, _ := p.Args["rollover"].(bool)
+
+				budgetID := budgeting.NewBudgetID(userID, primitives.Category(category))
+				if _, err := dispatch(p.Context, commands, budgeting.SetBudgetCommand{
+					BudgetID:     budgetID,
+					UserID:       userID,
+					Category:     primitives.Category(category),
+					MonthlyLimit: primitives.MoneyForCommand{Amount: int64(monthlyLimit), CurrencyCode: currency},
+					Rollover:     rollover,
+					Since:        since,
+				}); err != nil {
+					return nil, err
+				}
+				return budgetID.String(), nil
+			},
+		},
+		"removeBudget": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				id, err := uuidArgument(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, budgeting.RemoveBudgetCommand{
+					BudgetID: primitives.BudgetID(id),
+					UserID:   userID,
+				})
+			},
+		},
+	}
+}
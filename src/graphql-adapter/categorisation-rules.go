@@ -0,0 +1,129 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/categorisation"
+	"app/primitives"
+	"fmt"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// ruleCriteriaArguments are the criteria of the arguments, a rule only restricts itself to an account the user owns
+func ruleCriteriaArguments(args map[string]interface{}, userID primitives.UserID, owners AccountOwners) (categorisation.RuleCriteria, error) {
+	criteria := categorisation.RuleCriteria{}
+
+	if value, ok := stringArgument(args, "counterpartyIban"); ok {
+		counterpartyIBAN, err := iban.NewIBAN(value)
+		if err != nil {
+			return criteria, invalidArgument("counterpartyIban", fmt.Errorf("invalid counterpartyIban %s", value))
+		}
+		criteria.CounterpartyIBAN = *counterpartyIBAN
+		criteria.HasCounterpartyIBAN = true
+	}
+
+	criteria.DescriptionPattern, _ = stringArgument(args, "descriptionPattern")
+
+	if _, ok := stringArgument(args, "accountId"); ok {
+		id, err := uuidArgument(args, "accountId")
+		if err != nil {
+			return criteria, err
+		}
+		if !owners.IsOwner(primitives.MonetaryAccountID(id), userID) {
+			return criteria, authorizationError(auth.ErrForbidden)
+		}
+		criteria.MonetaryAccountID = primitives.MonetaryAccountID(id)
+		criteria.HasMonetaryAccountID = true
+	}
+
+	currency, _ := stringArgument(args, "currency")
+	if min, ok := args["minAmount"].(int); ok {
+		criteria.AmountRange.Min = primitives.MoneyForCommand{Amount: int64(min), CurrencyCode: currency}
+		criteria.AmountRange.HasMin = true
+	}
+	if max, ok := args["maxAmount"].(int); ok {
+		criteria.AmountRange.Max = primitives.MoneyForCommand{Amount: int64(max), CurrencyCode: currency}
+		criteria.AmountRange.HasMax = true
+	}
+	if (criteria.AmountRange.HasMin || criteria.AmountRange.HasMax) && currency == "" {
+		return criteria, invalidArgument("currency", fmt.Errorf("an amount range needs a currency"))
+	}
+
+	return criteria, nil
+}
+
+func categorisationRuleMutationFields(commands eh.CommandHandler, owners AccountOwners) graphql.Fields {
+	return graphql.Fields{
+		"defineCategorisationRule": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Defines a categorisation rule of the authenticated user, or changes it when an id is given. A transaction gets the category of the first rule by priority whose criteria it satisfies. Resolves to the id of the rule",
+			Args: graphql.FieldConfigArgument{
+				"id":                 &graphql.ArgumentConfig{Type: graphql.ID},
+				"category":           &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"priority":           &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				"counterpartyIban":   &graphql.ArgumentConfig{Type: graphql.String},
+				"descriptionPattern": &graphql.ArgumentConfig{Type: graphql.String, Description: "Regular expression the description has to match"},
+				"accountId":          &graphql.ArgumentConfig{Type: graphql.ID},
+				"minAmount":          &graphql.ArgumentConfig{Type: graphql.Int, Description: "Inclusive minimum of the absolute amount in minor units of the currency"},
+				"maxAmount":          &graphql.ArgumentConfig{Type: graphql.Int, Description: "Inclusive maximum of the absolute amount in minor units of the currency"},
+				"currency":           &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				criteria, err := ruleCriteriaArguments(p.Args, userID, owners)
+				if err != nil {
+					return nil, err
+				}
+
+				ruleID := primitives.CategorisationRuleID(uuid.New())
+				if _, ok := stringArgument(p.Args, "id"); ok {
+					id, err := uuidArgument(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					ruleID = primitives.CategorisationRuleID(id)
+				}
+				category, _ := stringArgument(p.Args, "category")
+				priority, _ := p.Args["priority"].(int)
+
+				if _, err := dispatch(p.Context, commands, categorisation.DefineCategorisationRuleCommand{
+					RuleID:   ruleID,
+					UserID:   userID,
+					Category: primitives.Category(category),
+					Priority: priority,
+					Criteria: criteria,
+				}); err != nil {
+					return nil, err
+				}
+				return ruleID.String(), nil
+			},
+		},
+		"removeCategorisationRule": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				id, err := uuidArgument(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, categorisation.RemoveCategorisationRuleCommand{
+					RuleID: primitives.CategorisationRuleID(id),
+					UserID: userID,
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/primitives"
+	"errors"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Errors are extended with a code and the argument they are about, so clients can show them at the right input
+
+// argumentOfField is the argument of the mutations that fills a field of a command
+var argumentOfField = map[string]string{
+	"MonetaryAccountID":      "accountId",
+	"TransactionID":          "transactionId",
+	"RecurringTransactionID": "id",
+	"UserID":                 "userId",
+	"Institution":            "institution",
+	"Category":               "category",
+	"CurrencyCode":           "currency",
+	"Alias":                  "alias",
+	"Note":                   "note",
+	"Tags":                   "tags",
+	"Parts":                  "parts",
+	"Name":                   "name",
+	"Email":                  "email",
+	"Role":                   "role",
+	"HouseholdID":            "householdId",
+	"InvitationID":           "invitationId",
+	"ExpenseID":              "id",
+	"Shares":                 "shares",
+	"Amount":                 "amount",
+	"To":                     "to",
+	"GoalID":                 "id",
+	"Target":                 "target",
+	"Deadline":               "deadline",
+	"MonetaryAccountIDs":     "accountIds",
+	"RuleID":                 "id",
+	"BudgetID":               "id",
+	"Percentage":             "percentage",
+}
+
+// extendedError is an error with extensions, that graphql adds to the error in the response
+type extendedError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (err extendedError) Error() string {
+	return err.message
+}
+
+func (err extendedError) Extensions() map[string]interface{} {
+	return err.extensions
+}
+
+func invalidArgument(argument string, err error) error {
+	return extendedError{
+		message:    err.Error(),
+		extensions: map[string]interface{}{"code": "INVALID_ARGUMENT", "argument": argument},
+	}
+}
+
+// authorizationError tells whether the user is not authenticated or not allowed to see the data
+func authorizationError(err error) error {
+	code := "FORBIDDEN"
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		code = "UNAUTHENTICATED"
+	}
+	return extendedError{message: err.Error(), extensions: map[string]interface{}{"code": code}}
+}
+
+// commandError maps the error of a command to an error with the code and the argument of the field it is about
+func commandError(err error) error {
+	var fieldError eh.CommandFieldError
+	if errors.As(err, &fieldError) {
+		return fieldErrorOf(err, "MISSING_FIELD", fieldError.Field)
+	}
+
+	var validationError primitives.ValidationError
+	if errors.As(err, &validationError) {
+		return fieldErrorOf(err, "INVALID_FIELD", validationError.Field)
+	}
+
+	return extendedError{
+		message:    err.Error(),
+		extensions: map[string]interface{}{"code": "COMMAND_FAILED"},
+	}
+}
+
+func fieldErrorOf(err error, code string, field string) error {
+	extensions := map[string]interface{}{"code": code, "field": field}
+	if argument, ok := argumentOfField[field]; ok {
+		extensions["argument"] = argument
+	}
+	return extendedError{message: err.Error(), extensions: extensions}
+}
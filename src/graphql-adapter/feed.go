@@ -0,0 +1,98 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/bus"
+	syncstatus "app/sync-status"
+	"context"
+	"sync"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// feedBuffer is the number of events a subscription can fall behind before it misses events
+const feedBuffer = 100
+
+// Feed passes the events that graphql subscriptions are about to every subscription: new transactions and balances
+// from the eventhorizon event bus, and syncs from the updates on the bus
+type Feed struct {
+	mu            *sync.RWMutex
+	updates       <-chan bus.Update
+	subscriptions map[int]chan interface{}
+	next          *int
+}
+
+// NewFeed subscribes to the updates on the bus, Start passes them on until the bus is closed
+func NewFeed(subscriber bus.Subscriber) Feed {
+	res := new(Feed)
+	res.mu = new(sync.RWMutex)
+	res.updates = subscriber.SubscribeUpdates(bus.DefaultSubscriptionOptions)
+	res.subscriptions = make(map[int]chan interface{})
+	res.next = new(int)
+	return *res
+}
+
+// Start passes the syncs of the updates on the bus to the subscriptions until the bus is closed
+func (feed Feed) Start() {
+	for update := range feed.updates {
+		if sync, ok := syncstatus.SyncOf(update); ok {
+			feed.publish(sync)
+		}
+	}
+}
+
+// Matcher matches the events subscriptions are about
+func (feed Feed) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhMonetaryAccountBalanceSnapshotted,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (feed Feed) HandlerType() eh.EventHandlerType {
+	return "graphql-subscription-feed"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (feed Feed) HandleEvent(ctx context.Context, event eh.Event) error {
+	switch data := event.Data().(type) {
+	case *accountinformation.NewTransactionFound:
+		feed.publish(*data)
+	case *accountinformation.MonetaryAccountBalanceSnapshotted:
+		feed.publish(*data)
+	}
+	return nil
+}
+
+// publish never waits for a subscription, a subscription that is too far behind misses the event
+func (feed Feed) publish(event interface{}) {
+	feed.mu.RLock()
+	defer feed.mu.RUnlock()
+
+	for _, events := range feed.subscriptions {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+func (feed Feed) subscribe() (int, <-chan interface{}) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	id := *feed.next
+	*feed.next++
+
+	events := make(chan interface{}, feedBuffer)
+	feed.subscriptions[id] = events
+	return id, events
+}
+
+func (feed Feed) unsubscribe(id int) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	delete(feed.subscriptions, id)
+}
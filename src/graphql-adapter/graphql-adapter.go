@@ -1,10 +1,49 @@
 package graphqladapter
 
 import (
+	"app/budgeting"
+	"app/households"
+	"app/networth"
+	"app/reporting"
+	savingsgoals "app/savings-goals"
+	"app/search"
+	sharedexpenses "app/shared-expenses"
+	spendingmap "app/spending-map"
+	syncstatus "app/sync-status"
+	"app/users"
+
 	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
 )
 
-func NewSchema() (graphql.Schema, error) {
+// Repositories are the read models that are exposed through graphql, and the command handler of the mutations
+type Repositories struct {
+	Budgets  budgeting.BudgetRepository
+	Goals    savingsgoals.GoalRepository
+	Reports  reporting.Reporter
+	NetWorth networth.Calculator
+	Syncs    syncstatus.Tracker
+	Search   search.Index
+	Map      spendingmap.Map
+	// Feed passes the events that subscriptions are about
+	Feed Feed
+	// Commands handles the commands of mutations
+	Commands eh.CommandHandler
+	// Owners of monetary accounts, users only see the accounts they own and the accounts shared with their Households
+	Owners AccountOwners
+	// Joint accounts are the only accounts that are shared with households
+	Joint JointAccounts
+	// Payers are the accounts of recurring transactions, only their owners change them
+	Payers RecurringTransactionPayers
+	// Users that signed up and the Households they are members of
+	Users      users.Directory
+	Households households.Memberships
+	// SharedExpenses are the ledgers of the households, the amounts of shared expenses come from the Transactions
+	SharedExpenses sharedexpenses.Ledgers
+	Transactions   Transactions
+}
+
+func NewSchema(repositories Repositories) (graphql.Schema, error) {
 	fields := graphql.Fields{
 		"hello": &graphql.Field{
 			Type: graphql.String,
@@ -13,9 +52,43 @@ func NewSchema() (graphql.Schema, error) {
 			},
 		},
 	}
+	addFields(fields, budgetFields(repositories.Budgets))
+	addFields(fields, savingsGoalFields(repositories.Goals))
+	addFields(fields, reportFields(repositories.Reports))
+	addFields(fields, netWorthFields(repositories.NetWorth))
+	addFields(fields, syncStatusFields(repositories.Syncs))
+	addFields(fields, searchFields(repositories.Search))
+	addFields(fields, spendingMapFields(repositories.Map))
+	addFields(fields, userFields(repositories.Users, repositories.Households))
+	addFields(fields, sharedExpenseFields(repositories.SharedExpenses, repositories.Households))
+
+	// every member of a household reads the accounts shared with it, only the owners of the household change them
+	readers := households.NewSharedAccountOwners(repositories.Owners, repositories.Households, households.Viewer)
+	writers := households.NewSharedAccountOwners(repositories.Owners, repositories.Households, households.Owner)
+	addFields(fields, transactionFields(repositories.Transactions, readers))
+
+	mutations := mutationFields(repositories.Commands, writers, repositories.Payers)
+	addFields(mutations, userMutationFields(repositories.Commands, repositories.Owners, repositories.Joint, repositories.Users, repositories.Households))
+	addFields(mutations, sharedExpenseMutationFields(repositories.Commands, writers, repositories.Transactions, repositories.Households))
+	addFields(mutations, savingsGoalMutationFields(repositories.Commands, writers))
+	addFields(mutations, categorisationRuleMutationFields(repositories.Commands, writers))
+	addFields(mutations, budgetMutationFields(repositories.Commands))
+
 	rootQuery := graphql.ObjectConfig{Name: "RootQuery", Fields: fields}
-	schemaConfig := graphql.SchemaConfig{Query: graphql.NewObject(rootQuery)}
+	rootMutation := graphql.ObjectConfig{Name: "RootMutation", Fields: mutations}
+	rootSubscription := graphql.ObjectConfig{Name: "RootSubscription", Fields: subscriptionFields(readers)}
+	schemaConfig := graphql.SchemaConfig{
+		Query:        graphql.NewObject(rootQuery),
+		Mutation:     graphql.NewObject(rootMutation),
+		Subscription: graphql.NewObject(rootSubscription),
+	}
 	schema, err := graphql.NewSchema(schemaConfig)
 
 	return schema, err
 }
+
+func addFields(to graphql.Fields, from graphql.Fields) {
+	for name, field := range from {
+		to[name] = field
+	}
+}
@@ -2,13 +2,23 @@ package graphqladapter
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/graphql-go/handler"
 )
 
-func RegisterGraphql(r *mux.Router) error {
-	schema, err := NewSchema()
+// RegisterGraphql will register the graphql endpoint, resolving queries from the repositories,
+// browsers only open subscriptions from the allowed origins
+func RegisterGraphql(repositories Repositories, allowedOrigins []string) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		return registerGraphql(r, repositories, allowedOrigins)
+	}
+}
+
+func registerGraphql(r *mux.Router, repositories Repositories, allowedOrigins []string) error {
+	schema, err := NewSchema(repositories)
 
 	if err != nil {
 		fmt.Println("Unable to create graphql schema")
@@ -22,7 +32,13 @@ func RegisterGraphql(r *mux.Router) error {
 	})
 
 	router := r.PathPrefix("/graphql").Subrouter()
+	router.Path("/").MatcherFunc(isWebSocketUpgrade).Handler(subscriptionHandler(schema, repositories.Feed, allowedOrigins))
 	router.Handle("/", h)
 
 	return nil
 }
+
+// isWebSocketUpgrade matches the requests of subscriptions, the other requests go to the handler of queries
+func isWebSocketUpgrade(req *http.Request, match *mux.RouteMatch) bool {
+	return websocket.IsWebSocketUpgrade(req)
+}
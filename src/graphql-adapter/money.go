@@ -0,0 +1,34 @@
+package graphqladapter
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/graphql-go/graphql"
+)
+
+var moneyType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Money",
+	Fields: graphql.Fields{
+		"amount": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "Amount in minor units of the currency",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				m := p.Source.(money.Money)
+				return m.Amount(), nil
+			},
+		},
+		"currency": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				m := p.Source.(money.Money)
+				return m.Currency().Code, nil
+			},
+		},
+		"display": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				m := p.Source.(money.Money)
+				return m.Display(), nil
+			},
+		},
+	},
+})
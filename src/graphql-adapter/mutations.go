@@ -0,0 +1,316 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	exchangerates "app/exchange-rates"
+	"app/primitives"
+	"app/recurring"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Mutations dispatch a command to the command handler and resolve to true once it is handled
+
+func dispatch(ctx context.Context, commands eh.CommandHandler, cmd eh.Command) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := commands.HandleCommand(ctx, cmd); err != nil {
+		return nil, commandError(err)
+	}
+	return true, nil
+}
+
+func uuidArgument(args map[string]interface{}, name string) (uuid.UUID, error) {
+	value, _ := args[name].(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.UUID{}, invalidArgument(name, fmt.Errorf("invalid %s %s", name, value))
+	}
+	return id, nil
+}
+
+func authorizedTransactionArguments(p graphql.ResolveParams, owners AccountOwners) (primitives.MonetaryAccountID, primitives.TransactionID, error) {
+	accountID, err := authorizedAccountIDArgument(p, owners)
+	if err != nil {
+		return primitives.MonetaryAccountID{}, primitives.TransactionID{}, err
+	}
+	transactionID, err := uuidArgument(p.Args, "transactionId")
+	if err != nil {
+		return primitives.MonetaryAccountID{}, primitives.TransactionID{}, err
+	}
+	return accountID, primitives.TransactionID(transactionID), nil
+}
+
+// RecurringTransactionPayers resolves the monetary account that pays a recurring transaction
+type RecurringTransactionPayers interface {
+	AccountOf(recurringTransactionID primitives.RecurringTransactionID) (primitives.MonetaryAccountID, bool)
+}
+
+// authorizedRecurringTransactionArgument is the id argument, only when the authenticated user owns the account that pays the recurring transaction
+func authorizedRecurringTransactionArgument(p graphql.ResolveParams, owners AccountOwners, payers RecurringTransactionPayers) (primitives.RecurringTransactionID, error) {
+	userID, err := authenticatedUser(p)
+	if err != nil {
+		return primitives.RecurringTransactionID{}, err
+	}
+	id, err := uuidArgument(p.Args, "id")
+	if err != nil {
+		return primitives.RecurringTransactionID{}, err
+	}
+
+	recurringTransactionID := primitives.RecurringTransactionID(id)
+	accountID, ok := payers.AccountOf(recurringTransactionID)
+	if !ok || !owners.IsOwner(accountID, userID) {
+		return primitives.RecurringTransactionID{}, authorizationError(auth.ErrForbidden)
+	}
+	return recurringTransactionID, nil
+}
+
+func authorizedInstitutionArguments(p graphql.ResolveParams) (primitives.UserID, primitives.Institution, error) {
+	userID, err := authorizedUserIDArgument(p)
+	if err != nil {
+		return primitives.UserID{}, "", err
+	}
+	institution, _ := stringArgument(p.Args, "institution")
+	return userID, primitives.Institution(institution), nil
+}
+
+var transactionArguments = graphql.FieldConfigArgument{
+	"accountId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	"transactionId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+}
+
+var institutionArguments = graphql.FieldConfigArgument{
+	"userId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	"institution": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "Institution like Bunq"},
+}
+
+func mutationFields(commands eh.CommandHandler, owners AccountOwners, payers RecurringTransactionPayers) graphql.Fields {
+	return graphql.Fields{
+		"renameAccount": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Gives a monetary account an alias, without an alias the alias at the institution is used again",
+			Args: graphql.FieldConfigArgument{
+				"accountId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"alias":     &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, err := authorizedAccountIDArgument(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				alias, _ := stringArgument(p.Args, "alias")
+
+				return dispatch(p.Context, commands, accountinformation.RenameMonetaryAccountCommand{
+					MonetaryAccountID: accountID,
+					Alias:             alias,
+				})
+			},
+		},
+		"setAccountShare": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Sets the percentage of a joint monetary account that belongs to one of its owners",
+			Args: graphql.FieldConfigArgument{
+				"accountId":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"userId":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"percentage": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, err := authorizedAccountIDArgument(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				userID, err := userIDArgument(p.Args)
+				if err != nil {
+					return nil, invalidArgument("userId", err)
+				}
+				percentage, _ := p.Args["percentage"].(int)
+
+				return dispatch(p.Context, commands, accountinformation.SetMonetaryAccountShareCommand{
+					MonetaryAccountID: accountID,
+					UserID:            userID,
+					Percentage:        percentage,
+				})
+			},
+		},
+		"categoriseTransaction": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Sets the category of a transaction, categorisation rules no longer change it",
+			Args: withArguments(transactionArguments, graphql.FieldConfigArgument{
+				"category": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, transactionID, err := authorizedTransactionArguments(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				category, _ := stringArgument(p.Args, "category")
+
+				return dispatch(p.Context, commands, accountinformation.OverrideTransactionCategoryCommand{
+					MonetaryAccountID: accountID,
+					TransactionID:     transactionID,
+					Category:          primitives.Category(category),
+				})
+			},
+		},
+		"setTransactionNote": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Sets the note on a transaction, without a note the note is removed",
+			Args: withArguments(transactionArguments, graphql.FieldConfigArgument{
+				"note": &graphql.ArgumentConfig{Type: graphql.String},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, transactionID, err := authorizedTransactionArguments(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				note, _ := stringArgument(p.Args, "note")
+
+				return dispatch(p.Context, commands, accountinformation.SetTransactionNoteCommand{
+					MonetaryAccountID: accountID,
+					TransactionID:     transactionID,
+					Note:              note,
+				})
+			},
+		},
+		"setTransactionTags": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Sets the tags on a transaction, tags are stored in lower case. Without tags the tags are removed",
+			Args: withArguments(transactionArguments, graphql.FieldConfigArgument{
+				"tags": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, transactionID, err := authorizedTransactionArguments(p, owners)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, accountinformation.SetTransactionTagsCommand{
+					MonetaryAccountID: accountID,
+					TransactionID:     transactionID,
+					Tags:              stringsArgument(p.Args, "tags"),
+				})
+			},
+		},
+		"splitTransaction": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Splits a transaction across categories or people, the parts sum to the amount of the transaction. Without parts the split is removed",
+			Args: withArguments(transactionArguments, graphql.FieldConfigArgument{
+				"parts": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(transactionSplitPartInputType))},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, transactionID, err := authorizedTransactionArguments(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				parts, err := splitPartsArgument(p.Args, "parts")
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, accountinformation.SplitTransactionCommand{
+					MonetaryAccountID: accountID,
+					TransactionID:     transactionID,
+					Parts:             parts,
+				})
+			},
+		},
+		"cancelRecurringTransaction": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Marks a recurring transaction as cancelled, it is no longer expected",
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := authorizedRecurringTransactionArgument(p, owners, payers)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, recurring.CancelRecurringTransactionCommand{
+					RecurringTransactionID: id,
+				})
+			},
+		},
+		"ignoreRecurringTransaction": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Marks a recurring transaction as ignored",
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := authorizedRecurringTransactionArgument(p, owners, payers)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, recurring.IgnoreRecurringTransactionCommand{
+					RecurringTransactionID: id,
+				})
+			},
+		},
+		"refresh": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Refreshes the accounts of a user at all institutions right away",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, accountinformation.RefreshUserCommand{UserID: userID})
+			},
+		},
+		"setReportingCurrency": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Sets the currency that reports and the net worth of a user are converted to",
+			Args: graphql.FieldConfigArgument{
+				"userId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"currency": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+				currency, _ := stringArgument(p.Args, "currency")
+
+				return dispatch(p.Context, commands, exchangerates.SetReportingCurrencyCommand{UserID: userID, CurrencyCode: currency})
+			},
+		},
+		"connectInstitution": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Connects a user to an institution, so the accounts of the user at the institution are refreshed",
+			Args:        institutionArguments,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, institution, err := authorizedInstitutionArguments(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, accountinformation.ConnectInstitutionCommand{UserID: userID, Institution: institution})
+			},
+		},
+		"disconnectInstitution": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Disconnects a user from an institution, the accounts of the user at the institution are no longer refreshed",
+			Args:        institutionArguments,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, institution, err := authorizedInstitutionArguments(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, accountinformation.DisconnectInstitutionCommand{UserID: userID, Institution: institution})
+			},
+		},
+	}
+}
@@ -0,0 +1,307 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	"app/budgeting"
+	"app/categorisation"
+	exchangerates "app/exchange-rates"
+	"app/households"
+	"app/primitives"
+	"app/recurring"
+	savingsgoals "app/savings-goals"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// recordingCommandHandler records the commands it handles and fails them with err
+type recordingCommandHandler struct {
+	commands []eh.Command
+	err      error
+}
+
+func (handler *recordingCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	handler.commands = append(handler.commands, cmd)
+	return handler.err
+}
+
+func executeMutation(t *testing.T, commands eh.CommandHandler, owners fixedOwners, mutation string) *graphql.Result {
+	schema, err := NewSchema(Repositories{Commands: commands, Owners: owners, Households: households.NewMemberships()})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	return graphql.Do(graphql.Params{Schema: schema, RequestString: mutation, Context: auth.WithUserID(context.Background(), testUserID)})
+}
+
+func Test_Mutation_SetTransactionNote_DispatchesCommand(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	accountID := uuid.New()
+	transactionID := uuid.New()
+
+	result := executeMutation(t, commands, fixedOwners{primitives.MonetaryAccountID(accountID): testUserID}, `mutation { setTransactionNote(accountId: "`+accountID.String()+`", transactionId: "`+transactionID.String()+`", note: "Dinner with Sam") }`)
+
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected one command, got %d", len(commands.commands))
+	}
+	cmd, ok := commands.commands[0].(accountinformation.SetTransactionNoteCommand)
+	if !ok || cmd.MonetaryAccountID != primitives.MonetaryAccountID(accountID) || cmd.TransactionID != primitives.TransactionID(transactionID) || cmd.Note != "Dinner with Sam" {
+		t.Errorf("Expected the note to be set on the transaction, got %+v", commands.commands[0])
+	}
+}
+
+func Test_Mutation_ValidationErrorHasExtensions(t *testing.T) {
+	commands := &recordingCommandHandler{err: primitives.NewValidationError("TransactionID", "unknown transaction")}
+	accountID := primitives.MonetaryAccountID(uuid.New())
+
+	result := executeMutation(t, commands, fixedOwners{accountID: testUserID}, `mutation { categoriseTransaction(accountId: "`+accountID.String()+`", transactionId: "`+uuid.New().String()+`", category: "Groceries") }`)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected one error, got %v", result.Errors)
+	}
+	extensions := result.Errors[0].Extensions
+	if extensions["code"] != "INVALID_FIELD" || extensions["field"] != "TransactionID" || extensions["argument"] != "transactionId" {
+		t.Errorf("Expected the error to be about the transactionId argument, got %v", extensions)
+	}
+}
+
+func Test_Mutation_MissingFieldErrorHasExtensions(t *testing.T) {
+	commands := &recordingCommandHandler{err: eh.CommandFieldError{Field: "Institution"}}
+
+	result := executeMutation(t, commands, fixedOwners{}, `mutation { connectInstitution(userId: "`+testUserID.String()+`", institution: "") }`)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected one error, got %v", result.Errors)
+	}
+	if extensions := result.Errors[0].Extensions; extensions["code"] != "MISSING_FIELD" || extensions["argument"] != "institution" {
+		t.Errorf("Expected the institution to be missing, got %v", extensions)
+	}
+}
+
+func Test_Mutation_InvalidArgumentIsNotDispatched(t *testing.T) {
+	commands := &recordingCommandHandler{}
+
+	result := executeMutation(t, commands, fixedOwners{}, `mutation { cancelRecurringTransaction(id: "nope") }`)
+
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "INVALID_ARGUMENT" {
+		t.Fatalf("Expected an invalid argument, got %v", result.Errors)
+	}
+	if len(commands.commands) != 0 {
+		t.Errorf("Expected no command to be dispatched, got %v", commands.commands)
+	}
+}
+
+func Test_Mutation_OnlyOwnersChangeAccounts(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	owners := fixedOwners{accountID: primitives.UserID(uuid.New())}
+
+	result := executeMutation(t, commands, owners, `mutation { renameAccount(accountId: "`+accountID.String()+`", alias: "Mine now") }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Errorf("Expected renaming the account of another user to be forbidden, got %v", result.Errors)
+	}
+
+	result = executeMutation(t, commands, owners, `mutation { refresh(userId: "`+uuid.New().String()+`") }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Errorf("Expected refreshing another user to be forbidden, got %v", result.Errors)
+	}
+
+	if len(commands.commands) != 0 {
+		t.Errorf("Expected no command to be dispatched, got %v", commands.commands)
+	}
+}
+
+func Test_Mutation_SplitTransaction_DispatchesParts(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	transactionID := uuid.New()
+
+	result := executeMutation(t, commands, fixedOwners{accountID: testUserID}, `mutation { splitTransaction(accountId: "`+accountID.String()+`", transactionId: "`+transactionID.String()+`", parts: [
+		{category: "Restaurants", amount: 6000, currency: "EUR"},
+		{person: "Sam", amount: 4000, currency: "EUR"}
+	]) }`)
+
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(accountinformation.SplitTransactionCommand)
+	if !ok || len(cmd.Parts) != 2 || cmd.Parts[0].Category != "Restaurants" || cmd.Parts[1].Person != "Sam" || cmd.Parts[1].Amount.Amount != 4000 {
+		t.Errorf("Expected the transaction to be split, got %+v", commands.commands[0])
+	}
+
+	result = executeMutation(t, commands, fixedOwners{accountID: testUserID}, `mutation { splitTransaction(accountId: "`+accountID.String()+`", transactionId: "`+transactionID.String()+`", parts: [{category: "Restaurants", amount: 6000, currency: "EURO"}]) }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["argument"] != "parts" {
+		t.Errorf("Expected an unknown currency to be invalid, got %v", result.Errors)
+	}
+}
+
+func Test_Mutation_SetSavingsGoal_OnlyOnOwnAccounts(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	savingsID := primitives.MonetaryAccountID(uuid.New())
+	otherID := primitives.MonetaryAccountID(uuid.New())
+	owners := fixedOwners{savingsID: testUserID, otherID: primitives.UserID(uuid.New())}
+	setGoal := func(accountIDs string) *graphql.Result {
+		return executeMutation(t, commands, owners, `mutation { setSavingsGoal(name: "Holiday", target: 120000, currency: "EUR", deadline: "2030-06-30", accountIds: [`+accountIDs+`]) }`)
+	}
+
+	result := setGoal(`"` + savingsID.String() + `", "` + otherID.String() + `"`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Fatalf("Expected saving on the account of another user to be forbidden, got %v", result.Errors)
+	}
+
+	result = setGoal(`"` + savingsID.String() + `"`)
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(savingsgoals.SetSavingsGoalCommand)
+	if !ok || cmd.UserID != testUserID || cmd.Target.Amount != 120000 || cmd.Deadline.Format(dateLayout) != "2030-06-30" || len(cmd.MonetaryAccountIDs) != 1 || cmd.MonetaryAccountIDs[0] != savingsID {
+		t.Errorf("Expected the goal to be set, got %+v", commands.commands[0])
+	}
+	if id := result.Data.(map[string]interface{})["setSavingsGoal"]; id != cmd.GoalID.String() {
+		t.Errorf("Expected the id of the goal, got %v", id)
+	}
+}
+
+func Test_Mutation_SetReportingCurrency_OnlyForAuthenticatedUser(t *testing.T) {
+	commands := &recordingCommandHandler{}
+
+	result := executeMutation(t, commands, fixedOwners{}, `mutation { setReportingCurrency(userId: "`+uuid.New().String()+`", currency: "USD") }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Fatalf("Expected setting the currency of another user to be forbidden, got %v", result.Errors)
+	}
+
+	result = executeMutation(t, commands, fixedOwners{}, `mutation { setReportingCurrency(userId: "`+testUserID.String()+`", currency: "USD") }`)
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(exchangerates.SetReportingCurrencyCommand)
+	if !ok || cmd.UserID != testUserID || cmd.CurrencyCode != "USD" {
+		t.Errorf("Expected the reporting currency to be set, got %+v", commands.commands[0])
+	}
+}
+
+type fixedPayers map[primitives.RecurringTransactionID]primitives.MonetaryAccountID
+
+func (payers fixedPayers) AccountOf(recurringTransactionID primitives.RecurringTransactionID) (primitives.MonetaryAccountID, bool) {
+	accountID, ok := payers[recurringTransactionID]
+	return accountID, ok
+}
+
+func Test_Mutation_CancelRecurringTransaction_OnlyByOwnerOfPayingAccount(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	ownAccountID := primitives.MonetaryAccountID(uuid.New())
+	otherAccountID := primitives.MonetaryAccountID(uuid.New())
+	ownID := primitives.RecurringTransactionID(uuid.New())
+	otherID := primitives.RecurringTransactionID(uuid.New())
+	schema, err := NewSchema(Repositories{
+		Commands:   commands,
+		Owners:     fixedOwners{ownAccountID: testUserID, otherAccountID: primitives.UserID(uuid.New())},
+		Payers:     fixedPayers{ownID: ownAccountID, otherID: otherAccountID},
+		Households: households.NewMemberships(),
+	})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	cancel := func(id string) *graphql.Result {
+		return graphql.Do(graphql.Params{Schema: schema, RequestString: `mutation { cancelRecurringTransaction(id: "` + id + `") }`, Context: auth.WithUserID(context.Background(), testUserID)})
+	}
+
+	for _, id := range []string{otherID.String(), uuid.New().String()} {
+		if result := cancel(id); len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" {
+			t.Errorf("Expected cancelling a recurring transaction paid by another user to be forbidden, got %v", result.Errors)
+		}
+	}
+	if len(commands.commands) != 0 {
+		t.Fatalf("Expected no command to be dispatched, got %v", commands.commands)
+	}
+
+	if result := cancel(ownID.String()); result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	if cmd, ok := commands.commands[0].(recurring.CancelRecurringTransactionCommand); !ok || cmd.RecurringTransactionID != ownID {
+		t.Errorf("Expected the recurring transaction to be cancelled, got %+v", commands.commands[0])
+	}
+}
+
+func Test_Mutation_DefineCategorisationRule_OnlyOnOwnAccounts(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	checkingID := primitives.MonetaryAccountID(uuid.New())
+	otherID := primitives.MonetaryAccountID(uuid.New())
+	owners := fixedOwners{checkingID: testUserID, otherID: primitives.UserID(uuid.New())}
+	defineRule := func(accountID primitives.MonetaryAccountID) *graphql.Result {
+		return executeMutation(t, commands, owners, `mutation { defineCategorisationRule(category: "Groceries", priority: 2, descriptionPattern: "(?i)jumbo", accountId: "`+accountID.String()+`", maxAmount: 10000, currency: "EUR") }`)
+	}
+
+	result := defineRule(otherID)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Fatalf("Expected a rule on the account of another user to be forbidden, got %v", result.Errors)
+	}
+
+	result = defineRule(checkingID)
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(categorisation.DefineCategorisationRuleCommand)
+	if !ok || cmd.UserID != testUserID || cmd.Category != "Groceries" || cmd.Priority != 2 || cmd.Criteria.MonetaryAccountID != checkingID || cmd.Criteria.AmountRange.HasMin || cmd.Criteria.AmountRange.Max.Amount != 10000 {
+		t.Errorf("Expected the rule to be defined, got %+v", commands.commands[0])
+	}
+	if id := result.Data.(map[string]interface{})["defineCategorisationRule"]; id != cmd.RuleID.String() {
+		t.Errorf("Expected the id of the rule, got %v", id)
+	}
+
+	result = executeMutation(t, commands, owners, `mutation { removeCategorisationRule(id: "`+cmd.RuleID.String()+`") }`)
+	if remove, ok := commands.commands[1].(categorisation.RemoveCategorisationRuleCommand); result.HasErrors() || !ok || remove.RuleID != cmd.RuleID || remove.UserID != testUserID {
+		t.Errorf("Expected the rule of the authenticated user to be removed, got %v %+v", result.Errors, commands.commands[1])
+	}
+}
+
+func Test_Mutation_SetBudget_ForAuthenticatedUser(t *testing.T) {
+	commands := &recordingCommandHandler{}
+
+	result := executeMutation(t, commands, fixedOwners{}, `mutation { setBudget(category: "Groceries", monthlyLimit: 40000, currency: "EUR", rollover: true, since: "2020-03") }`)
+	if result.HasErrors() {
+		t.Fatalf("Expected the mutation to succeed, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(budgeting.SetBudgetCommand)
+	if !ok || cmd.BudgetID != budgeting.NewBudgetID(testUserID, "Groceries") || cmd.UserID != testUserID || cmd.MonthlyLimit.Amount != 40000 || !cmd.Rollover || budgeting.MonthOf(cmd.Since) != "2020-03" {
+		t.Errorf("Expected the budget to be set, got %+v", commands.commands[0])
+	}
+
+	result = executeMutation(t, commands, fixedOwners{}, `mutation { removeBudget(id: "`+cmd.BudgetID.String()+`") }`)
+	if remove, ok := commands.commands[1].(budgeting.RemoveBudgetCommand); result.HasErrors() || !ok || remove.BudgetID != cmd.BudgetID || remove.UserID != testUserID {
+		t.Errorf("Expected the budget of the authenticated user to be removed, got %v %+v", result.Errors, commands.commands[1])
+	}
+
+	result = executeMutation(t, commands, fixedOwners{}, `mutation { setBudget(category: "Groceries", monthlyLimit: 40000, currency: "EUR", since: "March") }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["argument"] != "since" || len(commands.commands) != 2 {
+		t.Errorf("Expected an invalid since to be rejected, got %v", result.Errors)
+	}
+}
+
+func Test_Mutation_SetAccountShare_OnlyByOwner(t *testing.T) {
+	commands := &recordingCommandHandler{}
+	jointID := primitives.MonetaryAccountID(uuid.New())
+	otherID := primitives.MonetaryAccountID(uuid.New())
+	partnerID := primitives.UserID(uuid.New())
+	owners := fixedOwners{jointID: testUserID, otherID: partnerID}
+	setShare := func(accountID primitives.MonetaryAccountID) *graphql.Result {
+		return executeMutation(t, commands, owners, `mutation { setAccountShare(accountId: "`+accountID.String()+`", userId: "`+partnerID.String()+`", percentage: 40) }`)
+	}
+
+	result := setShare(otherID)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Fatalf("Expected setting a share of the account of another user to be forbidden, got %v", result.Errors)
+	}
+
+	result = setShare(jointID)
+	cmd, ok := commands.commands[0].(accountinformation.SetMonetaryAccountShareCommand)
+	if result.HasErrors() || !ok || cmd.MonetaryAccountID != jointID || cmd.UserID != partnerID || cmd.Percentage != 40 {
+		t.Errorf("Expected the share to be set, got %v %+v", result.Errors, commands.commands[0])
+	}
+}
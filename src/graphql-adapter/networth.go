@@ -0,0 +1,65 @@
+package graphqladapter
+
+import (
+	"app/networth"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+var intervalType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "Interval",
+	Values: graphql.EnumValueConfigMap{
+		"DAILY":   &graphql.EnumValueConfig{Value: networth.Daily},
+		"WEEKLY":  &graphql.EnumValueConfig{Value: networth.Weekly},
+		"MONTHLY": &graphql.EnumValueConfig{Value: networth.Monthly},
+	},
+})
+
+var netWorthPointType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "NetWorthPoint",
+	Fields: graphql.Fields{
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(networth.Point).Date.Format(dateLayout), nil
+			},
+		},
+		"netWorth": &graphql.Field{
+			Type:        graphql.NewList(moneyType),
+			Description: "The net worth in the reporting currency, and in every currency without an exchange rate",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(networth.Point).NetWorth, nil
+			},
+		},
+	},
+})
+
+func netWorthFields(calculator networth.Calculator) graphql.Fields {
+	return graphql.Fields{
+		"netWorth": &graphql.Field{
+			Type:        graphql.NewList(netWorthPointType),
+			Description: "Net worth of a user over all its monetary accounts, joint accounts count for the share of the user",
+			Args: withArguments(periodArguments, graphql.FieldConfigArgument{
+				"interval": &graphql.ArgumentConfig{Type: intervalType, DefaultValue: networth.Monthly},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				period, err := periodArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				to := period.To
+				if !to.IsZero() {
+					to = to.Add(-time.Nanosecond)
+				}
+				return calculator.Timeline(userID, p.Args["interval"].(networth.Interval), period.From, to), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,204 @@
+package graphqladapter
+
+import (
+	"app/reporting"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const dateLayout = "2006-01-02"
+
+var reportDimensionType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "ReportDimension",
+	Values: graphql.EnumValueConfigMap{
+		"MONTH":        &graphql.EnumValueConfig{Value: reporting.ByMonth},
+		"CATEGORY":     &graphql.EnumValueConfig{Value: reporting.ByCategory},
+		"COUNTERPARTY": &graphql.EnumValueConfig{Value: reporting.ByCounterparty},
+		"ACCOUNT":      &graphql.EnumValueConfig{Value: reporting.ByAccount},
+		"TAG":          &graphql.EnumValueConfig{Value: reporting.ByTag},
+	},
+})
+
+var reportLineType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ReportLine",
+	Fields: graphql.Fields{
+		"key": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Key, nil
+			},
+		},
+		"label": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Label, nil
+			},
+		},
+		"income": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Income, nil
+			},
+		},
+		"expenses": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Expenses, nil
+			},
+		},
+		"net": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Net(), nil
+			},
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportLine).Count, nil
+			},
+		},
+	},
+})
+
+var yearOverYearLineType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "YearOverYearLine",
+	Fields: graphql.Fields{
+		"key": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).Key, nil
+			},
+		},
+		"label": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).Label, nil
+			},
+		},
+		"current": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).Current, nil
+			},
+		},
+		"previous": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).Previous, nil
+			},
+		},
+		"change": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).Change(), nil
+			},
+		},
+		"changePercentage": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.YearOverYearLine).ChangePercentage(), nil
+			},
+		},
+	},
+})
+
+var periodArguments = graphql.FieldConfigArgument{
+	"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	"from":   &graphql.ArgumentConfig{Type: graphql.String, Description: "Inclusive start date formatted as yyyy-mm-dd"},
+	"to":     &graphql.ArgumentConfig{Type: graphql.String, Description: "Exclusive end date formatted as yyyy-mm-dd"},
+}
+
+func periodArgument(args map[string]interface{}) (reporting.Period, error) {
+	var period reporting.Period
+	var err error
+
+	if value, ok := stringArgument(args, "from"); ok {
+		if period.From, err = time.Parse(dateLayout, value); err != nil {
+			return period, fmt.Errorf("invalid from date %s, expected format yyyy-mm-dd", value)
+		}
+	}
+
+	if value, ok := stringArgument(args, "to"); ok {
+		if period.To, err = time.Parse(dateLayout, value); err != nil {
+			return period, fmt.Errorf("invalid to date %s, expected format yyyy-mm-dd", value)
+		}
+	}
+
+	return period, nil
+}
+
+func withArguments(base graphql.FieldConfigArgument, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	res := graphql.FieldConfigArgument{}
+	for name, argument := range base {
+		res[name] = argument
+	}
+	for name, argument := range extra {
+		res[name] = argument
+	}
+	return res
+}
+
+func reportFields(reporter reporting.Reporter) graphql.Fields {
+	return graphql.Fields{
+		"spendingReport": &graphql.Field{
+			Type:        graphql.NewList(reportLineType),
+			Description: "Income and expenses of a user grouped by a dimension, excluding internal transfers",
+			Args: withArguments(periodArguments, graphql.FieldConfigArgument{
+				"groupBy": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reportDimensionType)},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				period, err := periodArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				return reporter.Report(userID, p.Args["groupBy"].(reporting.Dimension), period), nil
+			},
+		},
+		"yearOverYearReport": &graphql.Field{
+			Type:        graphql.NewList(yearOverYearLineType),
+			Description: "Expenses of a user in a year compared to the year before, grouped by a dimension",
+			Args: graphql.FieldConfigArgument{
+				"userId":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"groupBy": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reportDimensionType)},
+				"year":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return reporter.YearOverYear(userID, p.Args["groupBy"].(reporting.Dimension), p.Args["year"].(int)), nil
+			},
+		},
+		"topMerchants": &graphql.Field{
+			Type:        graphql.NewList(reportLineType),
+			Description: "Counterparties a user spent the most on, per currency",
+			Args: withArguments(periodArguments, graphql.FieldConfigArgument{
+				"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				period, err := periodArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				return reporter.TopCounterparties(userID, period, p.Args["limit"].(int)), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/primitives"
+	savingsgoals "app/savings-goals"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type goalAt struct {
+	savingsgoals.GoalOverview
+	at time.Time
+}
+
+var savingsGoalType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SavingsGoal",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).ID.String(), nil
+			},
+		},
+		"name": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Name, nil
+			},
+		},
+		"accountIds": &graphql.Field{
+			Type:        graphql.NewList(graphql.ID),
+			Description: "The accounts that the goal is saved on",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ids := p.Source.(goalAt).MonetaryAccountIDs
+				res := make([]string, 0, len(ids))
+				for _, id := range ids {
+					res = append(res, id.String())
+				}
+				return res, nil
+			},
+		},
+		"target": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.Target, nil
+			},
+		},
+		"since": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.Since.Format(dateLayout), nil
+			},
+		},
+		"deadline": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.Deadline.Format(dateLayout), nil
+			},
+		},
+		"saved": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.Saved(), nil
+			},
+		},
+		"remaining": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.Remaining(), nil
+			},
+		},
+		"percentageSaved": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.PercentageSaved(), nil
+			},
+		},
+		"plannedMonthlyContribution": &graphql.Field{
+			Type:        moneyType,
+			Description: "What has to be saved every month to reach the target from the time the goal was set",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Progress.PlannedMonthlyContribution(), nil
+			},
+		},
+		"requiredMonthlyContribution": &graphql.Field{
+			Type:        moneyType,
+			Description: "What has to be saved every month from now on to reach the target at the deadline",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				goal := p.Source.(goalAt)
+				return goal.Progress.RequiredMonthlyContribution(goal.at), nil
+			},
+		},
+		"reached": &graphql.Field{
+			Type: graphql.Boolean,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(goalAt).Reached, nil
+			},
+		},
+		"onTrack": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether the target is reached, or can be reached by saving no more than planned every month",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				goal := p.Source.(goalAt)
+				return goal.Reached || goal.Progress.IsOnTrack(goal.at), nil
+			},
+		},
+	},
+})
+
+func savingsGoalFields(repository savingsgoals.GoalRepository) graphql.Fields {
+	return graphql.Fields{
+		"savingsGoals": &graphql.Field{
+			Type:        graphql.NewList(savingsGoalType),
+			Description: "Savings goals of a user with their progress, the closest deadline first",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				goals, err := repository.FindForUser(p.Context, userID)
+				if err != nil {
+					return nil, err
+				}
+
+				now := time.Now()
+				res := make([]goalAt, 0, len(goals))
+				for _, goal := range goals {
+					res = append(res, goalAt{GoalOverview: goal, at: now})
+				}
+				return res, nil
+			},
+		},
+	}
+}
+
+// authorizedAccountIDsArgument are the accounts of the argument, only when the user owns all of them
+func authorizedAccountIDsArgument(args map[string]interface{}, name string, userID primitives.UserID, owners AccountOwners) ([]primitives.MonetaryAccountID, error) {
+	values := stringsArgument(args, name)
+	res := make([]primitives.MonetaryAccountID, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, invalidArgument(name, fmt.Errorf("invalid account id %s", value))
+		}
+		if !owners.IsOwner(primitives.MonetaryAccountID(id), userID) {
+			return nil, authorizationError(auth.ErrForbidden)
+		}
+		res = append(res, primitives.MonetaryAccountID(id))
+	}
+	return res, nil
+}
+
+func savingsGoalMutationFields(commands eh.CommandHandler, owners AccountOwners) graphql.Fields {
+	return graphql.Fields{
+		"setSavingsGoal": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Sets a savings goal of the authenticated user, or changes it when an id is given. Resolves to the id of the goal",
+			Args: graphql.FieldConfigArgument{
+				"id":         &graphql.ArgumentConfig{Type: graphql.ID},
+				"name":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"target":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int), Description: "Amount in minor units of the currency"},
+				"currency":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"deadline":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "Date formatted as yyyy-mm-dd"},
+				"accountIds": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				accountIDs, err := authorizedAccountIDsArgument(p.Args, "accountIds", userID, owners)
+				if err != nil {
+					return nil, err
+				}
+
+				goalID := primitives.GoalID(uuid.New())
+				if _, ok := stringArgument(p.Args, "id"); ok {
+					id, err := uuidArgument(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					goalID = primitives.GoalID(id)
+				}
+				value, _ := stringArgument(p.Args, "deadline")
+				deadline, err := time.Parse(dateLayout, value)
+				if err != nil {
+					return nil, invalidArgument("deadline", fmt.Errorf("invalid deadline %s, expected format yyyy-mm-dd", value))
+				}
+				name, _ := stringArgument(p.Args, "name")
+				target, _ := p.Args["target"].(int)
+				currency, _ := stringArgument(p.Args, "currency")
+
+				if _, err := dispatch(p.Context, commands, savingsgoals.SetSavingsGoalCommand{
+					GoalID:             goalID,
+					UserID:             userID,
+					Name:               name,
+					Target:             primitives.MoneyForCommand{Amount: int64(target), CurrencyCode: currency},
+					Deadline:           deadline,
+					MonetaryAccountIDs: accountIDs,
+					Timestamp:          time.Now(),
+				}); err != nil {
+					return nil, err
+				}
+				return goalID.String(), nil
+			},
+		},
+		"removeSavingsGoal": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				id, err := uuidArgument(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, savingsgoals.RemoveSavingsGoalCommand{
+					GoalID: primitives.GoalID(id),
+					UserID: userID,
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,213 @@
+package graphqladapter
+
+import (
+	"app/primitives"
+	"app/search"
+
+	"github.com/graphql-go/graphql"
+)
+
+var searchMatchType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "SearchMatch",
+	Description: "A highlighted word, as offsets in characters of the text",
+	Fields: graphql.Fields{
+		"start": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Match).Start, nil
+			},
+		},
+		"end": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "Exclusive",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Match).End, nil
+			},
+		},
+	},
+})
+
+var searchHighlightType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchHighlight",
+	Fields: graphql.Fields{
+		"field": &graphql.Field{
+			Type:        graphql.String,
+			Description: "description, counterparty, iban, category, note or tags",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Highlight).Field, nil
+			},
+		},
+		"text": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Highlight).Text, nil
+			},
+		},
+		"matches": &graphql.Field{
+			Type: graphql.NewList(searchMatchType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Highlight).Matches, nil
+			},
+		},
+	},
+})
+
+var searchHitType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchHit",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.ID.String(), nil
+			},
+		},
+		"accountId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.MonetaryAccountID.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type:        moneyType,
+			Description: "Negative when the money left the account",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.SignedAmount(), nil
+			},
+		},
+		"counterparty": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				counterparty := p.Source.(search.Hit).Transaction.Counterparty
+				if counterparty.HasName {
+					return counterparty.Name, nil
+				}
+				if counterparty.HasIBAN {
+					return counterparty.IBAN.PrintCode, nil
+				}
+				return nil, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.Description, nil
+			},
+		},
+		"category": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(search.Hit).Transaction.Category), nil
+			},
+		},
+		"note": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.Note, nil
+			},
+		},
+		"tags": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.Tags, nil
+			},
+		},
+		"splitParts": &graphql.Field{
+			Type:        graphql.NewList(transactionSplitPartType),
+			Description: "Empty when the transaction is not split",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.SplitParts, nil
+			},
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Transaction.TransactionDate.Format(dateLayout), nil
+			},
+		},
+		"highlights": &graphql.Field{
+			Type:        graphql.NewList(searchHighlightType),
+			Description: "Fields with the words that matched the text of the search",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Hit).Highlights, nil
+			},
+		},
+	},
+})
+
+var searchResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchResult",
+	Fields: graphql.Fields{
+		"total": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "Number of transactions that match, on all pages",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Result).Total, nil
+			},
+		},
+		"hits": &graphql.Field{
+			Type:        graphql.NewList(searchHitType),
+			Description: "The most recent transactions first",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(search.Result).Hits, nil
+			},
+		},
+	},
+})
+
+func searchFields(index search.Index) graphql.Fields {
+	return graphql.Fields{
+		"searchTransactions": &graphql.Field{
+			Type:        searchResultType,
+			Description: "Transactions of a user with words that start with each of the words of the text, like plumb for plumber",
+			Args: withArguments(periodArguments, graphql.FieldConfigArgument{
+				"text":      &graphql.ArgumentConfig{Type: graphql.String, Description: "Searched in the description, counterparty, IBAN, category, note and tags"},
+				"minAmount": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Inclusive minimum of the absolute amount in minor units"},
+				"maxAmount": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Inclusive maximum of the absolute amount in minor units"},
+				"accountId": &graphql.ArgumentConfig{Type: graphql.ID},
+				"category":  &graphql.ArgumentConfig{Type: graphql.String},
+				"offset":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				"limit":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: search.DefaultLimit},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				period, err := periodArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				query := search.Query{
+					UserID: userID,
+					Period: period,
+					Offset: p.Args["offset"].(int),
+					Limit:  p.Args["limit"].(int),
+				}
+				query.Text, _ = stringArgument(p.Args, "text")
+				if category, ok := stringArgument(p.Args, "category"); ok {
+					query.Category = primitives.Category(category)
+				}
+				if value, ok := p.Args["minAmount"].(int); ok {
+					minAmount := int64(value)
+					query.MinAmount = &minAmount
+				}
+				if value, ok := p.Args["maxAmount"].(int); ok {
+					maxAmount := int64(value)
+					query.MaxAmount = &maxAmount
+				}
+				if _, ok := stringArgument(p.Args, "accountId"); ok {
+					id, err := accountIDArgument(p.Args)
+					if err != nil {
+						return nil, invalidArgument("accountId", err)
+					}
+					accountID := primitives.MonetaryAccountID(id)
+					query.MonetaryAccountID = &accountID
+				}
+
+				return index.Search(query), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,371 @@
+package graphqladapter
+
+import (
+	"app/households"
+	"app/primitives"
+	"app/reporting"
+	sharedexpenses "app/shared-expenses"
+	"fmt"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Transactions looks up the transactions of monetary accounts
+type Transactions interface {
+	TransactionOf(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) (reporting.ReportedTransaction, bool)
+	TransactionsIn(monetaryAccountID primitives.MonetaryAccountID) []reporting.ReportedTransaction
+}
+
+var sharedBalanceType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "SharedBalance",
+	Description: "What a member owes another member of a household in one currency",
+	Fields: graphql.Fields{
+		"debtor": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Balance).Debtor.String(), nil
+			},
+		},
+		"creditor": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Balance).Creditor.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Balance).Amount, nil
+			},
+		},
+	},
+})
+
+var settleUpTransferType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SettleUpTransfer",
+	Fields: graphql.Fields{
+		"from": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Transfer).From.String(), nil
+			},
+		},
+		"to": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Transfer).To.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Transfer).Amount, nil
+			},
+		},
+	},
+})
+
+var expenseShareType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ExpenseShare",
+	Fields: graphql.Fields{
+		"userId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Share).UserID.String(), nil
+			},
+		},
+		"weight": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Share).Weight, nil
+			},
+		},
+	},
+})
+
+var expenseDebtType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ExpenseDebt",
+	Fields: graphql.Fields{
+		"userId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Debt).UserID.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.Debt).Amount, nil
+			},
+		},
+	},
+})
+
+var sharedExpenseType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SharedExpense",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).ExpenseID.String(), nil
+			},
+		},
+		"accountId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).MonetaryAccountID.String(), nil
+			},
+		},
+		"transactionId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).TransactionID.String(), nil
+			},
+		},
+		"paidBy": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).PaidBy.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).Amount, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).Description, nil
+			},
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).Date.Format(dateLayout), nil
+			},
+		},
+		"shares": &graphql.Field{
+			Type: graphql.NewList(expenseShareType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).Shares, nil
+			},
+		},
+		"debts": &graphql.Field{
+			Type:        graphql.NewList(expenseDebtType),
+			Description: "What the other members owe the member that paid",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(sharedexpenses.ExpenseShared).Debts, nil
+			},
+		},
+	},
+})
+
+func sharedLedgerType(ledgers sharedexpenses.Ledgers) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "SharedLedger",
+		Fields: graphql.Fields{
+			"balances": &graphql.Field{
+				Type:        graphql.NewList(sharedBalanceType),
+				Description: "What the members owe each other after all expenses and settlements",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return ledgers.BalancesOf(p.Source.(primitives.HouseholdID)), nil
+				},
+			},
+			"settleUp": &graphql.Field{
+				Type:        graphql.NewList(settleUpTransferType),
+				Description: "Transfers that settle all balances, per currency",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return ledgers.SettleUp(p.Source.(primitives.HouseholdID)), nil
+				},
+			},
+			"expenses": &graphql.Field{
+				Type:        graphql.NewList(sharedExpenseType),
+				Description: "The most recent first",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return ledgers.ExpensesOf(p.Source.(primitives.HouseholdID)), nil
+				},
+			},
+		},
+	})
+}
+
+func sharedExpenseFields(ledgers sharedexpenses.Ledgers, memberships households.Memberships) graphql.Fields {
+	return graphql.Fields{
+		"sharedExpenses": &graphql.Field{
+			Type:        sharedLedgerType(ledgers),
+			Description: "The shared expenses of a household and what its members owe each other",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, _, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				return householdID, nil
+			},
+		},
+	}
+}
+
+var expenseShareInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "ExpenseShareInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"userId": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"weight": &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "1 when not given"},
+	},
+})
+
+// sharesArgument are the shares of members of the household
+func sharesArgument(args map[string]interface{}, name string, householdID primitives.HouseholdID, memberships households.Memberships) ([]sharedexpenses.Share, error) {
+	values, _ := args[name].([]interface{})
+	res := make([]sharedexpenses.Share, 0, len(values))
+	for _, value := range values {
+		fields, _ := value.(map[string]interface{})
+		id, err := uuidArgument(fields, "userId")
+		if err != nil {
+			return nil, invalidArgument(name, err)
+		}
+		if _, isMember := memberships.RoleOf(householdID, primitives.UserID(id)); !isMember {
+			return nil, invalidArgument(name, fmt.Errorf("%s is not a member of the household", id))
+		}
+		weight, ok := fields["weight"].(int)
+		if !ok {
+			weight = 1
+		}
+		res = append(res, sharedexpenses.Share{UserID: primitives.UserID(id), Weight: weight})
+	}
+	return res, nil
+}
+
+// expenseAmountOf is the amount of the transaction, or of the part of the split transaction counted from 1
+func expenseAmountOf(transaction reporting.ReportedTransaction, part int) (money.Money, error) {
+	if part == 0 {
+		return *transaction.Amount.Absolute(), nil
+	}
+	if part < 0 || part > len(transaction.SplitParts) {
+		return money.Money{}, invalidArgument("part", fmt.Errorf("transaction has %d parts", len(transaction.SplitParts)))
+	}
+	return transaction.SplitParts[part-1].Amount, nil
+}
+
+func sharedExpenseMutationFields(commands eh.CommandHandler, owners AccountOwners, transactions Transactions, memberships households.Memberships) graphql.Fields {
+	return graphql.Fields{
+		"shareExpense": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Shares a transaction paid by the authenticated user with members of a household, resolves to the id of the expense",
+			Args: withArguments(transactionArguments, graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"part":        &graphql.ArgumentConfig{Type: graphql.Int, Description: "Part of a split transaction, counted from 1, without it the whole transaction is shared"},
+				"shares":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(expenseShareInputType)))},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, userID, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				accountID, transactionID, err := authorizedTransactionArguments(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				shares, err := sharesArgument(p.Args, "shares", householdID, memberships)
+				if err != nil {
+					return nil, err
+				}
+
+				transaction, ok := transactions.TransactionOf(accountID, transactionID)
+				if !ok {
+					return nil, invalidArgument("transactionId", fmt.Errorf("unknown transaction %s", transactionID))
+				}
+				part, _ := p.Args["part"].(int)
+				amount, err := expenseAmountOf(transaction, part)
+				if err != nil {
+					return nil, err
+				}
+
+				expenseID := sharedexpenses.NewExpenseID(accountID, transactionID, part)
+				if _, err := dispatch(p.Context, commands, sharedexpenses.ShareExpenseCommand{
+					HouseholdID:       householdID,
+					ExpenseID:         expenseID,
+					MonetaryAccountID: accountID,
+					TransactionID:     transactionID,
+					PaidBy:            userID,
+					Amount:            primitives.NewMoneyForCommand(amount),
+					Description:       transaction.Description,
+					Date:              transaction.TransactionDate,
+					Shares:            shares,
+				}); err != nil {
+					return nil, err
+				}
+				return expenseID.String(), nil
+			},
+		},
+		"unshareExpense": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Removes an expense from the shared expenses of a household",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"id":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, _, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				id, err := uuidArgument(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, sharedexpenses.UnshareExpenseCommand{
+					HouseholdID: householdID,
+					ExpenseID:   primitives.ExpenseID(id),
+				})
+			},
+		},
+		"recordSettlement": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Records that the authenticated user paid another member outside of the synced accounts, like in cash. Resolves to the id of the settlement",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"to":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"amount":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int), Description: "Amount in minor units of the currency"},
+				"currency":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, userID, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				to, err := uuidArgument(p.Args, "to")
+				if err != nil {
+					return nil, err
+				}
+				if _, isMember := memberships.RoleOf(householdID, primitives.UserID(to)); !isMember {
+					return nil, invalidArgument("to", fmt.Errorf("%s is not a member of the household", to))
+				}
+				amount, _ := p.Args["amount"].(int)
+				currency, _ := stringArgument(p.Args, "currency")
+
+				settlementID := primitives.SettlementID(uuid.New())
+				if _, err := dispatch(p.Context, commands, sharedexpenses.RecordSettlementCommand{
+					HouseholdID:  householdID,
+					SettlementID: settlementID,
+					From:         userID,
+					To:           primitives.UserID(to),
+					Amount:       primitives.MoneyForCommand{Amount: int64(amount), CurrencyCode: currency},
+					Date:         time.Now(),
+				}); err != nil {
+					return nil, err
+				}
+				return settlementID.String(), nil
+			},
+		},
+	}
+}
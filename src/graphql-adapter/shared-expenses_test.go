@@ -0,0 +1,106 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	"app/households"
+	"app/primitives"
+	"app/reporting"
+	sharedexpenses "app/shared-expenses"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type fixedTransactions []reporting.ReportedTransaction
+
+func (transactions fixedTransactions) TransactionOf(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) (reporting.ReportedTransaction, bool) {
+	for _, transaction := range transactions {
+		if transaction.MonetaryAccountID == monetaryAccountID && transaction.ID == transactionID {
+			return transaction, true
+		}
+	}
+	return reporting.ReportedTransaction{}, false
+}
+
+func (transactions fixedTransactions) TransactionsIn(monetaryAccountID primitives.MonetaryAccountID) []reporting.ReportedTransaction {
+	var res []reporting.ReportedTransaction
+	for _, transaction := range transactions {
+		if transaction.MonetaryAccountID == monetaryAccountID {
+			res = append(res, transaction)
+		}
+	}
+	return res
+}
+
+func Test_Mutation_ShareExpense_SharesPartOfTransactionWithMembers(t *testing.T) {
+	ctx := context.Background()
+	householdID := primitives.HouseholdID(uuid.New())
+	member := primitives.UserID(uuid.New())
+	stranger := primitives.UserID(uuid.New())
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	transactionID := primitives.TransactionID(uuid.New())
+
+	memberships := households.NewMemberships()
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhHouseholdCreated, &households.HouseholdCreated{ID: householdID, Name: "Home", OwnerUserID: testUserID}, time.Now()))
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhInvitationAccepted, &households.InvitationAccepted{ID: householdID, UserID: member, Role: households.Viewer}, time.Now()))
+	transactions := fixedTransactions{{
+		ID:                transactionID,
+		MonetaryAccountID: accountID,
+		Amount:            *money.New(-10000, "EUR"),
+		Description:       "Bistro",
+		SplitParts: []accountinformation.TransactionSplitPart{
+			{Category: "Restaurants", Amount: *money.New(6000, "EUR")},
+			{Category: "Gifts", Amount: *money.New(4000, "EUR")},
+		},
+		TransactionDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}}
+
+	commands := &recordingCommandHandler{}
+	schema, err := NewSchema(Repositories{
+		Commands:       commands,
+		Owners:         fixedOwners{accountID: testUserID},
+		Households:     memberships,
+		SharedExpenses: sharedexpenses.NewLedgers(),
+		Transactions:   transactions,
+	})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	share := func(part string, shareWith primitives.UserID) *graphql.Result {
+		return graphql.Do(graphql.Params{
+			Schema: schema,
+			RequestString: `mutation { shareExpense(householdId: "` + householdID.String() + `", accountId: "` + accountID.String() + `", transactionId: "` + transactionID.String() + `", part: ` + part + `, shares: [` +
+				`{userId: "` + testUserID.String() + `"}, {userId: "` + shareWith.String() + `", weight: 2}]) }`,
+			Context: auth.WithUserID(ctx, testUserID),
+		})
+	}
+
+	if result := share("1", stranger); len(result.Errors) != 1 || result.Errors[0].Extensions["argument"] != "shares" {
+		t.Errorf("Expected sharing with someone outside the household to be invalid, got %v", result.Errors)
+	}
+	if result := share("3", member); len(result.Errors) != 1 || result.Errors[0].Extensions["argument"] != "part" {
+		t.Errorf("Expected an unknown part to be invalid, got %v", result.Errors)
+	}
+	if len(commands.commands) != 0 {
+		t.Fatalf("Expected invalid arguments not to be dispatched, got %v", commands.commands)
+	}
+
+	result := share("2", member)
+	if result.HasErrors() {
+		t.Fatalf("Expected the expense to be shared, got %v", result.Errors)
+	}
+	expenseID := sharedexpenses.NewExpenseID(accountID, transactionID, 2)
+	if id := result.Data.(map[string]interface{})["shareExpense"]; id != expenseID.String() {
+		t.Errorf("Expected the id of the expense, got %v", id)
+	}
+	cmd, ok := commands.commands[0].(sharedexpenses.ShareExpenseCommand)
+	if !ok || cmd.ExpenseID != expenseID || cmd.PaidBy != testUserID || cmd.Amount.Amount != 4000 || cmd.Description != "Bistro" || len(cmd.Shares) != 2 || cmd.Shares[1].Weight != 2 {
+		t.Errorf("Expected the second part to be shared, got %+v", commands.commands[0])
+	}
+}
@@ -0,0 +1,233 @@
+package graphqladapter
+
+import (
+	"app/primitives"
+	"app/reporting"
+	spendingmap "app/spending-map"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+var locatedTransactionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LocatedTransaction",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Transaction.ID.String(), nil
+			},
+		},
+		"accountId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Transaction.MonetaryAccountID.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type:        moneyType,
+			Description: "Negative when the money left the account",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Transaction.SignedAmount(), nil
+			},
+		},
+		"counterparty": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				counterparty := p.Source.(spendingmap.LocatedTransaction).Transaction.Counterparty
+				if counterparty.HasName {
+					return counterparty.Name, nil
+				}
+				return nil, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Transaction.Description, nil
+			},
+		},
+		"category": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(spendingmap.LocatedTransaction).Transaction.Category), nil
+			},
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Transaction.TransactionDate.Format(dateLayout), nil
+			},
+		},
+		"latitude": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Location.Latitude, nil
+			},
+		},
+		"longitude": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Location.Longitude, nil
+			},
+		},
+		"accuracy": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "Radius in meters around the location the transaction was made in",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.LocatedTransaction).Accuracy, nil
+			},
+		},
+	},
+})
+
+var boundingBoxType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BoundingBox",
+	Fields: graphql.Fields{
+		"south": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.BoundingBox).South, nil
+			},
+		},
+		"west": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.BoundingBox).West, nil
+			},
+		},
+		"north": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.BoundingBox).North, nil
+			},
+		},
+		"east": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.BoundingBox).East, nil
+			},
+		},
+	},
+})
+
+var areaSpendingType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AreaSpending",
+	Fields: graphql.Fields{
+		"area": &graphql.Field{
+			Type: boundingBoxType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.AreaSpending).Area, nil
+			},
+		},
+		"expenses": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.AreaSpending).Expenses, nil
+			},
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(spendingmap.AreaSpending).Count, nil
+			},
+		},
+	},
+})
+
+var boundingBoxArguments = graphql.FieldConfigArgument{
+	"south": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+	"west":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+	"north": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+	"east":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+}
+
+func boundingBoxArgument(args map[string]interface{}) (spendingmap.BoundingBox, error) {
+	box := spendingmap.BoundingBox{
+		South: args["south"].(float64),
+		West:  args["west"].(float64),
+		North: args["north"].(float64),
+		East:  args["east"].(float64),
+	}
+	if !box.Valid() {
+		return box, invalidArgument("south", fmt.Errorf("invalid bounding box, south should not be above north and west not east of east"))
+	}
+	return box, nil
+}
+
+// mapArguments are the user and period of the transactions on a map
+func mapArguments(p graphql.ResolveParams) (primitives.UserID, reporting.Period, error) {
+	userID, err := authorizedUserIDArgument(p)
+	if err != nil {
+		return primitives.UserID{}, reporting.Period{}, err
+	}
+	period, err := periodArgument(p.Args)
+	return userID, period, err
+}
+
+func spendingMapFields(m spendingmap.Map) graphql.Fields {
+	return graphql.Fields{
+		"transactionsInArea": &graphql.Field{
+			Type:        graphql.NewList(locatedTransactionType),
+			Description: "Card payments of a user that were made in a bounding box, the most recent first",
+			Args:        withArguments(periodArguments, boundingBoxArguments),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, period, err := mapArguments(p)
+				if err != nil {
+					return nil, err
+				}
+				box, err := boundingBoxArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				return m.Within(userID, box, period), nil
+			},
+		},
+		"transactionsNear": &graphql.Field{
+			Type:        graphql.NewList(locatedTransactionType),
+			Description: "Card payments of a user that were made within a radius around a point, the most recent first",
+			Args: withArguments(periodArguments, graphql.FieldConfigArgument{
+				"latitude":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+				"longitude": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+				"radius":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float), Description: "Radius in meters"},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, period, err := mapArguments(p)
+				if err != nil {
+					return nil, err
+				}
+				radius := p.Args["radius"].(float64)
+				if radius <= 0 {
+					return nil, invalidArgument("radius", fmt.Errorf("radius should be positive"))
+				}
+
+				center := spendingmap.Point{Latitude: p.Args["latitude"].(float64), Longitude: p.Args["longitude"].(float64)}
+				return m.Near(userID, center, radius, period), nil
+			},
+		},
+		"spendingPerArea": &graphql.Field{
+			Type:        graphql.NewList(areaSpendingType),
+			Description: "Expenses of a user in a bounding box per square area and currency, the areas the user spent the most in first",
+			Args: withArguments(withArguments(periodArguments, boundingBoxArguments), graphql.FieldConfigArgument{
+				"areaSize": &graphql.ArgumentConfig{Type: graphql.Float, DefaultValue: 0.01, Description: "Size of the areas in degrees, 0.01 is about a kilometer"},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, period, err := mapArguments(p)
+				if err != nil {
+					return nil, err
+				}
+				box, err := boundingBoxArgument(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				areaSize := p.Args["areaSize"].(float64)
+				if areaSize <= 0 {
+					return nil, invalidArgument("areaSize", fmt.Errorf("areaSize should be positive"))
+				}
+
+				return m.SpendingPerArea(userID, box, areaSize, period), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"fmt"
+
+	"github.com/Rhymond/go-money"
+	"github.com/graphql-go/graphql"
+)
+
+var transactionSplitPartType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TransactionSplitPart",
+	Description: "Part of a split transaction that belongs to a category or a person, without a category the category of the transaction applies",
+	Fields: graphql.Fields{
+		"category": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(accountinformation.TransactionSplitPart).Category), nil
+			},
+		},
+		"person": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.TransactionSplitPart).Person, nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.TransactionSplitPart).Amount, nil
+			},
+		},
+	},
+})
+
+var transactionSplitPartInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TransactionSplitPartInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"category": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"person":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"amount":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int), Description: "Positive amount in minor units of the currency"},
+		"currency": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+// stringsArgument is a list of strings argument, empty when it is not given
+func stringsArgument(args map[string]interface{}, name string) []string {
+	values, _ := args[name].([]interface{})
+	res := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func splitPartsArgument(args map[string]interface{}, name string) ([]accountinformation.TransactionSplitPartForCommand, error) {
+	values, _ := args[name].([]interface{})
+	res := make([]accountinformation.TransactionSplitPartForCommand, 0, len(values))
+	for _, value := range values {
+		fields, _ := value.(map[string]interface{})
+		category, _ := fields["category"].(string)
+		person, _ := fields["person"].(string)
+		amount, _ := fields["amount"].(int)
+		currency, _ := fields["currency"].(string)
+		if money.GetCurrency(currency) == nil {
+			return nil, invalidArgument(name, fmt.Errorf("unknown currency %s", currency))
+		}
+
+		res = append(res, accountinformation.TransactionSplitPartForCommand{
+			Category: primitives.Category(category),
+			Person:   person,
+			Amount:   primitives.MoneyForCommand{Amount: int64(amount), CurrencyCode: currency},
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,168 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	syncstatus "app/sync-status"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+// Subscriptions are executed once for every event of the Feed, with the event as root object.
+// A field resolves to nil for events that it is not about, those results are not sent to the subscriber
+
+func eventOf(p graphql.ResolveParams) interface{} {
+	root, _ := p.Source.(map[string]interface{})
+	return root["event"]
+}
+
+func accountIDArgument(args map[string]interface{}) (uuid.UUID, error) {
+	value, _ := args["accountId"].(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("invalid accountId %s", value)
+	}
+	return id, nil
+}
+
+var addedTransactionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AddedTransaction",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.NewTransactionFound).ID.String(), nil
+			},
+		},
+		"accountId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.NewTransactionFound).MonetaryAccountID.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type:        moneyType,
+			Description: "Negative when the money left the account",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				transaction := p.Source.(accountinformation.NewTransactionFound)
+				if transaction.MonetaryAccountID == transaction.FromMonetaryAccountID {
+					return *transaction.Amount.Absolute().Negative(), nil
+				}
+				return *transaction.Amount.Absolute(), nil
+			},
+		},
+		"counterparty": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				counterparty := p.Source.(accountinformation.NewTransactionFound).Counterparty()
+				if counterparty.HasName {
+					return counterparty.Name, nil
+				}
+				if counterparty.HasIBAN {
+					return counterparty.IBAN.PrintCode, nil
+				}
+				return nil, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.NewTransactionFound).Description, nil
+			},
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.NewTransactionFound).TransactionDate.Format(dateLayout), nil
+			},
+		},
+	},
+})
+
+var changedBalanceType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ChangedBalance",
+	Fields: graphql.Fields{
+		"accountId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.MonetaryAccountBalanceSnapshotted).ID.String(), nil
+			},
+		},
+		"balance": &graphql.Field{
+			Type: moneyType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.MonetaryAccountBalanceSnapshotted).Balance, nil
+			},
+		},
+		"timestamp": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(accountinformation.MonetaryAccountBalanceSnapshotted).Timestamp.Format(time.RFC3339), nil
+			},
+		},
+	},
+})
+
+func subscriptionFields(owners AccountOwners) graphql.Fields {
+	return graphql.Fields{
+		"transactionAdded": &graphql.Field{
+			Type:        addedTransactionType,
+			Description: "Transactions of a monetary account as they are found",
+			Args: graphql.FieldConfigArgument{
+				"accountId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, err := authorizedAccountIDArgument(p, owners)
+				if err != nil {
+					return nil, err
+				}
+
+				transaction, ok := eventOf(p).(accountinformation.NewTransactionFound)
+				if !ok || transaction.MonetaryAccountID != accountID {
+					return nil, nil
+				}
+				return transaction, nil
+			},
+		},
+		"balanceChanged": &graphql.Field{
+			Type:        changedBalanceType,
+			Description: "Balances of a monetary account as they are fetched",
+			Args: graphql.FieldConfigArgument{
+				"accountId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, err := authorizedAccountIDArgument(p, owners)
+				if err != nil {
+					return nil, err
+				}
+
+				balance, ok := eventOf(p).(accountinformation.MonetaryAccountBalanceSnapshotted)
+				if !ok || balance.ID != accountID {
+					return nil, nil
+				}
+				return balance, nil
+			},
+		},
+		"syncStatus": &graphql.Field{
+			Type:        syncType,
+			Description: "Syncs of the accounts of a user as they start and finish",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				sync, ok := eventOf(p).(syncstatus.Sync)
+				if !ok || sync.UserID != userID {
+					return nil, nil
+				}
+				return sync, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package graphqladapter
+
+import (
+	syncstatus "app/sync-status"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+var syncType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Sync",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).SyncID.String(), nil
+			},
+		},
+		"account": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The id of the account at its institution",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).InstitutionEntityID, nil
+			},
+		},
+		"started": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).Started.Format(time.RFC3339), nil
+			},
+		},
+		"finished": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Empty while the sync is running",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				sync := p.Source.(syncstatus.Sync)
+				if sync.Running() {
+					return nil, nil
+				}
+				return sync.Finished.Format(time.RFC3339), nil
+			},
+		},
+		"running": &graphql.Field{
+			Type: graphql.Boolean,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).Running(), nil
+			},
+		},
+		"durationSeconds": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "How long the sync took, or has been running",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).Duration(time.Now()).Seconds(), nil
+			},
+		},
+		"documents": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).Documents, nil
+			},
+		},
+		"errors": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.Sync).Errors, nil
+			},
+		},
+	},
+})
+
+var accountSyncsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AccountSyncs",
+	Fields: graphql.Fields{
+		"account": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.AccountSyncs).InstitutionEntityID, nil
+			},
+		},
+		"syncs": &graphql.Field{
+			Type:        graphql.NewList(syncType),
+			Description: "The most recent sync first",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(syncstatus.AccountSyncs).Syncs, nil
+			},
+		},
+	},
+})
+
+func syncStatusFields(tracker syncstatus.Tracker) graphql.Fields {
+	return graphql.Fields{
+		"syncs": &graphql.Field{
+			Type:        graphql.NewList(accountSyncsType),
+			Description: "Sync history of the accounts of a user",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+				return tracker.SyncsOf(userID), nil
+			},
+		},
+		"stuckSyncs": &graphql.Field{
+			Type:        graphql.NewList(syncType),
+			Description: "Syncs of all users that have been running for too long",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return tracker.Stuck(), nil
+			},
+		},
+	}
+}
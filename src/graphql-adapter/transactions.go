@@ -0,0 +1,96 @@
+package graphqladapter
+
+import (
+	"app/reporting"
+
+	"github.com/graphql-go/graphql"
+)
+
+var accountTransactionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AccountTransaction",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).ID.String(), nil
+			},
+		},
+		"amount": &graphql.Field{
+			Type:        moneyType,
+			Description: "Negative when the money left the account",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).SignedAmount(), nil
+			},
+		},
+		"counterparty": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				counterparty := p.Source.(reporting.ReportedTransaction).Counterparty
+				if counterparty.HasName {
+					return counterparty.Name, nil
+				}
+				if counterparty.HasIBAN {
+					return counterparty.IBAN.PrintCode, nil
+				}
+				return nil, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).Description, nil
+			},
+		},
+		"category": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(reporting.ReportedTransaction).Category), nil
+			},
+		},
+		"note": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).Note, nil
+			},
+		},
+		"tags": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).Tags, nil
+			},
+		},
+		"splitParts": &graphql.Field{
+			Type:        graphql.NewList(transactionSplitPartType),
+			Description: "Empty when the transaction is not split",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).SplitParts, nil
+			},
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(reporting.ReportedTransaction).TransactionDate.Format(dateLayout), nil
+			},
+		},
+	},
+})
+
+// transactionFields are the transactions of a monetary account, for its owners and the members of the households it is shared with
+func transactionFields(transactions Transactions, readers AccountOwners) graphql.Fields {
+	return graphql.Fields{
+		"accountTransactions": &graphql.Field{
+			Type:        graphql.NewList(accountTransactionType),
+			Description: "Transactions of a monetary account, the newest first",
+			Args: graphql.FieldConfigArgument{
+				"accountId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				accountID, err := authorizedAccountIDArgument(p, readers)
+				if err != nil {
+					return nil, err
+				}
+				return transactions.TransactionsIn(accountID), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,445 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/households"
+	"app/primitives"
+	"app/users"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(users.User).ID.String(), nil
+			},
+		},
+		"name": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(users.User).Profile.Name, nil
+			},
+		},
+		"email": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(users.User).Profile.Email, nil
+			},
+		},
+		"status": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Active or Deactivated, the accounts of deactivated users are not refreshed",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(users.User).Status), nil
+			},
+		},
+		"signedUp": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(users.User).SignedUp.Format(time.RFC3339), nil
+			},
+		},
+	},
+})
+
+// householdMember is a member of a household with the role the member has
+type householdMember struct {
+	UserID primitives.UserID
+	Role   households.Role
+}
+
+var householdMemberType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "HouseholdMember",
+	Fields: graphql.Fields{
+		"userId": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(householdMember).UserID.String(), nil
+			},
+		},
+		"role": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Owner or Viewer",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(householdMember).Role), nil
+			},
+		},
+	},
+})
+
+var invitationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Invitation",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(households.Invitation).ID.String(), nil
+			},
+		},
+		"email": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(households.Invitation).Email, nil
+			},
+		},
+		"role": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(households.Invitation).Role), nil
+			},
+		},
+		"invitedBy": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(households.Invitation).InvitedBy.String(), nil
+			},
+		},
+	},
+})
+
+var householdType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Household",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.ID,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(households.Household).ID.String(), nil
+			},
+		},
+		"name": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(households.Household).Name, nil
+			},
+		},
+		"members": &graphql.Field{
+			Type:        graphql.NewList(householdMemberType),
+			Description: "Owners first",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var members []householdMember
+				for userID, role := range p.Source.(households.Household).Members {
+					members = append(members, householdMember{UserID: userID, Role: role})
+				}
+				sort.Slice(members, func(i, j int) bool {
+					if members[i].Role != members[j].Role {
+						return members[i].Role == households.Owner
+					}
+					return members[i].UserID.String() < members[j].UserID.String()
+				})
+				return members, nil
+			},
+		},
+		"invitations": &graphql.Field{
+			Type:        graphql.NewList(invitationType),
+			Description: "Invitations that are not accepted yet",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var invitations []households.Invitation
+				for _, invitation := range p.Source.(households.Household).Invitations {
+					invitations = append(invitations, invitation)
+				}
+				sort.Slice(invitations, func(i, j int) bool {
+					return invitations[i].Email < invitations[j].Email
+				})
+				return invitations, nil
+			},
+		},
+		"accountIds": &graphql.Field{
+			Type:        graphql.NewList(graphql.ID),
+			Description: "Joint accounts that are shared with the household",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var ids []string
+				for _, accountID := range p.Source.(households.Household).Accounts {
+					ids = append(ids, accountID.String())
+				}
+				return ids, nil
+			},
+		},
+	},
+})
+
+func userFields(directory users.Directory, memberships households.Memberships) graphql.Fields {
+	return graphql.Fields{
+		"user": &graphql.Field{
+			Type:        userType,
+			Description: "Profile of a user, empty until the user signed up",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+				user, ok := directory.UserOf(userID)
+				if !ok {
+					return nil, nil
+				}
+				return user, nil
+			},
+		},
+		"households": &graphql.Field{
+			Type:        graphql.NewList(householdType),
+			Description: "Households a user is a member of",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+				return memberships.HouseholdsOf(userID), nil
+			},
+		},
+	}
+}
+
+// authorizedHouseholdArgument is the householdId argument, only when the authenticated user of the request is a member
+// of the household. The aggregate decides what the role of the member allows
+func authorizedHouseholdArgument(p graphql.ResolveParams, memberships households.Memberships) (primitives.HouseholdID, primitives.UserID, error) {
+	userID, err := authenticatedUser(p)
+	if err != nil {
+		return primitives.HouseholdID{}, primitives.UserID{}, err
+	}
+	id, err := uuidArgument(p.Args, "householdId")
+	if err != nil {
+		return primitives.HouseholdID{}, primitives.UserID{}, err
+	}
+
+	householdID := primitives.HouseholdID(id)
+	if _, isMember := memberships.RoleOf(householdID, userID); !isMember {
+		return primitives.HouseholdID{}, primitives.UserID{}, authorizationError(auth.ErrForbidden)
+	}
+	return householdID, userID, nil
+}
+
+var profileArguments = graphql.FieldConfigArgument{
+	"name":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	"email": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+}
+
+// JointAccounts tells whether a monetary account is a joint account
+type JointAccounts interface {
+	IsJoint(monetaryAccountID primitives.MonetaryAccountID) bool
+}
+
+func userMutationFields(commands eh.CommandHandler, owners AccountOwners, joint JointAccounts, directory users.Directory, memberships households.Memberships) graphql.Fields {
+	return graphql.Fields{
+		"signUp": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Signs up the authenticated user, the accounts of users are only refreshed once they signed up",
+			Args:        profileArguments,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				name, _ := stringArgument(p.Args, "name")
+				email, _ := stringArgument(p.Args, "email")
+
+				return dispatch(p.Context, commands, users.SignUpCommand{
+					UserID:   userID,
+					Name:     name,
+					Email:    email,
+					SignedUp: time.Now(),
+				})
+			},
+		},
+		"updateProfile": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: withArguments(profileArguments, graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+				name, _ := stringArgument(p.Args, "name")
+				email, _ := stringArgument(p.Args, "email")
+
+				return dispatch(p.Context, commands, users.UpdateProfileCommand{UserID: userID, Name: name, Email: email})
+			},
+		},
+		"deactivateUser": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Deactivates a user, the accounts of the user are no longer refreshed",
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, users.DeactivateUserCommand{UserID: userID})
+			},
+		},
+		"reactivateUser": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authorizedUserIDArgument(p)
+				if err != nil {
+					return nil, err
+				}
+
+				return dispatch(p.Context, commands, users.ReactivateUserCommand{UserID: userID})
+			},
+		},
+		"createHousehold": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Creates a household with the authenticated user as owner, resolves to the id of the household",
+			Args: graphql.FieldConfigArgument{
+				"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				name, _ := stringArgument(p.Args, "name")
+
+				householdID := primitives.HouseholdID(uuid.New())
+				if _, err := dispatch(p.Context, commands, households.CreateHouseholdCommand{
+					HouseholdID: householdID,
+					Name:        name,
+					OwnerUserID: userID,
+				}); err != nil {
+					return nil, err
+				}
+				return householdID.String(), nil
+			},
+		},
+		"inviteToHousehold": &graphql.Field{
+			Type:        graphql.ID,
+			Description: "Invites someone by email to a household, resolves to the id of the invitation",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"email":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"role":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "Owner or Viewer"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, userID, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				email, _ := stringArgument(p.Args, "email")
+				value, _ := stringArgument(p.Args, "role")
+				role, err := households.ParseRole(value)
+				if err != nil {
+					return nil, invalidArgument("role", err)
+				}
+
+				invitationID := primitives.InvitationID(uuid.New())
+				if _, err := dispatch(p.Context, commands, households.InviteMemberCommand{
+					HouseholdID:  householdID,
+					InvitationID: invitationID,
+					InvitedBy:    userID,
+					Email:        email,
+					Role:         role,
+				}); err != nil {
+					return nil, err
+				}
+				return invitationID.String(), nil
+			},
+		},
+		"acceptInvitation": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Makes the authenticated user a member of the household, the invitation has to be for the email of the user",
+			Args: graphql.FieldConfigArgument{
+				"householdId":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"invitationId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := authenticatedUser(p)
+				if err != nil {
+					return nil, err
+				}
+				householdID, err := uuidArgument(p.Args, "householdId")
+				if err != nil {
+					return nil, err
+				}
+				invitationID, err := uuidArgument(p.Args, "invitationId")
+				if err != nil {
+					return nil, err
+				}
+
+				invitation, ok := memberships.InvitationOf(primitives.HouseholdID(householdID), primitives.InvitationID(invitationID))
+				user, signedUp := directory.UserOf(userID)
+				if !ok || !signedUp || users.NormalisedEmail(user.Profile.Email) != invitation.Email {
+					return nil, authorizationError(auth.ErrForbidden)
+				}
+
+				return dispatch(p.Context, commands, households.AcceptInvitationCommand{
+					HouseholdID:  invitation.HouseholdID,
+					InvitationID: invitation.ID,
+					UserID:       userID,
+				})
+			},
+		},
+		"removeHouseholdMember": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Removes a member from a household, members can remove themselves to leave",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"userId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, removedBy, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				userID, err := userIDArgument(p.Args)
+				if err != nil {
+					return nil, invalidArgument("userId", err)
+				}
+
+				return dispatch(p.Context, commands, households.RemoveMemberCommand{
+					HouseholdID: householdID,
+					RemovedBy:   removedBy,
+					UserID:      userID,
+				})
+			},
+		},
+		"shareAccountWithHousehold": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Shares a joint account the authenticated user owns with the members of a household",
+			Args: graphql.FieldConfigArgument{
+				"householdId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"accountId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				householdID, userID, err := authorizedHouseholdArgument(p, memberships)
+				if err != nil {
+					return nil, err
+				}
+				accountID, err := authorizedAccountIDArgument(p, owners)
+				if err != nil {
+					return nil, err
+				}
+				if !joint.IsJoint(accountID) {
+					return nil, invalidArgument("accountId", fmt.Errorf("only joint accounts can be shared with a household"))
+				}
+
+				return dispatch(p.Context, commands, households.ShareAccountCommand{
+					HouseholdID:       householdID,
+					SharedBy:          userID,
+					MonetaryAccountID: accountID,
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package graphqladapter
+
+import (
+	"app/auth"
+	"app/households"
+	"app/primitives"
+	"app/users"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	eh "github.com/looplab/eventhorizon"
+)
+
+func Test_Mutation_AcceptInvitation_OnlyForEmailOfInvitation(t *testing.T) {
+	ctx := context.Background()
+	householdID := primitives.HouseholdID(uuid.New())
+	invitationID := primitives.InvitationID(uuid.New())
+
+	directory := users.NewDirectory()
+	directory.HandleEvent(ctx, eh.NewEvent(users.EhUserSignedUp, &users.UserSignedUp{ID: testUserID, Name: "Sam", Email: "Sam@Example.com"}, time.Now()))
+	memberships := households.NewMemberships()
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhHouseholdCreated, &households.HouseholdCreated{ID: householdID, Name: "Home", OwnerUserID: primitives.UserID(uuid.New())}, time.Now()))
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhMemberInvited, &households.MemberInvited{ID: householdID, InvitationID: invitationID, Email: "alex@example.com", Role: households.Viewer}, time.Now()))
+
+	commands := &recordingCommandHandler{}
+	schema, err := NewSchema(Repositories{Commands: commands, Users: directory, Households: memberships})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	accept := func() *graphql.Result {
+		return graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: `mutation { acceptInvitation(householdId: "` + householdID.String() + `", invitationId: "` + invitationID.String() + `") }`,
+			Context:       auth.WithUserID(ctx, testUserID),
+		})
+	}
+
+	if result := accept(); len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Fatalf("Expected the invitation of someone else to be forbidden, got %v", result.Errors)
+	}
+
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhMemberInvited, &households.MemberInvited{ID: householdID, InvitationID: invitationID, Email: "sam@example.com", Role: households.Viewer}, time.Now()))
+	if result := accept(); result.HasErrors() {
+		t.Fatalf("Expected the invitation to be accepted, got %v", result.Errors)
+	}
+	cmd, ok := commands.commands[0].(households.AcceptInvitationCommand)
+	if !ok || cmd.HouseholdID != householdID || cmd.UserID != testUserID {
+		t.Errorf("Expected the user to accept the invitation, got %+v", commands.commands[0])
+	}
+}
+
+// fixedJoint are the joint accounts
+type fixedJoint map[primitives.MonetaryAccountID]bool
+
+func (joint fixedJoint) IsJoint(monetaryAccountID primitives.MonetaryAccountID) bool {
+	return joint[monetaryAccountID]
+}
+
+func Test_Mutation_ShareAccountWithHousehold_OnlyJointAccounts(t *testing.T) {
+	ctx := context.Background()
+	householdID := primitives.HouseholdID(uuid.New())
+	jointID := primitives.MonetaryAccountID(uuid.New())
+	singleID := primitives.MonetaryAccountID(uuid.New())
+
+	memberships := households.NewMemberships()
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhHouseholdCreated, &households.HouseholdCreated{ID: householdID, Name: "Home", OwnerUserID: testUserID}, time.Now()))
+
+	commands := &recordingCommandHandler{}
+	schema, err := NewSchema(Repositories{
+		Commands:   commands,
+		Owners:     fixedOwners{jointID: testUserID, singleID: testUserID},
+		Joint:      fixedJoint{jointID: true},
+		Households: memberships,
+	})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	share := func(accountID primitives.MonetaryAccountID) *graphql.Result {
+		return graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: `mutation { shareAccountWithHousehold(householdId: "` + householdID.String() + `", accountId: "` + accountID.String() + `") }`,
+			Context:       auth.WithUserID(ctx, testUserID),
+		})
+	}
+
+	if result := share(singleID); len(result.Errors) != 1 || result.Errors[0].Extensions["argument"] != "accountId" || len(commands.commands) != 0 {
+		t.Fatalf("Expected sharing an account that is not joint to be invalid, got %v", result.Errors)
+	}
+	if result := share(jointID); result.HasErrors() || len(commands.commands) != 1 {
+		t.Errorf("Expected the joint account to be shared, got %v", result.Errors)
+	}
+}
+
+func Test_SharedAccount_OnlyReadByViewers(t *testing.T) {
+	ctx := context.Background()
+	householdID := primitives.HouseholdID(uuid.New())
+	owner := primitives.UserID(uuid.New())
+	sharedID := primitives.MonetaryAccountID(uuid.New())
+	otherID := primitives.MonetaryAccountID(uuid.New())
+	transactionID := primitives.TransactionID(uuid.New())
+
+	memberships := households.NewMemberships()
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhHouseholdCreated, &households.HouseholdCreated{ID: householdID, Name: "Home", OwnerUserID: owner}, time.Now()))
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhInvitationAccepted, &households.InvitationAccepted{ID: householdID, UserID: testUserID, Role: households.Viewer}, time.Now()))
+	memberships.HandleEvent(ctx, eh.NewEvent(households.EhAccountShared, &households.AccountShared{ID: householdID, MonetaryAccountID: sharedID, SharedBy: owner}, time.Now()))
+
+	commands := &recordingCommandHandler{}
+	schema, err := NewSchema(Repositories{
+		Commands:     commands,
+		Owners:       fixedOwners{sharedID: owner, otherID: owner},
+		Households:   memberships,
+		Transactions: fixedTransactions{{ID: transactionID, MonetaryAccountID: sharedID, Amount: *money.New(-2500, "EUR"), Description: "Groceries"}},
+	})
+	if err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	execute := func(request string) *graphql.Result {
+		return graphql.Do(graphql.Params{Schema: schema, RequestString: request, Context: auth.WithUserID(ctx, testUserID)})
+	}
+
+	result := execute(`{ accountTransactions(accountId: "` + sharedID.String() + `") { description } }`)
+	if transactions, _ := result.Data.(map[string]interface{})["accountTransactions"].([]interface{}); result.HasErrors() || len(transactions) != 1 {
+		t.Errorf("Expected the viewer to read the shared account, got %v %v", result.Data, result.Errors)
+	}
+
+	result = execute(`{ accountTransactions(accountId: "` + otherID.String() + `") { description } }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Errorf("Expected an account that is not shared to be forbidden, got %v", result.Errors)
+	}
+
+	result = execute(`mutation { categoriseTransaction(accountId: "` + sharedID.String() + `", transactionId: "` + transactionID.String() + `", category: "Food") }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "FORBIDDEN" || len(commands.commands) != 0 {
+		t.Errorf("Expected a viewer not to change the shared account, got %v", result.Errors)
+	}
+}
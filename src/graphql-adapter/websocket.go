@@ -0,0 +1,271 @@
+package graphqladapter
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// The subscription transport speaks the graphql-ws protocol of subscriptions-transport-ws, that Apollo and GraphiQL use
+const (
+	subscriptionProtocol = "graphql-ws"
+
+	typeConnectionInit      = "connection_init"
+	typeConnectionAck       = "connection_ack"
+	typeConnectionError     = "connection_error"
+	typeConnectionKeepAlive = "ka"
+	typeConnectionTerminate = "connection_terminate"
+	typeStart               = "start"
+	typeStop                = "stop"
+	typeData                = "data"
+	typeError               = "error"
+	typeComplete            = "complete"
+)
+
+const keepAliveInterval = 20 * time.Second
+
+type operationMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type startPayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// newUpgrader accepts websockets of the allowed origins, of the origin of the server itself
+// and of clients that are not browsers, as those send no origin
+func newUpgrader(allowedOrigins []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		Subprotocols: []string{subscriptionProtocol},
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			for _, allowed := range allowedOrigins {
+				if strings.EqualFold(origin, allowed) {
+					return true
+				}
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		},
+	}
+}
+
+// subscriptionConnection is a websocket with the operations a client started on it
+type subscriptionConnection struct {
+	schema graphql.Schema
+	feed   Feed
+	socket *websocket.Conn
+	// writing serialises the messages, a websocket can only be written by one goroutine at a time
+	writing    sync.Mutex
+	mu         sync.Mutex
+	operations map[string]context.CancelFunc
+}
+
+func subscriptionHandler(schema graphql.Schema, feed Feed, allowedOrigins []string) http.HandlerFunc {
+	upgrader := newUpgrader(allowedOrigins)
+	return func(res http.ResponseWriter, req *http.Request) {
+		socket, err := upgrader.Upgrade(res, req, nil)
+		if err != nil {
+			log.Printf("Could not upgrade to websocket: %v", err)
+			return
+		}
+
+		connection := &subscriptionConnection{
+			schema:     schema,
+			feed:       feed,
+			socket:     socket,
+			operations: make(map[string]context.CancelFunc),
+		}
+		connection.serve(req.Context())
+	}
+}
+
+func (connection *subscriptionConnection) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		connection.socket.Close()
+	}()
+
+	go connection.keepAlive(ctx)
+
+	for {
+		var message operationMessage
+		if err := connection.socket.ReadJSON(&message); err != nil {
+			return
+		}
+
+		switch message.Type {
+		case typeConnectionInit:
+			connection.send(operationMessage{Type: typeConnectionAck})
+		case typeStart:
+			connection.start(ctx, message)
+		case typeStop:
+			connection.stop(message.ID)
+		case typeConnectionTerminate:
+			return
+		default:
+			connection.send(errorMessage(typeConnectionError, message.ID, "unknown message type "+message.Type))
+		}
+	}
+}
+
+func (connection *subscriptionConnection) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			connection.send(operationMessage{Type: typeConnectionKeepAlive})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// start executes queries and mutations once, subscriptions are executed for every event of the feed until they are stopped
+func (connection *subscriptionConnection) start(ctx context.Context, message operationMessage) {
+	var payload startPayload
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		connection.send(errorMessage(typeError, message.ID, "invalid payload: "+err.Error()))
+		return
+	}
+
+	params := graphql.Params{
+		Schema:         connection.schema,
+		RequestString:  payload.Query,
+		VariableValues: payload.Variables,
+		OperationName:  payload.OperationName,
+		Context:        ctx,
+	}
+
+	subscription, err := isSubscription(payload)
+	if err != nil {
+		connection.send(errorMessage(typeError, message.ID, err.Error()))
+		return
+	}
+	if !subscription {
+		connection.sendResult(message.ID, graphql.Do(params))
+		connection.send(operationMessage{ID: message.ID, Type: typeComplete})
+		return
+	}
+
+	// executing without an event validates the subscription and its arguments
+	if result := graphql.Do(params); result.HasErrors() {
+		connection.sendResult(message.ID, result)
+		connection.send(operationMessage{ID: message.ID, Type: typeComplete})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	connection.mu.Lock()
+	if stopPrevious, ok := connection.operations[message.ID]; ok {
+		stopPrevious()
+	}
+	connection.operations[message.ID] = cancel
+	connection.mu.Unlock()
+
+	go connection.subscribe(ctx, message.ID, params)
+}
+
+func (connection *subscriptionConnection) subscribe(ctx context.Context, id string, params graphql.Params) {
+	subscriptionID, events := connection.feed.subscribe()
+	defer connection.feed.unsubscribe(subscriptionID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			params.RootObject = map[string]interface{}{"event": event}
+			result := graphql.Do(params)
+			if !result.HasErrors() && !hasData(result) {
+				continue
+			}
+			connection.sendResult(id, result)
+		}
+	}
+}
+
+func (connection *subscriptionConnection) stop(id string) {
+	connection.mu.Lock()
+	cancel, ok := connection.operations[id]
+	delete(connection.operations, id)
+	connection.mu.Unlock()
+
+	if ok {
+		cancel()
+		connection.send(operationMessage{ID: id, Type: typeComplete})
+	}
+}
+
+func (connection *subscriptionConnection) sendResult(id string, result *graphql.Result) {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		connection.send(errorMessage(typeError, id, err.Error()))
+		return
+	}
+	connection.send(operationMessage{ID: id, Type: typeData, Payload: payload})
+}
+
+func (connection *subscriptionConnection) send(message operationMessage) {
+	connection.writing.Lock()
+	defer connection.writing.Unlock()
+
+	if err := connection.socket.WriteJSON(message); err != nil {
+		log.Printf("Could not write to websocket: %v", err)
+	}
+}
+
+func errorMessage(messageType string, id string, message string) operationMessage {
+	payload, _ := json.Marshal(map[string]string{"message": message})
+	return operationMessage{ID: id, Type: messageType, Payload: payload}
+}
+
+// isSubscription tells whether the operation that is started is a subscription
+func isSubscription(payload startPayload) (bool, error) {
+	document, err := parser.Parse(parser.ParseParams{Source: payload.Query})
+	if err != nil {
+		return false, err
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if payload.OperationName == "" || (operation.Name != nil && operation.Name.Value == payload.OperationName) {
+			return operation.Operation == ast.OperationTypeSubscription, nil
+		}
+	}
+	return false, nil
+}
+
+// hasData tells whether any of the subscribed fields is about the event
+func hasData(result *graphql.Result) bool {
+	fields, _ := result.Data.(map[string]interface{})
+	for _, value := range fields {
+		if value != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package graphqladapter
+
+import (
+	accountinformation "app/account-information"
+	"app/auth"
+	"app/bus"
+	"app/households"
+	"app/primitives"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// authenticatedAs authenticates every request as the user, like the middleware of auth does for a valid token
+func authenticatedAs(userID primitives.UserID) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(res, req.WithContext(auth.WithUserID(req.Context(), userID)))
+		})
+	}
+}
+
+// fixedOwners owns every account in it
+type fixedOwners map[primitives.MonetaryAccountID]primitives.UserID
+
+func (owners fixedOwners) IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool {
+	owner, ok := owners[monetaryAccountID]
+	return ok && owner == userID
+}
+
+var testUserID = primitives.UserID(uuid.New())
+
+// serveSubscriptions serves graphql to the test user, returning the url of subscriptions
+func serveSubscriptions(t *testing.T, feed Feed, owners fixedOwners, allowedOrigins []string) string {
+	r := mux.NewRouter()
+	r.Use(authenticatedAs(testUserID))
+	if err := RegisterGraphql(Repositories{Feed: feed, Owners: owners, Households: households.NewMemberships()}, allowedOrigins)(r); err != nil {
+		t.Fatalf("Could not register graphql: %v", err)
+	}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/graphql/"
+}
+
+func dialSubscriptions(t *testing.T, feed Feed, owners fixedOwners) *websocket.Conn {
+	dialer := websocket.Dialer{Subprotocols: []string{subscriptionProtocol}}
+	socket, _, err := dialer.Dial(serveSubscriptions(t, feed, owners, nil), nil)
+	if err != nil {
+		t.Fatalf("Could not connect: %v", err)
+	}
+	t.Cleanup(func() { socket.Close() })
+
+	socket.WriteJSON(operationMessage{Type: typeConnectionInit})
+	if ack := readMessage(t, socket); ack.Type != typeConnectionAck {
+		t.Fatalf("Expected the connection to be acknowledged, got %s", ack.Type)
+	}
+	return socket
+}
+
+func readMessage(t *testing.T, socket *websocket.Conn) operationMessage {
+	socket.SetReadDeadline(time.Now().Add(time.Second))
+	var message operationMessage
+	if err := socket.ReadJSON(&message); err != nil {
+		t.Fatalf("Could not read message: %v", err)
+	}
+	return message
+}
+
+func startOperation(socket *websocket.Conn, id string, query string) {
+	payload, _ := json.Marshal(startPayload{Query: query})
+	socket.WriteJSON(operationMessage{ID: id, Type: typeStart, Payload: payload})
+}
+
+func waitForSubscriptions(feed Feed, count int) {
+	for i := 0; i < 100; i++ {
+		feed.mu.RLock()
+		subscribed := len(feed.subscriptions)
+		feed.mu.RUnlock()
+		if subscribed == count {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_Subscription_TransactionAdded_SendsTransactionsOfAccount(t *testing.T) {
+	feed := NewFeed(bus.NewInMemoryBus())
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	socket := dialSubscriptions(t, feed, fixedOwners{accountID: testUserID})
+
+	startOperation(socket, "1", `subscription { transactionAdded(accountId: "`+accountID.String()+`") { description amount { amount } } }`)
+	waitForSubscriptions(feed, 1)
+
+	feed.publish(accountinformation.NewTransactionFound{
+		MonetaryAccountID: primitives.MonetaryAccountID(uuid.New()),
+		Amount:            *money.New(100, "EUR"),
+		Description:       "Other account",
+	})
+	feed.publish(accountinformation.NewTransactionFound{
+		MonetaryAccountID:     accountID,
+		FromMonetaryAccountID: accountID,
+		Amount:                *money.New(2500, "EUR"),
+		Description:           "Groceries",
+	})
+
+	message := readMessage(t, socket)
+	if message.Type != typeData || message.ID != "1" {
+		t.Fatalf("Expected data of the subscription, got %s", message.Type)
+	}
+	if payload := string(message.Payload); payload != `{"data":{"transactionAdded":{"amount":{"amount":-2500},"description":"Groceries"}}}` {
+		t.Errorf("Expected only the outgoing transaction of the account, got %s", payload)
+	}
+
+	socket.WriteJSON(operationMessage{ID: "1", Type: typeStop})
+	if message := readMessage(t, socket); message.Type != typeComplete {
+		t.Errorf("Expected the subscription to complete, got %s", message.Type)
+	}
+	waitForSubscriptions(feed, 0)
+}
+
+func Test_Subscription_InvalidArgumentCompletesWithError(t *testing.T) {
+	feed := NewFeed(bus.NewInMemoryBus())
+	socket := dialSubscriptions(t, feed, fixedOwners{})
+
+	startOperation(socket, "1", `subscription { syncStatus(userId: "nobody") { running } }`)
+
+	if message := readMessage(t, socket); message.Type != typeData || !strings.Contains(string(message.Payload), "invalid userId nobody") {
+		t.Errorf("Expected the invalid user to be an error, got %s %s", message.Type, message.Payload)
+	}
+	if message := readMessage(t, socket); message.Type != typeComplete {
+		t.Errorf("Expected the subscription to complete, got %s", message.Type)
+	}
+}
+
+func Test_Subscription_AccountOfOtherUserIsForbidden(t *testing.T) {
+	feed := NewFeed(bus.NewInMemoryBus())
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	socket := dialSubscriptions(t, feed, fixedOwners{accountID: primitives.UserID(uuid.New())})
+
+	startOperation(socket, "1", `subscription { balanceChanged(accountId: "`+accountID.String()+`") { timestamp } }`)
+
+	if message := readMessage(t, socket); message.Type != typeData || !strings.Contains(string(message.Payload), `"code":"FORBIDDEN"`) {
+		t.Errorf("Expected the account of another user to be forbidden, got %s %s", message.Type, message.Payload)
+	}
+}
+
+func Test_Subscriptions_OnlyFromAllowedOrigins(t *testing.T) {
+	url := serveSubscriptions(t, NewFeed(bus.NewInMemoryBus()), fixedOwners{}, []string{"https://app.example.com"})
+	dialer := websocket.Dialer{Subprotocols: []string{subscriptionProtocol}}
+
+	socket, _, err := dialer.Dial(url, http.Header{"Origin": []string{"https://app.example.com"}})
+	if err != nil {
+		t.Fatalf("Expected a connection from an allowed origin, got %v", err)
+	}
+	socket.Close()
+
+	_, res, err := dialer.Dial(url, http.Header{"Origin": []string{"https://evil.example.com"}})
+	if err == nil || res == nil || res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a connection from another origin to be forbidden, got %v", err)
+	}
+}
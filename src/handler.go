@@ -16,12 +16,27 @@ package main
 
 import (
 	accountinformation "app/account-information"
+	"app/budgeting"
+	"app/categorisation"
+	exchangerates "app/exchange-rates"
+	"app/export"
+	"app/households"
+	"app/networth"
+	"app/recurring"
+	"app/reporting"
+	savingsgoals "app/savings-goals"
+	"app/search"
+	sharedexpenses "app/shared-expenses"
+	spendingmap "app/spending-map"
+	"app/users"
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	eh "github.com/looplab/eventhorizon"
 	"github.com/looplab/eventhorizon/aggregatestore/events"
+	commandbus "github.com/looplab/eventhorizon/commandhandler/bus"
 	eventbus "github.com/looplab/eventhorizon/eventbus/local"
 	eventstore "github.com/looplab/eventhorizon/eventstore/memory"
 	"github.com/looplab/eventhorizon/middleware/eventhandler/observer"
@@ -32,6 +47,19 @@ type Handler struct {
 	EventBus       eh.EventBus
 	CommandHandler eh.CommandHandler
 	Repo           eh.ReadWriteRepo
+	Budgets        budgeting.BudgetRepository
+	SavingsGoals   savingsgoals.GoalRepository
+	Reports        reporting.Reporter
+	Converter      exchangerates.ReportingConverter
+	NetWorth       networth.Calculator
+	Exporter       export.Exporter
+	AccountOwners  *reporting.TransactionProjector
+	Search         search.Index
+	SpendingMap    spendingmap.Map
+	Users          users.Directory
+	Households     households.Memberships
+	Payers         recurring.Payers
+	SharedExpenses sharedexpenses.Ledgers
 }
 
 func newEventStore() *eventstore.EventStore {
@@ -58,18 +86,94 @@ func newAggregateStore(store *eventstore.EventStore, bus *eventbus.EventBus) *ev
 }
 
 // NewHandler sets up the full Event Horizon domain for the TodoMVC app and
-// returns a handler exposing some of the components.
-func NewHandler() (*Handler, error) {
+// returns a handler exposing some of the components. The commands about users are handled by the userCommandHandler,
+// the directory is kept up to date with the signed up users
+func NewHandler(directory users.Directory, userCommandHandler eh.CommandHandler) (*Handler, error) {
 	eventStore := newEventStore()
 	eventBus := newEventBus()
 
 	eventBus.AddHandler(eh.MatchAny(),
 		eh.UseEventHandlerMiddleware(&EventLogger{}, observer.Middleware))
 
-	commandHandler, err := accountinformation.SetupDomain(eventStore, eventBus)
+	commandBus := commandbus.NewCommandHandler()
+
+	accountInformationHandler, err := accountinformation.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, accountInformationHandler, accountinformation.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	categorisationHandler, err := categorisation.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, categorisationHandler, categorisation.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	budgetingHandler, err := budgeting.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, budgetingHandler, budgeting.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	exchangeRatesHandler, err := exchangerates.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, exchangeRatesHandler, exchangerates.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	savingsGoalsHandler, err := savingsgoals.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, savingsGoalsHandler, savingsgoals.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	recurringHandler, err := recurring.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, recurringHandler, recurring.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	usersHandler, err := users.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, usersHandler, users.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	householdsHandler, err := households.SetupDomain(eventStore, eventBus)
 	if err != nil {
 		return nil, err
 	}
+	if err := registerCommandHandler(commandBus, householdsHandler, households.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	sharedExpensesHandler, err := sharedexpenses.SetupDomain(eventStore, eventBus)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerCommandHandler(commandBus, sharedExpensesHandler, sharedexpenses.CommandTypes()); err != nil {
+		return nil, err
+	}
+
+	if err := registerCommandHandler(commandBus, userCommandHandler, accountinformation.UserCommandTypes()); err != nil {
+		return nil, err
+	}
+
+	var commandHandler eh.CommandHandler = commandBus
 
 	// Create a tiny logging middleware for the command handler.
 	commandHandlerLogger := func(h eh.CommandHandler) eh.CommandHandler {
@@ -80,6 +184,91 @@ func NewHandler() (*Handler, error) {
 	}
 	commandHandler = eh.UseCommandHandlerMiddleware(commandHandler, commandHandlerLogger)
 
+	transferDetector := accountinformation.NewTransferDetector(commandHandler)
+	if err := eventBus.AddHandler(transferDetector.Matcher(), transferDetector); err != nil {
+		return nil, fmt.Errorf("could not add internal transfer detector: %w", err)
+	}
+
+	categoriser := categorisation.NewTransactionCategoriser(commandHandler)
+	if err := eventBus.AddHandler(categoriser.Matcher(), categoriser); err != nil {
+		return nil, fmt.Errorf("could not add transaction categoriser: %w", err)
+	}
+
+	spendingTracker := budgeting.NewSpendingTracker(commandHandler)
+	if err := eventBus.AddHandler(spendingTracker.Matcher(), spendingTracker); err != nil {
+		return nil, fmt.Errorf("could not add budget spending tracker: %w", err)
+	}
+
+	budgets, err := budgeting.SetupReadModel(eventBus)
+	if err != nil {
+		return nil, err
+	}
+
+	progressTracker := savingsgoals.NewProgressTracker(commandHandler)
+	if err := eventBus.AddHandler(progressTracker.Matcher(), progressTracker); err != nil {
+		return nil, fmt.Errorf("could not add savings goal progress tracker: %w", err)
+	}
+
+	savingsGoals, err := savingsgoals.SetupReadModel(eventBus)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := loadExchangeRates()
+	if err != nil {
+		return nil, err
+	}
+
+	reportingCurrencies := exchangerates.NewReportingCurrencies()
+	if err := eventBus.AddHandler(reportingCurrencies.Matcher(), reportingCurrencies); err != nil {
+		return nil, fmt.Errorf("could not add reporting currencies: %w", err)
+	}
+	converter := exchangerates.NewReportingConverter(exchangerates.NewConverter(rates), reportingCurrencies)
+
+	transactionProjector := reporting.NewTransactionProjector()
+	if err := eventBus.AddHandler(transactionProjector.Matcher(), transactionProjector); err != nil {
+		return nil, fmt.Errorf("could not add reporting projector: %w", err)
+	}
+
+	balanceProjector := networth.NewBalanceProjector()
+	if err := eventBus.AddHandler(balanceProjector.Matcher(), balanceProjector); err != nil {
+		return nil, fmt.Errorf("could not add net worth projector: %w", err)
+	}
+
+	searchIndex := search.NewIndex()
+	if err := eventBus.AddHandler(searchIndex.Matcher(), searchIndex); err != nil {
+		return nil, fmt.Errorf("could not add transaction search index: %w", err)
+	}
+
+	spendingMap := spendingmap.NewMap()
+	if err := eventBus.AddHandler(spendingMap.Matcher(), spendingMap); err != nil {
+		return nil, fmt.Errorf("could not add spending map: %w", err)
+	}
+
+	if err := eventBus.AddHandler(directory.Matcher(), directory); err != nil {
+		return nil, fmt.Errorf("could not add user directory: %w", err)
+	}
+
+	payers := recurring.NewPayers()
+	if err := eventBus.AddHandler(payers.Matcher(), payers); err != nil {
+		return nil, fmt.Errorf("could not add recurring transaction payers: %w", err)
+	}
+
+	memberships := households.NewMemberships()
+	if err := eventBus.AddHandler(memberships.Matcher(), memberships); err != nil {
+		return nil, fmt.Errorf("could not add household memberships: %w", err)
+	}
+
+	ledgers := sharedexpenses.NewLedgers()
+	if err := eventBus.AddHandler(ledgers.Matcher(), ledgers); err != nil {
+		return nil, fmt.Errorf("could not add shared expense ledgers: %w", err)
+	}
+
+	settlementDetector := sharedexpenses.NewSettlementDetector(commandHandler, ledgers, memberships)
+	if err := eventBus.AddHandler(settlementDetector.Matcher(), settlementDetector); err != nil {
+		return nil, fmt.Errorf("could not add settlement detector: %w", err)
+	}
+
 	// // Create the repository and wrap in a version repository.
 	// repo := repo.NewRepo()
 	// repo.SetEntityFactory(func() eh.Entity { return &domain.TodoList{} })
@@ -100,10 +289,42 @@ func NewHandler() (*Handler, error) {
 	return &Handler{
 		EventBus:       eventBus,
 		CommandHandler: commandHandler,
+		Budgets:        budgets,
+		SavingsGoals:   savingsGoals,
+		Reports:        reporting.NewReporter(transactionProjector, converter),
+		Converter:      converter,
+		NetWorth:       networth.NewCalculator(balanceProjector, converter),
+		Exporter:       export.NewExporter(transactionProjector),
+		AccountOwners:  transactionProjector,
+		Search:         searchIndex,
+		SpendingMap:    spendingMap,
+		Users:          directory,
+		Households:     memberships,
+		Payers:         payers,
+		SharedExpenses: ledgers,
 		// Repo:           todoRepo,
 	}, nil
 }
 
+// loadExchangeRates loads the reference rates of the European Central Bank from the file in EXCHANGE_RATES_FILE,
+// without it amounts are only reported in their original currency
+func loadExchangeRates() (*exchangerates.HistoricalRates, error) {
+	path, ok := os.LookupEnv("EXCHANGE_RATES_FILE")
+	if !ok {
+		return exchangerates.NewHistoricalRates(exchangerates.ECBBaseCurrency), nil
+	}
+	return exchangerates.LoadECBFile(path)
+}
+
+func registerCommandHandler(commandBus *commandbus.CommandHandler, handler eh.CommandHandler, commandTypes []eh.CommandType) error {
+	for _, commandType := range commandTypes {
+		if err := commandBus.SetHandler(handler, commandType); err != nil {
+			return fmt.Errorf("could not register handler for %s: %w", commandType, err)
+		}
+	}
+	return nil
+}
+
 // EventLogger is a simple event handler for logging all events.
 type EventLogger struct{}
 
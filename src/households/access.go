@@ -0,0 +1,31 @@
+package households
+
+import "app/primitives"
+
+// AccountOwners tells whether a user is one of the owners of a monetary account
+type AccountOwners interface {
+	IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool
+}
+
+// SharedAccountOwners are the owners of monetary accounts together with the members of the households
+// the accounts are shared with, when their role in the household is at least the role.
+// With Viewer every member reads the shared accounts, with Owner only the owners of the household change them
+type SharedAccountOwners struct {
+	owners      AccountOwners
+	memberships Memberships
+	role        Role
+}
+
+// NewSharedAccountOwners creates SharedAccountOwners for members with at least the role
+func NewSharedAccountOwners(owners AccountOwners, memberships Memberships, role Role) SharedAccountOwners {
+	return SharedAccountOwners{owners: owners, memberships: memberships, role: role}
+}
+
+// IsOwner tells whether the user owns the monetary account, or is a member of a household it is shared with
+func (shared SharedAccountOwners) IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool {
+	if shared.owners.IsOwner(monetaryAccountID, userID) {
+		return true
+	}
+	role, isMember := shared.memberships.RoleFor(monetaryAccountID, userID)
+	return isMember && (role == Owner || shared.role == Viewer)
+}
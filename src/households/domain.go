@@ -0,0 +1,311 @@
+package households
+
+import (
+	"app/primitives"
+	"app/users"
+	"fmt"
+)
+
+// Role of a member in a household
+type Role string
+
+// Role enum
+const (
+	// Owner manages the household: invites and removes members and shares accounts
+	Owner Role = "Owner"
+	// Viewer only sees the accounts that are shared with the household
+	Viewer Role = "Viewer"
+)
+
+// ParseRole parses the role of a member
+func ParseRole(role string) (Role, error) {
+	switch Role(role) {
+	case Owner, Viewer:
+		return Role(role), nil
+	default:
+		return "", fmt.Errorf("unknown role %s", role)
+	}
+}
+
+type invitation struct {
+	email     string
+	role      Role
+	invitedBy primitives.UserID
+}
+
+type householdState struct {
+	ID          primitives.HouseholdID
+	initialized bool
+	name        string
+	members     map[primitives.UserID]Role
+	invitations map[primitives.InvitationID]invitation
+	accounts    map[primitives.MonetaryAccountID]primitives.UserID
+}
+
+func emptyHouseholdState(id primitives.HouseholdID) *householdState {
+	res := new(householdState)
+	res.ID = id
+	res.members = make(map[primitives.UserID]Role)
+	res.invitations = make(map[primitives.InvitationID]invitation)
+	res.accounts = make(map[primitives.MonetaryAccountID]primitives.UserID)
+	return res
+}
+
+func (state *householdState) copy() *householdState {
+	res := *state
+	res.members = make(map[primitives.UserID]Role, len(state.members))
+	for userID, role := range state.members {
+		res.members[userID] = role
+	}
+	res.invitations = make(map[primitives.InvitationID]invitation, len(state.invitations))
+	for id, invitation := range state.invitations {
+		res.invitations[id] = invitation
+	}
+	res.accounts = make(map[primitives.MonetaryAccountID]primitives.UserID, len(state.accounts))
+	for accountID, sharedBy := range state.accounts {
+		res.accounts[accountID] = sharedBy
+	}
+	return &res
+}
+
+func (state *householdState) owners() int {
+	owners := 0
+	for _, role := range state.members {
+		if role == Owner {
+			owners++
+		}
+	}
+	return owners
+}
+
+type HouseholdEvent interface {
+	appliedTo(state *householdState) *householdState
+}
+
+type HouseholdCommand interface {
+	applyTo(state *householdState) ([]HouseholdEvent, error)
+}
+
+func validateOwner(state *householdState, userID primitives.UserID, field string) error {
+	if state == nil || !state.initialized {
+		return primitives.NewValidationError("HouseholdID", "unknown household")
+	}
+	if state.members[userID] != Owner {
+		return primitives.NewValidationError(field, "only owners of the household can do this")
+	}
+	return nil
+}
+
+// CreateHouseholdCommand creates a household, the user that creates it is its first owner
+type CreateHouseholdCommand struct {
+	HouseholdID primitives.HouseholdID
+	Name        string
+	OwnerUserID primitives.UserID
+}
+
+func (cmd CreateHouseholdCommand) applyTo(state *householdState) ([]HouseholdEvent, error) {
+	if state != nil && state.initialized {
+		return nil, primitives.NewValidationError("HouseholdID", "already exists")
+	}
+	return []HouseholdEvent{newHouseholdCreated(cmd)}, nil
+}
+
+// InviteMemberCommand invites someone by email to become a member of the household with a role
+type InviteMemberCommand struct {
+	HouseholdID  primitives.HouseholdID
+	InvitationID primitives.InvitationID
+	InvitedBy    primitives.UserID
+	Email        string
+	Role         Role
+}
+
+func (cmd InviteMemberCommand) applyTo(state *householdState) ([]HouseholdEvent, error) {
+	if err := validateOwner(state, cmd.InvitedBy, "InvitedBy"); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRole(string(cmd.Role)); err != nil {
+		return nil, primitives.NewValidationError("Role", err.Error())
+	}
+	if _, hasInvitation := state.invitations[cmd.InvitationID]; hasInvitation {
+		return nil, primitives.NewValidationError("InvitationID", "already exists")
+	}
+	for _, invitation := range state.invitations {
+		if invitation.email == users.NormalisedEmail(cmd.Email) {
+			return nil, primitives.NewValidationError("Email", "already invited")
+		}
+	}
+	return []HouseholdEvent{newMemberInvited(cmd)}, nil
+}
+
+// AcceptInvitationCommand makes the user a member of the household with the role of the invitation
+type AcceptInvitationCommand struct {
+	HouseholdID  primitives.HouseholdID
+	InvitationID primitives.InvitationID
+	UserID       primitives.UserID
+}
+
+func (cmd AcceptInvitationCommand) applyTo(state *householdState) ([]HouseholdEvent, error) {
+	if state == nil || !state.initialized {
+		return nil, primitives.NewValidationError("HouseholdID", "unknown household")
+	}
+	invitation, hasInvitation := state.invitations[cmd.InvitationID]
+	if !hasInvitation {
+		return nil, primitives.NewValidationError("InvitationID", "unknown invitation")
+	}
+	if _, isMember := state.members[cmd.UserID]; isMember {
+		return nil, primitives.NewValidationError("UserID", "already a member")
+	}
+	return []HouseholdEvent{newInvitationAccepted(cmd, invitation.role)}, nil
+}
+
+// RemoveMemberCommand removes a member from the household, owners remove members and members can leave themselves
+type RemoveMemberCommand struct {
+	HouseholdID primitives.HouseholdID
+	RemovedBy   primitives.UserID
+	UserID      primitives.UserID
+}
+
+func (cmd RemoveMemberCommand) applyTo(state *householdState) ([]HouseholdEvent, error) {
+	if cmd.RemovedBy != cmd.UserID {
+		if err := validateOwner(state, cmd.RemovedBy, "RemovedBy"); err != nil {
+			return nil, err
+		}
+	} else if state == nil || !state.initialized {
+		return nil, primitives.NewValidationError("HouseholdID", "unknown household")
+	}
+
+	role, isMember := state.members[cmd.UserID]
+	if !isMember {
+		return nil, nil
+	}
+	if role == Owner && state.owners() == 1 {
+		return nil, primitives.NewValidationError("UserID", "the last owner can not leave the household")
+	}
+	return []HouseholdEvent{newMemberRemoved(cmd)}, nil
+}
+
+// ShareAccountCommand shares a monetary account with the members of the household
+type ShareAccountCommand struct {
+	HouseholdID       primitives.HouseholdID
+	SharedBy          primitives.UserID
+	MonetaryAccountID primitives.MonetaryAccountID
+}
+
+func (cmd ShareAccountCommand) applyTo(state *householdState) ([]HouseholdEvent, error) {
+	if err := validateOwner(state, cmd.SharedBy, "SharedBy"); err != nil {
+		return nil, err
+	}
+	if _, isShared := state.accounts[cmd.MonetaryAccountID]; isShared {
+		return nil, nil
+	}
+	return []HouseholdEvent{newAccountShared(cmd)}, nil
+}
+
+type HouseholdCreated struct {
+	ID          primitives.HouseholdID
+	Name        string
+	OwnerUserID primitives.UserID
+}
+
+func newHouseholdCreated(cmd CreateHouseholdCommand) HouseholdCreated {
+	res := new(HouseholdCreated)
+	res.ID = cmd.HouseholdID
+	res.Name = cmd.Name
+	res.OwnerUserID = cmd.OwnerUserID
+	return *res
+}
+
+func (event HouseholdCreated) appliedTo(state *householdState) *householdState {
+	res := emptyHouseholdState(event.ID)
+	res.initialized = true
+	res.name = event.Name
+	res.members[event.OwnerUserID] = Owner
+	return res
+}
+
+type MemberInvited struct {
+	ID           primitives.HouseholdID
+	InvitationID primitives.InvitationID
+	InvitedBy    primitives.UserID
+	Email        string
+	Role         Role
+}
+
+func newMemberInvited(cmd InviteMemberCommand) MemberInvited {
+	res := new(MemberInvited)
+	res.ID = cmd.HouseholdID
+	res.InvitationID = cmd.InvitationID
+	res.InvitedBy = cmd.InvitedBy
+	res.Email = users.NormalisedEmail(cmd.Email)
+	res.Role = cmd.Role
+	return *res
+}
+
+func (event MemberInvited) appliedTo(state *householdState) *householdState {
+	res := state.copy()
+	res.invitations[event.InvitationID] = invitation{email: event.Email, role: event.Role, invitedBy: event.InvitedBy}
+	return res
+}
+
+type InvitationAccepted struct {
+	ID           primitives.HouseholdID
+	InvitationID primitives.InvitationID
+	UserID       primitives.UserID
+	Role         Role
+}
+
+func newInvitationAccepted(cmd AcceptInvitationCommand, role Role) InvitationAccepted {
+	res := new(InvitationAccepted)
+	res.ID = cmd.HouseholdID
+	res.InvitationID = cmd.InvitationID
+	res.UserID = cmd.UserID
+	res.Role = role
+	return *res
+}
+
+func (event InvitationAccepted) appliedTo(state *householdState) *householdState {
+	res := state.copy()
+	delete(res.invitations, event.InvitationID)
+	res.members[event.UserID] = event.Role
+	return res
+}
+
+type MemberRemoved struct {
+	ID        primitives.HouseholdID
+	UserID    primitives.UserID
+	RemovedBy primitives.UserID
+}
+
+func newMemberRemoved(cmd RemoveMemberCommand) MemberRemoved {
+	res := new(MemberRemoved)
+	res.ID = cmd.HouseholdID
+	res.UserID = cmd.UserID
+	res.RemovedBy = cmd.RemovedBy
+	return *res
+}
+
+func (event MemberRemoved) appliedTo(state *householdState) *householdState {
+	res := state.copy()
+	delete(res.members, event.UserID)
+	return res
+}
+
+type AccountShared struct {
+	ID                primitives.HouseholdID
+	MonetaryAccountID primitives.MonetaryAccountID
+	SharedBy          primitives.UserID
+}
+
+func newAccountShared(cmd ShareAccountCommand) AccountShared {
+	res := new(AccountShared)
+	res.ID = cmd.HouseholdID
+	res.MonetaryAccountID = cmd.MonetaryAccountID
+	res.SharedBy = cmd.SharedBy
+	return *res
+}
+
+func (event AccountShared) appliedTo(state *householdState) *householdState {
+	res := state.copy()
+	res.accounts[event.MonetaryAccountID] = event.SharedBy
+	return res
+}
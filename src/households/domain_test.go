@@ -0,0 +1,67 @@
+package households
+
+import (
+	"app/primitives"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	householdID = primitives.HouseholdID(uuid.New())
+	owner       = primitives.UserID(uuid.New())
+	partner     = primitives.UserID(uuid.New())
+)
+
+func created() *householdState {
+	return HouseholdCreated{ID: householdID, Name: "Home", OwnerUserID: owner}.appliedTo(nil)
+}
+
+func apply(t *testing.T, state *householdState, cmd HouseholdCommand) *householdState {
+	events, err := cmd.applyTo(state)
+	if err != nil {
+		t.Fatalf("Expected %T to be accepted, got %v", cmd, err)
+	}
+	for _, event := range events {
+		state = event.appliedTo(state)
+	}
+	return state
+}
+
+func Test_InvitationAccepted_AddsMemberWithRoleOfInvitation(t *testing.T) {
+	invitationID := primitives.InvitationID(uuid.New())
+	state := apply(t, created(), InviteMemberCommand{
+		HouseholdID: householdID, InvitationID: invitationID, InvitedBy: owner, Email: " Partner@Example.com", Role: Viewer,
+	})
+	if state.invitations[invitationID].email != "partner@example.com" {
+		t.Errorf("Expected the email of the invitation to be normalised, got %q", state.invitations[invitationID].email)
+	}
+
+	state = apply(t, state, AcceptInvitationCommand{HouseholdID: householdID, InvitationID: invitationID, UserID: partner})
+	if state.members[partner] != Viewer || len(state.invitations) != 0 {
+		t.Errorf("Expected the partner to be a viewer, got %+v", state)
+	}
+
+	if _, err := (AcceptInvitationCommand{HouseholdID: householdID, InvitationID: invitationID, UserID: partner}).applyTo(state); err == nil {
+		t.Errorf("Expected an invitation to be accepted only once")
+	}
+	if _, err := (ShareAccountCommand{HouseholdID: householdID, SharedBy: partner, MonetaryAccountID: primitives.MonetaryAccountID(uuid.New())}).applyTo(state); err == nil {
+		t.Errorf("Expected a viewer not to be able to share accounts")
+	}
+}
+
+func Test_RemoveMemberCommand_KeepsLastOwner(t *testing.T) {
+	if _, err := (RemoveMemberCommand{HouseholdID: householdID, RemovedBy: owner, UserID: owner}).applyTo(created()); err == nil {
+		t.Errorf("Expected the last owner not to be able to leave")
+	}
+
+	invitationID := primitives.InvitationID(uuid.New())
+	state := apply(t, created(), InviteMemberCommand{
+		HouseholdID: householdID, InvitationID: invitationID, InvitedBy: owner, Email: "partner@example.com", Role: Owner,
+	})
+	state = apply(t, state, AcceptInvitationCommand{HouseholdID: householdID, InvitationID: invitationID, UserID: partner})
+	state = apply(t, state, RemoveMemberCommand{HouseholdID: householdID, RemovedBy: partner, UserID: owner})
+	if _, isMember := state.members[owner]; isMember || state.members[partner] != Owner {
+		t.Errorf("Expected only the partner to be left as owner, got %+v", state.members)
+	}
+}
@@ -0,0 +1,236 @@
+package households
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(HouseholdAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// HouseholdAggregateType is the aggregate type for the household
+const HouseholdAggregateType = eh.AggregateType("household")
+
+// Aggregate is an aggregate for a household
+type Aggregate struct {
+	*events.AggregateBase
+	*householdState
+}
+
+const EhCreateHouseholdCommand = eh.CommandType("household:create")
+const EhInviteMemberCommand = eh.CommandType("household:invite")
+const EhAcceptInvitationCommand = eh.CommandType("household:accept-invitation")
+const EhRemoveMemberCommand = eh.CommandType("household:remove-member")
+const EhShareAccountCommand = eh.CommandType("household:share-account")
+
+const EhHouseholdCreated = eh.EventType("household:created")
+const EhMemberInvited = eh.EventType("household:member-invited")
+const EhInvitationAccepted = eh.EventType("household:invitation-accepted")
+const EhMemberRemoved = eh.EventType("household:member-removed")
+const EhAccountShared = eh.EventType("household:account-shared")
+
+// CommandTypes are all command types handled by the household aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhCreateHouseholdCommand,
+		EhInviteMemberCommand,
+		EhAcceptInvitationCommand,
+		EhRemoveMemberCommand,
+		EhShareAccountCommand,
+	}
+}
+
+func (cmd CreateHouseholdCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.HouseholdID)
+}
+
+func (cmd CreateHouseholdCommand) AggregateType() eh.AggregateType {
+	return HouseholdAggregateType
+}
+
+func (cmd CreateHouseholdCommand) CommandType() eh.CommandType {
+	return EhCreateHouseholdCommand
+}
+
+func (cmd InviteMemberCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.HouseholdID)
+}
+
+func (cmd InviteMemberCommand) AggregateType() eh.AggregateType {
+	return HouseholdAggregateType
+}
+
+func (cmd InviteMemberCommand) CommandType() eh.CommandType {
+	return EhInviteMemberCommand
+}
+
+func (cmd AcceptInvitationCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.HouseholdID)
+}
+
+func (cmd AcceptInvitationCommand) AggregateType() eh.AggregateType {
+	return HouseholdAggregateType
+}
+
+func (cmd AcceptInvitationCommand) CommandType() eh.CommandType {
+	return EhAcceptInvitationCommand
+}
+
+func (cmd RemoveMemberCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.HouseholdID)
+}
+
+func (cmd RemoveMemberCommand) AggregateType() eh.AggregateType {
+	return HouseholdAggregateType
+}
+
+func (cmd RemoveMemberCommand) CommandType() eh.CommandType {
+	return EhRemoveMemberCommand
+}
+
+func (cmd ShareAccountCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.HouseholdID)
+}
+
+func (cmd ShareAccountCommand) AggregateType() eh.AggregateType {
+	return HouseholdAggregateType
+}
+
+func (cmd ShareAccountCommand) CommandType() eh.CommandType {
+	return EhShareAccountCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(HouseholdAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhHouseholdCreated, func() eh.EventData {
+		return &HouseholdCreated{}
+	})
+
+	eh.RegisterEventData(EhMemberInvited, func() eh.EventData {
+		return &MemberInvited{}
+	})
+
+	eh.RegisterEventData(EhInvitationAccepted, func() eh.EventData {
+		return &InvitationAccepted{}
+	})
+
+	eh.RegisterEventData(EhMemberRemoved, func() eh.EventData {
+		return &MemberRemoved{}
+	})
+
+	eh.RegisterEventData(EhAccountShared, func() eh.EventData {
+		return &AccountShared{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.householdState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.householdState = eventInDomain.appliedTo(a.householdState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (HouseholdEvent, error) {
+	switch event.EventType() {
+	case EhHouseholdCreated:
+		return event.Data().(HouseholdEvent), nil
+	case EhMemberInvited:
+		return event.Data().(HouseholdEvent), nil
+	case EhInvitationAccepted:
+		return event.Data().(HouseholdEvent), nil
+	case EhMemberRemoved:
+		return event.Data().(HouseholdEvent), nil
+	case EhAccountShared:
+		return event.Data().(HouseholdEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event HouseholdEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case HouseholdCreated:
+		return EhHouseholdCreated, nil
+	case MemberInvited:
+		return EhMemberInvited, nil
+	case InvitationAccepted:
+		return EhInvitationAccepted, nil
+	case MemberRemoved:
+		return EhMemberRemoved, nil
+	case AccountShared:
+		return EhAccountShared, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (HouseholdCommand, error) {
+	switch cmd := cmd.(type) {
+	case CreateHouseholdCommand:
+		return cmd, nil
+	case InviteMemberCommand:
+		return cmd, nil
+	case AcceptInvitationCommand:
+		return cmd, nil
+	case RemoveMemberCommand:
+		return cmd, nil
+	case ShareAccountCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
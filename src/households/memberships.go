@@ -0,0 +1,188 @@
+package households
+
+import (
+	"app/primitives"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Household is the read model of a household
+type Household struct {
+	ID          primitives.HouseholdID
+	Name        string
+	Members     map[primitives.UserID]Role
+	Invitations map[primitives.InvitationID]Invitation
+	Accounts    []primitives.MonetaryAccountID
+}
+
+// Invitation is a pending invitation to become a member of a household
+type Invitation struct {
+	ID          primitives.InvitationID
+	HouseholdID primitives.HouseholdID
+	Email       string
+	Role        Role
+	InvitedBy   primitives.UserID
+}
+
+// Memberships keeps the households, to look up the households of a user and the accounts shared with them
+type Memberships struct {
+	mu         *sync.RWMutex
+	households map[primitives.HouseholdID]*Household
+}
+
+// NewMemberships creates empty Memberships
+func NewMemberships() Memberships {
+	res := new(Memberships)
+	res.mu = new(sync.RWMutex)
+	res.households = make(map[primitives.HouseholdID]*Household)
+	return *res
+}
+
+// Matcher matches all events of households
+func (memberships Memberships) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		EhHouseholdCreated,
+		EhMemberInvited,
+		EhInvitationAccepted,
+		EhMemberRemoved,
+		EhAccountShared,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (memberships Memberships) HandlerType() eh.EventHandlerType {
+	return "household-memberships"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (memberships Memberships) HandleEvent(ctx context.Context, event eh.Event) error {
+	memberships.mu.Lock()
+	defer memberships.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *HouseholdCreated:
+		memberships.households[data.ID] = &Household{
+			ID:          data.ID,
+			Name:        data.Name,
+			Members:     map[primitives.UserID]Role{data.OwnerUserID: Owner},
+			Invitations: make(map[primitives.InvitationID]Invitation),
+		}
+	case *MemberInvited:
+		if household, ok := memberships.households[data.ID]; ok {
+			household.Invitations[data.InvitationID] = Invitation{
+				ID:          data.InvitationID,
+				HouseholdID: data.ID,
+				Email:       data.Email,
+				Role:        data.Role,
+				InvitedBy:   data.InvitedBy,
+			}
+		}
+	case *InvitationAccepted:
+		if household, ok := memberships.households[data.ID]; ok {
+			delete(household.Invitations, data.InvitationID)
+			household.Members[data.UserID] = data.Role
+		}
+	case *MemberRemoved:
+		if household, ok := memberships.households[data.ID]; ok {
+			delete(household.Members, data.UserID)
+		}
+	case *AccountShared:
+		if household, ok := memberships.households[data.ID]; ok {
+			household.Accounts = append(household.Accounts, data.MonetaryAccountID)
+		}
+	}
+	return nil
+}
+
+// HouseholdsOf returns the households the user is a member of, ordered by name
+func (memberships Memberships) HouseholdsOf(userID primitives.UserID) []Household {
+	memberships.mu.RLock()
+	defer memberships.mu.RUnlock()
+
+	var res []Household
+	for _, household := range memberships.households {
+		if _, isMember := household.Members[userID]; isMember {
+			res = append(res, household.copy())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Name != res[j].Name {
+			return res[i].Name < res[j].Name
+		}
+		return uuid.UUID(res[i].ID).String() < uuid.UUID(res[j].ID).String()
+	})
+	return res
+}
+
+// RoleOf returns the role of the user in the household, if the user is a member
+func (memberships Memberships) RoleOf(householdID primitives.HouseholdID, userID primitives.UserID) (Role, bool) {
+	memberships.mu.RLock()
+	defer memberships.mu.RUnlock()
+
+	household, ok := memberships.households[householdID]
+	if !ok {
+		return "", false
+	}
+	role, isMember := household.Members[userID]
+	return role, isMember
+}
+
+// RoleFor returns the role of the user in the households the monetary account is shared with,
+// when the user is a member of several of those households the strongest role
+func (memberships Memberships) RoleFor(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) (Role, bool) {
+	memberships.mu.RLock()
+	defer memberships.mu.RUnlock()
+
+	var res Role
+	for _, household := range memberships.households {
+		role, isMember := household.Members[userID]
+		if !isMember || !household.shares(monetaryAccountID) {
+			continue
+		}
+		if role == Owner {
+			return Owner, true
+		}
+		res = role
+	}
+	return res, res != ""
+}
+
+// InvitationOf returns the pending invitation of the household
+func (memberships Memberships) InvitationOf(householdID primitives.HouseholdID, invitationID primitives.InvitationID) (Invitation, bool) {
+	memberships.mu.RLock()
+	defer memberships.mu.RUnlock()
+
+	household, ok := memberships.households[householdID]
+	if !ok {
+		return Invitation{}, false
+	}
+	invitation, ok := household.Invitations[invitationID]
+	return invitation, ok
+}
+
+func (household Household) shares(monetaryAccountID primitives.MonetaryAccountID) bool {
+	for _, accountID := range household.Accounts {
+		if accountID == monetaryAccountID {
+			return true
+		}
+	}
+	return false
+}
+
+func (household Household) copy() Household {
+	res := household
+	res.Members = make(map[primitives.UserID]Role, len(household.Members))
+	for userID, role := range household.Members {
+		res.Members[userID] = role
+	}
+	res.Invitations = make(map[primitives.InvitationID]Invitation, len(household.Invitations))
+	for id, invitation := range household.Invitations {
+		res.Invitations[id] = invitation
+	}
+	res.Accounts = append([]primitives.MonetaryAccountID(nil), household.Accounts...)
+	return res
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"app/connectors"
+	syncstatus "app/sync-status"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,16 +13,39 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func registerHealthchecks(r *mux.Router) error {
-	healthchecks := r.PathPrefix("/health").Subrouter()
-	healthchecks.HandleFunc("/ready", func(res http.ResponseWriter, req *http.Request) {
-		fmt.Fprintf(res, "OK")
-	})
-	return nil
+func registerHealthchecks(registry *connectors.Registry, syncTracker syncstatus.Tracker) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		healthchecks := r.PathPrefix("/health").Subrouter()
+		healthchecks.HandleFunc("/ready", func(res http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(res, "OK")
+		})
+		healthchecks.HandleFunc("/connectors", func(res http.ResponseWriter, req *http.Request) {
+			health := registry.CheckHealth(req.Context())
+
+			res.Header().Set("Content-Type", "application/json")
+			for _, connector := range health {
+				if !connector.Healthy {
+					res.WriteHeader(http.StatusServiceUnavailable)
+					break
+				}
+			}
+			json.NewEncoder(res).Encode(health)
+		})
+		healthchecks.HandleFunc("/syncs", func(res http.ResponseWriter, req *http.Request) {
+			if err := syncTracker.CheckHealth(); err != nil {
+				http.Error(res, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintf(res, "OK")
+		})
+		return nil
+	}
 }
 
-func ServeHttp(ctx context.Context, muxes []func(r *mux.Router) error) (err error) {
+// ServeHttp serves the routes of the muxes, every request passes the middleware first
+func ServeHttp(ctx context.Context, middleware mux.MiddlewareFunc, muxes []func(r *mux.Router) error) (err error) {
 	r := mux.NewRouter()
+	r.Use(middleware)
 
 	for _, m := range muxes {
 		if err = m(r); err != nil {
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"app/primitives"
+	statementimport "app/statement-import"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// runImportCSVCommand parses a csv export with a profile. A dry run prints what would be imported,
+// otherwise the export is uploaded to the import endpoint of a running server, with the token in AUTH_TOKEN
+func runImportCSVCommand(args []string) error {
+	flags := flag.NewFlagSet("import-csv", flag.ContinueOnError)
+	profileName := flags.String("profile", "", "name of a built-in profile: ing, rabobank or abnamro")
+	profileFile := flags.String("profile-file", "", "json file with a user defined profile")
+	userID := flags.String("user", "", "id of the user the accounts belong to")
+	dryRun := flags.Bool("dry-run", false, "only print what would be imported")
+	server := flags.String("server", "http://localhost:9000", "address of the server to import into")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import-csv [flags] <file>")
+	}
+
+	profile, err := loadProfile(*profileName, *profileFile)
+	if err != nil {
+		return err
+	}
+
+	export, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("could not read csv export: %w", err)
+	}
+
+	statements, err := statementimport.ParseCSV(bytes.NewReader(export), profile)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statementimport.Summarise(statements))
+	}
+
+	if *userID == "" {
+		return fmt.Errorf("a user is needed to import")
+	}
+	return uploadCSV(*server, *userID, profile, export)
+}
+
+func loadProfile(name string, file string) (statementimport.Profile, error) {
+	// without a file only the profiles of the banks are known locally, those are not defined by any user
+	if file == "" {
+		return statementimport.NewProfiles().Get(primitives.UserID{}, name)
+	}
+
+	definition, err := os.Open(file)
+	if err != nil {
+		return statementimport.Profile{}, fmt.Errorf("could not open profile: %w", err)
+	}
+	defer definition.Close()
+
+	return statementimport.ReadProfile(definition)
+}
+
+func uploadCSV(server string, userID string, profile statementimport.Profile, export []byte) error {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+
+	filePart, err := form.CreateFormFile("file", "export.csv")
+	if err != nil {
+		return err
+	}
+	filePart.Write(export)
+
+	profilePart, err := form.CreateFormFile("profile", "profile.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(profilePart).Encode(profile); err != nil {
+		return err
+	}
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server+"/statements/csv?userId="+url.QueryEscape(userID), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	res, err := http.DefaultClient.Do(authorized(req))
+	if err != nil {
+		return fmt.Errorf("could not upload csv export: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted {
+		message, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("import failed with status %d: %s", res.StatusCode, message)
+	}
+	_, err = io.Copy(os.Stdout, res.Body)
+	return err
+}
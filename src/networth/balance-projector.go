@@ -0,0 +1,155 @@
+package networth
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// BalanceSnapshot the balance of a monetary account at a moment
+type BalanceSnapshot struct {
+	Balance   money.Money
+	Timestamp time.Time
+}
+
+// AccountHistory the balance history of a monetary account and how it is shared by its owners
+type AccountHistory struct {
+	MonetaryAccountID primitives.MonetaryAccountID
+	Joint             bool
+	Owners            map[primitives.UserID]bool
+	Shares            map[primitives.UserID]int
+	Snapshots         []BalanceSnapshot
+}
+
+// BalanceAt is the last balance on or before the moment, false when there was no snapshot yet
+func (history AccountHistory) BalanceAt(t time.Time) (money.Money, bool) {
+	i := sort.Search(len(history.Snapshots), func(i int) bool { return history.Snapshots[i].Timestamp.After(t) })
+	if i == 0 {
+		return money.Money{}, false
+	}
+	return history.Snapshots[i-1].Balance, true
+}
+
+// ShareOf is the percentage of the account that belongs to the user. Single accounts belong to their owners completely,
+// owners of joint accounts without a configured share split what is not configured for the others equally
+func (history AccountHistory) ShareOf(userID primitives.UserID) float64 {
+	if !history.Owners[userID] {
+		return 0
+	}
+	if !history.Joint {
+		return 100
+	}
+	if percentage, ok := history.Shares[userID]; ok {
+		return float64(percentage)
+	}
+
+	remaining := 100
+	unconfigured := 0
+	for ownerID := range history.Owners {
+		if percentage, ok := history.Shares[ownerID]; ok {
+			remaining -= percentage
+		} else {
+			unconfigured++
+		}
+	}
+	return float64(remaining) / float64(unconfigured)
+}
+
+// BalanceProjector keeps the balance history of all monetary accounts of the account-information domain
+type BalanceProjector struct {
+	mu       sync.RWMutex
+	accounts map[primitives.MonetaryAccountID]*AccountHistory
+}
+
+// NewBalanceProjector creates an empty BalanceProjector
+func NewBalanceProjector() *BalanceProjector {
+	return &BalanceProjector{accounts: make(map[primitives.MonetaryAccountID]*AccountHistory)}
+}
+
+// Matcher matches all events the projector is interested in
+func (projector *BalanceProjector) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewMonetaryAccountFound,
+		accountinformation.EhMonetaryAccountBecameJoint,
+		accountinformation.EhMonetaryAccountBecameSingular,
+		accountinformation.EhMonetaryAccountUserAdded,
+		accountinformation.EhMonetaryAccountShareSet,
+		accountinformation.EhMonetaryAccountBalanceSnapshotted,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (projector *BalanceProjector) HandlerType() eh.EventHandlerType {
+	return "net-worth-balance-projector"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (projector *BalanceProjector) HandleEvent(ctx context.Context, event eh.Event) error {
+	projector.mu.Lock()
+	defer projector.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *accountinformation.NewMonetaryAccountFound:
+		projector.accountOf(data.ID).Joint = data.Joint
+	case *accountinformation.MonetaryAccountBecameJoint:
+		projector.accountOf(data.ID).Joint = true
+	case *accountinformation.MonetaryAccountBecameSingular:
+		projector.accountOf(data.ID).Joint = false
+	case *accountinformation.MonetaryAccountUserAdded:
+		projector.accountOf(data.ID).Owners[data.UserID] = true
+	case *accountinformation.MonetaryAccountShareSet:
+		projector.accountOf(data.ID).Shares[data.UserID] = data.Percentage
+	case *accountinformation.MonetaryAccountBalanceSnapshotted:
+		account := projector.accountOf(data.ID)
+		account.Snapshots = append(account.Snapshots, BalanceSnapshot{Balance: data.Balance, Timestamp: data.Timestamp})
+		sort.SliceStable(account.Snapshots, func(i, j int) bool {
+			return account.Snapshots[i].Timestamp.Before(account.Snapshots[j].Timestamp)
+		})
+	}
+	return nil
+}
+
+func (projector *BalanceProjector) accountOf(monetaryAccountID primitives.MonetaryAccountID) *AccountHistory {
+	account, ok := projector.accounts[monetaryAccountID]
+	if !ok {
+		account = &AccountHistory{
+			MonetaryAccountID: monetaryAccountID,
+			Owners:            make(map[primitives.UserID]bool),
+			Shares:            make(map[primitives.UserID]int),
+		}
+		projector.accounts[monetaryAccountID] = account
+	}
+	return account
+}
+
+// AccountsOf returns the balance history of all monetary accounts the user owns
+func (projector *BalanceProjector) AccountsOf(userID primitives.UserID) []AccountHistory {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	var res []AccountHistory
+	for _, account := range projector.accounts {
+		if !account.Owners[userID] {
+			continue
+		}
+
+		history := *account
+		history.Owners = make(map[primitives.UserID]bool, len(account.Owners))
+		for ownerID := range account.Owners {
+			history.Owners[ownerID] = true
+		}
+		history.Shares = make(map[primitives.UserID]int, len(account.Shares))
+		for ownerID, percentage := range account.Shares {
+			history.Shares[ownerID] = percentage
+		}
+		history.Snapshots = append([]BalanceSnapshot(nil), account.Snapshots...)
+		res = append(res, history)
+	}
+	return res
+}
@@ -0,0 +1,141 @@
+package networth
+
+import (
+	"app/primitives"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Interval the distance between the points of a timeline
+type Interval string
+
+// Interval enum
+const (
+	Daily   Interval = "Daily"
+	Weekly  Interval = "Weekly"
+	Monthly Interval = "Monthly"
+)
+
+// endOf is the last day of the interval the day is in, weeks end on sunday
+func (interval Interval) endOf(day time.Time) time.Time {
+	switch interval {
+	case Weekly:
+		return day.AddDate(0, 0, (7-int(day.Weekday()))%7)
+	case Monthly:
+		return time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	default:
+		return day
+	}
+}
+
+// Point the net worth of a user at the end of a day. There is an amount per currency that could not be converted into the reporting currency
+type Point struct {
+	Date     time.Time
+	NetWorth []money.Money
+}
+
+// Converter converts amounts into the reporting currency of a user
+type Converter interface {
+	ReportingCurrencyOf(userID primitives.UserID) string
+	Convert(amount money.Money, currencyCode string, date time.Time) (money.Money, error)
+}
+
+// AccountSource gives access to the balance histories of the accounts of a user
+type AccountSource interface {
+	AccountsOf(userID primitives.UserID) []AccountHistory
+}
+
+// Calculator computes the net worth of users over time
+type Calculator struct {
+	source    AccountSource
+	converter Converter
+}
+
+// NewCalculator creates a Calculator on the accounts of the source, without a converter every currency is totalled separately
+func NewCalculator(source AccountSource, converter Converter) Calculator {
+	return Calculator{source: source, converter: converter}
+}
+
+// Timeline resamples the balances of all accounts of the user to a point per interval between from and to,
+// the last known balance of an account is carried forward until a new one is snapshotted.
+// A zero from starts at the first snapshot, a zero to ends today
+func (calculator Calculator) Timeline(userID primitives.UserID, interval Interval, from time.Time, to time.Time) []Point {
+	accounts := calculator.source.AccountsOf(userID)
+
+	if from.IsZero() {
+		from = firstSnapshot(accounts)
+		if from.IsZero() {
+			return nil
+		}
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	var points []Point
+	for day := truncateToDay(from); !day.After(to); {
+		end := interval.endOf(day)
+		if end.After(to) {
+			end = truncateToDay(to)
+		}
+		points = append(points, calculator.pointAt(userID, accounts, end))
+		day = end.AddDate(0, 0, 1)
+	}
+	return points
+}
+
+func (calculator Calculator) pointAt(userID primitives.UserID, accounts []AccountHistory, day time.Time) Point {
+	endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	var reportingCurrency string
+	if calculator.converter != nil {
+		reportingCurrency = calculator.converter.ReportingCurrencyOf(userID)
+	}
+
+	totals := make(map[string]int64)
+	for _, account := range accounts {
+		balance, ok := account.BalanceAt(endOfDay)
+		if !ok {
+			continue
+		}
+
+		if reportingCurrency != "" {
+			if converted, err := calculator.converter.Convert(balance, reportingCurrency, day); err == nil {
+				balance = converted
+			}
+		}
+
+		share := float64(balance.Amount()) * account.ShareOf(userID) / 100
+		totals[balance.Currency().Code] += int64(math.Round(share))
+	}
+
+	if len(totals) == 0 && reportingCurrency != "" {
+		totals[reportingCurrency] = 0
+	}
+
+	point := Point{Date: day}
+	for currency, amount := range totals {
+		point.NetWorth = append(point.NetWorth, *money.New(amount, currency))
+	}
+	sort.Slice(point.NetWorth, func(i, j int) bool {
+		return point.NetWorth[i].Currency().Code < point.NetWorth[j].Currency().Code
+	})
+	return point
+}
+
+func firstSnapshot(accounts []AccountHistory) time.Time {
+	var first time.Time
+	for _, account := range accounts {
+		if len(account.Snapshots) > 0 && (first.IsZero() || account.Snapshots[0].Timestamp.Before(first)) {
+			first = account.Snapshots[0].Timestamp
+		}
+	}
+	return first
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,94 @@
+package networth
+
+import (
+	"app/primitives"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+var partnerID = primitives.UserID(uuid.New())
+
+type fakeSource []AccountHistory
+
+func (source fakeSource) AccountsOf(userID primitives.UserID) []AccountHistory {
+	return source
+}
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func account(joint bool, snapshots ...BalanceSnapshot) AccountHistory {
+	owners := map[primitives.UserID]bool{userID: true}
+	if joint {
+		owners[partnerID] = true
+	}
+	return AccountHistory{
+		MonetaryAccountID: primitives.MonetaryAccountID(uuid.New()),
+		Joint:             joint,
+		Owners:            owners,
+		Shares:            make(map[primitives.UserID]int),
+		Snapshots:         snapshots,
+	}
+}
+
+func snapshot(amount int64, timestamp time.Time) BalanceSnapshot {
+	return BalanceSnapshot{Balance: *money.New(amount, "EUR"), Timestamp: timestamp}
+}
+
+func Test_Timeline_CarriesLastValueForward(t *testing.T) {
+	calculator := NewCalculator(fakeSource{
+		account(false, snapshot(1000, day(time.January, 1).Add(9*time.Hour)), snapshot(3000, day(time.January, 3).Add(9*time.Hour))),
+	}, nil)
+
+	points := calculator.Timeline(userID, Daily, day(time.January, 1), day(time.January, 4))
+
+	expected := []int64{1000, 1000, 3000, 3000}
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %d points, got %d", len(expected), len(points))
+	}
+	for i, amount := range expected {
+		if points[i].NetWorth[0].Amount() != amount {
+			t.Errorf("Point %d is %d, expected %d", i, points[i].NetWorth[0].Amount(), amount)
+		}
+	}
+}
+
+func Test_Timeline_SplitsJointAccounts(t *testing.T) {
+	joint := account(true, snapshot(10000, day(time.January, 1)))
+	configured := account(true, snapshot(10000, day(time.January, 1)))
+	configured.Shares[partnerID] = 70
+
+	calculator := NewCalculator(fakeSource{joint, configured}, nil)
+
+	points := calculator.Timeline(userID, Daily, day(time.January, 1), day(time.January, 1))
+
+	if len(points) != 1 || points[0].NetWorth[0].Amount() != 8000 {
+		t.Errorf("Expected a net worth of 5000 + 3000, got %+v", points)
+	}
+}
+
+func Test_Timeline_MonthlyPointsAtEndOfMonth(t *testing.T) {
+	calculator := NewCalculator(fakeSource{
+		account(false, snapshot(1000, day(time.January, 10)), snapshot(2000, day(time.February, 10))),
+	}, nil)
+
+	points := calculator.Timeline(userID, Monthly, time.Time{}, day(time.March, 15))
+
+	expectedDates := []time.Time{day(time.January, 31), day(time.February, 29), day(time.March, 15)}
+	if len(points) != len(expectedDates) {
+		t.Fatalf("Expected %d points, got %d", len(expectedDates), len(points))
+	}
+	for i, date := range expectedDates {
+		if !points[i].Date.Equal(date) {
+			t.Errorf("Point %d is on %s, expected %s", i, points[i].Date, date)
+		}
+	}
+	if points[2].NetWorth[0].Amount() != 2000 {
+		t.Errorf("Expected last balance to be carried forward")
+	}
+}
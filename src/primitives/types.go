@@ -30,12 +30,17 @@ func (userID UserID) String() string {
 	return uuid.UUID(userID).String()
 }
 
-// Institution enum
+// Institution a bank the accounts of a user are at, connectors can register institutions besides the known ones
 type Institution string
 
 // Institution enum
 const (
-	Bunq Institution = "Bunq"
+	Bunq     Institution = "Bunq"
+	ING      Institution = "ING"
+	ABNAmro  Institution = "ABNAmro"
+	Rabobank Institution = "Rabobank"
+	// OtherInstitution is an institution that is not supported by name, for instance a bank of an imported statement
+	OtherInstitution Institution = "Other"
 )
 
 type TransactionID uuid.UUID
@@ -51,12 +56,48 @@ func (monetaryAccountID MonetaryAccountID) String() string {
 	return uuid.UUID(monetaryAccountID).String()
 }
 
+// Category is a user defined name for a kind of spending or income
+type Category string
+
+// Uncategorised is the category of transactions no rule applies to
+const Uncategorised Category = "Uncategorised"
+
+type CategorisationRuleID uuid.UUID
+
+func (categorisationRuleID CategorisationRuleID) String() string {
+	return uuid.UUID(categorisationRuleID).String()
+}
+
+type BudgetID uuid.UUID
+
+func (budgetID BudgetID) String() string {
+	return uuid.UUID(budgetID).String()
+}
+
+type TransferID uuid.UUID
+
+func (transferID TransferID) String() string {
+	return uuid.UUID(transferID).String()
+}
+
 type SyncID uuid.UUID
 
 func (syncID SyncID) String() string {
 	return uuid.UUID(syncID).String()
 }
 
+type HouseholdID uuid.UUID
+
+func (householdID HouseholdID) String() string {
+	return uuid.UUID(householdID).String()
+}
+
+type InvitationID uuid.UUID
+
+func (invitationID InvitationID) String() string {
+	return uuid.UUID(invitationID).String()
+}
+
 type MoneyForCommand struct {
 	Amount       int64
 	CurrencyCode string
@@ -69,3 +110,36 @@ func (m MoneyForCommand) ToMoney() money.Money {
 func NewMoneyForCommand(m money.Money) MoneyForCommand {
 	return MoneyForCommand{Amount: m.Amount(), CurrencyCode: m.Currency().Code}
 }
+
+// ValidationError rejects a command, Field is the field of the command that is invalid
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// NewValidationError constructs a ValidationError
+func NewValidationError(field string, message string) ValidationError {
+	return ValidationError{Field: field, Message: message}
+}
+
+func (err ValidationError) Error() string {
+	return "invalid " + err.Field + ": " + err.Message
+}
+
+type ExpenseID uuid.UUID
+
+func (expenseID ExpenseID) String() string {
+	return uuid.UUID(expenseID).String()
+}
+
+type SettlementID uuid.UUID
+
+func (settlementID SettlementID) String() string {
+	return uuid.UUID(settlementID).String()
+}
+
+type GoalID uuid.UUID
+
+func (goalID GoalID) String() string {
+	return uuid.UUID(goalID).String()
+}
@@ -0,0 +1,297 @@
+package psd2connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// ConsentStatus the status of an account information consent, as defined by the Berlin Group
+type ConsentStatus string
+
+// ConsentStatus enum
+const (
+	ConsentReceived        ConsentStatus = "received"
+	ConsentRejected        ConsentStatus = "rejected"
+	ConsentValid           ConsentStatus = "valid"
+	ConsentRevokedByPSU    ConsentStatus = "revokedByPsu"
+	ConsentExpired         ConsentStatus = "expired"
+	ConsentTerminatedByTPP ConsentStatus = "terminatedByTpp"
+)
+
+// usable tells whether accounts can still be read with the consent, or whether it is waiting for the user to authorise it
+func (status ConsentStatus) usable() bool {
+	return status == ConsentValid
+}
+
+// final tells whether the consent can never become valid again
+func (status ConsentStatus) final() bool {
+	switch status {
+	case ConsentRejected, ConsentRevokedByPSU, ConsentExpired, ConsentTerminatedByTPP:
+		return true
+	}
+	return false
+}
+
+// BookingStatus whether a transaction is booked on the account or still pending
+type BookingStatus string
+
+// BookingStatus enum
+const (
+	Booked  BookingStatus = "booked"
+	Pending BookingStatus = "pending"
+)
+
+type psd2API interface {
+	createConsent(ctx context.Context, validUntil time.Time, redirectURL string) (createdConsent, error)
+	fetchConsentStatus(ctx context.Context, consentID string) (ConsentStatus, error)
+	fetchAccounts(ctx context.Context, consentID string) ([]apiAccount, error)
+	fetchBalances(ctx context.Context, consentID string, resourceID string) ([]apiBalance, error)
+	fetchTransactions(ctx context.Context, consentID string, resourceID string, from time.Time) ([]apiTransaction, error)
+}
+
+// berlinGroupAPI is a client of the account information service of the NextGenPSD2 framework of the Berlin Group
+type berlinGroupAPI struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newBerlinGroupAPI(baseURL string, client *http.Client) berlinGroupAPI {
+	return berlinGroupAPI{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+type amount struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+func (a amount) toMoney() (money.Money, error) {
+	currency := money.GetCurrency(a.Currency)
+	if currency == nil {
+		return money.Money{}, fmt.Errorf("unknown currency %s", a.Currency)
+	}
+
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(a.Amount), 64)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("invalid amount %s", a.Amount)
+	}
+	return *money.New(int64(math.Round(parsed*math.Pow10(currency.Fraction))), currency.Code), nil
+}
+
+type accountReference struct {
+	IBAN string `json:"iban,omitempty"`
+}
+
+type href struct {
+	Href string `json:"href"`
+}
+
+type createdConsent struct {
+	ConsentID     string        `json:"consentId"`
+	ConsentStatus ConsentStatus `json:"consentStatus"`
+	Links         struct {
+		SCARedirect *href `json:"scaRedirect"`
+	} `json:"_links"`
+}
+
+type apiAccount struct {
+	ResourceID string `json:"resourceId"`
+	IBAN       string `json:"iban"`
+	Currency   string `json:"currency"`
+	Name       string `json:"name"`
+	Product    string `json:"product"`
+	OwnerName  string `json:"ownerName"`
+}
+
+type apiBalance struct {
+	BalanceAmount amount `json:"balanceAmount"`
+	BalanceType   string `json:"balanceType"`
+	ReferenceDate string `json:"referenceDate"`
+}
+
+type apiTransaction struct {
+	TransactionID                     string           `json:"transactionId"`
+	EntryReference                    string           `json:"entryReference"`
+	BookingDate                       string           `json:"bookingDate"`
+	ValueDate                         string           `json:"valueDate"`
+	TransactionAmount                 amount           `json:"transactionAmount"`
+	CreditorName                      string           `json:"creditorName"`
+	CreditorAccount                   accountReference `json:"creditorAccount"`
+	DebtorName                        string           `json:"debtorName"`
+	DebtorAccount                     accountReference `json:"debtorAccount"`
+	RemittanceInformationUnstructured string           `json:"remittanceInformationUnstructured"`
+	BookingStatus                     BookingStatus    `json:"-"`
+}
+
+func (api berlinGroupAPI) createConsent(ctx context.Context, validUntil time.Time, redirectURL string) (createdConsent, error) {
+	body := map[string]interface{}{
+		"access":                   map[string]string{"availableAccounts": "allAccounts", "allPsd2": "allAccounts"},
+		"recurringIndicator":       true,
+		"validUntil":               validUntil.Format(dateLayout),
+		"frequencyPerDay":          4,
+		"combinedServiceIndicator": false,
+	}
+
+	var res createdConsent
+	headers := map[string]string{"TPP-Redirect-URI": redirectURL}
+	if err := api.do(ctx, http.MethodPost, "/v1/consents", headers, body, &res); err != nil {
+		return createdConsent{}, fmt.Errorf("could not create consent: %w", err)
+	}
+	return res, nil
+}
+
+func (api berlinGroupAPI) fetchConsentStatus(ctx context.Context, consentID string) (ConsentStatus, error) {
+	var res struct {
+		ConsentStatus ConsentStatus `json:"consentStatus"`
+	}
+	if err := api.do(ctx, http.MethodGet, "/v1/consents/"+url.PathEscape(consentID)+"/status", nil, nil, &res); err != nil {
+		return "", fmt.Errorf("could not fetch status of consent %s: %w", consentID, err)
+	}
+	return res.ConsentStatus, nil
+}
+
+func (api berlinGroupAPI) fetchAccounts(ctx context.Context, consentID string) ([]apiAccount, error) {
+	var res struct {
+		Accounts []apiAccount `json:"accounts"`
+	}
+	if err := api.do(ctx, http.MethodGet, "/v1/accounts", consentHeaders(consentID), nil, &res); err != nil {
+		return nil, fmt.Errorf("could not fetch accounts: %w", err)
+	}
+	return res.Accounts, nil
+}
+
+func (api berlinGroupAPI) fetchBalances(ctx context.Context, consentID string, resourceID string) ([]apiBalance, error) {
+	var res struct {
+		Balances []apiBalance `json:"balances"`
+	}
+	if err := api.do(ctx, http.MethodGet, "/v1/accounts/"+url.PathEscape(resourceID)+"/balances", consentHeaders(consentID), nil, &res); err != nil {
+		return nil, fmt.Errorf("could not fetch balances of account %s: %w", resourceID, err)
+	}
+	return res.Balances, nil
+}
+
+// fetchTransactions fetches the booked and pending transactions from the date on, following the pages the ASPSP links to
+func (api berlinGroupAPI) fetchTransactions(ctx context.Context, consentID string, resourceID string, from time.Time) ([]apiTransaction, error) {
+	query := url.Values{}
+	query.Set("bookingStatus", "both")
+	query.Set("dateFrom", from.Format(dateLayout))
+	path := "/v1/accounts/" + url.PathEscape(resourceID) + "/transactions?" + query.Encode()
+
+	var transactions []apiTransaction
+	for path != "" {
+		var res struct {
+			Transactions struct {
+				Booked  []apiTransaction `json:"booked"`
+				Pending []apiTransaction `json:"pending"`
+				Links   struct {
+					Next *href `json:"next"`
+				} `json:"_links"`
+			} `json:"transactions"`
+		}
+		if err := api.do(ctx, http.MethodGet, path, consentHeaders(consentID), nil, &res); err != nil {
+			return nil, fmt.Errorf("could not fetch transactions of account %s: %w", resourceID, err)
+		}
+
+		for _, transaction := range res.Transactions.Booked {
+			transaction.BookingStatus = Booked
+			transactions = append(transactions, transaction)
+		}
+		for _, transaction := range res.Transactions.Pending {
+			transaction.BookingStatus = Pending
+			transactions = append(transactions, transaction)
+		}
+
+		path = ""
+		if res.Transactions.Links.Next != nil {
+			path = strings.TrimPrefix(res.Transactions.Links.Next.Href, api.baseURL)
+		}
+	}
+	return transactions, nil
+}
+
+func consentHeaders(consentID string) map[string]string {
+	return map[string]string{"Consent-ID": consentID}
+}
+
+func (api berlinGroupAPI) do(ctx context.Context, method string, path string, headers map[string]string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, api.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-ID", uuid.New().String())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, res.StatusCode, content)
+	}
+	return json.Unmarshal(content, out)
+}
+
+// bookedBalance is the balance of the account with only booked transactions, preferring the most recent one
+func bookedBalance(balances []apiBalance) (money.Money, error) {
+	for _, balanceType := range []string{"interimBooked", "closingBooked", "expected", "interimAvailable"} {
+		for _, balance := range balances {
+			if balance.BalanceType == balanceType {
+				return balance.BalanceAmount.toMoney()
+			}
+		}
+	}
+	return money.Money{}, fmt.Errorf("no booked balance")
+}
+
+func parseOptionalIBAN(reference accountReference) *iban.IBAN {
+	if reference.IBAN == "" {
+		return nil
+	}
+	res, err := iban.NewIBAN(reference.IBAN)
+	if err != nil {
+		return nil
+	}
+	return res
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,116 @@
+package psd2connector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"sort"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+func (account apiAccount) mapToDocument(userID primitives.UserID, institution primitives.Institution, accountIBAN iban.IBAN, balance money.Money, fetchTimestamp time.Time) bus.MonetaryAccountDocument {
+	alias := account.Name
+	if alias == "" {
+		alias = account.Product
+	}
+	if alias == "" {
+		alias = accountIBAN.PrintCode
+	}
+
+	return bus.MonetaryAccountDocument{
+		Iban:                accountIBAN,
+		Joint:               false,
+		OwnerUserID:         userID,
+		Alias:               alias,
+		Institution:         institution,
+		InstitutionEntityID: account.ResourceID,
+		Balance:             balance,
+		FetchTimestamp:      fetchTimestamp,
+	}
+}
+
+type bookedTransaction struct {
+	apiTransaction
+	amount      money.Money
+	bookingDate time.Time
+}
+
+// bookedTransactionsOf maps the booked transactions, oldest first. Pending transactions are left out,
+// they are published once they are booked as an ASPSP does not have to keep their id or amount
+func bookedTransactionsOf(transactions []apiTransaction) ([]bookedTransaction, error) {
+	var res []bookedTransaction
+	for _, transaction := range transactions {
+		if transaction.BookingStatus != Booked {
+			continue
+		}
+
+		amount, err := transaction.TransactionAmount.toMoney()
+		if err != nil {
+			return nil, err
+		}
+		date := transaction.BookingDate
+		if date == "" {
+			date = transaction.ValueDate
+		}
+		bookingDate, err := time.Parse(dateLayout, date)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, bookedTransaction{apiTransaction: transaction, amount: amount, bookingDate: bookingDate})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].bookingDate.Before(res[j].bookingDate)
+	})
+	return res, nil
+}
+
+// balancesAfter works back from the current booked balance, as not every ASPSP reports the balance after a transaction
+func balancesAfter(transactions []bookedTransaction, balance money.Money) []money.Money {
+	res := make([]money.Money, len(transactions))
+	current := balance.Amount()
+	for i := len(transactions) - 1; i >= 0; i-- {
+		res[i] = *money.New(current, balance.Currency().Code)
+		if transactions[i].amount.Currency().Code == balance.Currency().Code {
+			current -= transactions[i].amount.Amount()
+		}
+	}
+	return res
+}
+
+func (transaction bookedTransaction) mapToDocument(accountIBAN iban.IBAN, institution primitives.Institution, balanceAfterMutation money.Money, fetchTimestamp time.Time) bus.TransactionDocument {
+	ownIBAN := accountIBAN
+	reference := transaction.TransactionID
+	if reference == "" {
+		reference = transaction.EntryReference
+	}
+
+	document := bus.TransactionDocument{
+		Amount:               transaction.amount,
+		Description:          transaction.RemittanceInformationUnstructured,
+		BalanceAfterMutation: balanceAfterMutation,
+		TransactionDate:      transaction.bookingDate,
+		FetchTimestamp:       fetchTimestamp,
+	}
+
+	if transaction.amount.IsPositive() {
+		document.FromName = optionalString(transaction.DebtorName)
+		document.FromIBAN = parseOptionalIBAN(transaction.DebtorAccount)
+
+		document.ToIBAN = &ownIBAN
+		document.ToInstition = &institution
+		document.ToInstitionEntityID = &reference
+	} else {
+		document.FromIBAN = &ownIBAN
+		document.FromInstition = &institution
+		document.FromInstitutionEntityID = &reference
+
+		document.ToName = optionalString(transaction.CreditorName)
+		document.ToIBAN = parseOptionalIBAN(transaction.CreditorAccount)
+	}
+
+	return document
+}
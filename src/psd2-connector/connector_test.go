@@ -0,0 +1,190 @@
+package psd2connector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChannels struct {
+	updates      chan bus.Update
+	accounts     chan bus.MonetaryAccountDocument
+	transactions chan bus.TransactionDocument
+}
+
+func newFakeChannels() fakeChannels {
+	return fakeChannels{
+		updates:      make(chan bus.Update, 10),
+		accounts:     make(chan bus.MonetaryAccountDocument, 10),
+		transactions: make(chan bus.TransactionDocument, 10),
+	}
+}
+
+func (c fakeChannels) PublishUpdate(ctx context.Context, update bus.Update) error {
+	c.updates <- update
+	return nil
+}
+
+func (c fakeChannels) PublishAccount(ctx context.Context, document bus.MonetaryAccountDocument) error {
+	c.accounts <- document
+	return nil
+}
+
+func (c fakeChannels) PublishTransaction(ctx context.Context, document bus.TransactionDocument) error {
+	c.transactions <- document
+	return nil
+}
+
+func (c fakeChannels) PublishSchedule(ctx context.Context, document bus.ScheduleDocument) error {
+	return nil
+}
+
+func (c fakeChannels) PublishDirectDebit(ctx context.Context, document bus.DirectDebitTransactionDocument) error {
+	return nil
+}
+
+// mockASPSP serves a single account with two pages of transactions
+func mockASPSP(t *testing.T, consentStatus ConsentStatus) *httptest.Server {
+	var server *httptest.Server
+	respond := func(res http.ResponseWriter, body string) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(body))
+	}
+	requireConsent := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(res http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Consent-ID") != "consent-1" || req.Header.Get("X-Request-ID") == "" {
+				http.Error(res, `{"tppMessages":[{"code":"CONSENT_UNKNOWN"}]}`, http.StatusUnauthorized)
+				return
+			}
+			handler(res, req)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/consents", func(res http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, true, body["recurringIndicator"])
+		assert.Equal(t, "https://example.com/consented", req.Header.Get("TPP-Redirect-URI"))
+		res.WriteHeader(http.StatusCreated)
+		respond(res, `{"consentStatus":"received","consentId":"consent-1","_links":{"scaRedirect":{"href":"https://aspsp.example.com/authorise/consent-1"}}}`)
+	})
+	mux.HandleFunc("/v1/consents/consent-1/status", func(res http.ResponseWriter, req *http.Request) {
+		respond(res, `{"consentStatus":"`+string(consentStatus)+`"}`)
+	})
+	mux.HandleFunc("/v1/accounts", requireConsent(func(res http.ResponseWriter, req *http.Request) {
+		respond(res, `{"accounts":[{"resourceId":"acc-1","iban":"NL91ABNA0417164300","currency":"EUR","name":"Main account"}]}`)
+	}))
+	mux.HandleFunc("/v1/accounts/acc-1/balances", requireConsent(func(res http.ResponseWriter, req *http.Request) {
+		respond(res, `{"balances":[
+			{"balanceAmount":{"currency":"EUR","amount":"1200.00"},"balanceType":"interimAvailable"},
+			{"balanceAmount":{"currency":"EUR","amount":"1000.00"},"balanceType":"closingBooked","referenceDate":"2020-01-31"}]}`)
+	}))
+	mux.HandleFunc("/v1/accounts/acc-1/transactions", requireConsent(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "2" {
+			respond(res, `{"transactions":{"booked":[
+				{"transactionId":"tx-1","bookingDate":"2020-01-02","transactionAmount":{"currency":"EUR","amount":"2500.00"},
+				 "debtorName":"Employer","debtorAccount":{"iban":"NL39RABO0300065264"},"remittanceInformationUnstructured":"Salary"}]}}`)
+			return
+		}
+		assert.Equal(t, "both", req.URL.Query().Get("bookingStatus"))
+		respond(res, `{"transactions":{
+			"booked":[{"transactionId":"tx-2","bookingDate":"2020-01-20","transactionAmount":{"currency":"EUR","amount":"-1500.00"},
+				"creditorName":"Landlord","creditorAccount":{"iban":"NL02ABNA0123456789"},"remittanceInformationUnstructured":"Rent"}],
+			"pending":[{"transactionAmount":{"currency":"EUR","amount":"-12.50"},"creditorName":"Bakery","valueDate":"2020-02-01"}],
+			"_links":{"next":{"href":"`+server.URL+`/v1/accounts/acc-1/transactions?page=2"}}}}`)
+	}))
+
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func newTestCommand(server *httptest.Server, channels fakeChannels) StartUserRefreshCommand {
+	return NewStartUserRefreshCommand(context.Background(), ASPSP{
+		Institution: primitives.ABNAmro,
+		BaseURL:     server.URL,
+		RedirectURL: "https://example.com/consented",
+	}, channels)
+}
+
+func Test_Refresh_PublishesBookedTransactionsOfConsentedAccounts(t *testing.T) {
+	server := mockASPSP(t, ConsentValid)
+	defer server.Close()
+	channels := newFakeChannels()
+	cmd := newTestCommand(server, channels)
+	userID := primitives.UserID(uuid.New())
+
+	redirect, err := cmd.Connect(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://aspsp.example.com/authorise/consent-1", redirect)
+
+	cmd.Refresh(userID)
+
+	assert.Len(t, channels.updates, 2)
+	start := (<-channels.updates).(bus.StartRefreshUpdate)
+	assert.Equal(t, "acc-1", start.InstitutionEntityID)
+	done := (<-channels.updates).(bus.DoneRefreshingUpdate)
+	assert.Equal(t, start.SyncID, done.SyncID)
+	assert.Equal(t, 3, done.Documents)
+	assert.Empty(t, done.Errors)
+
+	account := <-channels.accounts
+	assert.Equal(t, userID, account.OwnerUserID)
+	assert.Equal(t, primitives.ABNAmro, account.Institution)
+	assert.Equal(t, "Main account", account.Alias)
+	assert.Equal(t, int64(100000), account.Balance.Amount())
+
+	assert.Len(t, channels.transactions, 2)
+	salary := <-channels.transactions
+	assert.Equal(t, "Salary", salary.Description)
+	assert.Equal(t, "Employer", *salary.FromName)
+	assert.Equal(t, "NL91ABNA0417164300", salary.ToIBAN.Code)
+	assert.Equal(t, int64(250000), salary.BalanceAfterMutation.Amount())
+	assert.Equal(t, time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC), salary.TransactionDate)
+
+	rent := <-channels.transactions
+	assert.Equal(t, int64(-150000), rent.Amount.Amount())
+	assert.Equal(t, "tx-2", *rent.FromInstitutionEntityID)
+	assert.Equal(t, "Landlord", *rent.ToName)
+	assert.Equal(t, int64(100000), rent.BalanceAfterMutation.Amount())
+}
+
+func Test_Refresh_WaitsForConsentToBeAuthorised(t *testing.T) {
+	server := mockASPSP(t, ConsentReceived)
+	defer server.Close()
+	channels := newFakeChannels()
+	cmd := newTestCommand(server, channels)
+	userID := primitives.UserID(uuid.New())
+
+	_, err := cmd.Connect(userID)
+	assert.NoError(t, err)
+	cmd.Refresh(userID)
+
+	assert.Len(t, channels.accounts, 0)
+	consents, _ := cmd.consentRepository.fetchConsentsForUser(userID)
+	assert.Len(t, consents, 1)
+}
+
+func Test_Refresh_RemovesRevokedConsent(t *testing.T) {
+	server := mockASPSP(t, ConsentRevokedByPSU)
+	defer server.Close()
+	channels := newFakeChannels()
+	cmd := newTestCommand(server, channels)
+	userID := primitives.UserID(uuid.New())
+
+	_, err := cmd.Connect(userID)
+	assert.NoError(t, err)
+	cmd.Refresh(userID)
+
+	assert.Len(t, channels.accounts, 0)
+	consents, _ := cmd.consentRepository.fetchConsentsForUser(userID)
+	assert.Len(t, consents, 0)
+}
@@ -0,0 +1,99 @@
+package psd2connector
+
+import (
+	"app/primitives"
+	"sync"
+	"time"
+)
+
+type consent struct {
+	id         string
+	userID     primitives.UserID
+	validUntil time.Time
+}
+
+type consentRepository interface {
+	fetchConsentsForUser(userID primitives.UserID) ([]consent, error)
+	saveConsent(consent consent) error
+	deleteConsent(userID primitives.UserID, id string) error
+}
+
+type inMemoryConsentRepository struct {
+	mu       *sync.Mutex
+	consents map[primitives.UserID]map[string]consent
+}
+
+func newInMemoryConsentRepository() inMemoryConsentRepository {
+	return inMemoryConsentRepository{
+		mu:       new(sync.Mutex),
+		consents: make(map[primitives.UserID]map[string]consent),
+	}
+}
+
+func (repo inMemoryConsentRepository) fetchConsentsForUser(userID primitives.UserID) ([]consent, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var res []consent
+	for _, consent := range repo.consents[userID] {
+		res = append(res, consent)
+	}
+	return res, nil
+}
+
+func (repo inMemoryConsentRepository) saveConsent(saved consent) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	consents, ok := repo.consents[saved.userID]
+	if !ok {
+		consents = make(map[string]consent)
+		repo.consents[saved.userID] = consents
+	}
+	consents[saved.id] = saved
+	return nil
+}
+
+func (repo inMemoryConsentRepository) deleteConsent(userID primitives.UserID, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.consents[userID], id)
+	return nil
+}
+
+type refreshTimestampRepository interface {
+	fetchLastRefreshFor(resourceID string) (*time.Time, error)
+	saveLastRefresh(resourceID string, lastRefresh time.Time) error
+}
+
+type inMemoryRefreshTimestampRepository struct {
+	mu         *sync.Mutex
+	timestamps map[string]time.Time
+}
+
+func newInMemoryRefreshTimestampRepository() inMemoryRefreshTimestampRepository {
+	return inMemoryRefreshTimestampRepository{
+		mu:         new(sync.Mutex),
+		timestamps: make(map[string]time.Time),
+	}
+}
+
+func (repo inMemoryRefreshTimestampRepository) fetchLastRefreshFor(resourceID string) (*time.Time, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	lastRefresh, ok := repo.timestamps[resourceID]
+	if !ok {
+		return nil, nil
+	}
+	return &lastRefresh, nil
+}
+
+func (repo inMemoryRefreshTimestampRepository) saveLastRefresh(resourceID string, lastRefresh time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.timestamps[resourceID] = lastRefresh
+	return nil
+}
@@ -0,0 +1,45 @@
+package psd2connector
+
+import (
+	"app/primitives"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type connectionRepository interface {
+	Connect(userID primitives.UserID, institution primitives.Institution)
+}
+
+func connectHandler(cmd StartUserRefreshCommand, connections connectionRepository) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, err := uuid.Parse(req.URL.Query().Get("userId"))
+		if err != nil {
+			http.Error(res, "userId should be a valid uuid", http.StatusBadRequest)
+			return
+		}
+
+		redirect, err := cmd.Connect(primitives.UserID(userID))
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadGateway)
+			return
+		}
+		connections.Connect(primitives.UserID(userID), cmd.Institution())
+
+		res.Header().Add("Access-Control-Expose-Headers", "Location")
+		res.Header().Add("Location", redirect)
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RegisterConsentController will register a http controller that lets a user consent to reading their accounts at the ASPSP,
+// the user is connected to the institution so its refreshes include the ASPSP
+func RegisterConsentController(cmd StartUserRefreshCommand, connections connectionRepository) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix(fmt.Sprintf("/psd2/%s", cmd.aspsp.Institution)).Subrouter()
+		controller.Methods("POST").Path("/connect").HandlerFunc(connectHandler(cmd, connections))
+		return nil
+	}
+}
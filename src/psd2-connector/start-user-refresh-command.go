@@ -0,0 +1,213 @@
+package psd2connector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+// consentValidity is how long a consent is asked for, the maximum the Berlin Group allows
+const consentValidity = 90 * 24 * time.Hour
+
+// ASPSP the bank a connector reads accounts from through its NextGenPSD2 interface
+type ASPSP struct {
+	Institution primitives.Institution
+	BaseURL     string
+	RedirectURL string
+}
+
+// StartUserRefreshCommand allows the caller to refresh the accounts and transactions of the users that consented to it at an ASPSP
+type StartUserRefreshCommand struct {
+	aspsp                      ASPSP
+	api                        psd2API
+	consentRepository          consentRepository
+	refreshTimestampRepository refreshTimestampRepository
+	publisher                  bus.Publisher
+	context                    context.Context
+}
+
+// NewStartUserRefreshCommand creates a new StartUserRefreshCommand for the ASPSP, that publishes on the bus
+func NewStartUserRefreshCommand(ctx context.Context, aspsp ASPSP, publisher bus.Publisher) StartUserRefreshCommand {
+	cmd := new(StartUserRefreshCommand)
+	cmd.aspsp = aspsp
+	cmd.api = newBerlinGroupAPI(aspsp.BaseURL, &http.Client{Timeout: 30 * time.Second})
+	cmd.consentRepository = newInMemoryConsentRepository()
+	cmd.refreshTimestampRepository = newInMemoryRefreshTimestampRepository()
+	cmd.publisher = publisher
+	cmd.context = ctx
+	return *cmd
+}
+
+// Institution is the institution of the ASPSP
+func (cmd StartUserRefreshCommand) Institution() primitives.Institution {
+	return cmd.aspsp.Institution
+}
+
+// Connect asks the ASPSP for a consent to read the accounts of the user, the user has to authorise it at the returned url
+func (cmd StartUserRefreshCommand) Connect(userID primitives.UserID) (string, error) {
+	validUntil := time.Now().Add(consentValidity)
+	created, err := cmd.api.createConsent(cmd.context, validUntil, cmd.aspsp.RedirectURL)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cmd.consentRepository.saveConsent(consent{id: created.ConsentID, userID: userID, validUntil: validUntil}); err != nil {
+		return "", fmt.Errorf("could not save consent: %w", err)
+	}
+
+	if created.Links.SCARedirect == nil {
+		return "", nil
+	}
+	return created.Links.SCARedirect.Href, nil
+}
+
+// Refresh starts the refresh of the given user
+func (cmd StartUserRefreshCommand) Refresh(userID primitives.UserID) {
+	consents, err := cmd.consentRepository.fetchConsentsForUser(userID)
+	if err != nil {
+		log.Printf("Could not fetch consents of user %s: %v", userID, err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, userConsent := range consents {
+		wg.Add(1)
+		go func(userConsent consent) {
+			defer wg.Done()
+			cmd.refreshConsent(userID, userConsent)
+		}(userConsent)
+	}
+	wg.Wait()
+}
+
+func (cmd StartUserRefreshCommand) refreshConsent(userID primitives.UserID, consent consent) {
+	status, err := cmd.api.fetchConsentStatus(cmd.context, consent.id)
+	if err != nil {
+		log.Printf("Could not refresh consent %s of user %s: %v", consent.id, userID, err)
+		return
+	}
+
+	if status.final() {
+		log.Printf("Removing consent %s for user %s, status: %s", consent.id, userID, status)
+		if err := cmd.consentRepository.deleteConsent(userID, consent.id); err != nil {
+			log.Printf("Could not remove consent, err: %v", err)
+		}
+		return
+	}
+	if !status.usable() {
+		return
+	}
+
+	accounts, err := cmd.api.fetchAccounts(cmd.context, consent.id)
+	if err != nil {
+		log.Printf("Could not refresh consent %s of user %s: %v", consent.id, userID, err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(account apiAccount) {
+			defer wg.Done()
+			if err := cmd.syncAccount(userID, consent.id, account); err != nil {
+				log.Printf("Could not sync account %s of user %s: %v", account.ResourceID, userID, err)
+			}
+		}(account)
+	}
+	wg.Wait()
+}
+
+func (cmd StartUserRefreshCommand) syncAccount(userID primitives.UserID, consentID string, account apiAccount) error {
+	startUpdate := bus.StartRefreshUpdate{
+		UserID:              userID,
+		InstitutionEntityID: account.ResourceID,
+		SyncID:              primitives.SyncID(uuid.New()),
+		Started:             time.Now(),
+	}
+	refresh, err := bus.StartRefresh(cmd.context, cmd.publisher, startUpdate)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.publishAccount(refresh, userID, consentID, account)
+	if doneErr := refresh.Done(cmd.context, err); doneErr != nil {
+		log.Printf("Could not publish on bus: %v", doneErr)
+	}
+	return err
+}
+
+func (cmd StartUserRefreshCommand) publishAccount(refresh *bus.RefreshPublisher, userID primitives.UserID, consentID string, account apiAccount) error {
+	accountIBAN, err := iban.NewIBAN(account.IBAN)
+	if err != nil {
+		return err
+	}
+
+	lastRefresh, err := cmd.refreshTimestampRepository.fetchLastRefreshFor(account.ResourceID)
+	if err != nil {
+		log.Printf("Could not fetch last refresh of account %s: %v", account.ResourceID, err)
+	}
+	from := time.Unix(0, 0)
+	if lastRefresh != nil {
+		from = *lastRefresh
+	}
+
+	balances, err := cmd.api.fetchBalances(cmd.context, consentID, account.ResourceID)
+	if err != nil {
+		return err
+	}
+	balance, err := bookedBalance(balances)
+	if err != nil {
+		return err
+	}
+
+	transactions, err := cmd.api.fetchTransactions(cmd.context, consentID, account.ResourceID, from)
+	if err != nil {
+		return err
+	}
+	booked, err := bookedTransactionsOf(transactions)
+	if err != nil {
+		return err
+	}
+
+	started := refresh.Started().Started
+	if err := refresh.PublishAccount(cmd.context, account.mapToDocument(userID, cmd.aspsp.Institution, *accountIBAN, balance, started)); err != nil {
+		return err
+	}
+
+	balancesAfter := balancesAfter(booked, balance)
+	for i, transaction := range booked {
+		if err := refresh.PublishTransaction(cmd.context, transaction.mapToDocument(*accountIBAN, cmd.aspsp.Institution, balancesAfter[i], started)); err != nil {
+			return err
+		}
+	}
+
+	return cmd.refreshTimestampRepository.saveLastRefresh(account.ResourceID, started)
+}
+
+// HealthCheck tells whether the ASPSP can be reached
+func (cmd StartUserRefreshCommand) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, cmd.aspsp.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("%s can not be reached: %w", cmd.aspsp.Institution, err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s responded with status %d", cmd.aspsp.Institution, res.StatusCode)
+	}
+	return nil
+}
@@ -21,20 +21,29 @@ type DocumentsFromBusConsumer struct {
 	handler                               eh.CommandHandler
 	recurringTransactionIDFetcher         RecurringTransactionIDFetcher
 	recurringTransactionInstanceIDFetcher RecurringTransactionInstanceIDFetcher
+	directDebitChannel                    <-chan bus.DirectDebitTransactionDocument
+	scheduleChannel                       <-chan bus.ScheduleDocument
 }
 
+// NewDocumentsFromBusConsumer subscribes to the direct debits and schedules on the bus, Start consumes them until the bus is closed
 func NewDocumentsFromBusConsumer(
 	ctx context.Context,
 	handler eh.CommandHandler,
+	subscriber bus.Subscriber,
 ) DocumentsConsumer {
-	return DocumentsFromBusConsumer{context: ctx, handler: handler}
+	return DocumentsFromBusConsumer{
+		context:            ctx,
+		handler:            handler,
+		directDebitChannel: subscriber.SubscribeDirectDebits(bus.DefaultSubscriptionOptions),
+		scheduleChannel:    subscriber.SubscribeSchedules(bus.DefaultSubscriptionOptions),
+	}
 }
 
 func (consumer DocumentsFromBusConsumer) Start() {
 	wg := sync.WaitGroup{}
 
-	directDebitChannel := bus.DirectDebitChannelForReading()
-	scheduleChannel := bus.ScheduleChannelForReading()
+	directDebitChannel := consumer.directDebitChannel
+	scheduleChannel := consumer.scheduleChannel
 
 	for {
 		select {
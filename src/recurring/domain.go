@@ -22,6 +22,10 @@ type Status string
 const (
 	Active Status = "Active"
 	Ended  Status = "Ended"
+	// Cancelled is a recurring transaction the user cancelled, it is not expected anymore
+	Cancelled Status = "Cancelled"
+	// Ignored is a recurring transaction the user does not want to see, it might still occur
+	Ignored Status = "Ignored"
 )
 
 // TransactionParty party of a transaction
@@ -75,6 +79,11 @@ type RecurringTransactionCommand interface {
 	applyTo(state *recurringTransactionState) ([]RecurringTransactionEvent, []scheduledRecurringTransactionCommand)
 }
 
+// validatedCommand is a command that is rejected when it does not apply to the state, instead of being ignored
+type validatedCommand interface {
+	validate(state *recurringTransactionState) error
+}
+
 type scheduledRecurringTransactionCommand struct {
 	RecurringTransactionCommand
 	Identifier string
@@ -116,7 +125,7 @@ func (cmd ProcessScheduleCommand) applyTo(state *recurringTransactionState) ([]R
 		events = append(events, newRecurringTransactionStartDateChanged(state.ID, cmd.StartDate))
 	}
 
-	if cmd.endsAfter(time.Now()) && state.status != Ended {
+	if cmd.endsAfter(time.Now()) && state.status == Active {
 		events = append(events, newRecurringTransactionEnded(state.ID))
 	} else if !cmd.endsAfter(time.Now()) && state.status == Ended {
 		events = append(events, newRecurringTransactionReopened(state.ID))
@@ -247,6 +256,45 @@ func (cmd RecheckStatusCommand) applyTo(state *recurringTransactionState) ([]Rec
 	return events, nil
 }
 
+// CancelRecurringTransactionCommand marks a recurring transaction as cancelled by the user
+type CancelRecurringTransactionCommand struct {
+	RecurringTransactionID primitives.RecurringTransactionID
+}
+
+func (cmd CancelRecurringTransactionCommand) validate(state *recurringTransactionState) error {
+	return validateKnown(state)
+}
+
+func (cmd CancelRecurringTransactionCommand) applyTo(state *recurringTransactionState) ([]RecurringTransactionEvent, []scheduledRecurringTransactionCommand) {
+	if state.status == Cancelled {
+		return nil, nil
+	}
+	return []RecurringTransactionEvent{newRecurringTransactionCancelled(state.ID)}, nil
+}
+
+// IgnoreRecurringTransactionCommand marks a recurring transaction as ignored by the user
+type IgnoreRecurringTransactionCommand struct {
+	RecurringTransactionID primitives.RecurringTransactionID
+}
+
+func (cmd IgnoreRecurringTransactionCommand) validate(state *recurringTransactionState) error {
+	return validateKnown(state)
+}
+
+func (cmd IgnoreRecurringTransactionCommand) applyTo(state *recurringTransactionState) ([]RecurringTransactionEvent, []scheduledRecurringTransactionCommand) {
+	if state.status == Ignored {
+		return nil, nil
+	}
+	return []RecurringTransactionEvent{newRecurringTransactionIgnored(state.ID)}, nil
+}
+
+func validateKnown(state *recurringTransactionState) error {
+	if state == nil || !state.details.initialized {
+		return primitives.NewValidationError("RecurringTransactionID", "unknown recurring transaction")
+	}
+	return nil
+}
+
 type NewRecurringTransactionFound struct {
 	From      TransactionParty
 	To        TransactionParty
@@ -262,9 +310,8 @@ func newNewRecurringTransactionFoundFromSchedule(cmd ProcessScheduleCommand) New
 	res.Amount = cmd.Amount.ToMoney()
 	res.EndDate = cmd.EndDate
 	res.StartDate = cmd.StartDate
-	// TODO
-	// res.From = cmd.From
-	// res.To = cmd.To
+	res.From = NewTransactionParty(&cmd.FromIBAN, nil)
+	res.To = NewTransactionParty(&cmd.ToIBAN, &cmd.ToName)
 	res.Frequency = cmd.Frequency
 	res.Source = primitives.Schedule
 	return *res
@@ -274,9 +321,8 @@ func newNewRecurringTransactionFoundFromDirectDebit(cmd ProcessDirectDebitTransa
 	res := new(NewRecurringTransactionFound)
 	res.Amount = cmd.Amount.ToMoney()
 	res.StartDate = cmd.TransactionDate
-	// TODO
-	// res.From = cmd.FromIBAN
-	// res.To = cmd.ToIBAN
+	res.From = cmd.From
+	res.To = cmd.To
 	res.Frequency = frequency
 	res.Source = primitives.DirectDebit
 	return *res
@@ -393,6 +439,42 @@ func (event RecurringTransactionReopened) appliedTo(state *recurringTransactionS
 	return &res
 }
 
+type RecurringTransactionCancelled struct {
+	ID primitives.RecurringTransactionID
+}
+
+func newRecurringTransactionCancelled(id primitives.RecurringTransactionID) RecurringTransactionCancelled {
+	res := new(RecurringTransactionCancelled)
+	res.ID = id
+	return *res
+}
+
+func (event RecurringTransactionCancelled) appliedTo(state *recurringTransactionState) *recurringTransactionState {
+	res := recurringTransactionState{}
+	copier.Copy(&res, &state)
+
+	res.status = Cancelled
+	return &res
+}
+
+type RecurringTransactionIgnored struct {
+	ID primitives.RecurringTransactionID
+}
+
+func newRecurringTransactionIgnored(id primitives.RecurringTransactionID) RecurringTransactionIgnored {
+	res := new(RecurringTransactionIgnored)
+	res.ID = id
+	return *res
+}
+
+func (event RecurringTransactionIgnored) appliedTo(state *recurringTransactionState) *recurringTransactionState {
+	res := recurringTransactionState{}
+	copier.Copy(&res, &state)
+
+	res.status = Ignored
+	return &res
+}
+
 type NewRecurringTransactionInstanceFound struct {
 	ID                   primitives.RecurringTransactionInstanceID
 	RecurringTransaction primitives.RecurringTransactionID
@@ -44,3 +44,21 @@ func Test_NewRecurringTransactionInstanceFound_NoopOnExistingTransaction(t *test
 
 func Test_NewRecurringTransactionInstanceFound_SetsLastTransactionDate(t *testing.T) {
 }
+
+func Test_CancelRecurringTransactionCommand_Cancels(t *testing.T) {
+	if err := (CancelRecurringTransactionCommand{RecurringTransactionID: recurringTransactionId}).validate(nil); err == nil {
+		t.Fatalf("Expected an unknown recurring transaction to be rejected")
+	}
+
+	state := &recurringTransactionState{ID: recurringTransactionId, status: Active}
+	state.details.initialized = true
+	cmd := CancelRecurringTransactionCommand{RecurringTransactionID: recurringTransactionId}
+
+	events, _ := cmd.applyTo(state)
+	if len(events) != 1 {
+		t.Fatalf("Expected the recurring transaction to be cancelled, got %v", events)
+	}
+	if result := events[0].appliedTo(state); result.status != Cancelled {
+		t.Errorf("Expected the status to be cancelled, got %s", result.status)
+	}
+}
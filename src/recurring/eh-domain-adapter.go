@@ -43,6 +43,8 @@ const EhProcessScheduleCommand = eh.CommandType("recurring:process-schedule")
 const EhProcessDirectDebitTransactionDocumentCommand = eh.CommandType("recurring:process-direct-debit-tx")
 const EhProcessScheduledTransactionCommand = eh.CommandType("recurring:process-scheduled-tx")
 const EhRecheckStatusCommand = eh.CommandType("recurring:recheck")
+const EhCancelRecurringTransactionCommand = eh.CommandType("recurring:cancel")
+const EhIgnoreRecurringTransactionCommand = eh.CommandType("recurring:ignore")
 
 const EhNewRecurringTransactionFound = eh.EventType("recurring:new-found")
 const EhRecurringTransactionAmountChanged = eh.EventType("recurring:amount-changed")
@@ -51,6 +53,20 @@ const EhRecurringTransactionEnded = eh.EventType("recurring:ended")
 const EhRecurringTransactionStartDateChanged = eh.EventType("recurring:start-date-changed")
 const EhRecurringTransactionReopened = eh.EventType("recurring:reopened")
 const EhNewRecurringTransactionInstanceFound = eh.EventType("recurring:instance-found")
+const EhRecurringTransactionCancelled = eh.EventType("recurring:cancelled")
+const EhRecurringTransactionIgnored = eh.EventType("recurring:ignored")
+
+// CommandTypes are all command types handled by the recurring transaction aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhProcessScheduleCommand,
+		EhProcessDirectDebitTransactionDocumentCommand,
+		EhProcessScheduledTransactionCommand,
+		EhRecheckStatusCommand,
+		EhCancelRecurringTransactionCommand,
+		EhIgnoreRecurringTransactionCommand,
+	}
+}
 
 func (cmd ProcessScheduleCommand) AggregateID() uuid.UUID {
 	return uuid.UUID(cmd.RecurringTransactionID)
@@ -100,6 +116,30 @@ func (cmd RecheckStatusCommand) CommandType() eh.CommandType {
 	return EhRecheckStatusCommand
 }
 
+func (cmd CancelRecurringTransactionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.RecurringTransactionID)
+}
+
+func (cmd CancelRecurringTransactionCommand) AggregateType() eh.AggregateType {
+	return RecurringTransactionAggregateType
+}
+
+func (cmd CancelRecurringTransactionCommand) CommandType() eh.CommandType {
+	return EhCancelRecurringTransactionCommand
+}
+
+func (cmd IgnoreRecurringTransactionCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.RecurringTransactionID)
+}
+
+func (cmd IgnoreRecurringTransactionCommand) AggregateType() eh.AggregateType {
+	return RecurringTransactionAggregateType
+}
+
+func (cmd IgnoreRecurringTransactionCommand) CommandType() eh.CommandType {
+	return EhIgnoreRecurringTransactionCommand
+}
+
 func init() {
 	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
 		return &Aggregate{
@@ -134,6 +174,14 @@ func init() {
 	eh.RegisterEventData(EhNewRecurringTransactionInstanceFound, func() eh.EventData {
 		return &NewRecurringTransactionInstanceFound{}
 	})
+
+	eh.RegisterEventData(EhRecurringTransactionCancelled, func() eh.EventData {
+		return &RecurringTransactionCancelled{}
+	})
+
+	eh.RegisterEventData(EhRecurringTransactionIgnored, func() eh.EventData {
+		return &RecurringTransactionIgnored{}
+	})
 }
 
 // HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
@@ -143,6 +191,12 @@ func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
 		return err
 	}
 
+	if validated, ok := domainCommand.(validatedCommand); ok {
+		if err := validated.validate(a.recurringTransactionState); err != nil {
+			return err
+		}
+	}
+
 	events, _ := domainCommand.applyTo(a.recurringTransactionState) // TODO: scheduled commands
 	for _, event := range events {
 		eventType, err := mapToEhEventType(event)
@@ -182,6 +236,10 @@ func mapToDomainEvent(event eh.Event) (RecurringTransactionEvent, error) {
 		return event.Data().(RecurringTransactionEvent), nil
 	case EhNewRecurringTransactionInstanceFound:
 		return event.Data().(RecurringTransactionEvent), nil
+	case EhRecurringTransactionCancelled:
+		return event.Data().(RecurringTransactionEvent), nil
+	case EhRecurringTransactionIgnored:
+		return event.Data().(RecurringTransactionEvent), nil
 	default:
 		return nil, fmt.Errorf("unable to understand evnt %v", event)
 	}
@@ -203,6 +261,10 @@ func mapToEhEventType(event RecurringTransactionEvent) (eh.EventType, error) {
 		return EhRecurringTransactionReopened, nil
 	case NewRecurringTransactionInstanceFound:
 		return EhNewRecurringTransactionInstanceFound, nil
+	case RecurringTransactionCancelled:
+		return EhRecurringTransactionCancelled, nil
+	case RecurringTransactionIgnored:
+		return EhRecurringTransactionIgnored, nil
 	}
 	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
 }
@@ -217,6 +279,10 @@ func mapToDomainCommand(cmd eh.Command) (RecurringTransactionCommand, error) {
 		return cmd, nil
 	case RecheckStatusCommand:
 		return cmd, nil
+	case CancelRecurringTransactionCommand:
+		return cmd, nil
+	case IgnoreRecurringTransactionCommand:
+		return cmd, nil
 
 	default:
 		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
@@ -0,0 +1,71 @@
+package recurring
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"sync"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Payers resolves the monetary account that pays a recurring transaction, by the IBAN it is paid from
+type Payers struct {
+	mu       *sync.RWMutex
+	ibans    map[primitives.RecurringTransactionID]string
+	accounts map[string]primitives.MonetaryAccountID
+}
+
+// NewPayers creates empty Payers
+func NewPayers() Payers {
+	res := new(Payers)
+	res.mu = new(sync.RWMutex)
+	res.ibans = make(map[primitives.RecurringTransactionID]string)
+	res.accounts = make(map[string]primitives.MonetaryAccountID)
+	return *res
+}
+
+// Matcher matches the events with the IBANs of recurring transactions and monetary accounts
+func (payers Payers) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		EhNewRecurringTransactionFound,
+		EhNewRecurringTransactionInstanceFound,
+		accountinformation.EhNewMonetaryAccountFound,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (payers Payers) HandlerType() eh.EventHandlerType {
+	return "recurring-transaction-payers"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (payers Payers) HandleEvent(ctx context.Context, event eh.Event) error {
+	payers.mu.Lock()
+	defer payers.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *NewRecurringTransactionFound:
+		if data.From.HasIBAN {
+			payers.ibans[primitives.RecurringTransactionID(event.AggregateID())] = data.From.IBAN.Code
+		}
+	case *NewRecurringTransactionInstanceFound:
+		payers.ibans[data.RecurringTransaction] = data.From.Code
+	case *accountinformation.NewMonetaryAccountFound:
+		payers.accounts[data.Iban.Code] = data.ID
+	}
+	return nil
+}
+
+// AccountOf returns the monetary account that pays the recurring transaction
+func (payers Payers) AccountOf(recurringTransactionID primitives.RecurringTransactionID) (primitives.MonetaryAccountID, bool) {
+	payers.mu.RLock()
+	defer payers.mu.RUnlock()
+
+	payerIBAN, ok := payers.ibans[recurringTransactionID]
+	if !ok {
+		return primitives.MonetaryAccountID{}, false
+	}
+	accountID, ok := payers.accounts[payerIBAN]
+	return accountID, ok
+}
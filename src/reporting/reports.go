@@ -0,0 +1,311 @@
+package reporting
+
+import (
+	"app/primitives"
+	"sort"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Dimension what transactions are grouped by in a report
+type Dimension string
+
+// Dimension enum
+const (
+	ByMonth        Dimension = "Month"
+	ByCategory     Dimension = "Category"
+	ByCounterparty Dimension = "Counterparty"
+	ByAccount      Dimension = "Account"
+	ByTag          Dimension = "Tag"
+)
+
+// Period a half open range of transaction dates, a zero bound is unbounded
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains tells whether the time is in the period
+func (period Period) Contains(t time.Time) bool {
+	return (period.From.IsZero() || !t.Before(period.From)) && (period.To.IsZero() || t.Before(period.To))
+}
+
+// YearPeriod is the period of a calendar year
+func YearPeriod(year int) Period {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Period{From: from, To: from.AddDate(1, 0, 0)}
+}
+
+// ReportLine the totals of a group of transactions in a single currency
+type ReportLine struct {
+	Key      string
+	Label    string
+	Income   money.Money
+	Expenses money.Money
+	Count    int
+}
+
+// Net is the income minus the expenses
+func (line ReportLine) Net() money.Money {
+	net, _ := line.Income.Subtract(&line.Expenses)
+	return *net
+}
+
+// YearOverYearLine the expenses of a group of transactions in a year compared to the year before
+type YearOverYearLine struct {
+	Key      string
+	Label    string
+	Current  money.Money
+	Previous money.Money
+}
+
+// Change is the difference in expenses compared to the year before
+func (line YearOverYearLine) Change() money.Money {
+	change, _ := line.Current.Subtract(&line.Previous)
+	return *change
+}
+
+// ChangePercentage is the relative difference in expenses compared to the year before, nil when there were none
+func (line YearOverYearLine) ChangePercentage() *float64 {
+	if line.Previous.IsZero() {
+		return nil
+	}
+	change := line.Change()
+	percentage := float64(change.Amount()) * 100 / float64(line.Previous.Amount())
+	return &percentage
+}
+
+// TransactionSource gives access to the transactions to report on
+type TransactionSource interface {
+	TransactionsOf(userID primitives.UserID) []ReportedTransaction
+	AliasOf(monetaryAccountID primitives.MonetaryAccountID) string
+}
+
+// Converter converts amounts into the reporting currency of a user
+type Converter interface {
+	ReportingCurrencyOf(userID primitives.UserID) string
+	Convert(amount money.Money, currencyCode string, date time.Time) (money.Money, error)
+}
+
+// Reporter aggregates the transactions of a user into reports
+type Reporter struct {
+	source    TransactionSource
+	converter Converter
+}
+
+// NewReporter creates a Reporter on the transactions of the source, without a converter amounts are reported in their original currency
+func NewReporter(source TransactionSource, converter Converter) Reporter {
+	return Reporter{source: source, converter: converter}
+}
+
+type groupKey struct {
+	key      string
+	currency string
+}
+
+// Report groups the transactions of the user in the period, excluding internal transfers.
+// By category the parts of a split transaction are reported in their own category, by tag a transaction is reported
+// in each of its tags.
+// Amounts are converted into the reporting currency of the user with the rate on the transaction date,
+// amounts that can not be converted get lines of their own currency, as amounts in different currencies are never summed.
+func (reporter Reporter) Report(userID primitives.UserID, dimension Dimension, period Period) []ReportLine {
+	lines := make(map[groupKey]*ReportLine)
+	var order []groupKey
+
+	var reportingCurrency string
+	if reporter.converter != nil {
+		reportingCurrency = reporter.converter.ReportingCurrencyOf(userID)
+	}
+
+	for _, transaction := range reporter.source.TransactionsOf(userID) {
+		if transaction.InternalTransfer || !period.Contains(transaction.TransactionDate) {
+			continue
+		}
+
+		// a transaction is counted once per line, even when several of its parts are summed on the same line
+		counted := make(map[groupKey]bool)
+		for _, part := range reporter.partsOf(transaction, dimension) {
+			amount := reporter.amountIn(part.amount, transaction.TransactionDate, reportingCurrency)
+			currency := amount.Currency().Code
+			group := groupKey{key: part.key, currency: currency}
+
+			line, ok := lines[group]
+			if !ok {
+				line = &ReportLine{Key: part.key, Label: part.label, Income: *money.New(0, currency), Expenses: *money.New(0, currency)}
+				lines[group] = line
+				order = append(order, group)
+			}
+
+			if transaction.Outgoing {
+				expenses, _ := line.Expenses.Add(amount.Absolute())
+				line.Expenses = *expenses
+			} else {
+				income, _ := line.Income.Add(amount.Absolute())
+				line.Income = *income
+			}
+			if !counted[group] {
+				line.Count++
+				counted[group] = true
+			}
+		}
+	}
+
+	res := make([]ReportLine, 0, len(order))
+	for _, group := range order {
+		res = append(res, *lines[group])
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Key != res[j].Key {
+			return res[i].Key < res[j].Key
+		}
+		return res[i].Expenses.Currency().Code < res[j].Expenses.Currency().Code
+	})
+	return res
+}
+
+// YearOverYear compares the expenses per group of the year with the year before
+func (reporter Reporter) YearOverYear(userID primitives.UserID, dimension Dimension, year int) []YearOverYearLine {
+	if dimension == ByMonth {
+		dimension = byMonthOfYear
+	}
+
+	current := reporter.Report(userID, dimension, YearPeriod(year))
+	previous := reporter.Report(userID, dimension, YearPeriod(year-1))
+
+	lines := make(map[groupKey]*YearOverYearLine)
+	var order []groupKey
+	lineFor := func(line ReportLine) *YearOverYearLine {
+		currency := line.Expenses.Currency().Code
+		group := groupKey{key: line.Key, currency: currency}
+		res, ok := lines[group]
+		if !ok {
+			res = &YearOverYearLine{Key: line.Key, Label: line.Label, Current: *money.New(0, currency), Previous: *money.New(0, currency)}
+			lines[group] = res
+			order = append(order, group)
+		}
+		return res
+	}
+
+	for _, line := range current {
+		lineFor(line).Current = line.Expenses
+	}
+	for _, line := range previous {
+		lineFor(line).Previous = line.Expenses
+	}
+
+	res := make([]YearOverYearLine, 0, len(order))
+	for _, group := range order {
+		res = append(res, *lines[group])
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+// TopCounterparties are the counterparties the user spent the most on in the period, per currency
+func (reporter Reporter) TopCounterparties(userID primitives.UserID, period Period, limit int) []ReportLine {
+	lines := reporter.Report(userID, ByCounterparty, period)
+
+	var res []ReportLine
+	for _, line := range lines {
+		if line.Expenses.IsPositive() {
+			res = append(res, line)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Expenses.Currency().Code != res[j].Expenses.Currency().Code {
+			return res[i].Expenses.Currency().Code < res[j].Expenses.Currency().Code
+		}
+		return res[i].Expenses.Amount() > res[j].Expenses.Amount()
+	})
+
+	perCurrency := make(map[string]int)
+	top := make([]ReportLine, 0, len(res))
+	for _, line := range res {
+		currency := line.Expenses.Currency().Code
+		if limit > 0 && perCurrency[currency] >= limit {
+			continue
+		}
+		perCurrency[currency]++
+		top = append(top, line)
+	}
+	return top
+}
+
+func (reporter Reporter) amountIn(amount money.Money, date time.Time, currencyCode string) money.Money {
+	if reporter.converter == nil || currencyCode == "" {
+		return amount
+	}
+
+	converted, err := reporter.converter.Convert(amount, currencyCode, date)
+	if err != nil {
+		return amount
+	}
+	return converted
+}
+
+// reportedPart is the part of the amount of a transaction that is reported on the line of the key
+type reportedPart struct {
+	key    string
+	label  string
+	amount money.Money
+}
+
+// untaggedLabel is the label of the transactions without tags, when reporting by tag
+const untaggedLabel = "Untagged"
+
+func (reporter Reporter) partsOf(transaction ReportedTransaction, dimension Dimension) []reportedPart {
+	switch {
+	case dimension == ByCategory && len(transaction.SplitParts) > 0:
+		res := make([]reportedPart, 0, len(transaction.SplitParts))
+		for _, part := range transaction.SplitParts {
+			category := part.Category
+			if category == "" {
+				category = transaction.Category
+			}
+			res = append(res, reportedPart{key: string(category), label: string(category), amount: part.Amount})
+		}
+		return res
+	case dimension == ByTag && len(transaction.Tags) == 0:
+		return []reportedPart{{key: "", label: untaggedLabel, amount: transaction.Amount}}
+	case dimension == ByTag:
+		res := make([]reportedPart, 0, len(transaction.Tags))
+		for _, tag := range transaction.Tags {
+			res = append(res, reportedPart{key: tag, label: tag, amount: transaction.Amount})
+		}
+		return res
+	default:
+		key, label := reporter.keyOf(transaction, dimension)
+		return []reportedPart{{key: key, label: label, amount: transaction.Amount}}
+	}
+}
+
+// byMonthOfYear groups on the month without the year, so years can be compared
+const byMonthOfYear Dimension = "MonthOfYear"
+
+func (reporter Reporter) keyOf(transaction ReportedTransaction, dimension Dimension) (string, string) {
+	switch dimension {
+	case ByCategory:
+		return string(transaction.Category), string(transaction.Category)
+	case ByCounterparty:
+		counterparty := transaction.Counterparty
+		if counterparty.HasIBAN {
+			return counterparty.IBAN.Code, counterparty.Name
+		}
+		if counterparty.HasName {
+			return counterparty.Name, counterparty.Name
+		}
+		return "", "Unknown"
+	case ByAccount:
+		return transaction.MonetaryAccountID.String(), reporter.source.AliasOf(transaction.MonetaryAccountID)
+	case byMonthOfYear:
+		month := transaction.TransactionDate.Format("01")
+		return month, transaction.TransactionDate.Format("January")
+	default:
+		month := transaction.TransactionDate.Format("2006-01")
+		return month, month
+	}
+}
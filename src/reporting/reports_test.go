@@ -0,0 +1,199 @@
+package reporting
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+var accountID = primitives.MonetaryAccountID(uuid.New())
+
+type fakeSource []ReportedTransaction
+
+func (source fakeSource) TransactionsOf(userID primitives.UserID) []ReportedTransaction {
+	return source
+}
+
+func (source fakeSource) AliasOf(monetaryAccountID primitives.MonetaryAccountID) string {
+	return "Main"
+}
+
+func transaction(amount int64, outgoing bool, category primitives.Category, counterparty string, date time.Time) ReportedTransaction {
+	return ReportedTransaction{
+		ID:                primitives.TransactionID(uuid.New()),
+		MonetaryAccountID: accountID,
+		Counterparty:      accountinformation.TransactionParty{Name: counterparty, HasName: true},
+		Amount:            *money.New(amount, "EUR"),
+		Outgoing:          outgoing,
+		Category:          category,
+		TransactionDate:   date,
+	}
+}
+
+type fakeConverter struct{}
+
+func (converter fakeConverter) ReportingCurrencyOf(userID primitives.UserID) string {
+	return "EUR"
+}
+
+func (converter fakeConverter) Convert(amount money.Money, currencyCode string, date time.Time) (money.Money, error) {
+	if amount.Currency().Code != "USD" {
+		return money.Money{}, fmt.Errorf("no rate for %s", amount.Currency().Code)
+	}
+	return *money.New(amount.Amount()/2, currencyCode), nil
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+func Test_Report_GroupsByCategoryAndExcludesInternalTransfers(t *testing.T) {
+	transfer := transaction(-50000, true, primitives.Uncategorised, "Savings", date(2020, time.January, 1))
+	transfer.InternalTransfer = true
+
+	reporter := NewReporter(fakeSource{
+		transaction(-1000, true, "Groceries", "Shop", date(2020, time.January, 2)),
+		transaction(-2500, true, "Groceries", "Shop", date(2020, time.January, 3)),
+		transaction(300000, false, "Salary", "Employer", date(2020, time.January, 25)),
+		transfer,
+	}, nil)
+
+	lines := reporter.Report(userID, ByCategory, Period{})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if lines[0].Key != "Groceries" || lines[0].Expenses.Amount() != 3500 || lines[0].Count != 2 {
+		t.Errorf("Unexpected groceries line %+v", lines[0])
+	}
+	if lines[1].Key != "Salary" || lines[1].Income.Amount() != 300000 {
+		t.Errorf("Unexpected salary line %+v", lines[1])
+	}
+	if net := lines[1].Net(); net.Amount() != 300000 {
+		t.Errorf("Expected net of 300000, got %d", net.Amount())
+	}
+}
+
+func Test_Report_OnlyIncludesTransactionsInPeriod(t *testing.T) {
+	reporter := NewReporter(fakeSource{
+		transaction(-1000, true, "Groceries", "Shop", date(2020, time.January, 31)),
+		transaction(-2000, true, "Groceries", "Shop", date(2020, time.February, 1)),
+	}, nil)
+
+	lines := reporter.Report(userID, ByMonth, Period{From: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)})
+
+	if len(lines) != 1 || lines[0].Key != "2020-02" {
+		t.Errorf("Expected only February to be reported, got %+v", lines)
+	}
+}
+
+func Test_YearOverYear_ComparesExpenses(t *testing.T) {
+	reporter := NewReporter(fakeSource{
+		transaction(-1000, true, "Groceries", "Shop", date(2019, time.March, 1)),
+		transaction(-1500, true, "Groceries", "Shop", date(2020, time.March, 1)),
+		transaction(-700, true, "Travel", "Airline", date(2020, time.June, 1)),
+	}, nil)
+
+	lines := reporter.YearOverYear(userID, ByCategory, 2020)
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if change := lines[0].Change(); change.Amount() != 500 {
+		t.Errorf("Expected a change of 500, got %d", change.Amount())
+	}
+	if percentage := lines[0].ChangePercentage(); percentage == nil || *percentage != 50 {
+		t.Errorf("Expected a change of 50 percent, got %v", percentage)
+	}
+	if lines[1].ChangePercentage() != nil {
+		t.Errorf("Expected no change percentage without expenses the year before")
+	}
+}
+
+func Test_TopCounterparties_OrdersByExpensesAndLimits(t *testing.T) {
+	reporter := NewReporter(fakeSource{
+		transaction(-1000, true, "Groceries", "Bakery", date(2020, time.January, 1)),
+		transaction(-5000, true, "Groceries", "Supermarket", date(2020, time.January, 2)),
+		transaction(-3000, true, "Travel", "Airline", date(2020, time.January, 3)),
+		transaction(9000, false, "Salary", "Employer", date(2020, time.January, 4)),
+	}, nil)
+
+	lines := reporter.TopCounterparties(userID, Period{}, 2)
+
+	if len(lines) != 2 || lines[0].Label != "Supermarket" || lines[1].Label != "Airline" {
+		t.Errorf("Unexpected top counterparties %+v", lines)
+	}
+}
+
+func Test_Report_ConvertsIntoReportingCurrency(t *testing.T) {
+	inDollars := transaction(-2000, true, "Travel", "Airline", date(2020, time.January, 1))
+	inDollars.Amount = *money.New(-2000, "USD")
+	inPounds := transaction(-500, true, "Travel", "Hotel", date(2020, time.January, 2))
+	inPounds.Amount = *money.New(-500, "GBP")
+
+	reporter := NewReporter(fakeSource{
+		transaction(-1000, true, "Travel", "Train", date(2020, time.January, 3)),
+		inDollars,
+		inPounds,
+	}, fakeConverter{})
+
+	lines := reporter.Report(userID, ByCategory, Period{})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if lines[0].Expenses.Currency().Code != "EUR" || lines[0].Expenses.Amount() != 2000 {
+		t.Errorf("Expected 2000 EUR of expenses, got %d %s", lines[0].Expenses.Amount(), lines[0].Expenses.Currency().Code)
+	}
+	if lines[1].Expenses.Currency().Code != "GBP" {
+		t.Errorf("Expected amounts without a rate to keep their currency")
+	}
+}
+
+func Test_Report_ReportsSplitPartsAndTags(t *testing.T) {
+	dinner := transaction(-10000, true, "Restaurants", "Bistro", date(2020, time.January, 1))
+	dinner.Tags = []string{"holiday", "tax"}
+	dinner.SplitParts = []accountinformation.TransactionSplitPart{
+		{Category: "Gifts", Amount: *money.New(4000, "EUR")},
+		{Person: "Alice", Amount: *money.New(6000, "EUR")},
+	}
+
+	reporter := NewReporter(fakeSource{
+		dinner,
+		transaction(-1000, true, "Groceries", "Supermarket", date(2020, time.January, 2)),
+	}, nil)
+
+	lines := reporter.Report(userID, ByCategory, Period{})
+	if len(lines) != 3 || lines[0].Key != "Gifts" || lines[0].Expenses.Amount() != 4000 || lines[2].Key != "Restaurants" || lines[2].Expenses.Amount() != 6000 {
+		t.Errorf("Expected the parts of the dinner in their categories, got %+v", lines)
+	}
+
+	lines = reporter.Report(userID, ByTag, Period{})
+	if len(lines) != 3 || lines[0].Label != untaggedLabel || lines[0].Expenses.Amount() != 1000 || lines[1].Key != "holiday" || lines[2].Expenses.Amount() != 10000 {
+		t.Errorf("Expected the dinner in each of its tags, got %+v", lines)
+	}
+}
+
+func Test_Report_CountsSplitTransactionsOnce(t *testing.T) {
+	dinner := transaction(-10000, true, "Restaurants", "Bistro", date(2020, time.January, 1))
+	dinner.SplitParts = []accountinformation.TransactionSplitPart{
+		{Person: "Alice", Amount: *money.New(4000, "EUR")},
+		{Person: "Bob", Amount: *money.New(6000, "EUR")},
+	}
+
+	reporter := NewReporter(fakeSource{
+		dinner,
+		transaction(-1000, true, "Restaurants", "Cafe", date(2020, time.January, 2)),
+	}, nil)
+
+	lines := reporter.Report(userID, ByCategory, Period{})
+	if len(lines) != 1 || lines[0].Expenses.Amount() != 11000 || lines[0].Count != 2 {
+		t.Errorf("Expected the parts of the dinner summed but counted once, got %+v", lines)
+	}
+}
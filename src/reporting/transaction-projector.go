@@ -0,0 +1,262 @@
+package reporting
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// ReportedTransaction a transaction as seen from one of the monetary accounts of a user
+type ReportedTransaction struct {
+	ID                primitives.TransactionID
+	MonetaryAccountID primitives.MonetaryAccountID
+	Counterparty      accountinformation.TransactionParty
+	Amount            money.Money
+	Outgoing          bool
+	Category          primitives.Category
+	Description       string
+	Note              string
+	Attachments       []accountinformation.TransactionAttachment
+	Tags              []string
+	SplitParts        []accountinformation.TransactionSplitPart
+	TransactionDate   time.Time
+	InternalTransfer  bool
+	TransferID        primitives.TransferID
+}
+
+// SignedAmount is the amount as seen from the monetary account, negative when it is outgoing
+func (transaction ReportedTransaction) SignedAmount() money.Money {
+	if transaction.Outgoing {
+		return *transaction.Amount.Absolute().Negative()
+	}
+	return *transaction.Amount.Absolute()
+}
+
+// TransactionProjector keeps the transactions of the account-information domain, to report on
+type TransactionProjector struct {
+	mu           sync.RWMutex
+	aliases      map[primitives.MonetaryAccountID]string
+	localAliases map[primitives.MonetaryAccountID]string
+	ibans        map[primitives.MonetaryAccountID]iban.IBAN
+	accounts     map[string]primitives.MonetaryAccountID
+	joint        map[primitives.MonetaryAccountID]bool
+	owners       map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	transactions map[primitives.MonetaryAccountID]map[primitives.TransactionID]ReportedTransaction
+}
+
+// NewTransactionProjector creates an empty TransactionProjector
+func NewTransactionProjector() *TransactionProjector {
+	return &TransactionProjector{
+		aliases:      make(map[primitives.MonetaryAccountID]string),
+		localAliases: make(map[primitives.MonetaryAccountID]string),
+		ibans:        make(map[primitives.MonetaryAccountID]iban.IBAN),
+		accounts:     make(map[string]primitives.MonetaryAccountID),
+		joint:        make(map[primitives.MonetaryAccountID]bool),
+		owners:       make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool),
+		transactions: make(map[primitives.MonetaryAccountID]map[primitives.TransactionID]ReportedTransaction),
+	}
+}
+
+// Matcher matches all events the projector is interested in
+func (projector *TransactionProjector) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewMonetaryAccountFound,
+		accountinformation.EhMonetaryAccountAliasUpdated,
+		accountinformation.EhMonetaryAccountBecameJoint,
+		accountinformation.EhMonetaryAccountBecameSingular,
+		accountinformation.EhMonetaryAccountRenamed,
+		accountinformation.EhMonetaryAccountUserAdded,
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhTransactionCategorised,
+		accountinformation.EhInternalTransferDetected,
+		accountinformation.EhTransactionNoteSet,
+		accountinformation.EhTransactionAttachmentAdded,
+		accountinformation.EhTransactionTagsSet,
+		accountinformation.EhTransactionSplit,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (projector *TransactionProjector) HandlerType() eh.EventHandlerType {
+	return "reporting-transaction-projector"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (projector *TransactionProjector) HandleEvent(ctx context.Context, event eh.Event) error {
+	projector.mu.Lock()
+	defer projector.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *accountinformation.NewMonetaryAccountFound:
+		projector.aliases[data.ID] = data.Alias
+		projector.ibans[data.ID] = data.Iban
+		projector.accounts[data.Iban.Code] = data.ID
+		projector.joint[data.ID] = data.Joint
+	case *accountinformation.MonetaryAccountAliasUpdated:
+		projector.aliases[data.ID] = data.Alias
+	case *accountinformation.MonetaryAccountBecameJoint:
+		projector.joint[data.ID] = true
+	case *accountinformation.MonetaryAccountBecameSingular:
+		projector.joint[data.ID] = false
+	case *accountinformation.MonetaryAccountRenamed:
+		projector.localAliases[data.ID] = data.Alias
+	case *accountinformation.MonetaryAccountUserAdded:
+		projector.ownerAdded(data.ID, data.UserID)
+	case *accountinformation.NewTransactionFound:
+		projector.transactionFound(*data)
+	case *accountinformation.TransactionCategorised:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			transaction.Category = data.Category
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	case *accountinformation.InternalTransferDetected:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			transaction.InternalTransfer = true
+			transaction.TransferID = data.TransferID
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	case *accountinformation.TransactionNoteSet:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			transaction.Note = data.Note
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	case *accountinformation.TransactionAttachmentAdded:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			attachments := make([]accountinformation.TransactionAttachment, 0, len(transaction.Attachments)+1)
+			transaction.Attachments = append(append(attachments, transaction.Attachments...), data.Attachment)
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	case *accountinformation.TransactionTagsSet:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			transaction.Tags = append([]string{}, data.Tags...)
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	case *accountinformation.TransactionSplit:
+		if transaction, ok := projector.transactions[data.ID][data.TransactionID]; ok {
+			transaction.SplitParts = append([]accountinformation.TransactionSplitPart{}, data.Parts...)
+			projector.transactions[data.ID][data.TransactionID] = transaction
+		}
+	}
+	return nil
+}
+
+func (projector *TransactionProjector) ownerAdded(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) {
+	owners, ok := projector.owners[monetaryAccountID]
+	if !ok {
+		owners = make(map[primitives.UserID]bool)
+		projector.owners[monetaryAccountID] = owners
+	}
+	owners[userID] = true
+}
+
+func (projector *TransactionProjector) transactionFound(event accountinformation.NewTransactionFound) {
+	transactions, ok := projector.transactions[event.MonetaryAccountID]
+	if !ok {
+		transactions = make(map[primitives.TransactionID]ReportedTransaction)
+		projector.transactions[event.MonetaryAccountID] = transactions
+	}
+
+	transactions[event.ID] = ReportedTransaction{
+		ID:                event.ID,
+		MonetaryAccountID: event.MonetaryAccountID,
+		Counterparty:      event.Counterparty(),
+		Amount:            event.Amount,
+		Outgoing:          event.MonetaryAccountID == event.FromMonetaryAccountID,
+		Category:          primitives.Uncategorised,
+		Description:       event.Description,
+		TransactionDate:   event.TransactionDate,
+	}
+}
+
+// TransactionsOf returns all transactions on the monetary accounts of the user
+func (projector *TransactionProjector) TransactionsOf(userID primitives.UserID) []ReportedTransaction {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	var res []ReportedTransaction
+	for monetaryAccountID, owners := range projector.owners {
+		if !owners[userID] {
+			continue
+		}
+		for _, transaction := range projector.transactions[monetaryAccountID] {
+			res = append(res, transaction)
+		}
+	}
+	return res
+}
+
+// TransactionOf returns the transaction on the monetary account
+func (projector *TransactionProjector) TransactionOf(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID) (ReportedTransaction, bool) {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	transaction, ok := projector.transactions[monetaryAccountID][transactionID]
+	return transaction, ok
+}
+
+// IsOwner tells whether the user is one of the owners of the monetary account
+func (projector *TransactionProjector) IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	return projector.owners[monetaryAccountID][userID]
+}
+
+// IsJoint tells whether the monetary account is a joint account
+func (projector *TransactionProjector) IsJoint(monetaryAccountID primitives.MonetaryAccountID) bool {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	return projector.joint[monetaryAccountID]
+}
+
+// TransactionsIn returns the transactions on the monetary account, the newest first
+func (projector *TransactionProjector) TransactionsIn(monetaryAccountID primitives.MonetaryAccountID) []ReportedTransaction {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	res := make([]ReportedTransaction, 0, len(projector.transactions[monetaryAccountID]))
+	for _, transaction := range projector.transactions[monetaryAccountID] {
+		res = append(res, transaction)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].TransactionDate.After(res[j].TransactionDate)
+	})
+	return res
+}
+
+// AliasOf returns the alias of the monetary account, the alias the user gave it before the alias of the institution
+func (projector *TransactionProjector) AliasOf(monetaryAccountID primitives.MonetaryAccountID) string {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	if alias := projector.localAliases[monetaryAccountID]; alias != "" {
+		return alias
+	}
+	return projector.aliases[monetaryAccountID]
+}
+
+// IBANOf returns the IBAN of the monetary account
+func (projector *TransactionProjector) IBANOf(monetaryAccountID primitives.MonetaryAccountID) (iban.IBAN, bool) {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	accountIBAN, ok := projector.ibans[monetaryAccountID]
+	return accountIBAN, ok
+}
+
+// AccountOf returns the monetary account with the IBAN
+func (projector *TransactionProjector) AccountOf(accountIBAN iban.IBAN) (primitives.MonetaryAccountID, bool) {
+	projector.mu.RLock()
+	defer projector.mu.RUnlock()
+
+	monetaryAccountID, ok := projector.accounts[accountIBAN.Code]
+	return monetaryAccountID, ok
+}
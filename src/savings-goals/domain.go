@@ -0,0 +1,467 @@
+package savingsgoals
+
+import (
+	"app/primitives"
+	"fmt"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Progress is how far the linked accounts of a savings goal are towards its target. The plan of a goal is to save
+// the target in equal monthly contributions, from the time the goal is set until the deadline
+type Progress struct {
+	Target   money.Money
+	Since    time.Time
+	Deadline time.Time
+	Balances map[primitives.MonetaryAccountID]int64
+}
+
+func newProgress() Progress {
+	return Progress{Balances: make(map[primitives.MonetaryAccountID]int64)}
+}
+
+// Saved is the total balance of the linked accounts
+func (progress Progress) Saved() money.Money {
+	return *money.New(progress.saved(), progress.Target.Currency().Code)
+}
+
+// Remaining is what still has to be saved to reach the target
+func (progress Progress) Remaining() money.Money {
+	return *money.New(progress.remaining(), progress.Target.Currency().Code)
+}
+
+// PercentageSaved is the percentage of the target that is saved
+func (progress Progress) PercentageSaved() float64 {
+	if progress.Target.Amount() <= 0 {
+		return 0
+	}
+	return float64(progress.saved()) * 100 / float64(progress.Target.Amount())
+}
+
+// IsReached tells whether the linked accounts hold at least the target
+func (progress Progress) IsReached() bool {
+	return progress.remaining() == 0
+}
+
+// RequiredMonthlyContribution is what has to be saved every month from the time on to reach the target at the deadline,
+// everything that remains once the deadline passed
+func (progress Progress) RequiredMonthlyContribution(at time.Time) money.Money {
+	months := monthsBetween(at, progress.Deadline)
+	if months == 0 {
+		return progress.Remaining()
+	}
+	return *money.New(divideRoundingUp(progress.remaining(), int64(months)), progress.Target.Currency().Code)
+}
+
+// PlannedMonthlyContribution is what has to be saved every month to reach the target when nothing is saved when the goal is set
+func (progress Progress) PlannedMonthlyContribution() money.Money {
+	months := monthsBetween(progress.Since, progress.Deadline)
+	if months == 0 {
+		return progress.Target
+	}
+	return *money.New(divideRoundingUp(progress.Target.Amount(), int64(months)), progress.Target.Currency().Code)
+}
+
+// IsOnTrack tells whether the target is reached, or can be reached by saving no more than planned every month until the deadline
+func (progress Progress) IsOnTrack(at time.Time) bool {
+	if progress.IsReached() {
+		return true
+	}
+	if !at.Before(progress.Deadline) {
+		return false
+	}
+	required := progress.RequiredMonthlyContribution(at)
+	planned := progress.PlannedMonthlyContribution()
+	return required.Amount() <= planned.Amount()
+}
+
+func (progress Progress) saved() int64 {
+	var saved int64
+	for _, balance := range progress.Balances {
+		saved += balance
+	}
+	return saved
+}
+
+func (progress Progress) remaining() int64 {
+	remaining := progress.Target.Amount() - progress.saved()
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// monthsBetween is the number of monthly contributions from one time until another, a started month counts as a whole
+func monthsBetween(from time.Time, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	if to.Day() > from.Day() {
+		months++
+	}
+	if months < 1 {
+		return 1
+	}
+	return months
+}
+
+func divideRoundingUp(amount int64, parts int64) int64 {
+	if amount <= 0 {
+		return 0
+	}
+	return (amount + parts - 1) / parts
+}
+
+type goalState struct {
+	ID          primitives.GoalID
+	initialized bool
+	userID      primitives.UserID
+	name        string
+	accounts    map[primitives.MonetaryAccountID]bool
+	progress    Progress
+	reached     bool
+	offTrack    bool
+}
+
+func emptyGoalState(id primitives.GoalID) *goalState {
+	res := new(goalState)
+	res.ID = id
+	res.accounts = make(map[primitives.MonetaryAccountID]bool)
+	res.progress = newProgress()
+	return res
+}
+
+func (state *goalState) copy() *goalState {
+	res := *state
+	res.accounts = make(map[primitives.MonetaryAccountID]bool, len(state.accounts))
+	for id := range state.accounts {
+		res.accounts[id] = true
+	}
+	res.progress.Balances = make(map[primitives.MonetaryAccountID]int64, len(state.progress.Balances))
+	for id, balance := range state.progress.Balances {
+		res.progress.Balances[id] = balance
+	}
+	return &res
+}
+
+type GoalEvent interface {
+	appliedTo(state *goalState) *goalState
+}
+
+type GoalCommand interface {
+	applyTo(state *goalState) ([]GoalEvent, error)
+}
+
+// SetSavingsGoalCommand sets a new savings goal, or changes the goal of the user. The plan of a goal starts at the
+// timestamp it is first set
+type SetSavingsGoalCommand struct {
+	GoalID             primitives.GoalID
+	UserID             primitives.UserID
+	Name               string
+	Target             primitives.MoneyForCommand
+	Deadline           time.Time
+	MonetaryAccountIDs []primitives.MonetaryAccountID
+	Timestamp          time.Time
+}
+
+func (cmd SetSavingsGoalCommand) validate() error {
+	if money.GetCurrency(cmd.Target.CurrencyCode) == nil {
+		return primitives.NewValidationError("Target", fmt.Sprintf("unknown currency %s", cmd.Target.CurrencyCode))
+	}
+	if cmd.Target.Amount <= 0 {
+		return primitives.NewValidationError("Target", "target of a savings goal must be positive")
+	}
+	if !cmd.Deadline.After(cmd.Timestamp) {
+		return primitives.NewValidationError("Deadline", "deadline of a savings goal must be in the future")
+	}
+	linked := make(map[primitives.MonetaryAccountID]bool, len(cmd.MonetaryAccountIDs))
+	for _, id := range cmd.MonetaryAccountIDs {
+		if linked[id] {
+			return primitives.NewValidationError("MonetaryAccountIDs", fmt.Sprintf("account %s is linked more than once", id))
+		}
+		linked[id] = true
+	}
+	return nil
+}
+
+func (cmd SetSavingsGoalCommand) applyTo(state *goalState) ([]GoalEvent, error) {
+	if err := cmd.validate(); err != nil {
+		return nil, err
+	}
+
+	if state == nil || !state.initialized {
+		event := newSavingsGoalSet(cmd)
+		return append([]GoalEvent{event}, progressEventsAfter(state, []GoalEvent{event}, cmd.Timestamp)...), nil
+	}
+
+	if state.userID != cmd.UserID {
+		return nil, primitives.NewValidationError("GoalID", fmt.Sprintf("unknown savings goal %s", cmd.GoalID))
+	}
+
+	if state.progress.Target.Currency().Code != cmd.Target.CurrencyCode {
+		return nil, primitives.NewValidationError("Target", fmt.Sprintf("currency of savings goal can not be changed from %s to %s", state.progress.Target.Currency().Code, cmd.Target.CurrencyCode))
+	}
+
+	if state.name == cmd.Name && state.progress.Target.Amount() == cmd.Target.Amount && state.progress.Deadline.Equal(cmd.Deadline) && equalAccounts(state.accounts, cmd.MonetaryAccountIDs) {
+		return nil, nil
+	}
+
+	event := newSavingsGoalChanged(cmd)
+	return append([]GoalEvent{event}, progressEventsAfter(state, []GoalEvent{event}, cmd.Timestamp)...), nil
+}
+
+func equalAccounts(accounts map[primitives.MonetaryAccountID]bool, ids []primitives.MonetaryAccountID) bool {
+	if len(accounts) != len(ids) {
+		return false
+	}
+	for _, id := range ids {
+		if !accounts[id] {
+			return false
+		}
+	}
+	return true
+}
+
+type RemoveSavingsGoalCommand struct {
+	GoalID primitives.GoalID
+	UserID primitives.UserID
+}
+
+func (cmd RemoveSavingsGoalCommand) applyTo(state *goalState) ([]GoalEvent, error) {
+	if state == nil || !state.initialized || state.userID != cmd.UserID {
+		return nil, primitives.NewValidationError("GoalID", fmt.Sprintf("unknown savings goal %s", cmd.GoalID))
+	}
+
+	return []GoalEvent{newSavingsGoalRemoved(cmd.GoalID)}, nil
+}
+
+// RecordGoalBalanceCommand records the balance of a linked account at the time of a snapshot, and checks the progress
+// of the goal at that time
+type RecordGoalBalanceCommand struct {
+	GoalID            primitives.GoalID
+	MonetaryAccountID primitives.MonetaryAccountID
+	Balance           primitives.MoneyForCommand
+	Timestamp         time.Time
+}
+
+func (cmd RecordGoalBalanceCommand) applyTo(state *goalState) ([]GoalEvent, error) {
+	if state == nil || !state.initialized {
+		return nil, fmt.Errorf("savings goal %s does not exist", cmd.GoalID)
+	}
+
+	if !state.accounts[cmd.MonetaryAccountID] || state.progress.Target.Currency().Code != cmd.Balance.CurrencyCode {
+		return nil, nil
+	}
+
+	var events []GoalEvent
+	if balance, hasBalance := state.progress.Balances[cmd.MonetaryAccountID]; !hasBalance || balance != cmd.Balance.Amount {
+		events = append(events, newGoalBalanceRecorded(cmd))
+	}
+	return append(events, progressEventsAfter(state, events, cmd.Timestamp)...), nil
+}
+
+// progressEventsAfter are the events of reaching the goal, or getting off or back on track, after the events at the time
+func progressEventsAfter(state *goalState, events []GoalEvent, at time.Time) []GoalEvent {
+	after := state
+	for _, event := range events {
+		after = event.appliedTo(after)
+	}
+
+	switch {
+	case after.reached:
+		return nil
+	case after.progress.IsReached():
+		return []GoalEvent{newGoalReached(after, at)}
+	case !after.progress.IsOnTrack(at) && !after.offTrack:
+		return []GoalEvent{newGoalOffTrack(after, at)}
+	case after.progress.IsOnTrack(at) && after.offTrack:
+		return []GoalEvent{newGoalBackOnTrack(after.ID)}
+	}
+	return nil
+}
+
+type SavingsGoalSet struct {
+	ID                 primitives.GoalID
+	UserID             primitives.UserID
+	Name               string
+	Target             money.Money
+	Since              time.Time
+	Deadline           time.Time
+	MonetaryAccountIDs []primitives.MonetaryAccountID
+}
+
+func newSavingsGoalSet(cmd SetSavingsGoalCommand) SavingsGoalSet {
+	res := new(SavingsGoalSet)
+	res.ID = cmd.GoalID
+	res.UserID = cmd.UserID
+	res.Name = cmd.Name
+	res.Target = cmd.Target.ToMoney()
+	res.Since = cmd.Timestamp
+	res.Deadline = cmd.Deadline
+	res.MonetaryAccountIDs = cmd.MonetaryAccountIDs
+	return *res
+}
+
+func (event SavingsGoalSet) appliedTo(state *goalState) *goalState {
+	res := emptyGoalState(event.ID)
+	res.initialized = true
+	res.userID = event.UserID
+	res.name = event.Name
+	for _, id := range event.MonetaryAccountIDs {
+		res.accounts[id] = true
+	}
+	res.progress.Target = event.Target
+	res.progress.Since = event.Since
+	res.progress.Deadline = event.Deadline
+	return res
+}
+
+type SavingsGoalChanged struct {
+	ID                 primitives.GoalID
+	Name               string
+	Target             money.Money
+	Deadline           time.Time
+	MonetaryAccountIDs []primitives.MonetaryAccountID
+}
+
+func newSavingsGoalChanged(cmd SetSavingsGoalCommand) SavingsGoalChanged {
+	res := new(SavingsGoalChanged)
+	res.ID = cmd.GoalID
+	res.Name = cmd.Name
+	res.Target = cmd.Target.ToMoney()
+	res.Deadline = cmd.Deadline
+	res.MonetaryAccountIDs = cmd.MonetaryAccountIDs
+	return *res
+}
+
+// appliedTo forgets the balances of accounts that are no longer linked, a goal with a new target can be reached again
+func (event SavingsGoalChanged) appliedTo(state *goalState) *goalState {
+	res := state.copy()
+	res.name = event.Name
+	res.accounts = make(map[primitives.MonetaryAccountID]bool, len(event.MonetaryAccountIDs))
+	for _, id := range event.MonetaryAccountIDs {
+		res.accounts[id] = true
+	}
+	for id := range res.progress.Balances {
+		if !res.accounts[id] {
+			delete(res.progress.Balances, id)
+		}
+	}
+	if res.progress.Target.Amount() != event.Target.Amount() {
+		res.reached = false
+	}
+	res.progress.Target = event.Target
+	res.progress.Deadline = event.Deadline
+	return res
+}
+
+type SavingsGoalRemoved struct {
+	ID primitives.GoalID
+}
+
+func newSavingsGoalRemoved(id primitives.GoalID) SavingsGoalRemoved {
+	res := new(SavingsGoalRemoved)
+	res.ID = id
+	return *res
+}
+
+func (event SavingsGoalRemoved) appliedTo(state *goalState) *goalState {
+	return emptyGoalState(event.ID)
+}
+
+type GoalBalanceRecorded struct {
+	ID                primitives.GoalID
+	MonetaryAccountID primitives.MonetaryAccountID
+	Balance           int64
+	Timestamp         time.Time
+}
+
+func newGoalBalanceRecorded(cmd RecordGoalBalanceCommand) GoalBalanceRecorded {
+	res := new(GoalBalanceRecorded)
+	res.ID = cmd.GoalID
+	res.MonetaryAccountID = cmd.MonetaryAccountID
+	res.Balance = cmd.Balance.Amount
+	res.Timestamp = cmd.Timestamp
+	return *res
+}
+
+func (event GoalBalanceRecorded) appliedTo(state *goalState) *goalState {
+	res := state.copy()
+	res.progress.Balances[event.MonetaryAccountID] = event.Balance
+	return res
+}
+
+type GoalReached struct {
+	ID        primitives.GoalID
+	UserID    primitives.UserID
+	Name      string
+	Target    money.Money
+	Saved     money.Money
+	Timestamp time.Time
+}
+
+func newGoalReached(state *goalState, at time.Time) GoalReached {
+	res := new(GoalReached)
+	res.ID = state.ID
+	res.UserID = state.userID
+	res.Name = state.name
+	res.Target = state.progress.Target
+	res.Saved = state.progress.Saved()
+	res.Timestamp = at
+	return *res
+}
+
+func (event GoalReached) appliedTo(state *goalState) *goalState {
+	res := state.copy()
+	res.reached = true
+	res.offTrack = false
+	return res
+}
+
+type GoalOffTrack struct {
+	ID                          primitives.GoalID
+	UserID                      primitives.UserID
+	Name                        string
+	Remaining                   money.Money
+	Deadline                    time.Time
+	PlannedMonthlyContribution  money.Money
+	RequiredMonthlyContribution money.Money
+	Timestamp                   time.Time
+}
+
+func newGoalOffTrack(state *goalState, at time.Time) GoalOffTrack {
+	res := new(GoalOffTrack)
+	res.ID = state.ID
+	res.UserID = state.userID
+	res.Name = state.name
+	res.Remaining = state.progress.Remaining()
+	res.Deadline = state.progress.Deadline
+	res.PlannedMonthlyContribution = state.progress.PlannedMonthlyContribution()
+	res.RequiredMonthlyContribution = state.progress.RequiredMonthlyContribution(at)
+	res.Timestamp = at
+	return *res
+}
+
+func (event GoalOffTrack) appliedTo(state *goalState) *goalState {
+	res := state.copy()
+	res.offTrack = true
+	return res
+}
+
+type GoalBackOnTrack struct {
+	ID primitives.GoalID
+}
+
+func newGoalBackOnTrack(id primitives.GoalID) GoalBackOnTrack {
+	res := new(GoalBackOnTrack)
+	res.ID = id
+	return *res
+}
+
+func (event GoalBackOnTrack) appliedTo(state *goalState) *goalState {
+	res := state.copy()
+	res.offTrack = false
+	return res
+}
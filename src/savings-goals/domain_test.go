@@ -0,0 +1,162 @@
+package savingsgoals
+
+import (
+	"app/primitives"
+	"app/utils"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+var goalID = primitives.GoalID(uuid.New())
+var savingsAccountID = primitives.MonetaryAccountID(uuid.New())
+var january = time.Date(2020, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+func newStateAfter(state *goalState, cmd GoalCommand) *goalState {
+	events, _ := cmd.applyTo(state)
+	for i := 0; i < len(events); i++ {
+		state = events[i].appliedTo(state)
+	}
+	return state
+}
+
+func eventTypesOf(events []GoalEvent) []string {
+	res := make([]string, 0, len(events))
+	for _, event := range events {
+		res = append(res, utils.TypeNameOf(event))
+	}
+	return res
+}
+
+// setGoal saves 1200 EUR in the 12 months of 2020, 100 EUR a month
+func setGoal() SetSavingsGoalCommand {
+	return SetSavingsGoalCommand{
+		GoalID:             goalID,
+		UserID:             userID,
+		Name:               "Holiday",
+		Target:             primitives.NewMoneyForCommand(*money.New(120000, "EUR")),
+		Deadline:           january.AddDate(1, 0, 0),
+		MonetaryAccountIDs: []primitives.MonetaryAccountID{savingsAccountID},
+		Timestamp:          january,
+	}
+}
+
+func balance(amount int64, timestamp time.Time) RecordGoalBalanceCommand {
+	return RecordGoalBalanceCommand{
+		GoalID:            goalID,
+		MonetaryAccountID: savingsAccountID,
+		Balance:           primitives.NewMoneyForCommand(*money.New(amount, "EUR")),
+		Timestamp:         timestamp,
+	}
+}
+
+func Test_Progress_ComputesRequiredMonthlyContribution(t *testing.T) {
+	state := newStateAfter(nil, setGoal())
+	state = newStateAfter(state, balance(30000, january.AddDate(0, 3, 0)))
+
+	if planned := state.progress.PlannedMonthlyContribution(); planned.Amount() != 10000 {
+		t.Errorf("Expected 100 EUR planned a month, found %d", planned.Amount())
+	}
+	if required := state.progress.RequiredMonthlyContribution(january.AddDate(0, 3, 0)); required.Amount() != 10000 {
+		t.Errorf("Expected 900 EUR in 9 months to require 100 EUR a month, found %d", required.Amount())
+	}
+	if required := state.progress.RequiredMonthlyContribution(january.AddDate(0, 5, 1)); required.Amount() != 12858 {
+		t.Errorf("Expected 900 EUR in 7 started months to require 128.58 EUR a month, found %d", required.Amount())
+	}
+	if required := state.progress.RequiredMonthlyContribution(january.AddDate(1, 1, 0)); required.Amount() != 90000 {
+		t.Errorf("Expected everything that remains after the deadline, found %d", required.Amount())
+	}
+	if percentage := state.progress.PercentageSaved(); percentage != 25 {
+		t.Errorf("Expected 25 percent saved, found %f", percentage)
+	}
+}
+
+func Test_SetSavingsGoalCommand_RejectsInvalidGoals(t *testing.T) {
+	past := setGoal()
+	past.Deadline = january.AddDate(0, 0, -1)
+	negative := setGoal()
+	negative.Target.Amount = -100
+	duplicate := setGoal()
+	duplicate.MonetaryAccountIDs = []primitives.MonetaryAccountID{savingsAccountID, savingsAccountID}
+
+	for _, cmd := range []SetSavingsGoalCommand{past, negative, duplicate} {
+		if _, err := cmd.applyTo(nil); err == nil {
+			t.Errorf("Expected an error for %+v", cmd)
+		}
+	}
+
+	state := newStateAfter(nil, setGoal())
+	otherUser := setGoal()
+	otherUser.UserID = primitives.UserID(uuid.New())
+	if _, err := otherUser.applyTo(state); err == nil {
+		t.Errorf("Expected an error when changing the goal of another user")
+	}
+}
+
+func Test_RecordGoalBalanceCommand_EmitsOffTrackOnceAndReachedOnce(t *testing.T) {
+	state := newStateAfter(nil, setGoal())
+
+	steps := []struct {
+		cmd      RecordGoalBalanceCommand
+		expected []string
+	}{
+		{balance(10000, january.AddDate(0, 1, 0)), []string{"GoalBalanceRecorded"}},
+		{balance(10000, january.AddDate(0, 2, 0)), []string{"GoalOffTrack"}},
+		{balance(12000, january.AddDate(0, 2, 1)), []string{"GoalBalanceRecorded"}},
+		{balance(30000, january.AddDate(0, 3, 0)), []string{"GoalBalanceRecorded", "GoalBackOnTrack"}},
+		{balance(125000, january.AddDate(0, 4, 0)), []string{"GoalBalanceRecorded", "GoalReached"}},
+		{balance(5000, january.AddDate(0, 5, 0)), []string{"GoalBalanceRecorded"}},
+	}
+
+	for i, step := range steps {
+		events, err := step.cmd.applyTo(state)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		eventTypes := eventTypesOf(events)
+		if len(eventTypes) != len(step.expected) {
+			t.Fatalf("Step %d expected %v, found %v", i, step.expected, eventTypes)
+		}
+		for j := range eventTypes {
+			if eventTypes[j] != step.expected[j] {
+				t.Errorf("Step %d expected %v, found %v", i, step.expected, eventTypes)
+			}
+		}
+		state = newStateAfter(state, step.cmd)
+	}
+}
+
+func Test_RecordGoalBalanceCommand_IgnoresUnlinkedAccountsAndOtherCurrencies(t *testing.T) {
+	state := newStateAfter(nil, setGoal())
+
+	unlinked := balance(10000, january)
+	unlinked.MonetaryAccountID = primitives.MonetaryAccountID(uuid.New())
+	dollars := balance(10000, january)
+	dollars.Balance.CurrencyCode = "USD"
+
+	for _, cmd := range []RecordGoalBalanceCommand{unlinked, dollars} {
+		if events, _ := cmd.applyTo(state); len(events) != 0 {
+			t.Errorf("Expected zero events, found %v", eventTypesOf(events))
+		}
+	}
+}
+
+func Test_SavingsGoalChanged_ForgetsUnlinkedAccounts(t *testing.T) {
+	state := newStateAfter(nil, setGoal())
+	state = newStateAfter(state, balance(30000, january))
+
+	cmd := setGoal()
+	cmd.MonetaryAccountIDs = []primitives.MonetaryAccountID{primitives.MonetaryAccountID(uuid.New())}
+	cmd.Timestamp = january.AddDate(0, 0, 1)
+	state = newStateAfter(state, cmd)
+
+	if saved := state.progress.Saved(); saved.Amount() != 0 {
+		t.Errorf("Expected nothing saved on the newly linked account, found %d", saved.Amount())
+	}
+	if !state.progress.Since.Equal(january) {
+		t.Errorf("Expected the plan to start when the goal was first set, found %s", state.progress.Since)
+	}
+}
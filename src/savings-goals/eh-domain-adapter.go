@@ -0,0 +1,222 @@
+package savingsgoals
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(GoalAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// GoalAggregateType is the aggregate type for the savings goal
+const GoalAggregateType = eh.AggregateType("savings-goal")
+
+// Aggregate is an aggregate for a savings goal
+type Aggregate struct {
+	*events.AggregateBase
+	*goalState
+}
+
+const EhSetSavingsGoalCommand = eh.CommandType("savings-goal:set")
+const EhRemoveSavingsGoalCommand = eh.CommandType("savings-goal:remove")
+const EhRecordGoalBalanceCommand = eh.CommandType("savings-goal:record-balance")
+
+const EhSavingsGoalSet = eh.EventType("savings-goal:set")
+const EhSavingsGoalChanged = eh.EventType("savings-goal:changed")
+const EhSavingsGoalRemoved = eh.EventType("savings-goal:removed")
+const EhGoalBalanceRecorded = eh.EventType("savings-goal:balance-recorded")
+const EhGoalReached = eh.EventType("savings-goal:reached")
+const EhGoalOffTrack = eh.EventType("savings-goal:off-track")
+const EhGoalBackOnTrack = eh.EventType("savings-goal:back-on-track")
+
+// CommandTypes are all command types handled by the savings goal aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhSetSavingsGoalCommand,
+		EhRemoveSavingsGoalCommand,
+		EhRecordGoalBalanceCommand,
+	}
+}
+
+func (cmd SetSavingsGoalCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.GoalID)
+}
+
+func (cmd SetSavingsGoalCommand) AggregateType() eh.AggregateType {
+	return GoalAggregateType
+}
+
+func (cmd SetSavingsGoalCommand) CommandType() eh.CommandType {
+	return EhSetSavingsGoalCommand
+}
+
+func (cmd RemoveSavingsGoalCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.GoalID)
+}
+
+func (cmd RemoveSavingsGoalCommand) AggregateType() eh.AggregateType {
+	return GoalAggregateType
+}
+
+func (cmd RemoveSavingsGoalCommand) CommandType() eh.CommandType {
+	return EhRemoveSavingsGoalCommand
+}
+
+func (cmd RecordGoalBalanceCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.GoalID)
+}
+
+func (cmd RecordGoalBalanceCommand) AggregateType() eh.AggregateType {
+	return GoalAggregateType
+}
+
+func (cmd RecordGoalBalanceCommand) CommandType() eh.CommandType {
+	return EhRecordGoalBalanceCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(GoalAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhSavingsGoalSet, func() eh.EventData {
+		return &SavingsGoalSet{}
+	})
+
+	eh.RegisterEventData(EhSavingsGoalChanged, func() eh.EventData {
+		return &SavingsGoalChanged{}
+	})
+
+	eh.RegisterEventData(EhSavingsGoalRemoved, func() eh.EventData {
+		return &SavingsGoalRemoved{}
+	})
+
+	eh.RegisterEventData(EhGoalBalanceRecorded, func() eh.EventData {
+		return &GoalBalanceRecorded{}
+	})
+
+	eh.RegisterEventData(EhGoalReached, func() eh.EventData {
+		return &GoalReached{}
+	})
+
+	eh.RegisterEventData(EhGoalOffTrack, func() eh.EventData {
+		return &GoalOffTrack{}
+	})
+
+	eh.RegisterEventData(EhGoalBackOnTrack, func() eh.EventData {
+		return &GoalBackOnTrack{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.goalState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.goalState = eventInDomain.appliedTo(a.goalState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (GoalEvent, error) {
+	switch event.EventType() {
+	case EhSavingsGoalSet:
+		return event.Data().(GoalEvent), nil
+	case EhSavingsGoalChanged:
+		return event.Data().(GoalEvent), nil
+	case EhSavingsGoalRemoved:
+		return event.Data().(GoalEvent), nil
+	case EhGoalBalanceRecorded:
+		return event.Data().(GoalEvent), nil
+	case EhGoalReached:
+		return event.Data().(GoalEvent), nil
+	case EhGoalOffTrack:
+		return event.Data().(GoalEvent), nil
+	case EhGoalBackOnTrack:
+		return event.Data().(GoalEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event GoalEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case SavingsGoalSet:
+		return EhSavingsGoalSet, nil
+	case SavingsGoalChanged:
+		return EhSavingsGoalChanged, nil
+	case SavingsGoalRemoved:
+		return EhSavingsGoalRemoved, nil
+	case GoalBalanceRecorded:
+		return EhGoalBalanceRecorded, nil
+	case GoalReached:
+		return EhGoalReached, nil
+	case GoalOffTrack:
+		return EhGoalOffTrack, nil
+	case GoalBackOnTrack:
+		return EhGoalBackOnTrack, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (GoalCommand, error) {
+	switch cmd := cmd.(type) {
+	case SetSavingsGoalCommand:
+		return cmd, nil
+	case RemoveSavingsGoalCommand:
+		return cmd, nil
+	case RecordGoalBalanceCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
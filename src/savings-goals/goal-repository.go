@@ -0,0 +1,147 @@
+package savingsgoals
+
+import (
+	"app/primitives"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/eventhandler/projector"
+	"github.com/looplab/eventhorizon/repo/memory"
+)
+
+// GoalOverview is the read model of a savings goal
+type GoalOverview struct {
+	ID                 primitives.GoalID
+	UserID             primitives.UserID
+	Name               string
+	MonetaryAccountIDs []primitives.MonetaryAccountID
+	Progress           Progress
+	Reached            bool
+	OffTrack           bool
+}
+
+// EntityID implements the EntityID method of the eventhorizon.Entity interface.
+func (overview *GoalOverview) EntityID() uuid.UUID {
+	return uuid.UUID(overview.ID)
+}
+
+// GoalRepository gives access to the savings goals of users
+type GoalRepository interface {
+	FindForUser(ctx context.Context, userID primitives.UserID) ([]GoalOverview, error)
+}
+
+type readRepoGoalRepository struct {
+	repo eh.ReadRepo
+}
+
+// FindForUser finds the goals of the user, the closest deadline first
+func (repository readRepoGoalRepository) FindForUser(ctx context.Context, userID primitives.UserID) ([]GoalOverview, error) {
+	entities, err := repository.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var goals []GoalOverview
+	for _, entity := range entities {
+		overview, ok := entity.(*GoalOverview)
+		if ok && overview.UserID == userID {
+			goals = append(goals, *overview)
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool {
+		return goals[i].Progress.Deadline.Before(goals[j].Progress.Deadline)
+	})
+	return goals, nil
+}
+
+// SetupReadModel projects the savings goal events into an in memory repository
+func SetupReadModel(eventBus eh.EventBus) (GoalRepository, error) {
+	repo := memory.NewRepo()
+	repo.SetEntityFactory(func() eh.Entity { return &GoalOverview{} })
+
+	goalProjector := projector.NewEventHandler(&Projector{}, repo)
+	goalProjector.SetEntityFactory(func() eh.Entity { return &GoalOverview{} })
+
+	err := eventBus.AddHandler(eh.MatchAnyEventOf(
+		EhSavingsGoalSet,
+		EhSavingsGoalChanged,
+		EhSavingsGoalRemoved,
+		EhGoalBalanceRecorded,
+		EhGoalReached,
+		EhGoalOffTrack,
+		EhGoalBackOnTrack,
+	), goalProjector)
+	if err != nil {
+		return nil, fmt.Errorf("could not add savings goal projector: %w", err)
+	}
+
+	return readRepoGoalRepository{repo: repo}, nil
+}
+
+// Projector projects savings goal events onto a GoalOverview
+type Projector struct{}
+
+// ProjectorType implements the ProjectorType method of the eventhorizon.Projector interface.
+func (p *Projector) ProjectorType() projector.Type {
+	return "savings-goal"
+}
+
+// Project implements the Project method of the eventhorizon.Projector interface.
+func (p *Projector) Project(ctx context.Context, event eh.Event, entity eh.Entity) (eh.Entity, error) {
+	overview, ok := entity.(*GoalOverview)
+	if !ok {
+		return nil, fmt.Errorf("model is of incorrect type")
+	}
+
+	res := *overview
+	res.Progress.Balances = make(map[primitives.MonetaryAccountID]int64, len(overview.Progress.Balances))
+	for id, balance := range overview.Progress.Balances {
+		res.Progress.Balances[id] = balance
+	}
+
+	switch data := event.Data().(type) {
+	case *SavingsGoalSet:
+		res.ID = data.ID
+		res.UserID = data.UserID
+		res.Name = data.Name
+		res.MonetaryAccountIDs = data.MonetaryAccountIDs
+		res.Progress.Target = data.Target
+		res.Progress.Since = data.Since
+		res.Progress.Deadline = data.Deadline
+	case *SavingsGoalChanged:
+		res.Name = data.Name
+		res.MonetaryAccountIDs = data.MonetaryAccountIDs
+		linked := make(map[primitives.MonetaryAccountID]bool, len(data.MonetaryAccountIDs))
+		for _, id := range data.MonetaryAccountIDs {
+			linked[id] = true
+		}
+		for id := range res.Progress.Balances {
+			if !linked[id] {
+				delete(res.Progress.Balances, id)
+			}
+		}
+		if res.Progress.Target.Amount() != data.Target.Amount() {
+			res.Reached = false
+		}
+		res.Progress.Target = data.Target
+		res.Progress.Deadline = data.Deadline
+	case *SavingsGoalRemoved:
+		return nil, nil
+	case *GoalBalanceRecorded:
+		res.Progress.Balances[data.MonetaryAccountID] = data.Balance
+	case *GoalReached:
+		res.Reached = true
+		res.OffTrack = false
+	case *GoalOffTrack:
+		res.OffTrack = true
+	case *GoalBackOnTrack:
+		res.OffTrack = false
+	default:
+		return nil, fmt.Errorf("could not project event: %s", event.EventType())
+	}
+
+	return &res, nil
+}
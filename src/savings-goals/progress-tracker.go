@@ -0,0 +1,135 @@
+package savingsgoals
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type balanceSnapshot struct {
+	balance   money.Money
+	timestamp time.Time
+}
+
+// ProgressTracker records the balance snapshots of the account-information domain on the savings goals the accounts are linked to
+type ProgressTracker struct {
+	handler eh.CommandHandler
+
+	mu        sync.Mutex
+	snapshots map[primitives.MonetaryAccountID]balanceSnapshot
+	goals     map[primitives.MonetaryAccountID]map[primitives.GoalID]bool
+	accounts  map[primitives.GoalID][]primitives.MonetaryAccountID
+}
+
+// NewProgressTracker creates a ProgressTracker that dispatches its commands to the handler
+func NewProgressTracker(handler eh.CommandHandler) *ProgressTracker {
+	return &ProgressTracker{
+		handler:   handler,
+		snapshots: make(map[primitives.MonetaryAccountID]balanceSnapshot),
+		goals:     make(map[primitives.MonetaryAccountID]map[primitives.GoalID]bool),
+		accounts:  make(map[primitives.GoalID][]primitives.MonetaryAccountID),
+	}
+}
+
+// Matcher matches all events the tracker is interested in
+func (tracker *ProgressTracker) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhMonetaryAccountBalanceSnapshotted,
+		EhSavingsGoalSet,
+		EhSavingsGoalChanged,
+		EhSavingsGoalRemoved,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (tracker *ProgressTracker) HandlerType() eh.EventHandlerType {
+	return "savings-goal-progress-tracker"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (tracker *ProgressTracker) HandleEvent(ctx context.Context, event eh.Event) error {
+	var commands []eh.Command
+
+	switch data := event.Data().(type) {
+	case *accountinformation.MonetaryAccountBalanceSnapshotted:
+		commands = tracker.balanceSnapshotted(data.ID, balanceSnapshot{balance: data.Balance, timestamp: data.Timestamp})
+	case *SavingsGoalSet:
+		commands = tracker.accountsLinked(data.ID, data.MonetaryAccountIDs)
+	case *SavingsGoalChanged:
+		commands = tracker.accountsLinked(data.ID, data.MonetaryAccountIDs)
+	case *SavingsGoalRemoved:
+		tracker.accountsLinked(data.ID, nil)
+	}
+
+	var firstErr error
+	for _, cmd := range commands {
+		if err := tracker.handler.HandleCommand(ctx, cmd); err != nil {
+			log.Printf("Could not track savings goal progress: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (tracker *ProgressTracker) balanceSnapshotted(monetaryAccountID primitives.MonetaryAccountID, snapshot balanceSnapshot) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if previous, ok := tracker.snapshots[monetaryAccountID]; ok && previous.timestamp.After(snapshot.timestamp) {
+		return nil
+	}
+	tracker.snapshots[monetaryAccountID] = snapshot
+
+	var commands []eh.Command
+	for goalID := range tracker.goals[monetaryAccountID] {
+		commands = append(commands, recordBalance(goalID, monetaryAccountID, snapshot))
+	}
+	return commands
+}
+
+// accountsLinked replaces the accounts linked to the goal, and records the last known balances of the accounts on it
+func (tracker *ProgressTracker) accountsLinked(goalID primitives.GoalID, monetaryAccountIDs []primitives.MonetaryAccountID) []eh.Command {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for _, monetaryAccountID := range tracker.accounts[goalID] {
+		delete(tracker.goals[monetaryAccountID], goalID)
+	}
+	if len(monetaryAccountIDs) == 0 {
+		delete(tracker.accounts, goalID)
+		return nil
+	}
+	tracker.accounts[goalID] = monetaryAccountIDs
+
+	var commands []eh.Command
+	for _, monetaryAccountID := range monetaryAccountIDs {
+		goals, ok := tracker.goals[monetaryAccountID]
+		if !ok {
+			goals = make(map[primitives.GoalID]bool)
+			tracker.goals[monetaryAccountID] = goals
+		}
+		goals[goalID] = true
+
+		if snapshot, ok := tracker.snapshots[monetaryAccountID]; ok {
+			commands = append(commands, recordBalance(goalID, monetaryAccountID, snapshot))
+		}
+	}
+	return commands
+}
+
+func recordBalance(goalID primitives.GoalID, monetaryAccountID primitives.MonetaryAccountID, snapshot balanceSnapshot) RecordGoalBalanceCommand {
+	return RecordGoalBalanceCommand{
+		GoalID:            goalID,
+		MonetaryAccountID: monetaryAccountID,
+		Balance:           primitives.NewMoneyForCommand(snapshot.balance),
+		Timestamp:         snapshot.timestamp,
+	}
+}
@@ -0,0 +1,58 @@
+package savingsgoals
+
+import (
+	accountinformation "app/account-information"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+type recordingCommandHandler struct {
+	commands []eh.Command
+}
+
+func (handler *recordingCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	handler.commands = append(handler.commands, cmd)
+	return nil
+}
+
+func Test_ProgressTracker_RecordsBalancesOfLinkedAccounts(t *testing.T) {
+	ctx := context.Background()
+	commands := &recordingCommandHandler{}
+	tracker := NewProgressTracker(commands)
+
+	snapshot := func(amount int64, timestamp time.Time) eh.Event {
+		return eh.NewEvent(accountinformation.EhMonetaryAccountBalanceSnapshotted, &accountinformation.MonetaryAccountBalanceSnapshotted{ID: savingsAccountID, Balance: *money.New(amount, "EUR"), Timestamp: timestamp}, time.Now())
+	}
+
+	tracker.HandleEvent(ctx, snapshot(20000, january))
+	if len(commands.commands) != 0 {
+		t.Fatalf("Expected no commands without goals, got %v", commands.commands)
+	}
+
+	set := newSavingsGoalSet(setGoal())
+	tracker.HandleEvent(ctx, eh.NewEvent(EhSavingsGoalSet, &set, time.Now()))
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected the last known balance to be recorded on the new goal, got %v", commands.commands)
+	}
+
+	tracker.HandleEvent(ctx, snapshot(10000, january.AddDate(0, 0, -1)))
+	tracker.HandleEvent(ctx, snapshot(30000, january.AddDate(0, 0, 1)))
+	if len(commands.commands) != 2 {
+		t.Fatalf("Expected only the newer snapshot to be recorded, got %v", commands.commands)
+	}
+	cmd, ok := commands.commands[1].(RecordGoalBalanceCommand)
+	if !ok || cmd.GoalID != goalID || cmd.Balance.Amount != 30000 {
+		t.Errorf("Expected the balance to be recorded on the goal, got %+v", commands.commands[1])
+	}
+
+	removed := newSavingsGoalRemoved(goalID)
+	tracker.HandleEvent(ctx, eh.NewEvent(EhSavingsGoalRemoved, &removed, time.Now()))
+	tracker.HandleEvent(ctx, snapshot(40000, january.AddDate(0, 0, 2)))
+	if len(commands.commands) != 2 {
+		t.Errorf("Expected no balances recorded on a removed goal, got %v", commands.commands)
+	}
+}
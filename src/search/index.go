@@ -0,0 +1,320 @@
+package search
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"app/reporting"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Fields of a transaction that are searched in full-text
+const (
+	DescriptionField  = "description"
+	CounterpartyField = "counterparty"
+	IBANField         = "iban"
+	CategoryField     = "category"
+	NoteField         = "note"
+	TagsField         = "tags"
+)
+
+// DefaultLimit is the number of hits on a page when the query has no limit
+const DefaultLimit = 20
+
+// Query searches the transactions of a user. Text matches words that start with each of the words of the text,
+// the other filters are only applied when they are set
+type Query struct {
+	UserID primitives.UserID
+	Text   string
+	Period reporting.Period
+	// MinAmount and MaxAmount are inclusive bounds of the absolute amount in minor units
+	MinAmount         *int64
+	MaxAmount         *int64
+	MonetaryAccountID *primitives.MonetaryAccountID
+	Category          primitives.Category
+	Offset            int
+	Limit             int
+}
+
+// Match is a highlighted word, as offsets in runes of the text of the field
+type Match struct {
+	Start int
+	End   int
+}
+
+// Highlight are the words of a field that matched the text of the query
+type Highlight struct {
+	Field   string
+	Text    string
+	Matches []Match
+}
+
+// Hit is a transaction that matches the query
+type Hit struct {
+	Transaction reporting.ReportedTransaction
+	Highlights  []Highlight
+}
+
+// Result is a page of hits, with the total number of transactions that match the query
+type Result struct {
+	Total int
+	Hits  []Hit
+}
+
+type documentKey struct {
+	monetaryAccountID primitives.MonetaryAccountID
+	transactionID     primitives.TransactionID
+}
+
+// Index is a search index over the transactions of the account-information domain, with an inverted index of the
+// words of the transactions for full-text matching
+type Index struct {
+	mu        *sync.RWMutex
+	owners    map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	documents map[documentKey]reporting.ReportedTransaction
+	words     map[string]map[documentKey]bool
+}
+
+// NewIndex creates an empty Index
+func NewIndex() Index {
+	res := new(Index)
+	res.mu = new(sync.RWMutex)
+	res.owners = make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool)
+	res.documents = make(map[documentKey]reporting.ReportedTransaction)
+	res.words = make(map[string]map[documentKey]bool)
+	return *res
+}
+
+// Matcher matches the events that change what is searched or what is shown of the hits
+func (index Index) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhMonetaryAccountUserAdded,
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhTransactionCategorised,
+		accountinformation.EhTransactionNoteSet,
+		accountinformation.EhTransactionTagsSet,
+		accountinformation.EhTransactionSplit,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (index Index) HandlerType() eh.EventHandlerType {
+	return "transaction-search-index"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (index Index) HandleEvent(ctx context.Context, event eh.Event) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *accountinformation.MonetaryAccountUserAdded:
+		owners, ok := index.owners[data.ID]
+		if !ok {
+			owners = make(map[primitives.UserID]bool)
+			index.owners[data.ID] = owners
+		}
+		owners[data.UserID] = true
+	case *accountinformation.NewTransactionFound:
+		key := documentKey{data.MonetaryAccountID, data.ID}
+		if _, ok := index.documents[key]; ok {
+			return nil
+		}
+		index.add(key, reporting.ReportedTransaction{
+			ID:                data.ID,
+			MonetaryAccountID: data.MonetaryAccountID,
+			Counterparty:      data.Counterparty(),
+			Amount:            data.Amount,
+			Outgoing:          data.MonetaryAccountID == data.FromMonetaryAccountID,
+			Category:          primitives.Uncategorised,
+			Description:       data.Description,
+			TransactionDate:   data.TransactionDate,
+		})
+	case *accountinformation.TransactionCategorised:
+		index.update(documentKey{data.ID, data.TransactionID}, func(transaction *reporting.ReportedTransaction) {
+			transaction.Category = data.Category
+		})
+	case *accountinformation.TransactionNoteSet:
+		index.update(documentKey{data.ID, data.TransactionID}, func(transaction *reporting.ReportedTransaction) {
+			transaction.Note = data.Note
+		})
+	case *accountinformation.TransactionTagsSet:
+		index.update(documentKey{data.ID, data.TransactionID}, func(transaction *reporting.ReportedTransaction) {
+			transaction.Tags = append([]string{}, data.Tags...)
+		})
+	case *accountinformation.TransactionSplit:
+		index.update(documentKey{data.ID, data.TransactionID}, func(transaction *reporting.ReportedTransaction) {
+			transaction.SplitParts = append([]accountinformation.TransactionSplitPart{}, data.Parts...)
+		})
+	}
+	return nil
+}
+
+func (index Index) add(key documentKey, transaction reporting.ReportedTransaction) {
+	index.documents[key] = transaction
+	for _, field := range fieldsOf(transaction) {
+		for _, token := range tokenize(field.Text) {
+			documents, ok := index.words[token.text]
+			if !ok {
+				documents = make(map[documentKey]bool)
+				index.words[token.text] = documents
+			}
+			documents[key] = true
+		}
+	}
+}
+
+func (index Index) remove(key documentKey) {
+	for _, field := range fieldsOf(index.documents[key]) {
+		for _, token := range tokenize(field.Text) {
+			delete(index.words[token.text], key)
+			if len(index.words[token.text]) == 0 {
+				delete(index.words, token.text)
+			}
+		}
+	}
+	delete(index.documents, key)
+}
+
+// update indexes the words of the transaction again after it changed
+func (index Index) update(key documentKey, change func(transaction *reporting.ReportedTransaction)) {
+	transaction, ok := index.documents[key]
+	if !ok {
+		return
+	}
+	index.remove(key)
+	change(&transaction)
+	index.add(key, transaction)
+}
+
+// fieldsOf are the texts of the transaction that are searched in full-text
+func fieldsOf(transaction reporting.ReportedTransaction) []Highlight {
+	res := []Highlight{{Field: DescriptionField, Text: transaction.Description}}
+	if transaction.Counterparty.HasName {
+		res = append(res, Highlight{Field: CounterpartyField, Text: transaction.Counterparty.Name})
+	}
+	if transaction.Counterparty.HasIBAN {
+		res = append(res, Highlight{Field: IBANField, Text: transaction.Counterparty.IBAN.PrintCode + " " + transaction.Counterparty.IBAN.Code})
+	}
+	res = append(res, Highlight{Field: CategoryField, Text: string(transaction.Category)})
+	if transaction.Note != "" {
+		res = append(res, Highlight{Field: NoteField, Text: transaction.Note})
+	}
+	if len(transaction.Tags) > 0 {
+		res = append(res, Highlight{Field: TagsField, Text: strings.Join(transaction.Tags, " ")})
+	}
+	return res
+}
+
+// Search returns a page of the transactions of the user that match the query, the most recent transactions first
+func (index Index) Search(query Query) Result {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	queryTerms := terms(query.Text)
+	var hits []Hit
+	for key := range index.candidates(queryTerms) {
+		transaction := index.documents[key]
+		if !index.owners[key.monetaryAccountID][query.UserID] || !query.matches(transaction) {
+			continue
+		}
+		hits = append(hits, Hit{Transaction: transaction, Highlights: highlightsOf(transaction, queryTerms)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i].Transaction, hits[j].Transaction
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.After(b.TransactionDate)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	return Result{Total: len(hits), Hits: page(hits, query.Offset, query.Limit)}
+}
+
+// candidates are the documents that have a word that starts with each of the terms, all documents without terms
+func (index Index) candidates(terms []string) map[documentKey]bool {
+	if len(terms) == 0 {
+		res := make(map[documentKey]bool, len(index.documents))
+		for key := range index.documents {
+			res[key] = true
+		}
+		return res
+	}
+
+	var res map[documentKey]bool
+	for _, term := range terms {
+		matching := make(map[documentKey]bool)
+		for word, documents := range index.words {
+			if !strings.HasPrefix(word, term) {
+				continue
+			}
+			for key := range documents {
+				if res == nil || res[key] {
+					matching[key] = true
+				}
+			}
+		}
+		res = matching
+		if len(res) == 0 {
+			break
+		}
+	}
+	return res
+}
+
+func (query Query) matches(transaction reporting.ReportedTransaction) bool {
+	if !query.Period.Contains(transaction.TransactionDate) {
+		return false
+	}
+	amount := transaction.Amount.Absolute().Amount()
+	if query.MinAmount != nil && amount < *query.MinAmount {
+		return false
+	}
+	if query.MaxAmount != nil && amount > *query.MaxAmount {
+		return false
+	}
+	if query.MonetaryAccountID != nil && transaction.MonetaryAccountID != *query.MonetaryAccountID {
+		return false
+	}
+	return query.Category == "" || transaction.Category == query.Category
+}
+
+func highlightsOf(transaction reporting.ReportedTransaction, terms []string) []Highlight {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var res []Highlight
+	for _, field := range fieldsOf(transaction) {
+		if field.Field == IBANField {
+			// the IBAN is indexed both printed and compact, it is highlighted as it matched
+			field.Text = transaction.Counterparty.IBAN.PrintCode
+			if len(highlight(field.Text, terms)) == 0 {
+				field.Text = transaction.Counterparty.IBAN.Code
+			}
+		}
+		if matches := highlight(field.Text, terms); len(matches) > 0 {
+			field.Matches = matches
+			res = append(res, field)
+		}
+	}
+	return res
+}
+
+func page(hits []Hit, offset int, limit int) []Hit {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if offset < 0 || offset >= len(hits) {
+		return nil
+	}
+	if offset+limit > len(hits) {
+		return hits[offset:]
+	}
+	return hits[offset : offset+limit]
+}
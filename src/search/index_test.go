@@ -0,0 +1,116 @@
+package search
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"app/reporting"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+var (
+	userID    = primitives.UserID(uuid.New())
+	accountID = primitives.MonetaryAccountID(uuid.New())
+)
+
+func newTestIndex(transactions ...accountinformation.NewTransactionFound) Index {
+	index := NewIndex()
+	index.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: accountID, UserID: userID}, time.Now()))
+	for i := range transactions {
+		index.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, &transactions[i], time.Now()))
+	}
+	return index
+}
+
+func payment(description string, counterparty string, cents int64, date time.Time) accountinformation.NewTransactionFound {
+	return accountinformation.NewTransactionFound{
+		ID:                    primitives.TransactionID(uuid.New()),
+		MonetaryAccountID:     accountID,
+		FromMonetaryAccountID: accountID,
+		To:                    accountinformation.NewTransactionParty(nil, &counterparty),
+		Amount:                *money.New(cents, "EUR"),
+		Description:           description,
+		TransactionDate:       date,
+	}
+}
+
+func Test_Search_MatchesWordsAndHighlights(t *testing.T) {
+	plumber := payment("Repair of the kitchen sink", "Loodgietersbedrijf De Kraan", 18500, time.Date(2020, time.April, 14, 0, 0, 0, 0, time.UTC))
+	index := newTestIndex(
+		plumber,
+		payment("Kitchen table", "Furniture Store", 45000, time.Date(2020, time.May, 2, 0, 0, 0, 0, time.UTC)),
+	)
+
+	result := index.Search(Query{UserID: userID, Text: "KITCHEN kraan"})
+	if result.Total != 1 || result.Hits[0].Transaction.ID != plumber.ID {
+		t.Fatalf("Expected only the payment to the plumber, got %+v", result)
+	}
+
+	highlights := result.Hits[0].Highlights
+	if len(highlights) != 2 || highlights[0].Field != DescriptionField || highlights[1].Field != CounterpartyField {
+		t.Fatalf("Expected the description and counterparty to be highlighted, got %+v", highlights)
+	}
+	if matches := highlights[0].Matches; len(matches) != 1 || highlights[0].Text[matches[0].Start:matches[0].End] != "kitchen" {
+		t.Errorf("Expected kitchen to be highlighted, got %+v", matches)
+	}
+
+	if result := index.Search(Query{UserID: primitives.UserID(uuid.New()), Text: "kitchen"}); result.Total != 0 {
+		t.Errorf("Expected transactions of other users not to be found, got %+v", result)
+	}
+}
+
+func Test_Search_FiltersAndPages(t *testing.T) {
+	spring := reporting.Period{From: time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)}
+	var transactions []accountinformation.NewTransactionFound
+	for day := 1; day <= 5; day++ {
+		transactions = append(transactions, payment("Groceries", "Supermarket", int64(day)*1000, time.Date(2020, time.April, day, 0, 0, 0, 0, time.UTC)))
+	}
+	transactions = append(transactions, payment("Groceries", "Supermarket", 3000, time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)))
+	index := newTestIndex(transactions...)
+
+	min, max := int64(2000), int64(4000)
+	result := index.Search(Query{UserID: userID, Text: "groc", Period: spring, MinAmount: &min, MaxAmount: &max, Offset: 1, Limit: 1})
+
+	if result.Total != 3 || len(result.Hits) != 1 {
+		t.Fatalf("Expected the second of three hits, got %+v", result)
+	}
+	if hit := result.Hits[0].Transaction; hit.ID != transactions[2].ID {
+		t.Errorf("Expected the most recent transactions first, got %+v", hit)
+	}
+}
+
+func Test_Search_ReindexesCategoryAndIBAN(t *testing.T) {
+	landlord, _ := iban.NewIBAN("NL91ABNA0417164300")
+	rent := payment("Rent", "Landlord", 95000, time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC))
+	rent.To = accountinformation.NewTransactionParty(landlord, nil)
+	index := newTestIndex(rent)
+
+	index.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhTransactionCategorised, &accountinformation.TransactionCategorised{ID: accountID, TransactionID: rent.ID, Category: "Housing"}, time.Now()))
+
+	if result := index.Search(Query{UserID: userID, Text: "housing"}); result.Total != 1 {
+		t.Errorf("Expected the new category to be found, got %+v", result)
+	}
+	if result := index.Search(Query{UserID: userID, Text: string(primitives.Uncategorised)}); result.Total != 0 {
+		t.Errorf("Expected the old category not to be found, got %+v", result)
+	}
+	if result := index.Search(Query{UserID: userID, Text: "NL91ABNA0417164300"}); result.Total != 1 || result.Hits[0].Highlights[0].Field != IBANField {
+		t.Errorf("Expected the compact IBAN to be found, got %+v", result)
+	}
+}
+
+func Test_Search_FindsTags(t *testing.T) {
+	dinner := payment("Dinner", "Bistro", 10000, time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC))
+	index := newTestIndex(dinner)
+
+	index.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhTransactionTagsSet, &accountinformation.TransactionTagsSet{ID: accountID, TransactionID: dinner.ID, Tags: []string{"holiday", "tax"}}, time.Now()))
+
+	if result := index.Search(Query{UserID: userID, Text: "holi"}); result.Total != 1 || result.Hits[0].Highlights[0].Field != TagsField {
+		t.Errorf("Expected the tag to be found, got %+v", result)
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a lower cased word of a text, with the byte offsets of the word in the text
+type token struct {
+	text  string
+	start int
+	end   int
+}
+
+// tokenize splits a text in words of letters and digits
+func tokenize(text string) []token {
+	var res []token
+	start := -1
+	for offset, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = offset
+		}
+		if !isWordRune && start >= 0 {
+			res = append(res, token{text: strings.ToLower(text[start:offset]), start: start, end: offset})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		res = append(res, token{text: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return res
+}
+
+// terms are the distinct words of a query
+func terms(query string) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, token := range tokenize(query) {
+		if !seen[token.text] {
+			seen[token.text] = true
+			res = append(res, token.text)
+		}
+	}
+	return res
+}
+
+// matchesAny tells whether a word starts with one of the terms, so a query matches while it is being typed
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight finds the words of the text that match the terms
+func highlight(text string, terms []string) []Match {
+	var res []Match
+	for _, token := range tokenize(text) {
+		if matchesAny(token.text, terms) {
+			res = append(res, Match{
+				Start: utf8.RuneCountInString(text[:token.start]),
+				End:   utf8.RuneCountInString(text[:token.end]),
+			})
+		}
+	}
+	return res
+}
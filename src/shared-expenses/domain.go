@@ -0,0 +1,302 @@
+package sharedexpenses
+
+import (
+	"app/primitives"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var parentUUID uuid.UUID
+
+func init() {
+	parentUUID = uuid.MustParse("8f0d2b6e-41a7-4c3e-9d1b-5e7a2c94f031")
+}
+
+// ledgerIDOf derives the id of the ledger aggregate of a household, which differs from the id of the household aggregate
+// because aggregates share the event store
+func ledgerIDOf(householdID primitives.HouseholdID) uuid.UUID {
+	return uuid.NewMD5(parentUUID, []byte("ledger-"+householdID.String()))
+}
+
+// NewExpenseID derives the id of the expense of a transaction, part 0 is the whole transaction and the parts of a
+// split transaction count from 1
+func NewExpenseID(monetaryAccountID primitives.MonetaryAccountID, transactionID primitives.TransactionID, part int) primitives.ExpenseID {
+	return primitives.ExpenseID(uuid.NewMD5(parentUUID, []byte(monetaryAccountID.String()+"-"+transactionID.String()+"-"+strconv.Itoa(part))))
+}
+
+// NewSettlementID derives the id of the settlement of a transfer between members
+func NewSettlementID(transactionID primitives.TransactionID) primitives.SettlementID {
+	return primitives.SettlementID(uuid.NewMD5(parentUUID, []byte("settlement-"+transactionID.String())))
+}
+
+// Share is the weight of a member in an expense, an expense is divided in proportion to the weights
+type Share struct {
+	UserID primitives.UserID
+	Weight int
+}
+
+// Debt is what a member owes the member that paid an expense
+type Debt struct {
+	UserID primitives.UserID
+	Amount money.Money
+}
+
+type expense struct {
+	paidBy primitives.UserID
+	amount money.Money
+	shares []Share
+}
+
+type ledgerState struct {
+	ID          primitives.HouseholdID
+	expenses    map[primitives.ExpenseID]expense
+	settlements map[primitives.SettlementID]bool
+}
+
+func emptyLedgerState(id primitives.HouseholdID) *ledgerState {
+	res := new(ledgerState)
+	res.ID = id
+	res.expenses = make(map[primitives.ExpenseID]expense)
+	res.settlements = make(map[primitives.SettlementID]bool)
+	return res
+}
+
+// copy copies the state, the ledger starts empty with the first expense or settlement of the household
+func (state *ledgerState) copy(id primitives.HouseholdID) *ledgerState {
+	res := emptyLedgerState(id)
+	if state == nil {
+		return res
+	}
+	for expenseID, expense := range state.expenses {
+		res.expenses[expenseID] = expense
+	}
+	for settlementID := range state.settlements {
+		res.settlements[settlementID] = true
+	}
+	return res
+}
+
+type LedgerEvent interface {
+	appliedTo(state *ledgerState) *ledgerState
+}
+
+type LedgerCommand interface {
+	applyTo(state *ledgerState) ([]LedgerEvent, error)
+}
+
+func validateAmount(amount money.Money, field string) error {
+	if money.GetCurrency(amount.Currency().Code) == nil {
+		return primitives.NewValidationError(field, fmt.Sprintf("unknown currency %s", amount.Currency().Code))
+	}
+	if !amount.IsPositive() {
+		return primitives.NewValidationError(field, "not positive")
+	}
+	return nil
+}
+
+func equalShares(a []Share, b []Share) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ShareExpenseCommand shares a transaction, or a part of a split transaction, paid by one member with members
+// of the household. Sharing an expense again replaces the shares
+type ShareExpenseCommand struct {
+	HouseholdID       primitives.HouseholdID
+	ExpenseID         primitives.ExpenseID
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	PaidBy            primitives.UserID
+	Amount            primitives.MoneyForCommand
+	Description       string `eh:"optional"`
+	Date              time.Time
+	Shares            []Share
+}
+
+func (cmd ShareExpenseCommand) applyTo(state *ledgerState) ([]LedgerEvent, error) {
+	amount := cmd.Amount.ToMoney()
+	if err := validateAmount(amount, "Amount"); err != nil {
+		return nil, err
+	}
+
+	members := make(map[primitives.UserID]bool, len(cmd.Shares))
+	for _, share := range cmd.Shares {
+		if share.Weight <= 0 {
+			return nil, primitives.NewValidationError("Shares", "weight of a share is not positive")
+		}
+		if members[share.UserID] {
+			return nil, primitives.NewValidationError("Shares", fmt.Sprintf("member %s has more than one share", share.UserID))
+		}
+		members[share.UserID] = true
+	}
+
+	if state != nil {
+		if existing, ok := state.expenses[cmd.ExpenseID]; ok && existing.paidBy == cmd.PaidBy && equalShares(existing.shares, cmd.Shares) {
+			if equal, err := existing.amount.Equals(&amount); err == nil && equal {
+				return nil, nil
+			}
+		}
+	}
+
+	event, err := newExpenseShared(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return []LedgerEvent{event}, nil
+}
+
+// UnshareExpenseCommand removes an expense from the ledger
+type UnshareExpenseCommand struct {
+	HouseholdID primitives.HouseholdID
+	ExpenseID   primitives.ExpenseID
+}
+
+func (cmd UnshareExpenseCommand) applyTo(state *ledgerState) ([]LedgerEvent, error) {
+	if state == nil {
+		return nil, nil
+	}
+	if _, ok := state.expenses[cmd.ExpenseID]; !ok {
+		return nil, nil
+	}
+	return []LedgerEvent{newExpenseUnshared(cmd)}, nil
+}
+
+// RecordSettlementCommand records a payment from one member to another to settle what they owe,
+// a transfer that is recognised as settlement refers to its transaction
+type RecordSettlementCommand struct {
+	HouseholdID       primitives.HouseholdID
+	SettlementID      primitives.SettlementID
+	From              primitives.UserID
+	To                primitives.UserID
+	Amount            primitives.MoneyForCommand
+	MonetaryAccountID primitives.MonetaryAccountID `eh:"optional"`
+	TransactionID     primitives.TransactionID     `eh:"optional"`
+	Date              time.Time
+}
+
+func (cmd RecordSettlementCommand) applyTo(state *ledgerState) ([]LedgerEvent, error) {
+	if cmd.From == cmd.To {
+		return nil, primitives.NewValidationError("To", "a member can not settle with itself")
+	}
+	if err := validateAmount(cmd.Amount.ToMoney(), "Amount"); err != nil {
+		return nil, err
+	}
+	if state != nil && state.settlements[cmd.SettlementID] {
+		return nil, nil
+	}
+	return []LedgerEvent{newSettlementRecorded(cmd)}, nil
+}
+
+// ExpenseShared is an expense paid by a member, divided over the members with a share. The debts are what the
+// other members owe the member that paid, the amount is allocated in proportion to the weights without losing cents
+type ExpenseShared struct {
+	ID                primitives.HouseholdID
+	ExpenseID         primitives.ExpenseID
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	PaidBy            primitives.UserID
+	Amount            money.Money
+	Description       string
+	Date              time.Time
+	Shares            []Share
+	Debts             []Debt
+}
+
+func newExpenseShared(cmd ShareExpenseCommand) (ExpenseShared, error) {
+	amount := cmd.Amount.ToMoney()
+	weights := make([]int, 0, len(cmd.Shares))
+	for _, share := range cmd.Shares {
+		weights = append(weights, share.Weight)
+	}
+
+	var debts []Debt
+	if len(weights) > 0 {
+		allocated, err := amount.Allocate(weights...)
+		if err != nil {
+			return ExpenseShared{}, primitives.NewValidationError("Shares", err.Error())
+		}
+		for i, share := range cmd.Shares {
+			if share.UserID != cmd.PaidBy && allocated[i].IsPositive() {
+				debts = append(debts, Debt{UserID: share.UserID, Amount: *allocated[i]})
+			}
+		}
+	}
+
+	res := new(ExpenseShared)
+	res.ID = cmd.HouseholdID
+	res.ExpenseID = cmd.ExpenseID
+	res.MonetaryAccountID = cmd.MonetaryAccountID
+	res.TransactionID = cmd.TransactionID
+	res.PaidBy = cmd.PaidBy
+	res.Amount = amount
+	res.Description = cmd.Description
+	res.Date = cmd.Date
+	res.Shares = append([]Share{}, cmd.Shares...)
+	res.Debts = debts
+	return *res, nil
+}
+
+func (event ExpenseShared) appliedTo(state *ledgerState) *ledgerState {
+	res := state.copy(event.ID)
+	res.expenses[event.ExpenseID] = expense{paidBy: event.PaidBy, amount: event.Amount, shares: event.Shares}
+	return res
+}
+
+type ExpenseUnshared struct {
+	ID        primitives.HouseholdID
+	ExpenseID primitives.ExpenseID
+}
+
+func newExpenseUnshared(cmd UnshareExpenseCommand) ExpenseUnshared {
+	res := new(ExpenseUnshared)
+	res.ID = cmd.HouseholdID
+	res.ExpenseID = cmd.ExpenseID
+	return *res
+}
+
+func (event ExpenseUnshared) appliedTo(state *ledgerState) *ledgerState {
+	res := state.copy(event.ID)
+	delete(res.expenses, event.ExpenseID)
+	return res
+}
+
+type SettlementRecorded struct {
+	ID                primitives.HouseholdID
+	SettlementID      primitives.SettlementID
+	From              primitives.UserID
+	To                primitives.UserID
+	Amount            money.Money
+	MonetaryAccountID primitives.MonetaryAccountID
+	TransactionID     primitives.TransactionID
+	Date              time.Time
+}
+
+func newSettlementRecorded(cmd RecordSettlementCommand) SettlementRecorded {
+	res := new(SettlementRecorded)
+	res.ID = cmd.HouseholdID
+	res.SettlementID = cmd.SettlementID
+	res.From = cmd.From
+	res.To = cmd.To
+	res.Amount = cmd.Amount.ToMoney()
+	res.MonetaryAccountID = cmd.MonetaryAccountID
+	res.TransactionID = cmd.TransactionID
+	res.Date = cmd.Date
+	return *res
+}
+
+func (event SettlementRecorded) appliedTo(state *ledgerState) *ledgerState {
+	res := state.copy(event.ID)
+	res.settlements[event.SettlementID] = true
+	return res
+}
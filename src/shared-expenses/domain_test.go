@@ -0,0 +1,114 @@
+package sharedexpenses
+
+import (
+	"app/primitives"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+)
+
+var (
+	householdID = primitives.HouseholdID(uuid.New())
+	alice       = primitives.UserID(uuid.New())
+	bob         = primitives.UserID(uuid.New())
+	carol       = primitives.UserID(uuid.New())
+)
+
+func apply(t *testing.T, state *ledgerState, cmd LedgerCommand) (*ledgerState, []LedgerEvent) {
+	events, err := cmd.applyTo(state)
+	if err != nil {
+		t.Fatalf("Expected %T to be accepted, got %v", cmd, err)
+	}
+	for _, event := range events {
+		state = event.appliedTo(state)
+	}
+	return state, events
+}
+
+func dinner(amount int64, shares ...Share) ShareExpenseCommand {
+	accountID := primitives.MonetaryAccountID(uuid.New())
+	transactionID := primitives.TransactionID(uuid.New())
+	return ShareExpenseCommand{
+		HouseholdID:       householdID,
+		ExpenseID:         NewExpenseID(accountID, transactionID, 0),
+		MonetaryAccountID: accountID,
+		TransactionID:     transactionID,
+		PaidBy:            alice,
+		Amount:            primitives.NewMoneyForCommand(*money.New(amount, "EUR")),
+		Description:       "Dinner",
+		Date:              time.Date(2020, time.March, 1, 20, 0, 0, 0, time.UTC),
+		Shares:            shares,
+	}
+}
+
+func Test_ShareExpenseCommand_AllocatesDebtsByWeight(t *testing.T) {
+	state, events := apply(t, nil, dinner(1000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}, Share{UserID: carol, Weight: 1}))
+
+	if len(events) != 1 {
+		t.Fatalf("Expected the expense to be shared, got %v", events)
+	}
+	debts := events[0].(ExpenseShared).Debts
+	if len(debts) != 2 || debts[0].UserID != bob || debts[1].UserID != carol {
+		t.Fatalf("Expected only the others to owe alice, got %+v", debts)
+	}
+	if debts[0].Amount.Amount()+debts[1].Amount.Amount() != 666 {
+		t.Errorf("Expected the others to owe two thirds without losing cents, got %+v", debts)
+	}
+	if len(state.expenses) != 1 {
+		t.Errorf("Expected the expense in the ledger, got %+v", state.expenses)
+	}
+
+	if _, events := apply(t, state, dinner(1000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}, Share{UserID: carol, Weight: 1})); len(events) != 1 {
+		t.Errorf("Expected another transaction to be another expense, got %v", events)
+	}
+}
+
+func Test_ShareExpenseCommand_IsIdempotent(t *testing.T) {
+	cmd := dinner(3000, Share{UserID: alice, Weight: 2}, Share{UserID: bob, Weight: 1})
+	state, _ := apply(t, nil, cmd)
+
+	if _, events := apply(t, state, cmd); len(events) != 0 {
+		t.Errorf("Expected sharing the same expense again to be ignored, got %v", events)
+	}
+
+	cmd.Shares = []Share{{UserID: alice, Weight: 1}, {UserID: bob, Weight: 1}}
+	if _, events := apply(t, state, cmd); len(events) != 1 || events[0].(ExpenseShared).Debts[0].Amount.Amount() != 1500 {
+		t.Errorf("Expected the shares to be replaced, got %v", events)
+	}
+}
+
+func Test_ShareExpenseCommand_RejectsInvalidShares(t *testing.T) {
+	invalid := []ShareExpenseCommand{
+		dinner(0, Share{UserID: bob, Weight: 1}),
+		dinner(1000, Share{UserID: bob, Weight: 0}),
+		dinner(1000, Share{UserID: bob, Weight: 1}, Share{UserID: bob, Weight: 2}),
+	}
+	for _, cmd := range invalid {
+		if _, err := cmd.applyTo(nil); err == nil {
+			t.Errorf("Expected %+v to be rejected", cmd)
+		}
+	}
+}
+
+func Test_RecordSettlementCommand_IsIdempotent(t *testing.T) {
+	cmd := RecordSettlementCommand{
+		HouseholdID:  householdID,
+		SettlementID: NewSettlementID(primitives.TransactionID(uuid.New())),
+		From:         bob,
+		To:           alice,
+		Amount:       primitives.NewMoneyForCommand(*money.New(500, "EUR")),
+		Date:         time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC),
+	}
+	state, _ := apply(t, nil, cmd)
+
+	if _, events := apply(t, state, cmd); len(events) != 0 {
+		t.Errorf("Expected the same settlement to be ignored, got %v", events)
+	}
+
+	cmd.To = bob
+	if _, err := cmd.applyTo(state); err == nil {
+		t.Errorf("Expected settling with yourself to be rejected")
+	}
+}
@@ -0,0 +1,186 @@
+package sharedexpenses
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(LedgerAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// LedgerAggregateType is the aggregate type for the shared expense ledger of a household
+const LedgerAggregateType = eh.AggregateType("shared-expense-ledger")
+
+// Aggregate is an aggregate for a shared expense ledger of a household
+type Aggregate struct {
+	*events.AggregateBase
+	*ledgerState
+}
+
+const EhShareExpenseCommand = eh.CommandType("shared-expense-ledger:share-expense")
+const EhUnshareExpenseCommand = eh.CommandType("shared-expense-ledger:unshare-expense")
+const EhRecordSettlementCommand = eh.CommandType("shared-expense-ledger:record-settlement")
+
+const EhExpenseShared = eh.EventType("shared-expense-ledger:expense-shared")
+const EhExpenseUnshared = eh.EventType("shared-expense-ledger:expense-unshared")
+const EhSettlementRecorded = eh.EventType("shared-expense-ledger:settlement-recorded")
+
+// CommandTypes are all command types handled by the shared expense ledger of a household aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhShareExpenseCommand,
+		EhUnshareExpenseCommand,
+		EhRecordSettlementCommand,
+	}
+}
+
+func (cmd ShareExpenseCommand) AggregateID() uuid.UUID {
+	return ledgerIDOf(cmd.HouseholdID)
+}
+
+func (cmd ShareExpenseCommand) AggregateType() eh.AggregateType {
+	return LedgerAggregateType
+}
+
+func (cmd ShareExpenseCommand) CommandType() eh.CommandType {
+	return EhShareExpenseCommand
+}
+
+func (cmd UnshareExpenseCommand) AggregateID() uuid.UUID {
+	return ledgerIDOf(cmd.HouseholdID)
+}
+
+func (cmd UnshareExpenseCommand) AggregateType() eh.AggregateType {
+	return LedgerAggregateType
+}
+
+func (cmd UnshareExpenseCommand) CommandType() eh.CommandType {
+	return EhUnshareExpenseCommand
+}
+
+func (cmd RecordSettlementCommand) AggregateID() uuid.UUID {
+	return ledgerIDOf(cmd.HouseholdID)
+}
+
+func (cmd RecordSettlementCommand) AggregateType() eh.AggregateType {
+	return LedgerAggregateType
+}
+
+func (cmd RecordSettlementCommand) CommandType() eh.CommandType {
+	return EhRecordSettlementCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(LedgerAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhExpenseShared, func() eh.EventData {
+		return &ExpenseShared{}
+	})
+
+	eh.RegisterEventData(EhExpenseUnshared, func() eh.EventData {
+		return &ExpenseUnshared{}
+	})
+
+	eh.RegisterEventData(EhSettlementRecorded, func() eh.EventData {
+		return &SettlementRecorded{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.ledgerState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.ledgerState = eventInDomain.appliedTo(a.ledgerState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (LedgerEvent, error) {
+	switch event.EventType() {
+	case EhExpenseShared:
+		return event.Data().(LedgerEvent), nil
+	case EhExpenseUnshared:
+		return event.Data().(LedgerEvent), nil
+	case EhSettlementRecorded:
+		return event.Data().(LedgerEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event LedgerEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case ExpenseShared:
+		return EhExpenseShared, nil
+	case ExpenseUnshared:
+		return EhExpenseUnshared, nil
+	case SettlementRecorded:
+		return EhSettlementRecorded, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (LedgerCommand, error) {
+	switch cmd := cmd.(type) {
+	case ShareExpenseCommand:
+		return cmd, nil
+	case UnshareExpenseCommand:
+		return cmd, nil
+	case RecordSettlementCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}
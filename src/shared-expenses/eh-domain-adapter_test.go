@@ -0,0 +1,52 @@
+package sharedexpenses
+
+import (
+	"app/households"
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	eventbus "github.com/looplab/eventhorizon/eventbus/local"
+	eventstore "github.com/looplab/eventhorizon/eventstore/memory"
+)
+
+func Test_SetupDomain_SharesTheEventStoreWithHouseholds(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.NewEventStore()
+	bus := eventbus.NewEventBus(nil)
+
+	householdsHandler, err := households.SetupDomain(store, bus)
+	if err != nil {
+		t.Fatalf("Could not setup households: %v", err)
+	}
+	ledgerHandler, err := SetupDomain(store, bus)
+	if err != nil {
+		t.Fatalf("Could not setup shared expenses: %v", err)
+	}
+
+	commands := []struct {
+		handler eh.CommandHandler
+		cmd     eh.Command
+	}{
+		{householdsHandler, households.CreateHouseholdCommand{HouseholdID: householdID, Name: "Home", OwnerUserID: alice}},
+		{ledgerHandler, dinner(3000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1})},
+		{ledgerHandler, RecordSettlementCommand{
+			HouseholdID:  householdID,
+			SettlementID: primitives.SettlementID(uuid.New()),
+			From:         bob,
+			To:           alice,
+			Amount:       primitives.NewMoneyForCommand(*money.New(1500, "EUR")),
+			Date:         time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, c := range commands {
+		if err := c.handler.HandleCommand(ctx, c.cmd); err != nil {
+			t.Fatalf("Expected %T to be handled, got %v", c.cmd, err)
+		}
+	}
+}
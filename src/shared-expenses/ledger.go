@@ -0,0 +1,252 @@
+package sharedexpenses
+
+import (
+	"app/primitives"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Balance is what a member owes another member of a household in one currency, after expenses and settlements
+type Balance struct {
+	Debtor   primitives.UserID
+	Creditor primitives.UserID
+	Amount   money.Money
+}
+
+// Transfer is a payment of a settle-up plan
+type Transfer struct {
+	From   primitives.UserID
+	To     primitives.UserID
+	Amount money.Money
+}
+
+// pair is two members, ordered by id so both directions share the balance
+type pair struct {
+	first  primitives.UserID
+	second primitives.UserID
+}
+
+func pairOf(a primitives.UserID, b primitives.UserID) pair {
+	if a.String() < b.String() {
+		return pair{first: a, second: b}
+	}
+	return pair{first: b, second: a}
+}
+
+// Ledgers keeps the running balances between the members of each household
+type Ledgers struct {
+	mu       *sync.RWMutex
+	expenses map[primitives.HouseholdID]map[primitives.ExpenseID]ExpenseShared
+	// balances are in minor units per currency, positive when the first of the pair owes the second
+	balances map[primitives.HouseholdID]map[pair]map[string]int64
+}
+
+// NewLedgers creates empty Ledgers
+func NewLedgers() Ledgers {
+	res := new(Ledgers)
+	res.mu = new(sync.RWMutex)
+	res.expenses = make(map[primitives.HouseholdID]map[primitives.ExpenseID]ExpenseShared)
+	res.balances = make(map[primitives.HouseholdID]map[pair]map[string]int64)
+	return *res
+}
+
+// Matcher matches all events of the shared expense ledgers
+func (ledgers Ledgers) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		EhExpenseShared,
+		EhExpenseUnshared,
+		EhSettlementRecorded,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (ledgers Ledgers) HandlerType() eh.EventHandlerType {
+	return "shared-expense-ledgers"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (ledgers Ledgers) HandleEvent(ctx context.Context, event eh.Event) error {
+	ledgers.mu.Lock()
+	defer ledgers.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *ExpenseShared:
+		expenses, ok := ledgers.expenses[data.ID]
+		if !ok {
+			expenses = make(map[primitives.ExpenseID]ExpenseShared)
+			ledgers.expenses[data.ID] = expenses
+		}
+		if previous, ok := expenses[data.ExpenseID]; ok {
+			ledgers.book(previous, -1)
+		}
+		expenses[data.ExpenseID] = *data
+		ledgers.book(*data, 1)
+	case *ExpenseUnshared:
+		if previous, ok := ledgers.expenses[data.ID][data.ExpenseID]; ok {
+			ledgers.book(previous, -1)
+			delete(ledgers.expenses[data.ID], data.ExpenseID)
+		}
+	case *SettlementRecorded:
+		ledgers.owe(data.ID, data.To, data.From, data.Amount.Currency().Code, data.Amount.Amount())
+	}
+	return nil
+}
+
+// book adds the debts of the expense to the balances, or removes them with sign -1
+func (ledgers Ledgers) book(expense ExpenseShared, sign int64) {
+	for _, debt := range expense.Debts {
+		ledgers.owe(expense.ID, debt.UserID, expense.PaidBy, debt.Amount.Currency().Code, sign*debt.Amount.Amount())
+	}
+}
+
+func (ledgers Ledgers) owe(householdID primitives.HouseholdID, debtor primitives.UserID, creditor primitives.UserID, currency string, amount int64) {
+	balances, ok := ledgers.balances[householdID]
+	if !ok {
+		balances = make(map[pair]map[string]int64)
+		ledgers.balances[householdID] = balances
+	}
+	members := pairOf(debtor, creditor)
+	perCurrency, ok := balances[members]
+	if !ok {
+		perCurrency = make(map[string]int64)
+		balances[members] = perCurrency
+	}
+	if members.first == debtor {
+		perCurrency[currency] += amount
+	} else {
+		perCurrency[currency] -= amount
+	}
+}
+
+// BalancesOf returns what the members of the household owe each other, ordered by debtor, creditor and currency
+func (ledgers Ledgers) BalancesOf(householdID primitives.HouseholdID) []Balance {
+	ledgers.mu.RLock()
+	defer ledgers.mu.RUnlock()
+
+	var res []Balance
+	for members, perCurrency := range ledgers.balances[householdID] {
+		for currency, amount := range perCurrency {
+			switch {
+			case amount > 0:
+				res = append(res, Balance{Debtor: members.first, Creditor: members.second, Amount: *money.New(amount, currency)})
+			case amount < 0:
+				res = append(res, Balance{Debtor: members.second, Creditor: members.first, Amount: *money.New(-amount, currency)})
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Debtor != res[j].Debtor {
+			return res[i].Debtor.String() < res[j].Debtor.String()
+		}
+		if res[i].Creditor != res[j].Creditor {
+			return res[i].Creditor.String() < res[j].Creditor.String()
+		}
+		return res[i].Amount.Currency().Code < res[j].Amount.Currency().Code
+	})
+	return res
+}
+
+// Owes returns what the debtor owes the creditor in the household in the currency, negative when the creditor owes the debtor
+func (ledgers Ledgers) Owes(householdID primitives.HouseholdID, debtor primitives.UserID, creditor primitives.UserID, currency string) money.Money {
+	ledgers.mu.RLock()
+	defer ledgers.mu.RUnlock()
+
+	members := pairOf(debtor, creditor)
+	amount := ledgers.balances[householdID][members][currency]
+	if members.first != debtor {
+		amount = -amount
+	}
+	return *money.New(amount, currency)
+}
+
+// ExpensesOf returns the shared expenses of the household, the most recent first
+func (ledgers Ledgers) ExpensesOf(householdID primitives.HouseholdID) []ExpenseShared {
+	ledgers.mu.RLock()
+	defer ledgers.mu.RUnlock()
+
+	res := make([]ExpenseShared, 0, len(ledgers.expenses[householdID]))
+	for _, expense := range ledgers.expenses[householdID] {
+		res = append(res, expense)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Date.Equal(res[j].Date) {
+			return res[i].Date.After(res[j].Date)
+		}
+		return res[i].ExpenseID.String() < res[j].ExpenseID.String()
+	})
+	return res
+}
+
+type netBalance struct {
+	userID primitives.UserID
+	amount int64
+}
+
+// SettleUp returns a plan that settles all balances of the household per currency with as few transfers as the
+// greedy approach finds: the member that owes the most pays the member that is owed the most, until everyone is even.
+// This takes at most one transfer less than the number of members with a balance
+func (ledgers Ledgers) SettleUp(householdID primitives.HouseholdID) []Transfer {
+	ledgers.mu.RLock()
+	net := make(map[string]map[primitives.UserID]int64)
+	for members, perCurrency := range ledgers.balances[householdID] {
+		for currency, amount := range perCurrency {
+			if _, ok := net[currency]; !ok {
+				net[currency] = make(map[primitives.UserID]int64)
+			}
+			net[currency][members.first] -= amount
+			net[currency][members.second] += amount
+		}
+	}
+	ledgers.mu.RUnlock()
+
+	currencies := make([]string, 0, len(net))
+	for currency := range net {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var res []Transfer
+	for _, currency := range currencies {
+		var debtors, creditors []netBalance
+		for userID, amount := range net[currency] {
+			if amount < 0 {
+				debtors = append(debtors, netBalance{userID: userID, amount: -amount})
+			} else if amount > 0 {
+				creditors = append(creditors, netBalance{userID: userID, amount: amount})
+			}
+		}
+		largestFirst := func(balances []netBalance) func(i, j int) bool {
+			return func(i, j int) bool {
+				if balances[i].amount != balances[j].amount {
+					return balances[i].amount > balances[j].amount
+				}
+				return balances[i].userID.String() < balances[j].userID.String()
+			}
+		}
+
+		for len(debtors) > 0 && len(creditors) > 0 {
+			sort.Slice(debtors, largestFirst(debtors))
+			sort.Slice(creditors, largestFirst(creditors))
+
+			amount := debtors[0].amount
+			if creditors[0].amount < amount {
+				amount = creditors[0].amount
+			}
+			res = append(res, Transfer{From: debtors[0].userID, To: creditors[0].userID, Amount: *money.New(amount, currency)})
+
+			debtors[0].amount -= amount
+			creditors[0].amount -= amount
+			if debtors[0].amount == 0 {
+				debtors = debtors[1:]
+			}
+			if creditors[0].amount == 0 {
+				creditors = creditors[1:]
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,145 @@
+package sharedexpenses
+
+import (
+	accountinformation "app/account-information"
+	"app/households"
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+func share(t *testing.T, ledgers Ledgers, cmd ShareExpenseCommand) {
+	event, err := newExpenseShared(cmd)
+	if err != nil {
+		t.Fatalf("Could not share expense: %v", err)
+	}
+	ledgers.HandleEvent(context.Background(), eh.NewEvent(EhExpenseShared, &event, time.Now()))
+}
+
+func Test_Ledgers_KeepsRunningBalancesPerPair(t *testing.T) {
+	ledgers := NewLedgers()
+	share(t, ledgers, dinner(3000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}, Share{UserID: carol, Weight: 1}))
+
+	groceries := dinner(1000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1})
+	groceries.PaidBy = bob
+	share(t, ledgers, groceries)
+
+	if owes := ledgers.Owes(householdID, bob, alice, "EUR"); owes.Amount() != 500 {
+		t.Errorf("Expected bob to owe alice 10 minus 5, got %d", owes.Amount())
+	}
+	if owes := ledgers.Owes(householdID, alice, bob, "EUR"); owes.Amount() != -500 {
+		t.Errorf("Expected alice to be owed by bob, got %d", owes.Amount())
+	}
+	if balances := ledgers.BalancesOf(householdID); len(balances) != 2 {
+		t.Errorf("Expected bob and carol to owe alice, got %+v", balances)
+	}
+
+	ledgers.HandleEvent(context.Background(), eh.NewEvent(EhExpenseUnshared, &ExpenseUnshared{ID: householdID, ExpenseID: groceries.ExpenseID}, time.Now()))
+	if owes := ledgers.Owes(householdID, bob, alice, "EUR"); owes.Amount() != 1000 {
+		t.Errorf("Expected the groceries to no longer count, got %d", owes.Amount())
+	}
+
+	ledgers.HandleEvent(context.Background(), eh.NewEvent(EhSettlementRecorded, &SettlementRecorded{ID: householdID, From: bob, To: alice, Amount: *money.New(1000, "EUR")}, time.Now()))
+	if balances := ledgers.BalancesOf(householdID); len(balances) != 1 || balances[0].Debtor != carol || balances[0].Amount.Amount() != 1000 {
+		t.Errorf("Expected only carol to owe alice after bob settled, got %+v", balances)
+	}
+}
+
+func Test_Ledgers_SettlesUpWithFewTransfers(t *testing.T) {
+	ledgers := NewLedgers()
+	// alice paid for everyone, then bob paid for carol: carol can pay alice directly instead of bob
+	share(t, ledgers, dinner(3000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}, Share{UserID: carol, Weight: 1}))
+	taxi := dinner(1000, Share{UserID: carol, Weight: 1})
+	taxi.PaidBy = bob
+	share(t, ledgers, taxi)
+
+	if balances := ledgers.BalancesOf(householdID); len(balances) != 3 {
+		t.Fatalf("Expected three balances, got %+v", balances)
+	}
+
+	plan := ledgers.SettleUp(householdID)
+	if len(plan) != 1 || plan[0].From != carol || plan[0].To != alice || plan[0].Amount.Amount() != 2000 {
+		t.Errorf("Expected carol to pay alice everything, got %+v", plan)
+	}
+}
+
+type fixedHouseholds []households.Household
+
+func (fixed fixedHouseholds) HouseholdsOf(userID primitives.UserID) []households.Household {
+	return fixed
+}
+
+func Test_SettlementDetector_RecognisesTransfersToCreditors(t *testing.T) {
+	ledgers := NewLedgers()
+	share(t, ledgers, dinner(3000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}))
+
+	commands := &recordingCommandHandler{}
+	detector := NewSettlementDetector(commands, ledgers, fixedHouseholds{{ID: householdID, Members: map[primitives.UserID]households.Role{alice: households.Owner, bob: households.Owner}}})
+	aliceAccount, bobAccount := primitives.MonetaryAccountID(uuid.New()), primitives.MonetaryAccountID(uuid.New())
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: aliceAccount, UserID: alice}, time.Now()))
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: bobAccount, UserID: bob}, time.Now()))
+
+	transfer := func(accountID primitives.MonetaryAccountID, from primitives.MonetaryAccountID, to primitives.MonetaryAccountID) *accountinformation.NewTransactionFound {
+		return &accountinformation.NewTransactionFound{
+			ID:                    primitives.TransactionID(uuid.New()),
+			MonetaryAccountID:     accountID,
+			FromMonetaryAccountID: from,
+			ToMonetaryAccountID:   to,
+			Amount:                *money.New(-1500, "EUR"),
+			TransactionDate:       time.Date(2020, time.March, 3, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, transfer(bobAccount, bobAccount, aliceAccount), time.Now()))
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, transfer(aliceAccount, bobAccount, aliceAccount), time.Now()))
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, transfer(aliceAccount, aliceAccount, bobAccount), time.Now()))
+
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected only the transfer of bob to alice to settle, got %+v", commands.commands)
+	}
+	cmd := commands.commands[0].(RecordSettlementCommand)
+	if cmd.From != bob || cmd.To != alice || cmd.Amount.Amount != 1500 || cmd.HouseholdID != householdID {
+		t.Errorf("Expected bob to settle with alice, got %+v", cmd)
+	}
+}
+
+func Test_SettlementDetector_SettlesNoMoreThanOwed(t *testing.T) {
+	ledgers := NewLedgers()
+	share(t, ledgers, dinner(3000, Share{UserID: alice, Weight: 1}, Share{UserID: bob, Weight: 1}))
+
+	commands := &recordingCommandHandler{}
+	detector := NewSettlementDetector(commands, ledgers, fixedHouseholds{{ID: householdID, Members: map[primitives.UserID]households.Role{alice: households.Owner, bob: households.Owner}}})
+	aliceAccount, bobAccount := primitives.MonetaryAccountID(uuid.New()), primitives.MonetaryAccountID(uuid.New())
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: aliceAccount, UserID: alice}, time.Now()))
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: bobAccount, UserID: bob}, time.Now()))
+
+	detector.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, &accountinformation.NewTransactionFound{
+		ID:                    primitives.TransactionID(uuid.New()),
+		MonetaryAccountID:     bobAccount,
+		FromMonetaryAccountID: bobAccount,
+		ToMonetaryAccountID:   aliceAccount,
+		Amount:                *money.New(-5000, "EUR"),
+		TransactionDate:       time.Date(2020, time.March, 3, 0, 0, 0, 0, time.UTC),
+	}, time.Now()))
+
+	if len(commands.commands) != 1 {
+		t.Fatalf("Expected the transfer of bob to alice to settle, got %+v", commands.commands)
+	}
+	if cmd := commands.commands[0].(RecordSettlementCommand); cmd.Amount.Amount != 1500 {
+		t.Errorf("Expected only the 15 bob owes to be settled, got %+v", cmd)
+	}
+}
+
+type recordingCommandHandler struct {
+	commands []eh.Command
+}
+
+func (handler *recordingCommandHandler) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	handler.commands = append(handler.commands, cmd)
+	return nil
+}
@@ -0,0 +1,146 @@
+package sharedexpenses
+
+import (
+	accountinformation "app/account-information"
+	"app/households"
+	"app/primitives"
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	eh "github.com/looplab/eventhorizon"
+)
+
+// Households gives the households a user is a member of
+type Households interface {
+	HouseholdsOf(userID primitives.UserID) []households.Household
+}
+
+// SettlementDetector recognises transfers from an account of a member to an account of another member it owes
+// in a household, like the transfers that arrive with the sync of bunq, and records them as settlements
+type SettlementDetector struct {
+	handler    eh.CommandHandler
+	ledgers    Ledgers
+	households Households
+
+	mu     sync.Mutex
+	owners map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+}
+
+// NewSettlementDetector creates a SettlementDetector that dispatches its settlements to the handler
+func NewSettlementDetector(handler eh.CommandHandler, ledgers Ledgers, households Households) *SettlementDetector {
+	return &SettlementDetector{
+		handler:    handler,
+		ledgers:    ledgers,
+		households: households,
+		owners:     make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool),
+	}
+}
+
+// Matcher matches all events the detector is interested in
+func (detector *SettlementDetector) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhMonetaryAccountUserAdded,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (detector *SettlementDetector) HandlerType() eh.EventHandlerType {
+	return "settlement-detector"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (detector *SettlementDetector) HandleEvent(ctx context.Context, event eh.Event) error {
+	switch data := event.Data().(type) {
+	case *accountinformation.MonetaryAccountUserAdded:
+		detector.ownerAdded(data.ID, data.UserID)
+	case *accountinformation.NewTransactionFound:
+		cmd, ok := detector.settlementOf(*data)
+		if !ok {
+			return nil
+		}
+		if err := detector.handler.HandleCommand(ctx, cmd); err != nil {
+			log.Printf("Could not record settlement: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (detector *SettlementDetector) ownerAdded(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	owners, ok := detector.owners[monetaryAccountID]
+	if !ok {
+		owners = make(map[primitives.UserID]bool)
+		detector.owners[monetaryAccountID] = owners
+	}
+	owners[userID] = true
+}
+
+// settlementOf is the settlement of the transfer, when an owner of the paying account owes an owner of the receiving
+// account in a household they are both a member of. Only the leg of the paying account is looked at, and no more than
+// what is owed is settled, the rest of the transfer is not about the household
+func (detector *SettlementDetector) settlementOf(event accountinformation.NewTransactionFound) (RecordSettlementCommand, bool) {
+	if event.MonetaryAccountID != event.FromMonetaryAccountID || event.FromMonetaryAccountID == event.ToMonetaryAccountID {
+		return RecordSettlementCommand{}, false
+	}
+
+	detector.mu.Lock()
+	payers := detector.owners[event.FromMonetaryAccountID]
+	var from, to []primitives.UserID
+	for userID := range payers {
+		if !detector.owners[event.ToMonetaryAccountID][userID] {
+			from = append(from, userID)
+		}
+	}
+	for userID := range detector.owners[event.ToMonetaryAccountID] {
+		if !payers[userID] {
+			to = append(to, userID)
+		}
+	}
+	detector.mu.Unlock()
+
+	// owners come from maps, sort them so the same transfer always settles the same debt
+	sortUsers(from)
+	sortUsers(to)
+
+	amount := event.Amount.Absolute()
+	for _, debtor := range from {
+		for _, household := range detector.households.HouseholdsOf(debtor) {
+			for _, creditor := range to {
+				if _, isMember := household.Members[creditor]; !isMember {
+					continue
+				}
+				owes := detector.ledgers.Owes(household.ID, debtor, creditor, amount.Currency().Code)
+				if !owes.IsPositive() {
+					continue
+				}
+				settled := *amount
+				if owes.Amount() < settled.Amount() {
+					settled = owes
+				}
+				return RecordSettlementCommand{
+					HouseholdID:       household.ID,
+					SettlementID:      NewSettlementID(event.ID),
+					From:              debtor,
+					To:                creditor,
+					Amount:            primitives.NewMoneyForCommand(settled),
+					MonetaryAccountID: event.MonetaryAccountID,
+					TransactionID:     event.ID,
+					Date:              event.TransactionDate,
+				}, true
+			}
+		}
+	}
+	return RecordSettlementCommand{}, false
+}
+
+func sortUsers(userIDs []primitives.UserID) {
+	sort.Slice(userIDs, func(i, j int) bool {
+		return userIDs[i].String() < userIDs[j].String()
+	})
+}
@@ -0,0 +1,98 @@
+package simulatedconnector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/rickb777/date/period"
+)
+
+func (account simulatedAccount) mapToDocument(userID primitives.UserID, balance int64, fetchTimestamp time.Time) bus.MonetaryAccountDocument {
+	return bus.MonetaryAccountDocument{
+		Iban:                account.iban,
+		Joint:               account.joint,
+		OwnerUserID:         userID,
+		Alias:               account.alias,
+		Institution:         primitives.Bunq,
+		InstitutionEntityID: account.entityID,
+		Balance:             *money.New(balance, currency),
+		FetchTimestamp:      fetchTimestamp,
+	}
+}
+
+// mapToDocument maps the transaction like bunq payments are mapped, the account that is paid from or to is the own account
+func (tx simulatedTransaction) mapToDocument(account simulatedAccount, fetchTimestamp time.Time) bus.TransactionDocument {
+	ownName := account.alias
+	ownIBAN := account.iban
+	institution := primitives.Bunq
+	entityID := tx.entityID
+	counterpartyName := tx.counterpartyName
+
+	document := bus.TransactionDocument{
+		Amount:                *money.New(tx.amount, currency),
+		Description:           tx.description,
+		InstitutionScheduleID: tx.scheduleID,
+		BalanceAfterMutation:  *money.New(tx.balanceAfter, currency),
+		Geolocation:           tx.geolocation,
+		TransactionDate:       tx.date,
+		FetchTimestamp:        fetchTimestamp,
+	}
+
+	if tx.amount > 0 {
+		document.FromName = &counterpartyName
+		document.FromIBAN = tx.counterpartyIBAN
+
+		document.ToName = &ownName
+		document.ToIBAN = &ownIBAN
+		document.ToInstition = &institution
+		document.ToInstitionEntityID = &entityID
+	} else {
+		document.FromName = &ownName
+		document.FromIBAN = &ownIBAN
+		document.FromInstition = &institution
+		document.FromInstitutionEntityID = &entityID
+
+		document.ToName = &counterpartyName
+		document.ToIBAN = tx.counterpartyIBAN
+	}
+
+	return document
+}
+
+func (tx simulatedTransaction) mapToDirectDebitDocument(account simulatedAccount, fetchTimestamp time.Time) bus.DirectDebitTransactionDocument {
+	counterpartyName := tx.counterpartyName
+
+	return bus.DirectDebitTransactionDocument{
+		Institution:         primitives.Bunq,
+		InstititionEntityID: tx.entityID,
+		FromIBAN:            account.iban,
+		FromName:            account.alias,
+		ToIBAN:              tx.counterpartyIBAN,
+		ToName:              &counterpartyName,
+		Description:         tx.description,
+		CreditSchemeID:      tx.mandate.creditorSchemeID,
+		MandateID:           tx.mandate.mandateID,
+		TransactionDate:     tx.date,
+		Amount:              *money.New(tx.amount, currency),
+		FetchTimestamp:      fetchTimestamp,
+	}
+}
+
+func (schedule simulatedSchedule) mapToDocument(account simulatedAccount, fetchTimestamp time.Time) bus.ScheduleDocument {
+	return bus.ScheduleDocument{
+		Institution:         primitives.Bunq,
+		InstitutionEntityID: schedule.entityID,
+		FromIBAN:            account.iban,
+		FromName:            account.alias,
+		ToIBAN:              schedule.counterpartyIBAN,
+		ToName:              schedule.counterpartyName,
+		Frequency:           period.NewYMD(0, 1, 0),
+		StartDate:           schedule.startDate,
+		EndDate:             nil,
+		Amount:              *money.New(schedule.amount, currency),
+		Description:         schedule.description,
+		FetchTimestamp:      fetchTimestamp,
+	}
+}
@@ -0,0 +1,272 @@
+package simulatedconnector
+
+import (
+	"app/bus"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/almerlucke/go-iban/iban"
+)
+
+const currency = "EUR"
+
+type simulatedAccount struct {
+	entityID       string
+	alias          string
+	iban           iban.IBAN
+	joint          bool
+	openingBalance int64
+}
+
+type mandate struct {
+	creditorSchemeID string
+	mandateID        string
+}
+
+type simulatedTransaction struct {
+	entityID         string
+	account          int
+	amount           int64
+	counterpartyName string
+	counterpartyIBAN *iban.IBAN
+	description      string
+	date             time.Time
+	scheduleID       *string
+	mandate          *mandate
+	geolocation      *bus.Geolocation
+	balanceAfter     int64
+}
+
+type simulatedSchedule struct {
+	entityID         string
+	account          int
+	counterpartyName string
+	counterpartyIBAN iban.IBAN
+	amount           int64
+	description      string
+	dayOfMonth       int
+	startDate        time.Time
+}
+
+type counterparty struct {
+	name string
+	iban iban.IBAN
+}
+
+type directDebitCreditor struct {
+	counterparty
+	creditorSchemeID string
+	dayOfMonth       int
+	min              int64
+	max              int64
+	account          int
+}
+
+type merchant struct {
+	name      string
+	latitude  float64
+	longitude float64
+	min       int64
+	max       int64
+	joint     bool
+}
+
+const (
+	mainAccount = iota
+	savingsAccount
+	householdAccount
+)
+
+var employer = counterparty{name: "Acme B.V.", iban: newIBAN("ABNA", 417164300)}
+var landlord = counterparty{name: "Woonstichting De Key", iban: newIBAN("INGB", 6543210)}
+
+var creditors = []directDebitCreditor{
+	{counterparty: counterparty{name: "Vattenfall", iban: newIBAN("INGB", 2345678)}, creditorSchemeID: "NL12ZZZ270871350000", dayOfMonth: 2, min: 9000, max: 16000, account: householdAccount},
+	{counterparty: counterparty{name: "Zilveren Kruis", iban: newIBAN("RABO", 300065264)}, creditorSchemeID: "NL59ZZZ301245860000", dayOfMonth: 1, min: 13000, max: 15000, account: mainAccount},
+	{counterparty: counterparty{name: "KPN", iban: newIBAN("ABNA", 543210987)}, creditorSchemeID: "NL47ZZZ020777760000", dayOfMonth: 20, min: 2500, max: 4500, account: mainAccount},
+}
+
+var merchants = []merchant{
+	{name: "Albert Heijn", latitude: 52.3731, longitude: 4.8922, min: 800, max: 9000, joint: true},
+	{name: "Jumbo", latitude: 52.3584, longitude: 4.8811, min: 1000, max: 7500, joint: true},
+	{name: "HEMA", latitude: 52.3702, longitude: 4.8952, min: 300, max: 4000},
+	{name: "Coffee Company", latitude: 52.3667, longitude: 4.8945, min: 280, max: 900},
+	{name: "NS", latitude: 52.3789, longitude: 4.9004, min: 250, max: 4800},
+	{name: "Bol.com", latitude: 52.0907, longitude: 5.1214, min: 1000, max: 12000},
+}
+
+// newIBAN builds a valid Dutch IBAN for the bank code and account number
+func newIBAN(bank string, number int64) iban.IBAN {
+	bban := fmt.Sprintf("%s%010d", bank, number)
+	res, _ := iban.NewIBAN(fmt.Sprintf("NL%02d%s", 98-mod97(bban+"NL00"), bban))
+	return *res
+}
+
+func mod97(value string) int {
+	rem := 0
+	for _, c := range value {
+		digits := string(c)
+		if c >= 'A' && c <= 'Z' {
+			digits = strconv.Itoa(int(c-'A') + 10)
+		}
+		for _, d := range digits {
+			rem = (rem*10 + int(d-'0')) % 97
+		}
+	}
+	return rem
+}
+
+// seedOf derives the seed of the dataset of a user, so every user gets a different but stable dataset
+func seedOf(seed int64, userID string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(userID))
+	return seed ^ int64(hash.Sum64())
+}
+
+type dataset struct {
+	accounts     []simulatedAccount
+	schedules    []simulatedSchedule
+	transactions []simulatedTransaction
+}
+
+// generate creates the dataset of a user from start up to end. Every day is generated from a seed of its own,
+// so a dataset that is generated up to a later end starts with the same transactions
+func generate(userSeed int64, start time.Time, end time.Time) dataset {
+	profile := rand.New(rand.NewSource(userSeed))
+	accountNumber := 2000000000 + profile.Int63n(1000000000)
+
+	var res dataset
+	res.accounts = []simulatedAccount{
+		{entityID: strconv.FormatInt(accountNumber, 10), alias: "Main", iban: newIBAN("BUNQ", accountNumber), openingBalance: 150000 + profile.Int63n(200000)},
+		{entityID: strconv.FormatInt(accountNumber+1, 10), alias: "Savings", iban: newIBAN("BUNQ", accountNumber+1), openingBalance: 500000 + profile.Int63n(1000000)},
+		{entityID: strconv.FormatInt(accountNumber+2, 10), alias: "Household", iban: newIBAN("BUNQ", accountNumber+2), joint: true, openingBalance: 80000},
+	}
+
+	salary := 280000 + profile.Int63n(150000)
+	firstDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	res.schedules = []simulatedSchedule{
+		{entityID: res.accounts[mainAccount].entityID + "-rent", account: mainAccount, counterpartyName: landlord.name, counterpartyIBAN: landlord.iban,
+			amount: -(95000 + profile.Int63n(50000)), description: "Rent", dayOfMonth: 1},
+		{entityID: res.accounts[mainAccount].entityID + "-savings", account: mainAccount, counterpartyName: res.accounts[savingsAccount].alias, counterpartyIBAN: res.accounts[savingsAccount].iban,
+			amount: -(2 + profile.Int63n(4)) * 10000, description: "Monthly savings", dayOfMonth: 26},
+		{entityID: res.accounts[mainAccount].entityID + "-household", account: mainAccount, counterpartyName: res.accounts[householdAccount].alias, counterpartyIBAN: res.accounts[householdAccount].iban,
+			amount: -60000, description: "Household contribution", dayOfMonth: 27},
+	}
+	for i := range res.schedules {
+		res.schedules[i].startDate = nextDayOfMonth(firstDay, res.schedules[i].dayOfMonth)
+	}
+
+	for day := firstDay; day.Before(end); day = day.AddDate(0, 0, 1) {
+		res.transactions = append(res.transactions, res.generateDay(userSeed, day, salary)...)
+	}
+
+	var transactions []simulatedTransaction
+	for _, transaction := range res.transactions {
+		if !transaction.date.Before(start) && transaction.date.Before(end) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].date.Before(transactions[j].date)
+	})
+
+	balances := make([]int64, len(res.accounts))
+	for i, account := range res.accounts {
+		balances[i] = account.openingBalance
+	}
+	for i := range transactions {
+		balances[transactions[i].account] += transactions[i].amount
+		transactions[i].balanceAfter = balances[transactions[i].account]
+	}
+	res.transactions = transactions
+	return res
+}
+
+func (res dataset) generateDay(userSeed int64, day time.Time, salary int64) []simulatedTransaction {
+	r := rand.New(rand.NewSource(userSeed ^ day.Unix()))
+	dayIndex := day.Unix() / 86400
+
+	var transactions []simulatedTransaction
+	add := func(transaction simulatedTransaction) {
+		transaction.entityID = fmt.Sprintf("%s-%d-%d", res.accounts[transaction.account].entityID, dayIndex, len(transactions))
+		transactions = append(transactions, transaction)
+	}
+	at := func(hour int, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	if day.Day() == 25 {
+		add(simulatedTransaction{account: mainAccount, amount: salary, counterpartyName: employer.name, counterpartyIBAN: &employer.iban,
+			description: "Salary " + day.Format("January 2006"), date: at(9, 0)})
+	}
+
+	for _, schedule := range res.schedules {
+		if day.Day() != schedule.dayOfMonth || day.Before(schedule.startDate) {
+			continue
+		}
+		scheduleID := schedule.entityID
+		add(simulatedTransaction{account: schedule.account, amount: schedule.amount, counterpartyName: schedule.counterpartyName, counterpartyIBAN: &schedule.counterpartyIBAN,
+			description: schedule.description, date: at(6, 0), scheduleID: &scheduleID})
+
+		for i, account := range res.accounts {
+			if account.iban.Code == schedule.counterpartyIBAN.Code {
+				from := res.accounts[schedule.account]
+				add(simulatedTransaction{account: i, amount: -schedule.amount, counterpartyName: from.alias, counterpartyIBAN: &from.iban,
+					description: schedule.description, date: at(6, 0)})
+			}
+		}
+	}
+
+	for _, creditor := range creditors {
+		if day.Day() != creditor.dayOfMonth {
+			continue
+		}
+		creditorIBAN := creditor.iban
+		add(simulatedTransaction{account: creditor.account, amount: -(creditor.min + r.Int63n(creditor.max-creditor.min)), counterpartyName: creditor.name, counterpartyIBAN: &creditorIBAN,
+			description: creditor.name + " " + day.Format("01-2006"), date: at(7, 0),
+			mandate: &mandate{creditorSchemeID: creditor.creditorSchemeID, mandateID: fmt.Sprintf("MNDT-%s-%d", creditor.creditorSchemeID[:4], userSeed%100000)}})
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		m := merchants[r.Intn(len(merchants))]
+		account := mainAccount
+		if m.joint {
+			account = householdAccount
+		}
+		add(simulatedTransaction{account: account, amount: -(m.min + r.Int63n(m.max-m.min)), counterpartyName: m.name,
+			description: "Card payment " + m.name, date: at(8+r.Intn(12), r.Intn(60)),
+			geolocation: &bus.Geolocation{
+				Latitude:  m.latitude + (r.Float64()-0.5)/100,
+				Longitude: m.longitude + (r.Float64()-0.5)/100,
+				Radius:    float64(5 + r.Intn(50)),
+			}})
+	}
+
+	return transactions
+}
+
+func nextDayOfMonth(from time.Time, dayOfMonth int) time.Time {
+	res := time.Date(from.Year(), from.Month(), dayOfMonth, 0, 0, 0, 0, time.UTC)
+	if res.Before(from) {
+		res = res.AddDate(0, 1, 0)
+	}
+	return res
+}
+
+// balanceAt is the balance of the account after all transactions before the time
+func (res dataset) balanceAt(account int, t time.Time) int64 {
+	balance := res.accounts[account].openingBalance
+	for _, transaction := range res.transactions {
+		if !transaction.date.Before(t) {
+			break
+		}
+		if transaction.account == account {
+			balance = transaction.balanceAfter
+		}
+	}
+	return balance
+}
@@ -0,0 +1,161 @@
+package simulatedconnector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChannels struct {
+	updates      chan bus.Update
+	accounts     chan bus.MonetaryAccountDocument
+	transactions chan bus.TransactionDocument
+	schedules    chan bus.ScheduleDocument
+	directDebits chan bus.DirectDebitTransactionDocument
+}
+
+func newFakeChannels() fakeChannels {
+	return fakeChannels{
+		updates:      make(chan bus.Update, 1000),
+		accounts:     make(chan bus.MonetaryAccountDocument, 1000),
+		transactions: make(chan bus.TransactionDocument, 1000),
+		schedules:    make(chan bus.ScheduleDocument, 1000),
+		directDebits: make(chan bus.DirectDebitTransactionDocument, 1000),
+	}
+}
+
+func (c fakeChannels) PublishUpdate(ctx context.Context, update bus.Update) error {
+	c.updates <- update
+	return nil
+}
+
+func (c fakeChannels) PublishAccount(ctx context.Context, document bus.MonetaryAccountDocument) error {
+	c.accounts <- document
+	return nil
+}
+
+func (c fakeChannels) PublishTransaction(ctx context.Context, document bus.TransactionDocument) error {
+	c.transactions <- document
+	return nil
+}
+
+func (c fakeChannels) PublishSchedule(ctx context.Context, document bus.ScheduleDocument) error {
+	c.schedules <- document
+	return nil
+}
+
+func (c fakeChannels) PublishDirectDebit(ctx context.Context, document bus.DirectDebitTransactionDocument) error {
+	c.directDebits <- document
+	return nil
+}
+
+var start = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func simulate(userID primitives.UserID, seed int64) fakeChannels {
+	channels := newFakeChannels()
+	cmd := NewStartUserRefreshCommand(context.Background(), Config{Seed: seed, Start: start, Step: 31 * 24 * time.Hour}, channels)
+	cmd.now = func() time.Time { return time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC) }
+
+	cmd.Refresh(userID)
+
+	close(channels.updates)
+	close(channels.accounts)
+	close(channels.transactions)
+	close(channels.schedules)
+	close(channels.directDebits)
+	return channels
+}
+
+func transactionsOf(channels fakeChannels) []bus.TransactionDocument {
+	var res []bus.TransactionDocument
+	for transaction := range channels.transactions {
+		res = append(res, transaction)
+	}
+	return res
+}
+
+func Test_Simulation_GeneratesRealisticDataset(t *testing.T) {
+	channels := simulate(primitives.UserID(uuid.New()), 42)
+
+	var salaries, scheduled, cardPayments int
+	for _, transaction := range transactionsOf(channels) {
+		if transaction.FromName != nil && *transaction.FromName == employer.name {
+			salaries++
+		}
+		if transaction.InstitutionScheduleID != nil {
+			scheduled++
+		}
+		if transaction.Geolocation != nil {
+			cardPayments++
+		}
+		if transaction.FromIBAN != nil {
+			_, err := iban.NewIBAN(transaction.FromIBAN.Code)
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 2, salaries)
+	assert.Equal(t, 6, scheduled)
+	assert.NotZero(t, cardPayments)
+
+	assert.Len(t, channels.schedules, 3)
+	directDebits := 0
+	for directDebit := range channels.directDebits {
+		assert.NotEmpty(t, directDebit.MandateID)
+		assert.NotEmpty(t, directDebit.CreditSchemeID)
+		directDebits++
+	}
+	assert.Equal(t, 2*len(creditors), directDebits)
+
+	// two ticks, each publishing the three accounts
+	assert.Len(t, channels.accounts, 6)
+	assert.Len(t, channels.updates, 12)
+}
+
+func Test_Simulation_BalancesFollowTheTransactions(t *testing.T) {
+	channels := simulate(primitives.UserID(uuid.New()), 42)
+
+	var accounts []bus.MonetaryAccountDocument
+	for account := range channels.accounts {
+		accounts = append(accounts, account)
+	}
+	main := accounts[len(accounts)-3]
+
+	var last *bus.TransactionDocument
+	for _, transaction := range transactionsOf(channels) {
+		transaction := transaction
+		own := transaction.FromIBAN
+		if transaction.Amount.IsPositive() {
+			own = transaction.ToIBAN
+		}
+		if own.Code != main.Iban.Code {
+			continue
+		}
+		if last != nil {
+			expected, _ := last.BalanceAfterMutation.Add(&transaction.Amount)
+			assert.Equal(t, expected.Amount(), transaction.BalanceAfterMutation.Amount())
+		}
+		last = &transaction
+	}
+	assert.Equal(t, last.BalanceAfterMutation.Amount(), main.Balance.Amount())
+}
+
+func Test_Simulation_IsSeeded(t *testing.T) {
+	userID := primitives.UserID(uuid.New())
+
+	first := transactionsOf(simulate(userID, 42))
+	second := transactionsOf(simulate(userID, 42))
+	other := transactionsOf(simulate(primitives.UserID(uuid.New()), 42))
+
+	assert.Equal(t, len(first), len(second))
+	for i := range first {
+		assert.Equal(t, first[i].Amount, second[i].Amount)
+		assert.Equal(t, first[i].TransactionDate, second[i].TransactionDate)
+	}
+	assert.NotEqual(t, first[0].FromIBAN.Code, other[0].FromIBAN.Code)
+}
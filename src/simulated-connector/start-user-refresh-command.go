@@ -0,0 +1,192 @@
+package simulatedconnector
+
+import (
+	"app/bus"
+	"app/primitives"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config of the simulation, every user gets a dataset of their own from the seed that is replayed from Start on,
+// every Tick the simulated time moves Step forward until it has caught up with the real time
+type Config struct {
+	Seed  int64
+	Start time.Time
+	Step  time.Duration
+	Tick  time.Duration
+}
+
+// DefaultConfig replays the last year a day per second
+func DefaultConfig() Config {
+	return Config{
+		Seed:  1,
+		Start: time.Now().AddDate(-1, 0, 0),
+		Step:  24 * time.Hour,
+		Tick:  time.Second,
+	}
+}
+
+type simulatedClocks struct {
+	mu      *sync.Mutex
+	times   map[primitives.UserID]time.Time
+	running map[primitives.UserID]bool
+}
+
+// StartUserRefreshCommand simulates bunq, it publishes a synthetic dataset of accounts, payments, schedules and direct debits
+// the way the bunq connector does, so the application can be run and tested without a connection to bunq
+type StartUserRefreshCommand struct {
+	config    Config
+	clocks    simulatedClocks
+	publisher bus.Publisher
+	now       func() time.Time
+	context   context.Context
+}
+
+// NewStartUserRefreshCommand creates a new StartUserRefreshCommand that simulates bunq, and publishes on the bus
+func NewStartUserRefreshCommand(ctx context.Context, config Config, publisher bus.Publisher) StartUserRefreshCommand {
+	cmd := new(StartUserRefreshCommand)
+	cmd.config = config
+	cmd.clocks = simulatedClocks{
+		mu:      new(sync.Mutex),
+		times:   make(map[primitives.UserID]time.Time),
+		running: make(map[primitives.UserID]bool),
+	}
+	cmd.publisher = publisher
+	cmd.now = time.Now
+	cmd.context = ctx
+	return *cmd
+}
+
+// Refresh replays the simulated time of the user up to now, a user that is already being replayed is left alone
+func (cmd StartUserRefreshCommand) Refresh(userID primitives.UserID) {
+	if !cmd.startSimulating(userID) {
+		return
+	}
+	defer cmd.stopSimulating(userID)
+
+	for {
+		from, to := cmd.advance(userID)
+		if !from.Before(to) {
+			return
+		}
+		if err := cmd.publish(userID, from, to); err != nil {
+			log.Printf("Could not publish simulated bunq data of user %s: %v", userID, err)
+			return
+		}
+
+		select {
+		case <-cmd.context.Done():
+			return
+		case <-time.After(cmd.config.Tick):
+		}
+	}
+}
+
+func (cmd StartUserRefreshCommand) startSimulating(userID primitives.UserID) bool {
+	cmd.clocks.mu.Lock()
+	defer cmd.clocks.mu.Unlock()
+
+	if cmd.clocks.running[userID] {
+		return false
+	}
+	cmd.clocks.running[userID] = true
+	return true
+}
+
+func (cmd StartUserRefreshCommand) stopSimulating(userID primitives.UserID) {
+	cmd.clocks.mu.Lock()
+	defer cmd.clocks.mu.Unlock()
+
+	delete(cmd.clocks.running, userID)
+}
+
+// advance moves the simulated time of the user a step forward, but never past the real time
+func (cmd StartUserRefreshCommand) advance(userID primitives.UserID) (time.Time, time.Time) {
+	cmd.clocks.mu.Lock()
+	defer cmd.clocks.mu.Unlock()
+
+	from, ok := cmd.clocks.times[userID]
+	if !ok {
+		from = cmd.config.Start
+	}
+
+	to := from.Add(cmd.config.Step)
+	if now := cmd.now(); to.After(now) {
+		to = now
+	}
+	if to.Before(from) {
+		to = from
+	}
+
+	cmd.clocks.times[userID] = to
+	return from, to
+}
+
+// publish publishes the accounts with their balance at the simulated time, and all that happened since the previous publish
+func (cmd StartUserRefreshCommand) publish(userID primitives.UserID, from time.Time, to time.Time) error {
+	data := generate(seedOf(cmd.config.Seed, userID.String()), cmd.config.Start, to)
+	firstPublish := !from.After(cmd.config.Start)
+
+	for i, account := range data.accounts {
+		if err := cmd.publishAccount(userID, data, i, account, from, to, firstPublish); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cmd StartUserRefreshCommand) publishAccount(userID primitives.UserID, data dataset, i int, account simulatedAccount, from time.Time, to time.Time, firstPublish bool) error {
+	startUpdate := bus.StartRefreshUpdate{
+		UserID:              userID,
+		InstitutionEntityID: account.entityID,
+		SyncID:              primitives.SyncID(uuid.New()),
+		Started:             time.Now(),
+	}
+	refresh, err := bus.StartRefresh(cmd.context, cmd.publisher, startUpdate)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.publishDocuments(refresh, userID, data, i, account, from, to, firstPublish)
+	if doneErr := refresh.Done(cmd.context, err); err == nil {
+		err = doneErr
+	}
+	return err
+}
+
+func (cmd StartUserRefreshCommand) publishDocuments(refresh *bus.RefreshPublisher, userID primitives.UserID, data dataset, i int, account simulatedAccount, from time.Time, to time.Time, firstPublish bool) error {
+	if err := refresh.PublishAccount(cmd.context, account.mapToDocument(userID, data.balanceAt(i, to), to)); err != nil {
+		return err
+	}
+
+	if firstPublish {
+		for _, schedule := range data.schedules {
+			if schedule.account != i {
+				continue
+			}
+			if err := refresh.PublishSchedule(cmd.context, schedule.mapToDocument(account, to)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, transaction := range data.transactions {
+		if transaction.account != i || transaction.date.Before(from) || !transaction.date.Before(to) {
+			continue
+		}
+		if err := refresh.PublishTransaction(cmd.context, transaction.mapToDocument(account, to)); err != nil {
+			return err
+		}
+		if transaction.mandate == nil {
+			continue
+		}
+		if err := refresh.PublishDirectDebit(cmd.context, transaction.mapToDirectDebitDocument(account, to)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package spendingmap
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// The transactions are written as a GeoJSON FeatureCollection (RFC 7946) of points, to overlay on a map
+
+type geoJSONGeometry struct {
+	Type string `json:"type"`
+	// Coordinates are the longitude and latitude, in that order
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func featureOf(located LocatedTransaction) geoJSONFeature {
+	transaction := located.Transaction
+	amount := transaction.SignedAmount()
+	properties := map[string]interface{}{
+		"accountId":   transaction.MonetaryAccountID.String(),
+		"description": transaction.Description,
+		"amount":      amount.Amount(),
+		"currency":    amount.Currency().Code,
+		"display":     amount.Display(),
+		"category":    string(transaction.Category),
+		"date":        transaction.TransactionDate.Format("2006-01-02"),
+		"accuracy":    located.Accuracy,
+	}
+	if transaction.Counterparty.HasName {
+		properties["counterparty"] = transaction.Counterparty.Name
+	}
+
+	return geoJSONFeature{
+		Type:       "Feature",
+		ID:         transaction.ID.String(),
+		Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float64{located.Location.Longitude, located.Location.Latitude}},
+		Properties: properties,
+	}
+}
+
+// WriteGeoJSON writes the transactions as a GeoJSON FeatureCollection of points, the amounts in minor units
+func WriteGeoJSON(writer io.Writer, transactions []LocatedTransaction) error {
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(transactions))}
+	for _, transaction := range transactions {
+		collection.Features = append(collection.Features, featureOf(transaction))
+	}
+	return json.NewEncoder(writer).Encode(collection)
+}
@@ -0,0 +1,88 @@
+package spendingmap
+
+import (
+	"math"
+)
+
+const earthRadius = 6371000.0
+
+// Point on the earth in degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// BoundingBox is the area between two latitudes and two longitudes in degrees, boxes do not cross the antimeridian
+type BoundingBox struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// Contains tells whether the point is in the box, points on the edges are in the box
+func (box BoundingBox) Contains(point Point) bool {
+	return point.Latitude >= box.South && point.Latitude <= box.North &&
+		point.Longitude >= box.West && point.Longitude <= box.East
+}
+
+// Valid tells whether the box is not empty and its corners are on the earth
+func (box BoundingBox) Valid() bool {
+	return box.South <= box.North && box.West <= box.East &&
+		box.South >= -90 && box.North <= 90 && box.West >= -180 && box.East <= 180
+}
+
+func (box BoundingBox) overlaps(other BoundingBox) bool {
+	return box.South <= other.North && other.South <= box.North && box.West <= other.East && other.West <= box.East
+}
+
+// Center is the point halfway the corners of the box
+func (box BoundingBox) Center() Point {
+	return Point{Latitude: (box.South + box.North) / 2, Longitude: (box.West + box.East) / 2}
+}
+
+// boxAround is the smallest box that contains the circle with the radius in meters around the center
+func boxAround(center Point, radius float64) BoundingBox {
+	latitudeDelta := radius / earthRadius * 180 / math.Pi
+	longitudeDelta := 180.0
+	if cos := math.Cos(center.Latitude * math.Pi / 180); cos > radius/earthRadius {
+		longitudeDelta = math.Min(180, latitudeDelta/cos)
+	}
+	return BoundingBox{
+		South: math.Max(-90, center.Latitude-latitudeDelta),
+		West:  math.Max(-180, center.Longitude-longitudeDelta),
+		North: math.Min(90, center.Latitude+latitudeDelta),
+		East:  math.Min(180, center.Longitude+longitudeDelta),
+	}
+}
+
+// Distance is the great-circle distance in meters between the points
+func Distance(from Point, to Point) float64 {
+	fromLatitude := from.Latitude * math.Pi / 180
+	toLatitude := to.Latitude * math.Pi / 180
+	latitudeDelta := toLatitude - fromLatitude
+	longitudeDelta := (to.Longitude - from.Longitude) * math.Pi / 180
+
+	a := math.Sin(latitudeDelta/2)*math.Sin(latitudeDelta/2) +
+		math.Cos(fromLatitude)*math.Cos(toLatitude)*math.Sin(longitudeDelta/2)*math.Sin(longitudeDelta/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// cell is a square of the grid of the given size in degrees
+type cell struct {
+	row    int
+	column int
+}
+
+func cellOf(point Point, size float64) cell {
+	return cell{row: int(math.Floor(point.Latitude / size)), column: int(math.Floor(point.Longitude / size))}
+}
+
+func (c cell) box(size float64) BoundingBox {
+	return BoundingBox{
+		South: float64(c.row) * size,
+		West:  float64(c.column) * size,
+		North: float64(c.row+1) * size,
+		East:  float64(c.column+1) * size,
+	}
+}
@@ -0,0 +1,95 @@
+package spendingmap
+
+import (
+	"app/primitives"
+	"app/reporting"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// World is the box around the whole earth
+var World = BoundingBox{South: -90, West: -180, North: 90, East: 180}
+
+// ParseQuery reads the user, box and period of a map from query parameters:
+// userId, bbox as west,south,east,north like GeoJSON and from/to as yyyy-mm-dd where to is exclusive
+func ParseQuery(query url.Values) (primitives.UserID, BoundingBox, reporting.Period, error) {
+	var period reporting.Period
+
+	userID, err := uuid.Parse(query.Get("userId"))
+	if err != nil {
+		return primitives.UserID{}, BoundingBox{}, period, fmt.Errorf("userId should be a valid uuid")
+	}
+
+	box := World
+	if bbox := query.Get("bbox"); bbox != "" {
+		if box, err = parseBoundingBox(bbox); err != nil {
+			return primitives.UserID{}, BoundingBox{}, period, err
+		}
+	}
+
+	if from := query.Get("from"); from != "" {
+		if period.From, err = time.Parse("2006-01-02", from); err != nil {
+			return primitives.UserID{}, BoundingBox{}, period, fmt.Errorf("invalid from date %s, expected format yyyy-mm-dd", from)
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if period.To, err = time.Parse("2006-01-02", to); err != nil {
+			return primitives.UserID{}, BoundingBox{}, period, fmt.Errorf("invalid to date %s, expected format yyyy-mm-dd", to)
+		}
+	}
+
+	return primitives.UserID(userID), box, period, nil
+}
+
+func parseBoundingBox(bbox string) (BoundingBox, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("invalid bbox %s, expected west,south,east,north", bbox)
+	}
+
+	var corners [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bbox %s, expected west,south,east,north", bbox)
+		}
+		corners[i] = value
+	}
+
+	box := BoundingBox{West: corners[0], South: corners[1], East: corners[2], North: corners[3]}
+	if !box.Valid() {
+		return BoundingBox{}, fmt.Errorf("invalid bbox %s, expected west,south,east,north", bbox)
+	}
+	return box, nil
+}
+
+func geoJSONHandler(m Map) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, box, period, err := ParseQuery(req.URL.Query())
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/geo+json")
+		if err := WriteGeoJSON(res, m.Within(userID, box, period)); err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// RegisterSpendingMapController will register a http controller that exports the located transactions of a user as GeoJSON
+func RegisterSpendingMapController(m Map) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		controller := r.PathPrefix("/map").Subrouter()
+		controller.Methods("GET").Path("/transactions.geojson").HandlerFunc(geoJSONHandler(m))
+		return nil
+	}
+}
@@ -0,0 +1,228 @@
+package spendingmap
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"app/reporting"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Rhymond/go-money"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// gridSize is the size in degrees of the cells the transactions are indexed in, about a kilometer
+const gridSize = 0.01
+
+// LocatedTransaction is a transaction with the place it was made
+type LocatedTransaction struct {
+	Transaction reporting.ReportedTransaction
+	Location    Point
+	// Accuracy is the radius in meters around the location the transaction was made in
+	Accuracy float64
+}
+
+// AreaSpending are the expenses in one currency in an area
+type AreaSpending struct {
+	Area     BoundingBox
+	Expenses money.Money
+	Count    int
+}
+
+type transactionKey struct {
+	monetaryAccountID primitives.MonetaryAccountID
+	transactionID     primitives.TransactionID
+}
+
+// Map keeps the transactions that have a geolocation, in a grid to find the transactions in an area
+type Map struct {
+	mu           *sync.RWMutex
+	owners       map[primitives.MonetaryAccountID]map[primitives.UserID]bool
+	transactions map[transactionKey]LocatedTransaction
+	cells        map[cell]map[transactionKey]bool
+}
+
+// NewMap creates an empty Map
+func NewMap() Map {
+	res := new(Map)
+	res.mu = new(sync.RWMutex)
+	res.owners = make(map[primitives.MonetaryAccountID]map[primitives.UserID]bool)
+	res.transactions = make(map[transactionKey]LocatedTransaction)
+	res.cells = make(map[cell]map[transactionKey]bool)
+	return *res
+}
+
+// Matcher matches the events about the owners and transactions of monetary accounts
+func (m Map) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		accountinformation.EhMonetaryAccountUserAdded,
+		accountinformation.EhNewTransactionFound,
+		accountinformation.EhTransactionCategorised,
+		accountinformation.EhInternalTransferDetected,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (m Map) HandlerType() eh.EventHandlerType {
+	return "spending-map"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (m Map) HandleEvent(ctx context.Context, event eh.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *accountinformation.MonetaryAccountUserAdded:
+		owners, ok := m.owners[data.ID]
+		if !ok {
+			owners = make(map[primitives.UserID]bool)
+			m.owners[data.ID] = owners
+		}
+		owners[data.UserID] = true
+	case *accountinformation.NewTransactionFound:
+		if data.HasGeolocation {
+			m.transactionFound(*data)
+		}
+	case *accountinformation.TransactionCategorised:
+		if transaction, ok := m.transactions[transactionKey{data.ID, data.TransactionID}]; ok {
+			transaction.Transaction.Category = data.Category
+			m.transactions[transactionKey{data.ID, data.TransactionID}] = transaction
+		}
+	case *accountinformation.InternalTransferDetected:
+		if transaction, ok := m.transactions[transactionKey{data.ID, data.TransactionID}]; ok {
+			transaction.Transaction.InternalTransfer = true
+			transaction.Transaction.TransferID = data.TransferID
+			m.transactions[transactionKey{data.ID, data.TransactionID}] = transaction
+		}
+	}
+	return nil
+}
+
+func (m Map) transactionFound(event accountinformation.NewTransactionFound) {
+	key := transactionKey{event.MonetaryAccountID, event.ID}
+	location := Point{Latitude: event.Geolocation.Latitude, Longitude: event.Geolocation.Longitude}
+	m.transactions[key] = LocatedTransaction{
+		Transaction: reporting.ReportedTransaction{
+			ID:                event.ID,
+			MonetaryAccountID: event.MonetaryAccountID,
+			Counterparty:      event.Counterparty(),
+			Amount:            event.Amount,
+			Outgoing:          event.MonetaryAccountID == event.FromMonetaryAccountID,
+			Category:          primitives.Uncategorised,
+			Description:       event.Description,
+			TransactionDate:   event.TransactionDate,
+		},
+		Location: location,
+		Accuracy: event.Geolocation.Radius,
+	}
+
+	c := cellOf(location, gridSize)
+	keys, ok := m.cells[c]
+	if !ok {
+		keys = make(map[transactionKey]bool)
+		m.cells[c] = keys
+	}
+	keys[key] = true
+}
+
+// within returns the transactions of the user in the box, only the cells that overlap the box are looked at
+func (m Map) within(userID primitives.UserID, box BoundingBox, period reporting.Period) []LocatedTransaction {
+	var res []LocatedTransaction
+	for c, keys := range m.cells {
+		if !c.box(gridSize).overlaps(box) {
+			continue
+		}
+		for key := range keys {
+			transaction := m.transactions[key]
+			if m.owners[key.monetaryAccountID][userID] && box.Contains(transaction.Location) && period.Contains(transaction.Transaction.TransactionDate) {
+				res = append(res, transaction)
+			}
+		}
+	}
+	return res
+}
+
+// Within returns the transactions of the user that were made in the box, the most recent first
+func (m Map) Within(userID primitives.UserID, box BoundingBox, period reporting.Period) []LocatedTransaction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return mostRecentFirst(m.within(userID, box, period))
+}
+
+// Near returns the transactions of the user that were made within the radius in meters around the center, the most recent first
+func (m Map) Near(userID primitives.UserID, center Point, radius float64, period reporting.Period) []LocatedTransaction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var res []LocatedTransaction
+	for _, transaction := range m.within(userID, boxAround(center, radius), period) {
+		if Distance(center, transaction.Location) <= radius {
+			res = append(res, transaction)
+		}
+	}
+	return mostRecentFirst(res)
+}
+
+// SpendingPerArea sums the expenses of the user in the box per square area of the given size in degrees and currency,
+// internal transfers are not expenses. The areas the user spent the most in come first, per currency
+func (m Map) SpendingPerArea(userID primitives.UserID, box BoundingBox, areaSize float64, period reporting.Period) []AreaSpending {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type areaKey struct {
+		area     cell
+		currency string
+	}
+	areas := make(map[areaKey]*AreaSpending)
+	for _, transaction := range m.within(userID, box, period) {
+		if !transaction.Transaction.Outgoing || transaction.Transaction.InternalTransfer {
+			continue
+		}
+
+		amount := transaction.Transaction.Amount.Absolute()
+		key := areaKey{area: cellOf(transaction.Location, areaSize), currency: amount.Currency().Code}
+		area, ok := areas[key]
+		if !ok {
+			area = &AreaSpending{Area: key.area.box(areaSize), Expenses: *money.New(0, key.currency)}
+			areas[key] = area
+		}
+		expenses, err := area.Expenses.Add(amount)
+		if err != nil {
+			continue
+		}
+		area.Expenses = *expenses
+		area.Count++
+	}
+
+	res := make([]AreaSpending, 0, len(areas))
+	for _, area := range areas {
+		res = append(res, *area)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Expenses.Currency().Code != res[j].Expenses.Currency().Code {
+			return res[i].Expenses.Currency().Code < res[j].Expenses.Currency().Code
+		}
+		if res[i].Expenses.Amount() != res[j].Expenses.Amount() {
+			return res[i].Expenses.Amount() > res[j].Expenses.Amount()
+		}
+		if res[i].Area.South != res[j].Area.South {
+			return res[i].Area.South < res[j].Area.South
+		}
+		return res[i].Area.West < res[j].Area.West
+	})
+	return res
+}
+
+func mostRecentFirst(transactions []LocatedTransaction) []LocatedTransaction {
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i].Transaction, transactions[j].Transaction
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.After(b.TransactionDate)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return transactions
+}
@@ -0,0 +1,120 @@
+package spendingmap
+
+import (
+	accountinformation "app/account-information"
+	"app/primitives"
+	"app/reporting"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	eh "github.com/looplab/eventhorizon"
+)
+
+var (
+	userID    = primitives.UserID(uuid.New())
+	accountID = primitives.MonetaryAccountID(uuid.New())
+	dam       = Point{Latitude: 52.3731, Longitude: 4.8926}
+)
+
+func cardPayment(counterparty string, cents int64, at Point) accountinformation.NewTransactionFound {
+	return accountinformation.NewTransactionFound{
+		ID:                    primitives.TransactionID(uuid.New()),
+		MonetaryAccountID:     accountID,
+		FromMonetaryAccountID: accountID,
+		To:                    accountinformation.NewTransactionParty(nil, &counterparty),
+		Amount:                *money.New(cents, "EUR"),
+		Description:           "Card payment " + counterparty,
+		Geolocation:           accountinformation.Geolocation{Latitude: at.Latitude, Longitude: at.Longitude, Radius: 10},
+		HasGeolocation:        true,
+		TransactionDate:       time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func newTestMap(transactions ...accountinformation.NewTransactionFound) Map {
+	m := NewMap()
+	m.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhMonetaryAccountUserAdded, &accountinformation.MonetaryAccountUserAdded{ID: accountID, UserID: userID}, time.Now()))
+	for i := range transactions {
+		m.HandleEvent(context.Background(), eh.NewEvent(accountinformation.EhNewTransactionFound, &transactions[i], time.Now()))
+	}
+	return m
+}
+
+func Test_Map_Near_FindsTransactionsWithinRadius(t *testing.T) {
+	bakery := cardPayment("Bakery", 450, Point{Latitude: 52.3741, Longitude: 4.8926})
+	rotterdam := cardPayment("Market", 1200, Point{Latitude: 51.9225, Longitude: 4.4792})
+	withoutLocation := cardPayment("Online shop", 2500, dam)
+	withoutLocation.HasGeolocation = false
+	m := newTestMap(bakery, rotterdam, withoutLocation)
+
+	near := m.Near(userID, dam, 500, reporting.Period{})
+	if len(near) != 1 || near[0].Transaction.ID != bakery.ID {
+		t.Errorf("Expected only the bakery within 500 meters, got %+v", near)
+	}
+	if distance := Distance(dam, near[0].Location); distance < 100 || distance > 120 {
+		t.Errorf("Expected the bakery to be about 111 meters away, got %f", distance)
+	}
+
+	if within := m.Within(userID, BoundingBox{South: 51, West: 4, North: 53, East: 5}, reporting.Period{}); len(within) != 2 {
+		t.Errorf("Expected both located transactions in the box, got %+v", within)
+	}
+	if within := m.Within(primitives.UserID(uuid.New()), World, reporting.Period{}); len(within) != 0 {
+		t.Errorf("Expected no transactions of other users, got %+v", within)
+	}
+}
+
+func Test_Map_SpendingPerArea_SumsExpensesPerArea(t *testing.T) {
+	m := newTestMap(
+		cardPayment("Bakery", 450, Point{Latitude: 52.3741, Longitude: 4.8926}),
+		cardPayment("Butcher", 1550, Point{Latitude: 52.3745, Longitude: 4.8930}),
+		cardPayment("Market", 1200, Point{Latitude: 51.9225, Longitude: 4.4792}),
+	)
+
+	areas := m.SpendingPerArea(userID, World, 0.1, reporting.Period{})
+	if len(areas) != 2 {
+		t.Fatalf("Expected two areas, got %+v", areas)
+	}
+	if areas[0].Expenses.Amount() != 2000 || areas[0].Count != 2 || !areas[0].Area.Contains(dam) {
+		t.Errorf("Expected the area around the Dam first, got %+v", areas[0])
+	}
+}
+
+func Test_GeoJSONHandler_WritesFeatureCollection(t *testing.T) {
+	m := newTestMap(cardPayment("Bakery", 450, Point{Latitude: 52.3741, Longitude: 4.8926}))
+	r := mux.NewRouter()
+	RegisterSpendingMapController(m)(r)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/map/transactions.geojson?userId="+userID.String()+"&bbox=4,52,5,53", nil))
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Coordinates [2]float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&collection); err != nil {
+		t.Fatalf("Expected GeoJSON, got %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 1 {
+		t.Fatalf("Expected one feature, got %+v", collection)
+	}
+	feature := collection.Features[0]
+	if feature.Geometry.Coordinates != [2]float64{4.8926, 52.3741} || feature.Properties["amount"] != float64(-450) {
+		t.Errorf("Expected the bakery as longitude, latitude with a negative amount, got %+v", feature)
+	}
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/map/transactions.geojson?userId="+userID.String()+"&bbox=5,52,4,53", nil))
+	if res.Code != 400 {
+		t.Errorf("Expected an inverted bbox to be rejected, got %d", res.Code)
+	}
+}
@@ -0,0 +1,246 @@
+package statementimport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+	DateTime  string     `xml:"Dt>DtTm"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtEntry struct {
+	Reference           string            `xml:"NtryRef"`
+	ServicerReference   string            `xml:"AcctSvcrRef"`
+	Amount              camtAmount        `xml:"Amt"`
+	Indicator           string            `xml:"CdtDbtInd"`
+	BookingDate         string            `xml:"BookgDt>Dt"`
+	BookingDateTime     string            `xml:"BookgDt>DtTm"`
+	Details             []camtTransaction `xml:"NtryDtls>TxDtls"`
+	AdditionalEntryInfo string            `xml:"AddtlNtryInf"`
+}
+
+type camtTransaction struct {
+	EndToEndID      string   `xml:"Refs>EndToEndId"`
+	DebtorName      string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorIBAN      string   `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+	CreditorName    string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorIBAN    string   `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+	Unstructured    []string `xml:"RmtInf>Ustrd"`
+	StructuredRefs  []string `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalTxInf string   `xml:"AddtlTxInf"`
+}
+
+// ParseCAMT053 parses ISO 20022 CAMT.053 bank to customer statements
+func ParseCAMT053(reader io.Reader) ([]Statement, error) {
+	var document camtDocument
+	if err := xml.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("could not parse CAMT.053 statement: %w", err)
+	}
+
+	statements := make([]Statement, 0, len(document.Statements))
+	for _, camt := range document.Statements {
+		statement, err := camt.toStatement()
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement %s: %w", camt.ID, err)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func (camt camtStatement) toStatement() (Statement, error) {
+	accountIBAN, err := parseIBAN(camt.IBAN)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement := Statement{IBAN: *accountIBAN}
+	statement.OpeningBalance = *money.New(0, camt.Currency)
+	statement.ClosingBalance = *money.New(0, camt.Currency)
+
+	for _, balance := range camt.Balances {
+		amount, err := balance.Amount.toMoney(balance.Indicator, camt.Currency)
+		if err != nil {
+			return Statement{}, err
+		}
+		switch balance.Code {
+		case "OPBD", "PRCD":
+			statement.OpeningBalance = amount
+		case "CLBD":
+			statement.ClosingBalance = amount
+			statement.ClosingDate, _ = parseCAMTDate(balance.Date, balance.DateTime)
+		}
+	}
+
+	for _, camtEntry := range camt.Entries {
+		entry, err := camtEntry.toEntry(camt.Currency)
+		if err != nil {
+			return Statement{}, err
+		}
+		statement.Entries = append(statement.Entries, entry)
+	}
+	return statement, nil
+}
+
+func (camt camtEntry) toEntry(currency string) (Entry, error) {
+	amount, err := camt.Amount.toMoney(camt.Indicator, currency)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	bookingDate, err := parseCAMTDate(camt.BookingDate, camt.BookingDateTime)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Reference:   firstNonEmpty(camt.ServicerReference, camt.Reference),
+		Amount:      amount,
+		BookingDate: bookingDate,
+		Description: strings.TrimSpace(camt.AdditionalEntryInfo),
+	}
+
+	if len(camt.Details) == 0 {
+		return entry, nil
+	}
+
+	details := camt.Details[0]
+	counterpartyName, counterpartyIBAN := details.CreditorName, details.CreditorIBAN
+	if amount.IsPositive() {
+		counterpartyName, counterpartyIBAN = details.DebtorName, details.DebtorIBAN
+	}
+
+	entry.CounterpartyName = optionalString(counterpartyName)
+	if counterpartyIBAN != "" {
+		var parsed *iban.IBAN
+		if parsed, err = parseIBAN(counterpartyIBAN); err == nil {
+			entry.CounterpartyIBAN = parsed
+		}
+	}
+
+	var remittance []string
+	remittance = append(remittance, details.Unstructured...)
+	remittance = append(remittance, details.StructuredRefs...)
+	if description := strings.TrimSpace(strings.Join(remittance, " ")); description != "" {
+		entry.Description = description
+	} else if details.AdditionalTxInf != "" {
+		entry.Description = strings.TrimSpace(details.AdditionalTxInf)
+	}
+	entry.Reference = firstNonEmpty(entry.Reference, details.EndToEndID)
+
+	return entry, nil
+}
+
+func (amount camtAmount) toMoney(indicator string, currency string) (money.Money, error) {
+	if amount.Currency != "" {
+		currency = amount.Currency
+	}
+
+	minorUnits, err := parseDecimal(amount.Value, ".", currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if indicator == "DBIT" {
+		minorUnits = -minorUnits
+	}
+	return *money.New(minorUnits, currency), nil
+}
+
+func parseCAMTDate(date string, dateTime string) (time.Time, error) {
+	if date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(date))
+	}
+	if dateTime != "" {
+		return time.Parse(time.RFC3339, strings.TrimSpace(dateTime))
+	}
+	return time.Time{}, fmt.Errorf("missing date")
+}
+
+// parseDecimal parses a decimal amount into the minor units of the currency, the integer and fraction digits are parsed
+// separately so no amount is rounded, and an amount with more decimals than the currency has is rejected
+func parseDecimal(value string, separator string, currency string) (int64, error) {
+	c := money.GetCurrency(currency)
+	if c == nil {
+		return 0, fmt.Errorf("unknown currency %s", currency)
+	}
+
+	value = strings.TrimSpace(value)
+	digits := strings.TrimLeft(value, "+-")
+	if len(value)-len(digits) > 1 {
+		return 0, fmt.Errorf("invalid amount %s", value)
+	}
+
+	integer, fraction := digits, ""
+	if i := strings.Index(digits, separator); i >= 0 {
+		integer, fraction = digits[:i], digits[i+len(separator):]
+	}
+	if integer == "" && fraction == "" || !isDigits(integer) || !isDigits(fraction) {
+		return 0, fmt.Errorf("invalid amount %s", value)
+	}
+
+	// trailing zeros are no extra precision
+	if len(fraction) > c.Fraction {
+		fraction = strings.TrimRight(fraction, "0")
+	}
+	if len(fraction) > c.Fraction {
+		return 0, fmt.Errorf("amount %s has more than %d decimals of %s", value, c.Fraction, currency)
+	}
+	fraction += strings.Repeat("0", c.Fraction-len(fraction))
+
+	minorUnits, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s", value)
+	}
+	if strings.HasPrefix(value, "-") {
+		minorUnits = -minorUnits
+	}
+	return minorUnits, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
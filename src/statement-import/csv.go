@@ -0,0 +1,233 @@
+package statementimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+type csvEntry struct {
+	entry        Entry
+	balanceAfter money.Money
+}
+
+type csvColumns struct {
+	profile Profile
+	header  map[string]int
+}
+
+func (columns csvColumns) index(column string) (int, error) {
+	if i, ok := columns.header[column]; ok {
+		return i, nil
+	}
+	if !columns.profile.HasHeader {
+		if i, err := strconv.Atoi(column); err == nil && i >= 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("column %s of profile %s not found", column, columns.profile.Name)
+}
+
+func (columns csvColumns) value(record []string, column string) (string, error) {
+	i, err := columns.index(column)
+	if err != nil {
+		return "", err
+	}
+	if i >= len(record) {
+		return "", fmt.Errorf("row has no column %s", column)
+	}
+	return strings.TrimSpace(record[i]), nil
+}
+
+func (columns csvColumns) optionalValue(record []string, column string) string {
+	if column == "" {
+		return ""
+	}
+	value, _ := columns.value(record, column)
+	return value
+}
+
+// ParseCSV parses the csv export of a bank with the profile, the rows of every account in the export become a statement.
+// Rows may be ordered oldest or newest first, the balance after each row gives the opening and closing balance
+func ParseCSV(reader io.Reader, profile Profile) ([]Statement, error) {
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = []rune(profile.Delimiter)[0]
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	columns := csvColumns{profile: profile, header: make(map[string]int)}
+	line := 1
+	if profile.HasHeader {
+		header, err := csvReader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("could not read csv header: %w", err)
+		}
+		for i, name := range header {
+			columns.header[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+		}
+		line++
+	}
+
+	var accounts []iban.IBAN
+	entries := make(map[string][]csvEntry)
+	for ; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read csv on line %d: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		account, entry, err := columns.parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row on line %d: %w", line, err)
+		}
+
+		if _, ok := entries[account.Code]; !ok {
+			accounts = append(accounts, account)
+		}
+		entries[account.Code] = append(entries[account.Code], entry)
+	}
+
+	statements := make([]Statement, 0, len(accounts))
+	for _, account := range accounts {
+		statements = append(statements, newCSVStatement(account, entries[account.Code]))
+	}
+	return statements, nil
+}
+
+func newCSVStatement(account iban.IBAN, entries []csvEntry) Statement {
+	if len(entries) > 1 && entries[0].entry.BookingDate.After(entries[len(entries)-1].entry.BookingDate) {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	first := entries[0]
+	last := entries[len(entries)-1]
+
+	statement := Statement{IBAN: account, ClosingBalance: last.balanceAfter, ClosingDate: last.entry.BookingDate}
+	if opening, err := first.balanceAfter.Subtract(&first.entry.Amount); err == nil {
+		statement.OpeningBalance = *opening
+	}
+	for _, entry := range entries {
+		statement.Entries = append(statement.Entries, entry.entry)
+	}
+	return statement
+}
+
+func (columns csvColumns) parseRecord(record []string) (iban.IBAN, csvEntry, error) {
+	profile := columns.profile
+
+	accountValue, err := columns.value(record, profile.AccountColumn)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, err
+	}
+	account, err := parseIBAN(accountValue)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, fmt.Errorf("account %s is not an IBAN", accountValue)
+	}
+
+	dateValue, err := columns.value(record, profile.DateColumn)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, err
+	}
+	bookingDate, err := time.Parse(profile.DateLayout, dateValue)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, fmt.Errorf("invalid date %s, expected layout %s", dateValue, profile.DateLayout)
+	}
+
+	currency := profile.Currency
+	if profile.CurrencyColumn != "" {
+		if currency, err = columns.value(record, profile.CurrencyColumn); err != nil {
+			return iban.IBAN{}, csvEntry{}, err
+		}
+	}
+
+	amount, err := columns.amount(record, profile.AmountColumn, currency)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, err
+	}
+	if profile.SignConvention == DebitCreditColumn {
+		amount = *amount.Absolute()
+		indicator, err := columns.value(record, profile.DebitCreditColumn)
+		if err != nil {
+			return iban.IBAN{}, csvEntry{}, err
+		}
+		if isDebit(indicator, profile.DebitValues) {
+			amount = *amount.Negative()
+		}
+	}
+
+	balanceAfter, err := columns.amount(record, profile.BalanceAfterColumn, currency)
+	if err != nil {
+		return iban.IBAN{}, csvEntry{}, err
+	}
+
+	var descriptions []string
+	for _, column := range profile.DescriptionColumns {
+		if value := columns.optionalValue(record, column); value != "" {
+			descriptions = append(descriptions, value)
+		}
+	}
+
+	entry := Entry{
+		Reference:        columns.optionalValue(record, profile.ReferenceColumn),
+		Amount:           amount,
+		BookingDate:      bookingDate,
+		CounterpartyName: optionalString(columns.optionalValue(record, profile.CounterpartyNameColumn)),
+		Description:      strings.Join(descriptions, " "),
+	}
+	if counterpartyIBAN := columns.optionalValue(record, profile.CounterpartyIBANColumn); counterpartyIBAN != "" {
+		if parsed, err := parseIBAN(counterpartyIBAN); err == nil {
+			entry.CounterpartyIBAN = parsed
+		}
+	}
+	if profile.CounterpartyInDescription {
+		applyCounterpartyInformation(&entry, entry.Description)
+	}
+
+	return *account, csvEntry{entry: entry, balanceAfter: balanceAfter}, nil
+}
+
+func (columns csvColumns) amount(record []string, column string, currency string) (money.Money, error) {
+	value, err := columns.value(record, column)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if columns.profile.DecimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	minorUnits, err := parseDecimal(value, columns.profile.DecimalSeparator, currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return *money.New(minorUnits, currency), nil
+}
+
+func isDebit(indicator string, debitValues []string) bool {
+	for _, value := range debitValues {
+		if strings.EqualFold(indicator, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package statementimport
+
+import (
+	"strings"
+	"testing"
+)
+
+const ingExport = `"Datum";"Naam / Omschrijving";"Rekening";"Tegenrekening";"Code";"Af Bij";"Bedrag (EUR)";"Mutatiesoort";"Mededelingen";"Saldo na mutatie";"Tag"
+"20200103";"Employer";"NL91ABNA0417164300";"DE89370400440532013000";"OV";"Bij";"1.000,00";"Overschrijving";"Salary";"1087,50";""
+"20200102";"Bakery";"NL91ABNA0417164300";"";"BA";"Af";"12,50";"Betaalautomaat";"Bread";"87,50";""
+`
+
+const abnAmroExport = "NL91ABNA0417164300\tEUR\t20200102\t100,00\t87,50\t20200102\t-12,50\tSEPA Overboeking                 IBAN: DE89370400440532013000        BIC: COBADEFFXXX  Naam: Bakery Omschrijving: Bread\n"
+
+func Test_ParseCSV_DebitCreditColumnNewestFirst(t *testing.T) {
+	statements, err := ParseCSV(strings.NewReader(ingExport), ING)
+
+	if err != nil || len(statements) != 1 {
+		t.Fatalf("Expected one statement, got %d (%v)", len(statements), err)
+	}
+	statement := statements[0]
+	if statement.OpeningBalance.Amount() != 10000 || statement.ClosingBalance.Amount() != 108750 {
+		t.Errorf("Unexpected balances %d and %d", statement.OpeningBalance.Amount(), statement.ClosingBalance.Amount())
+	}
+	if statement.Entries[0].Amount.Amount() != -1250 || statement.Entries[1].Amount.Amount() != 100000 {
+		t.Errorf("Entries not ordered oldest first or signs wrong: %+v", statement.Entries)
+	}
+	if statement.Entries[1].CounterpartyIBAN == nil || *statement.Entries[1].CounterpartyName != "Employer" {
+		t.Errorf("Counterparty not mapped")
+	}
+}
+
+func Test_ParseCSV_CounterpartyInDescription(t *testing.T) {
+	statements, err := ParseCSV(strings.NewReader(abnAmroExport), ABNAmro)
+
+	if err != nil || len(statements) != 1 {
+		t.Fatalf("Expected one statement, got %d (%v)", len(statements), err)
+	}
+	entry := statements[0].Entries[0]
+	if entry.Amount.Amount() != -1250 || *entry.CounterpartyName != "Bakery" || entry.Description != "Bread" || entry.CounterpartyIBAN == nil {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+}
+
+func Test_ReadProfile_Validates(t *testing.T) {
+	definition := `{"name": "mybank", "delimiter": ";", "hasHeader": true, "accountColumn": "Account", "dateColumn": "Date",
+		"dateLayout": "02-01-2006", "amountColumn": "Amount", "decimalSeparator": ",", "signConvention": "debit-credit-column",
+		"currency": "EUR", "balanceAfterColumn": "Balance"}`
+
+	if _, err := ReadProfile(strings.NewReader(definition)); err == nil {
+		t.Errorf("Expected an error for a debit/credit profile without debit values")
+	}
+}
+
+func Test_Summarise_ReportsWhatWouldBeImported(t *testing.T) {
+	statements, _ := ParseCSV(strings.NewReader(ingExport), ING)
+
+	summaries := Summarise(statements)
+
+	if len(summaries) != 1 || len(summaries[0].Transactions) != 2 || summaries[0].Transactions[1].BalanceAfter != summaries[0].ClosingBalance {
+		t.Errorf("Unexpected summary %+v", summaries)
+	}
+}
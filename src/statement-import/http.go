@@ -0,0 +1,198 @@
+package statementimport
+
+import (
+	"app/auth"
+	"app/bus"
+	"app/primitives"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const maxStatementSize = 32 << 20
+
+func userIDParameter(req *http.Request) (primitives.UserID, error) {
+	userID, err := uuid.Parse(req.URL.Query().Get("userId"))
+	if err != nil {
+		return primitives.UserID{}, fmt.Errorf("userId should be a valid uuid")
+	}
+	return primitives.UserID(userID), nil
+}
+
+func importHandler(importer Importer) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, err := userIDParameter(req)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		reader := bufio.NewReader(http.MaxBytesReader(res, req.Body, maxStatementSize))
+
+		format := Format(req.URL.Query().Get("format"))
+		if format == "" {
+			format = DetectFormat(reader)
+		}
+
+		statements, err := Parse(reader, format)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respondWithImport(res, req, importer, userID, statements)
+	}
+}
+
+// csvImportHandler imports a csv export, either as raw body with the name of a profile as query parameter,
+// or as multipart form with the export in a file part and a user defined profile as json in a profile part
+func csvImportHandler(importer Importer, profiles *Profiles) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, err := userIDParameter(req)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		authenticatedUser, ok := auth.UserIDFrom(req.Context())
+		if !ok {
+			http.Error(res, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var file io.Reader
+		var profile Profile
+
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := req.ParseMultipartForm(maxStatementSize); err != nil {
+				http.Error(res, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			part, _, err := req.FormFile("file")
+			if err != nil {
+				http.Error(res, "missing file part", http.StatusBadRequest)
+				return
+			}
+			defer part.Close()
+			file = part
+
+			if definition, _, err := req.FormFile("profile"); err == nil {
+				defer definition.Close()
+				profile, err = ReadProfile(definition)
+				if err != nil {
+					http.Error(res, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+		} else {
+			file = http.MaxBytesReader(res, req.Body, maxStatementSize)
+		}
+
+		if profile.Name == "" {
+			profile, err = profiles.Get(authenticatedUser, req.URL.Query().Get("profile"))
+			if err != nil {
+				http.Error(res, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		statements, err := ParseCSV(file, profile)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respondWithImport(res, req, importer, userID, statements)
+	}
+}
+
+// respondWithImport starts the import, or only reports what would be imported for a dry run
+func respondWithImport(res http.ResponseWriter, req *http.Request, importer Importer, userID primitives.UserID, statements []Statement) {
+	err := auth.Authorize(req.Context(), userID)
+	if err == nil {
+		err = importer.Authorize(userID, statements)
+	}
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		http.Error(res, err.Error(), http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(res, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+
+	if req.URL.Query().Get("dryRun") == "true" {
+		json.NewEncoder(res).Encode(Summarise(statements))
+		return
+	}
+
+	go func() {
+		if err := importer.Import(context.Background(), userID, statements); err != nil {
+			log.Printf("Could not import statements of user %s: %v", userID, err)
+		}
+	}()
+
+	res.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(res).Encode(Summarise(statements))
+}
+
+func defineProfileHandler(profiles *Profiles) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, ok := auth.UserIDFrom(req.Context())
+		if !ok {
+			http.Error(res, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		profile, err := ReadProfile(req.Body)
+		if err == nil {
+			err = profiles.Define(userID, profile)
+		}
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func listProfilesHandler(profiles *Profiles) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		userID, ok := auth.UserIDFrom(req.Context())
+		if !ok {
+			http.Error(res, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(profiles.Names(userID))
+	}
+}
+
+// RegisterImportController will register a http controller that accepts CAMT.053, MT940 and csv statement files.
+// The format of statements is detected when it is not given as query parameter, csv exports need a profile.
+// Statements are only imported into new accounts and accounts of the authenticated user
+func RegisterImportController(publisher bus.Publisher, accounts Accounts) func(r *mux.Router) error {
+	return func(r *mux.Router) error {
+		importer := NewImporter(publisher, accounts)
+		profiles := NewProfiles()
+
+		controller := r.PathPrefix("/statements").Subrouter()
+		controller.Methods("POST").Path("/import").HandlerFunc(importHandler(importer))
+		controller.Methods("POST").Path("/csv").HandlerFunc(csvImportHandler(importer, profiles))
+		controller.Methods("GET").Path("/csv/profiles").HandlerFunc(listProfilesHandler(profiles))
+		controller.Methods("POST").Path("/csv/profiles").HandlerFunc(defineProfileHandler(profiles))
+		return nil
+	}
+}
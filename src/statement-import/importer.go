@@ -0,0 +1,152 @@
+package statementimport
+
+import (
+	"app/auth"
+	"app/bus"
+	"app/primitives"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+// Accounts are the monetary accounts that are known already, with their owners
+type Accounts interface {
+	AccountOf(accountIBAN iban.IBAN) (primitives.MonetaryAccountID, bool)
+	IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool
+}
+
+// Importer publishes imported statements onto the bus, like the connectors of institutions do.
+// Re-importing overlapping statements is harmless, the transactions get the same id from the TransactionIDFetcher
+type Importer struct {
+	publisher bus.Publisher
+	accounts  Accounts
+}
+
+// NewImporter creates an Importer that publishes onto the bus
+func NewImporter(publisher bus.Publisher, accounts Accounts) Importer {
+	return Importer{publisher: publisher, accounts: accounts}
+}
+
+// Authorize rejects the statements when one of them is of a known account the user does not own,
+// a statement file can claim any IBAN, so importing it can not make the user an owner
+func (importer Importer) Authorize(userID primitives.UserID, statements []Statement) error {
+	for _, statement := range statements {
+		monetaryAccountID, ok := importer.accounts.AccountOf(statement.IBAN)
+		if ok && !importer.accounts.IsOwner(monetaryAccountID, userID) {
+			return fmt.Errorf("%w: account %s belongs to another user", auth.ErrForbidden, statement.IBAN.PrintCode)
+		}
+	}
+	return nil
+}
+
+// Import publishes the account and the transactions of every statement for the user
+func (importer Importer) Import(ctx context.Context, userID primitives.UserID, statements []Statement) error {
+	if err := importer.Authorize(userID, statements); err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if err := importer.importStatement(ctx, userID, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (importer Importer) importStatement(ctx context.Context, userID primitives.UserID, statement Statement) error {
+	startUpdate := bus.StartRefreshUpdate{
+		UserID:              userID,
+		InstitutionEntityID: statement.IBAN.Code,
+		SyncID:              primitives.SyncID(uuid.New()),
+		Started:             time.Now(),
+	}
+	refresh, err := bus.StartRefresh(ctx, importer.publisher, startUpdate)
+	if err != nil {
+		return err
+	}
+
+	err = importer.publishStatement(ctx, refresh, userID, statement)
+	if doneErr := refresh.Done(ctx, err); err == nil {
+		err = doneErr
+	}
+	return err
+}
+
+func (importer Importer) publishStatement(ctx context.Context, refresh *bus.RefreshPublisher, userID primitives.UserID, statement Statement) error {
+	fetchTimestamp := refresh.Started().Started
+	if err := refresh.PublishAccount(ctx, statement.mapToDocument(userID, fetchTimestamp)); err != nil {
+		return err
+	}
+
+	balances := statement.balanceAfterEntries()
+	for i, entry := range statement.Entries {
+		if err := refresh.PublishTransaction(ctx, entry.mapToDocument(statement.IBAN, balances[i], fetchTimestamp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (statement Statement) mapToDocument(userID primitives.UserID, fetchTimestamp time.Time) bus.MonetaryAccountDocument {
+	return bus.MonetaryAccountDocument{
+		Iban:                statement.IBAN,
+		Joint:               false,
+		OwnerUserID:         userID,
+		Alias:               statement.IBAN.PrintCode,
+		Institution:         InstitutionOf(statement.IBAN),
+		InstitutionEntityID: statement.IBAN.Code,
+		Balance:             statement.ClosingBalance,
+		FetchTimestamp:      statement.balanceTimestamp(fetchTimestamp),
+		Imported:            true,
+	}
+}
+
+// balanceTimestamp is when the closing balance was the balance of the account, the end of the closing date,
+// so importing an old statement never makes its closing balance the current balance
+func (statement Statement) balanceTimestamp(fetchTimestamp time.Time) time.Time {
+	if statement.ClosingDate.IsZero() {
+		return fetchTimestamp
+	}
+
+	endOfDay := statement.ClosingDate.AddDate(0, 0, 1).Add(-time.Second)
+	if endOfDay.After(fetchTimestamp) {
+		return fetchTimestamp
+	}
+	return endOfDay
+}
+
+func (entry Entry) mapToDocument(accountIBAN iban.IBAN, balanceAfterMutation money.Money, fetchTimestamp time.Time) bus.TransactionDocument {
+	ownIBAN := accountIBAN
+	institution := InstitutionOf(accountIBAN)
+	reference := entry.Reference
+
+	document := bus.TransactionDocument{
+		Amount:               entry.Amount,
+		Description:          entry.Description,
+		BalanceAfterMutation: balanceAfterMutation,
+		TransactionDate:      entry.BookingDate,
+		FetchTimestamp:       fetchTimestamp,
+	}
+
+	if entry.Amount.IsPositive() {
+		document.FromName = entry.CounterpartyName
+		document.FromIBAN = entry.CounterpartyIBAN
+
+		document.ToIBAN = &ownIBAN
+		document.ToInstition = &institution
+		document.ToInstitionEntityID = &reference
+	} else {
+		document.FromIBAN = &ownIBAN
+		document.FromInstition = &institution
+		document.FromInstitutionEntityID = &reference
+
+		document.ToName = entry.CounterpartyName
+		document.ToIBAN = entry.CounterpartyIBAN
+	}
+
+	return document
+}
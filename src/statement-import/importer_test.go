@@ -0,0 +1,104 @@
+package statementimport
+
+import (
+	"app/auth"
+	"app/bus"
+	"app/primitives"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/google/uuid"
+)
+
+// knownAccounts are accounts with a single owner, by IBAN
+type knownAccounts map[string]primitives.UserID
+
+func (accounts knownAccounts) AccountOf(accountIBAN iban.IBAN) (primitives.MonetaryAccountID, bool) {
+	_, ok := accounts[accountIBAN.Code]
+	return accountIDOf(accountIBAN.Code), ok
+}
+
+func (accounts knownAccounts) IsOwner(monetaryAccountID primitives.MonetaryAccountID, userID primitives.UserID) bool {
+	for code, owner := range accounts {
+		if accountIDOf(code) == monetaryAccountID && owner == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func accountIDOf(code string) primitives.MonetaryAccountID {
+	return primitives.MonetaryAccountID(uuid.NewMD5(uuid.NameSpaceOID, []byte(code)))
+}
+
+// recordingPublisher keeps the accounts that are published
+type recordingPublisher struct {
+	accounts []bus.MonetaryAccountDocument
+}
+
+func (publisher *recordingPublisher) PublishUpdate(ctx context.Context, update bus.Update) error {
+	return nil
+}
+
+func (publisher *recordingPublisher) PublishAccount(ctx context.Context, document bus.MonetaryAccountDocument) error {
+	publisher.accounts = append(publisher.accounts, document)
+	return nil
+}
+
+func (publisher *recordingPublisher) PublishTransaction(ctx context.Context, document bus.TransactionDocument) error {
+	return nil
+}
+
+func (publisher *recordingPublisher) PublishSchedule(ctx context.Context, document bus.ScheduleDocument) error {
+	return nil
+}
+
+func (publisher *recordingPublisher) PublishDirectDebit(ctx context.Context, document bus.DirectDebitTransactionDocument) error {
+	return nil
+}
+
+func Test_Import_RejectsAccountOfAnotherUser(t *testing.T) {
+	owner := primitives.UserID(uuid.New())
+	intruder := primitives.UserID(uuid.New())
+	statements, err := Parse(strings.NewReader(camt053), CAMT053)
+	if err != nil {
+		t.Fatalf("Could not parse statement: %v", err)
+	}
+
+	publisher := &recordingPublisher{}
+	importer := NewImporter(publisher, knownAccounts{"NL91ABNA0417164300": owner})
+
+	if err := importer.Import(context.Background(), intruder, statements); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("Expected the import into the account of another user to be forbidden, got %v", err)
+	}
+	if len(publisher.accounts) != 0 {
+		t.Errorf("Expected nothing to be published, got %+v", publisher.accounts)
+	}
+
+	if err := importer.Import(context.Background(), owner, statements); err != nil {
+		t.Errorf("Expected the owner to import, got %v", err)
+	}
+	if len(publisher.accounts) != 1 || !publisher.accounts[0].Imported {
+		t.Errorf("Expected the account to be published as imported, got %+v", publisher.accounts)
+	}
+}
+
+func Test_Import_BalanceAtClosingDate(t *testing.T) {
+	statements, err := Parse(strings.NewReader(camt053), CAMT053)
+	if err != nil {
+		t.Fatalf("Could not parse statement: %v", err)
+	}
+	publisher := &recordingPublisher{}
+
+	if err := NewImporter(publisher, knownAccounts{}).Import(context.Background(), primitives.UserID(uuid.New()), statements); err != nil {
+		t.Fatalf("Could not import statement: %v", err)
+	}
+
+	closingDate := statements[0].ClosingDate
+	if timestamp := publisher.accounts[0].FetchTimestamp; timestamp.Before(closingDate) || !timestamp.Before(closingDate.AddDate(0, 0, 1)) {
+		t.Errorf("Expected the balance at the end of the closing date %s, got %s", closingDate, timestamp)
+	}
+}
@@ -0,0 +1,237 @@
+package statementimport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+var mt940TagPattern = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):(.*)$`)
+var mt940BalancePattern = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+var mt940LinePattern = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)(.*)$`)
+
+// ParseMT940 parses SWIFT MT940 customer statements as exported by Dutch banks, every :20: tag starts a new statement
+func ParseMT940(reader io.Reader) ([]Statement, error) {
+	fields, err := readMT940Fields(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	var statement *Statement
+	var currency string
+
+	for _, field := range fields {
+		if field.tag == "20" {
+			if statement != nil {
+				statements = append(statements, *statement)
+			}
+			statement = &Statement{}
+			continue
+		}
+		if statement == nil {
+			return nil, fmt.Errorf("MT940 field :%s: found before the start of a statement", field.tag)
+		}
+
+		switch field.tag {
+		case "25":
+			accountIBAN, err := parseMT940Account(field.value)
+			if err != nil {
+				return nil, err
+			}
+			statement.IBAN = *accountIBAN
+		case "60F", "60M":
+			balance, _, err := parseMT940Balance(field.value)
+			if err != nil {
+				return nil, err
+			}
+			statement.OpeningBalance = balance
+			currency = balance.Currency().Code
+		case "62F", "62M":
+			balance, date, err := parseMT940Balance(field.value)
+			if err != nil {
+				return nil, err
+			}
+			statement.ClosingBalance = balance
+			statement.ClosingDate = date
+		case "61":
+			entry, err := parseMT940Line(field.value, currency)
+			if err != nil {
+				return nil, err
+			}
+			statement.Entries = append(statement.Entries, entry)
+		case "86":
+			if len(statement.Entries) > 0 {
+				applyCounterpartyInformation(&statement.Entries[len(statement.Entries)-1], field.value)
+			}
+		}
+	}
+
+	if statement != nil {
+		statements = append(statements, *statement)
+	}
+	return statements, nil
+}
+
+// readMT940Fields reads the tagged fields, lines without a tag continue the field before them
+func readMT940Fields(reader io.Reader) ([]mt940Field, error) {
+	var fields []mt940Field
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "-" || strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		if match := mt940TagPattern.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], value: match[2]})
+		} else if len(fields) > 0 {
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read MT940 statement: %w", err)
+	}
+	return fields, nil
+}
+
+func parseMT940Account(value string) (*iban.IBAN, error) {
+	account := strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if accountIBAN, err := parseIBAN(account); err == nil {
+		return accountIBAN, nil
+	}
+
+	// most banks add the currency to the account, like NL69INGB0123456789EUR
+	if len(account) > 3 {
+		if accountIBAN, err := parseIBAN(account[:len(account)-3]); err == nil {
+			return accountIBAN, nil
+		}
+	}
+	return nil, fmt.Errorf("account %s of MT940 statement is not an IBAN", value)
+}
+
+func parseMT940Balance(value string) (money.Money, time.Time, error) {
+	match := mt940BalancePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return money.Money{}, time.Time{}, fmt.Errorf("invalid MT940 balance %s", value)
+	}
+
+	date, err := time.Parse("060102", match[2])
+	if err != nil {
+		return money.Money{}, time.Time{}, fmt.Errorf("invalid date in MT940 balance %s", value)
+	}
+
+	amount, err := parseDecimal(match[4], ",", match[3])
+	if err != nil {
+		return money.Money{}, time.Time{}, err
+	}
+	if match[1] == "D" {
+		amount = -amount
+	}
+	return *money.New(amount, match[3]), date, nil
+}
+
+func parseMT940Line(value string, currency string) (Entry, error) {
+	firstLine := strings.SplitN(value, "\n", 2)[0]
+	match := mt940LinePattern.FindStringSubmatch(strings.TrimSpace(firstLine))
+	if match == nil {
+		return Entry{}, fmt.Errorf("invalid MT940 statement line %s", firstLine)
+	}
+	if currency == "" {
+		return Entry{}, fmt.Errorf("MT940 statement line found before the opening balance")
+	}
+
+	bookingDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid date in MT940 statement line %s", firstLine)
+	}
+
+	amount, err := parseDecimal(match[5], ",", currency)
+	if err != nil {
+		return Entry{}, err
+	}
+	// a reversal of a credit is a debit and the other way around
+	if match[3] == "D" || match[3] == "RC" {
+		amount = -amount
+	}
+
+	reference := match[6]
+	if len(reference) >= 4 {
+		reference = reference[4:]
+	}
+	if i := strings.Index(reference, "//"); i >= 0 {
+		reference = firstNonEmpty(reference[i+2:], reference[:i])
+	}
+	if reference == "NONREF" {
+		reference = ""
+	}
+
+	return Entry{
+		Reference:   strings.TrimSpace(reference),
+		Amount:      *money.New(amount, currency),
+		BookingDate: bookingDate,
+	}, nil
+}
+
+var structuredCounterpartyPattern = regexp.MustCompile(`/CNTP/([^/]*)/([^/]*)/([^/]*)/`)
+var structuredRemittancePattern = regexp.MustCompile(`/REMI/(?:USTD//|STRD/CUR/)?(.*?)/(?:[A-Z]{4}/|$)`)
+var structuredNamePattern = regexp.MustCompile(`/NAME/([^/]*)/`)
+var structuredIBANPattern = regexp.MustCompile(`/IBAN/([^/]*)/`)
+var freeTextIBANPattern = regexp.MustCompile(`(?i)IBAN:\s*([A-Z]{2}[0-9]{2}[A-Z0-9]+)`)
+var freeTextNamePattern = regexp.MustCompile(`(?i)NAAM:\s*(.*?)\s*(?:OMSCHRIJVING:|KENMERK:|MACHTIGING:|BIC:|$)`)
+var freeTextDescriptionPattern = regexp.MustCompile(`(?i)OMSCHRIJVING:\s*(.*?)\s*(?:KENMERK:|IBAN:|BIC:|NAAM:|$)`)
+
+// applyCounterpartyInformation reads the counterparty and description from the information of a statement line.
+// ING and Rabobank use structured /TAG/ fields, ABN AMRO uses free text with IBAN:, NAAM: and OMSCHRIJVING: labels
+func applyCounterpartyInformation(entry *Entry, value string) {
+	information := strings.ReplaceAll(value, "\n", "")
+
+	if strings.HasPrefix(information, "/") {
+		var counterpartyIBAN, counterpartyName string
+		if match := structuredCounterpartyPattern.FindStringSubmatch(information); match != nil {
+			counterpartyIBAN, counterpartyName = match[1], match[3]
+		}
+		if match := structuredIBANPattern.FindStringSubmatch(information); match != nil && counterpartyIBAN == "" {
+			counterpartyIBAN = match[1]
+		}
+		if match := structuredNamePattern.FindStringSubmatch(information); match != nil && counterpartyName == "" {
+			counterpartyName = match[1]
+		}
+
+		entry.CounterpartyName = optionalString(counterpartyName)
+		if parsed, err := parseIBAN(counterpartyIBAN); err == nil {
+			entry.CounterpartyIBAN = parsed
+		}
+		if match := structuredRemittancePattern.FindStringSubmatch(information); match != nil {
+			entry.Description = strings.TrimSpace(match[1])
+		}
+		return
+	}
+
+	if match := freeTextIBANPattern.FindStringSubmatch(information); match != nil {
+		if parsed, err := parseIBAN(match[1]); err == nil {
+			entry.CounterpartyIBAN = parsed
+		}
+	}
+	if match := freeTextNamePattern.FindStringSubmatch(information); match != nil {
+		entry.CounterpartyName = optionalString(match[1])
+	}
+	if match := freeTextDescriptionPattern.FindStringSubmatch(information); match != nil {
+		entry.Description = strings.TrimSpace(match[1])
+	} else {
+		entry.Description = strings.TrimSpace(information)
+	}
+}
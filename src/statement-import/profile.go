@@ -0,0 +1,215 @@
+package statementimport
+
+import (
+	"app/primitives"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SignConvention how a csv export tells debits from credits
+type SignConvention string
+
+// SignConvention enum
+const (
+	// SignedAmount negative amounts are debits
+	SignedAmount SignConvention = "signed"
+	// DebitCreditColumn a separate column tells whether the amount is a debit or a credit
+	DebitCreditColumn SignConvention = "debit-credit-column"
+)
+
+// Profile maps the columns of the csv export of a bank onto statement entries.
+// Columns are referred to by their header, or by their zero based index for exports without a header
+type Profile struct {
+	Name                      string         `json:"name"`
+	Delimiter                 string         `json:"delimiter"`
+	HasHeader                 bool           `json:"hasHeader"`
+	AccountColumn             string         `json:"accountColumn"`
+	DateColumn                string         `json:"dateColumn"`
+	DateLayout                string         `json:"dateLayout"`
+	AmountColumn              string         `json:"amountColumn"`
+	DecimalSeparator          string         `json:"decimalSeparator"`
+	SignConvention            SignConvention `json:"signConvention"`
+	DebitCreditColumn         string         `json:"debitCreditColumn,omitempty"`
+	DebitValues               []string       `json:"debitValues,omitempty"`
+	CurrencyColumn            string         `json:"currencyColumn,omitempty"`
+	Currency                  string         `json:"currency,omitempty"`
+	BalanceAfterColumn        string         `json:"balanceAfterColumn"`
+	CounterpartyIBANColumn    string         `json:"counterpartyIbanColumn,omitempty"`
+	CounterpartyNameColumn    string         `json:"counterpartyNameColumn,omitempty"`
+	DescriptionColumns        []string       `json:"descriptionColumns,omitempty"`
+	ReferenceColumn           string         `json:"referenceColumn,omitempty"`
+	CounterpartyInDescription bool           `json:"counterpartyInDescription,omitempty"`
+}
+
+func (profile Profile) validate() error {
+	if profile.Name == "" {
+		return fmt.Errorf("profile needs a name")
+	}
+	if len([]rune(profile.Delimiter)) != 1 {
+		return fmt.Errorf("delimiter of profile %s should be a single character", profile.Name)
+	}
+	if profile.AccountColumn == "" || profile.DateColumn == "" || profile.AmountColumn == "" || profile.BalanceAfterColumn == "" {
+		return fmt.Errorf("profile %s needs an account, date, amount and balance after column", profile.Name)
+	}
+	if profile.DateLayout == "" {
+		return fmt.Errorf("profile %s needs a date layout", profile.Name)
+	}
+	if profile.DecimalSeparator != "." && profile.DecimalSeparator != "," {
+		return fmt.Errorf("decimal separator of profile %s should be . or ,", profile.Name)
+	}
+	if profile.CurrencyColumn == "" && profile.Currency == "" {
+		return fmt.Errorf("profile %s needs a currency or a currency column", profile.Name)
+	}
+
+	switch profile.SignConvention {
+	case SignedAmount:
+	case DebitCreditColumn:
+		if profile.DebitCreditColumn == "" || len(profile.DebitValues) == 0 {
+			return fmt.Errorf("profile %s needs a debit/credit column and the values of debits", profile.Name)
+		}
+	default:
+		return fmt.Errorf("unknown sign convention %s of profile %s", profile.SignConvention, profile.Name)
+	}
+	return nil
+}
+
+// ING is the profile of the csv export of ING, with semicolons
+var ING = Profile{
+	Name:                   "ing",
+	Delimiter:              ";",
+	HasHeader:              true,
+	AccountColumn:          "Rekening",
+	DateColumn:             "Datum",
+	DateLayout:             "20060102",
+	AmountColumn:           "Bedrag (EUR)",
+	DecimalSeparator:       ",",
+	SignConvention:         DebitCreditColumn,
+	DebitCreditColumn:      "Af Bij",
+	DebitValues:            []string{"Af"},
+	Currency:               "EUR",
+	BalanceAfterColumn:     "Saldo na mutatie",
+	CounterpartyIBANColumn: "Tegenrekening",
+	CounterpartyNameColumn: "Naam / Omschrijving",
+	DescriptionColumns:     []string{"Mededelingen"},
+}
+
+// Rabobank is the profile of the csv export of Rabobank
+var Rabobank = Profile{
+	Name:                   "rabobank",
+	Delimiter:              ",",
+	HasHeader:              true,
+	AccountColumn:          "IBAN/BBAN",
+	DateColumn:             "Datum",
+	DateLayout:             "2006-01-02",
+	AmountColumn:           "Bedrag",
+	DecimalSeparator:       ",",
+	SignConvention:         SignedAmount,
+	CurrencyColumn:         "Munt",
+	BalanceAfterColumn:     "Saldo na trn",
+	CounterpartyIBANColumn: "Tegenrekening IBAN/BBAN",
+	CounterpartyNameColumn: "Naam tegenpartij",
+	DescriptionColumns:     []string{"Omschrijving-1", "Omschrijving-2", "Omschrijving-3"},
+	ReferenceColumn:        "Volgnr",
+}
+
+// ABNAmro is the profile of the tab separated export of ABN AMRO, which has no header
+// and keeps the counterparty in the description
+var ABNAmro = Profile{
+	Name:                      "abnamro",
+	Delimiter:                 "\t",
+	HasHeader:                 false,
+	AccountColumn:             "0",
+	CurrencyColumn:            "1",
+	DateColumn:                "2",
+	DateLayout:                "20060102",
+	BalanceAfterColumn:        "4",
+	AmountColumn:              "6",
+	DecimalSeparator:          ",",
+	SignConvention:            SignedAmount,
+	DescriptionColumns:        []string{"7"},
+	CounterpartyInDescription: true,
+}
+
+// Profiles are the known csv profiles, the ones of the common Dutch banks and the ones defined by each user,
+// a user only sees the profiles they defined themselves
+type Profiles struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+	defined  map[primitives.UserID]map[string]Profile
+}
+
+// NewProfiles creates Profiles with the profiles of the common Dutch banks
+func NewProfiles() *Profiles {
+	profiles := &Profiles{profiles: make(map[string]Profile), defined: make(map[primitives.UserID]map[string]Profile)}
+	for _, profile := range []Profile{ING, Rabobank, ABNAmro} {
+		profiles.profiles[profile.Name] = profile
+	}
+	return profiles
+}
+
+// Define adds or replaces a profile of the user
+func (profiles *Profiles) Define(userID primitives.UserID, profile Profile) error {
+	if err := profile.validate(); err != nil {
+		return err
+	}
+
+	profiles.mu.Lock()
+	defer profiles.mu.Unlock()
+
+	defined, ok := profiles.defined[userID]
+	if !ok {
+		defined = make(map[string]Profile)
+		profiles.defined[userID] = defined
+	}
+	defined[strings.ToLower(profile.Name)] = profile
+	return nil
+}
+
+// Get returns the profile with the name, a profile defined by the user takes precedence over the one of a bank
+func (profiles *Profiles) Get(userID primitives.UserID, name string) (Profile, error) {
+	profiles.mu.RLock()
+	defer profiles.mu.RUnlock()
+
+	if profile, ok := profiles.defined[userID][strings.ToLower(name)]; ok {
+		return profile, nil
+	}
+	profile, ok := profiles.profiles[strings.ToLower(name)]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown csv profile %s", name)
+	}
+	return profile, nil
+}
+
+// Names returns the names of the profiles of the banks and the ones defined by the user
+func (profiles *Profiles) Names(userID primitives.UserID) []string {
+	profiles.mu.RLock()
+	defer profiles.mu.RUnlock()
+
+	names := make([]string, 0, len(profiles.profiles)+len(profiles.defined[userID]))
+	for name := range profiles.profiles {
+		names = append(names, name)
+	}
+	for name := range profiles.defined[userID] {
+		if _, ok := profiles.profiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadProfile reads a user defined profile from json
+func ReadProfile(reader io.Reader) (Profile, error) {
+	var profile Profile
+	if err := json.NewDecoder(reader).Decode(&profile); err != nil {
+		return Profile{}, fmt.Errorf("could not read csv profile: %w", err)
+	}
+	if err := profile.validate(); err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,33 @@
+package statementimport
+
+import (
+	"app/primitives"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_Profiles_DefinedPerUser(t *testing.T) {
+	alice := primitives.UserID(uuid.New())
+	bob := primitives.UserID(uuid.New())
+	profiles := NewProfiles()
+
+	profile := Rabobank
+	profile.Name = "Savings"
+	if err := profiles.Define(alice, profile); err != nil {
+		t.Fatalf("Could not define profile: %v", err)
+	}
+
+	if _, err := profiles.Get(alice, "savings"); err != nil {
+		t.Errorf("Expected the profile for the user that defined it, got %v", err)
+	}
+	if _, err := profiles.Get(bob, "savings"); err == nil {
+		t.Errorf("Expected the profile to be unknown to another user")
+	}
+	if _, err := profiles.Get(bob, "ing"); err != nil {
+		t.Errorf("Expected the profiles of the banks for every user, got %v", err)
+	}
+	if names := profiles.Names(bob); len(names) != 3 {
+		t.Errorf("Expected only the profiles of the banks for another user, got %v", names)
+	}
+}
@@ -0,0 +1,108 @@
+package statementimport
+
+import (
+	"app/primitives"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/almerlucke/go-iban/iban"
+)
+
+// Format a file format of bank statements
+type Format string
+
+// Format enum
+const (
+	CAMT053 Format = "camt053"
+	MT940   Format = "mt940"
+)
+
+// Statement the entries booked on a monetary account, as exported by its bank
+type Statement struct {
+	IBAN           iban.IBAN
+	OpeningBalance money.Money
+	ClosingBalance money.Money
+	ClosingDate    time.Time
+	Entries        []Entry
+}
+
+// Entry a booking on the account of a statement, debit entries have a negative amount
+type Entry struct {
+	Reference        string
+	Amount           money.Money
+	BookingDate      time.Time
+	CounterpartyName *string
+	CounterpartyIBAN *iban.IBAN
+	Description      string
+}
+
+// InstitutionOf derives the institution from the bank code of a Dutch IBAN
+func InstitutionOf(accountIBAN iban.IBAN) primitives.Institution {
+	if accountIBAN.CountryCode != "NL" || len(accountIBAN.BBAN) < 4 {
+		return primitives.OtherInstitution
+	}
+
+	switch accountIBAN.BBAN[:4] {
+	case "INGB":
+		return primitives.ING
+	case "ABNA":
+		return primitives.ABNAmro
+	case "RABO":
+		return primitives.Rabobank
+	case "BUNQ":
+		return primitives.Bunq
+	default:
+		return primitives.OtherInstitution
+	}
+}
+
+// Parse parses all statements in the file
+func Parse(reader io.Reader, format Format) ([]Statement, error) {
+	switch format {
+	case CAMT053:
+		return ParseCAMT053(reader)
+	case MT940:
+		return ParseMT940(reader)
+	default:
+		return nil, fmt.Errorf("unknown statement format %s", format)
+	}
+}
+
+// DetectFormat guesses the format of a statement file from its first characters, xml is CAMT.053 and anything else MT940
+func DetectFormat(reader *bufio.Reader) Format {
+	start, _ := reader.Peek(512)
+	if bytes.HasPrefix(bytes.TrimSpace(start), []byte("<")) {
+		return CAMT053
+	}
+	return MT940
+}
+
+// balanceAfterEntries computes the balance after every entry, starting from the opening balance of the statement
+func (statement Statement) balanceAfterEntries() []money.Money {
+	balances := make([]money.Money, len(statement.Entries))
+	balance := statement.OpeningBalance
+	for i, entry := range statement.Entries {
+		if after, err := balance.Add(&entry.Amount); err == nil {
+			balance = *after
+		}
+		balances[i] = balance
+	}
+	return balances
+}
+
+func parseIBAN(s string) (*iban.IBAN, error) {
+	return iban.NewIBAN(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+}
+
+func optionalString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
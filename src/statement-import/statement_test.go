@@ -0,0 +1,121 @@
+package statementimport
+
+import (
+	"app/primitives"
+	"strings"
+	"testing"
+)
+
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Id>0001</Id>
+      <Acct><Id><IBAN>NL91ABNA0417164300</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2020-01-01</Dt></Dt></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">87.50</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2020-01-02</Dt></Dt></Bal>
+      <Ntry>
+        <Amt Ccy="EUR">12.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><Dt>2020-01-02</Dt></BookgDt>
+        <AcctSvcrRef>REF1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RltdPties><Cdtr><Nm>Bakery</Nm></Cdtr><CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct></RltdPties>
+          <RmtInf><Ustrd>Bread</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const mt940 = `:20:STARTUMS
+:25:NL91ABNA0417164300EUR
+:28C:00001
+:60F:C200101EUR100,00
+:61:2001020102D12,50NTRFNONREF//REF1
+:86:/CNTP/DE89370400440532013000/COBADEFFXXX/Bakery//REMI/USTD//Br
+ead/
+:61:2001030103C1000,00NTRFNONREF//REF2
+:86:SEPA OVERBOEKING IBAN: DE89370400440532013000 BIC: COBADEFFXXX NAAM
+: Employer OMSCHRIJVING: Salary KENMERK: 123
+:62F:C200103EUR1087,50
+-`
+
+func Test_ParseCAMT053(t *testing.T) {
+	statements, err := ParseCAMT053(strings.NewReader(camt053))
+
+	if err != nil || len(statements) != 1 {
+		t.Fatalf("Expected one statement, got %d (%v)", len(statements), err)
+	}
+	statement := statements[0]
+	if statement.IBAN.Code != "NL91ABNA0417164300" || statement.ClosingBalance.Amount() != 8750 {
+		t.Errorf("Unexpected statement %+v", statement)
+	}
+	entry := statement.Entries[0]
+	if entry.Amount.Amount() != -1250 || *entry.CounterpartyName != "Bakery" || entry.CounterpartyIBAN.Code != "DE89370400440532013000" || entry.Description != "Bread" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+}
+
+func Test_ParseMT940(t *testing.T) {
+	statements, err := ParseMT940(strings.NewReader(mt940))
+
+	if err != nil || len(statements) != 1 {
+		t.Fatalf("Expected one statement, got %d (%v)", len(statements), err)
+	}
+	statement := statements[0]
+	if statement.IBAN.Code != "NL91ABNA0417164300" || statement.OpeningBalance.Amount() != 10000 || len(statement.Entries) != 2 {
+		t.Fatalf("Unexpected statement %+v", statement)
+	}
+
+	debit := statement.Entries[0]
+	if debit.Amount.Amount() != -1250 || debit.Reference != "REF1" || *debit.CounterpartyName != "Bakery" || debit.Description != "Bread" {
+		t.Errorf("Unexpected structured entry %+v", debit)
+	}
+
+	credit := statement.Entries[1]
+	if credit.Amount.Amount() != 100000 || *credit.CounterpartyName != "Employer" || credit.Description != "Salary" || credit.CounterpartyIBAN == nil {
+		t.Errorf("Unexpected free text entry %+v", credit)
+	}
+
+	balances := statement.balanceAfterEntries()
+	if balances[1].Amount() != statement.ClosingBalance.Amount() {
+		t.Errorf("Expected balance after last entry to be the closing balance, got %d", balances[1].Amount())
+	}
+}
+
+func Test_Importer_MapsDebitsFromOwnAccount(t *testing.T) {
+	statements, _ := ParseMT940(strings.NewReader(mt940))
+	statement := statements[0]
+
+	debit := statement.Entries[0].mapToDocument(statement.IBAN, statement.OpeningBalance, statement.ClosingDate)
+	credit := statement.Entries[1].mapToDocument(statement.IBAN, statement.OpeningBalance, statement.ClosingDate)
+
+	if debit.FromIBAN.Code != statement.IBAN.Code || *debit.FromInstition != primitives.ABNAmro || *debit.ToName != "Bakery" {
+		t.Errorf("Debit should be paid from the own account")
+	}
+	if credit.ToIBAN.Code != statement.IBAN.Code || *credit.FromName != "Employer" {
+		t.Errorf("Credit should be paid to the own account")
+	}
+}
+
+func Test_ParseDecimal_ExactMinorUnits(t *testing.T) {
+	valid := map[string]int64{"1234.56": 123456, "0.29": 29, "-12.5": -1250, "100": 10000, "7.10": 710, "2.500": 250}
+	for value, expected := range valid {
+		if minorUnits, err := parseDecimal(value, ".", "EUR"); err != nil || minorUnits != expected {
+			t.Errorf("Expected %s to be %d minor units, got %d %v", value, expected, minorUnits, err)
+		}
+	}
+	if minorUnits, err := parseDecimal("100,", ",", "EUR"); err != nil || minorUnits != 10000 {
+		t.Errorf("Expected an amount without decimals after the separator, got %d %v", minorUnits, err)
+	}
+
+	for _, value := range []string{"12.345", "1e3", "", "-", "1.2.3", "--5"} {
+		if _, err := parseDecimal(value, ".", "EUR"); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+	if _, err := parseDecimal("10.5", ".", "JPY"); err == nil {
+		t.Errorf("Expected decimals of a currency without minor units to be rejected")
+	}
+}
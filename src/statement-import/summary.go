@@ -0,0 +1,56 @@
+package statementimport
+
+import (
+	"app/primitives"
+)
+
+// StatementSummary what an import of a statement creates, for a dry run
+type StatementSummary struct {
+	IBAN           string                 `json:"iban"`
+	Institution    primitives.Institution `json:"institution"`
+	OpeningBalance string                 `json:"openingBalance"`
+	ClosingBalance string                 `json:"closingBalance"`
+	Transactions   []TransactionSummary   `json:"transactions"`
+}
+
+// TransactionSummary a transaction an import creates, for a dry run
+type TransactionSummary struct {
+	Date             string `json:"date"`
+	Amount           string `json:"amount"`
+	BalanceAfter     string `json:"balanceAfter"`
+	CounterpartyName string `json:"counterpartyName,omitempty"`
+	CounterpartyIBAN string `json:"counterpartyIban,omitempty"`
+	Description      string `json:"description"`
+}
+
+// Summarise reports the accounts and transactions an import of the statements would create, without publishing anything
+func Summarise(statements []Statement) []StatementSummary {
+	summaries := make([]StatementSummary, 0, len(statements))
+	for _, statement := range statements {
+		summary := StatementSummary{
+			IBAN:           statement.IBAN.PrintCode,
+			Institution:    InstitutionOf(statement.IBAN),
+			OpeningBalance: statement.OpeningBalance.Display(),
+			ClosingBalance: statement.ClosingBalance.Display(),
+		}
+
+		balances := statement.balanceAfterEntries()
+		for i, entry := range statement.Entries {
+			transaction := TransactionSummary{
+				Date:         entry.BookingDate.Format("2006-01-02"),
+				Amount:       entry.Amount.Display(),
+				BalanceAfter: balances[i].Display(),
+				Description:  entry.Description,
+			}
+			if entry.CounterpartyName != nil {
+				transaction.CounterpartyName = *entry.CounterpartyName
+			}
+			if entry.CounterpartyIBAN != nil {
+				transaction.CounterpartyIBAN = entry.CounterpartyIBAN.PrintCode
+			}
+			summary.Transactions = append(summary.Transactions, transaction)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
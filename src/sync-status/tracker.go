@@ -0,0 +1,196 @@
+package syncstatus
+
+import (
+	"app/bus"
+	"app/primitives"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sync is one refresh of an account of a user by a connector
+type Sync struct {
+	SyncID              primitives.SyncID
+	UserID              primitives.UserID
+	InstitutionEntityID string
+	Started             time.Time
+	// Finished is zero while the sync is running
+	Finished  time.Time
+	Documents int
+	Errors    []string
+}
+
+// Running tells whether the sync has not finished yet
+func (sync Sync) Running() bool {
+	return sync.Finished.IsZero()
+}
+
+// Duration is how long the sync took, or has been running until now
+func (sync Sync) Duration(now time.Time) time.Duration {
+	if sync.Running() {
+		return now.Sub(sync.Started)
+	}
+	return sync.Finished.Sub(sync.Started)
+}
+
+// AccountSyncs are the syncs of an account, the most recent first
+type AccountSyncs struct {
+	InstitutionEntityID string
+	Syncs               []Sync
+}
+
+// Options how much history is kept and when a sync is stuck
+type Options struct {
+	// History is the number of syncs that are kept per account
+	History int
+	// StuckAfter is how long a sync can run before it is stuck
+	StuckAfter time.Duration
+}
+
+// DefaultOptions keeps the last 20 syncs of an account, a sync that runs for half an hour is stuck
+var DefaultOptions = Options{History: 20, StuckAfter: 30 * time.Minute}
+
+// Tracker keeps the sync history of the accounts of users from the updates on the bus
+type Tracker struct {
+	mu       *sync.RWMutex
+	updates  <-chan bus.Update
+	options  Options
+	accounts map[primitives.UserID]map[string][]Sync
+	now      func() time.Time
+}
+
+// NewTracker subscribes to the updates on the bus, Start tracks them until the bus is closed
+func NewTracker(subscriber bus.Subscriber, options Options) Tracker {
+	res := new(Tracker)
+	res.mu = new(sync.RWMutex)
+	res.updates = subscriber.SubscribeUpdates(bus.DefaultSubscriptionOptions)
+	res.options = options
+	res.accounts = make(map[primitives.UserID]map[string][]Sync)
+	res.now = time.Now
+	return *res
+}
+
+// Start tracks the updates until the bus is closed
+func (tracker Tracker) Start() {
+	for update := range tracker.updates {
+		tracker.track(update)
+	}
+}
+
+// SyncOf is the sync as it is known from the update, false when the update is not about a sync
+func SyncOf(update bus.Update) (Sync, bool) {
+	switch update := update.(type) {
+	case bus.StartRefreshUpdate:
+		return Sync{
+			SyncID:              update.SyncID,
+			UserID:              update.UserID,
+			InstitutionEntityID: update.InstitutionEntityID,
+			Started:             update.Started,
+		}, true
+
+	case bus.DoneRefreshingUpdate:
+		return Sync{
+			SyncID:              update.SyncID,
+			UserID:              update.UserID,
+			InstitutionEntityID: update.InstititutionEntityID,
+			Started:             update.Started,
+			Finished:            update.Finished,
+			Documents:           update.Documents,
+			Errors:              update.Errors,
+		}, true
+	}
+	return Sync{}, false
+}
+
+func (tracker Tracker) track(update bus.Update) {
+	sync, ok := SyncOf(update)
+	if !ok {
+		return
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if !sync.Running() {
+		syncs := tracker.accounts[sync.UserID][sync.InstitutionEntityID]
+		for i := range syncs {
+			if syncs[i].SyncID == sync.SyncID {
+				syncs[i] = sync
+				return
+			}
+		}
+	}
+
+	// a new sync, or the start of the sync was missed or is no longer kept
+	tracker.started(sync)
+}
+
+func (tracker Tracker) started(sync Sync) {
+	accounts, ok := tracker.accounts[sync.UserID]
+	if !ok {
+		accounts = make(map[string][]Sync)
+		tracker.accounts[sync.UserID] = accounts
+	}
+
+	syncs := append([]Sync{sync}, accounts[sync.InstitutionEntityID]...)
+	if len(syncs) > tracker.options.History {
+		syncs = syncs[:tracker.options.History]
+	}
+	accounts[sync.InstitutionEntityID] = syncs
+}
+
+// SyncsOf returns the sync history of every account of the user
+func (tracker Tracker) SyncsOf(userID primitives.UserID) []AccountSyncs {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+
+	res := make([]AccountSyncs, 0, len(tracker.accounts[userID]))
+	for institutionEntityID, syncs := range tracker.accounts[userID] {
+		res = append(res, AccountSyncs{
+			InstitutionEntityID: institutionEntityID,
+			Syncs:               append([]Sync(nil), syncs...),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].InstitutionEntityID < res[j].InstitutionEntityID
+	})
+	return res
+}
+
+// Stuck returns the syncs that have been running for longer than StuckAfter, the longest running first.
+// A sync of an account that was followed by a finished sync was abandoned, it is not stuck
+func (tracker Tracker) Stuck() []Sync {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+
+	now := tracker.now()
+	var stuck []Sync
+	for _, accounts := range tracker.accounts {
+		for _, syncs := range accounts {
+			for _, sync := range syncs {
+				if !sync.Running() {
+					break
+				}
+				if sync.Duration(now) > tracker.options.StuckAfter {
+					stuck = append(stuck, sync)
+				}
+			}
+		}
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].Started.Before(stuck[j].Started)
+	})
+	return stuck
+}
+
+// CheckHealth returns an error when syncs are stuck
+func (tracker Tracker) CheckHealth() error {
+	if stuck := tracker.Stuck(); len(stuck) > 0 {
+		return fmt.Errorf("%d syncs are running for more than %s, the oldest of account %s since %s",
+			len(stuck), tracker.options.StuckAfter, stuck[0].InstitutionEntityID, stuck[0].Started.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package syncstatus
+
+import (
+	"app/bus"
+	"app/primitives"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	userID = primitives.UserID(uuid.New())
+	now    = time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newTestTracker() Tracker {
+	tracker := NewTracker(bus.NewInMemoryBus(), Options{History: 2, StuckAfter: time.Hour})
+	tracker.now = func() time.Time { return now }
+	return tracker
+}
+
+func start(account string, started time.Time) bus.StartRefreshUpdate {
+	return bus.StartRefreshUpdate{
+		UserID:              userID,
+		SyncID:              primitives.SyncID(uuid.New()),
+		InstitutionEntityID: account,
+		Started:             started,
+	}
+}
+
+func done(start bus.StartRefreshUpdate, finished time.Time, documents int, errors ...string) bus.DoneRefreshingUpdate {
+	update := bus.NewDoneRefreshingUpdateFrom(start)
+	update.Finished = finished
+	update.Documents = documents
+	update.Errors = errors
+	return update
+}
+
+func Test_Tracker_KeepsHistoryPerAccount(t *testing.T) {
+	tracker := newTestTracker()
+
+	first := start("1", now.Add(-3*time.Minute))
+	second := start("1", now.Add(-2*time.Minute))
+	third := start("1", now.Add(-time.Minute))
+	other := start("2", now.Add(-time.Minute))
+	for _, update := range []bus.Update{
+		first, done(first, now.Add(-170*time.Second), 4),
+		second, done(second, now.Add(-110*time.Second), 0, "could not fetch transactions"),
+		third,
+		other, done(other, now, 1),
+	} {
+		tracker.track(update)
+	}
+
+	accounts := tracker.SyncsOf(userID)
+	if len(accounts) != 2 || accounts[0].InstitutionEntityID != "1" || accounts[1].InstitutionEntityID != "2" {
+		t.Fatalf("Expected the syncs of both accounts, got %+v", accounts)
+	}
+
+	syncs := accounts[0].Syncs
+	if len(syncs) != 2 {
+		t.Fatalf("Expected the history to be limited to 2 syncs, got %d", len(syncs))
+	}
+	if syncs[0].SyncID != third.SyncID || !syncs[0].Running() || syncs[0].Duration(now) != time.Minute {
+		t.Errorf("Expected the running sync first, got %+v", syncs[0])
+	}
+	if syncs[1].Running() || syncs[1].Duration(now) != 10*time.Second || len(syncs[1].Errors) != 1 {
+		t.Errorf("Expected the failed sync second, got %+v", syncs[1])
+	}
+	if accounts[1].Syncs[0].Documents != 1 {
+		t.Errorf("Expected the documents of the sync to be counted, got %d", accounts[1].Syncs[0].Documents)
+	}
+}
+
+func Test_Tracker_DetectsStuckSyncs(t *testing.T) {
+	tracker := newTestTracker()
+
+	abandoned := start("1", now.Add(-3*time.Hour))
+	finished := start("1", now.Add(-2*time.Hour))
+	stuck := start("2", now.Add(-2*time.Hour))
+	running := start("3", now.Add(-time.Minute))
+	for _, update := range []bus.Update{abandoned, finished, done(finished, now.Add(-time.Hour), 3), stuck, running} {
+		tracker.track(update)
+	}
+
+	stuckSyncs := tracker.Stuck()
+	if len(stuckSyncs) != 1 || stuckSyncs[0].SyncID != stuck.SyncID {
+		t.Errorf("Expected only the sync of account 2 to be stuck, got %+v", stuckSyncs)
+	}
+	if tracker.CheckHealth() == nil {
+		t.Errorf("Expected the tracker to be unhealthy with a stuck sync")
+	}
+}
+
+func Test_Tracker_Start_ConsumesUpdatesFromBus(t *testing.T) {
+	updates := bus.NewInMemoryBus()
+	tracker := NewTracker(updates, DefaultOptions)
+	tracked := make(chan struct{})
+	go func() {
+		tracker.Start()
+		close(tracked)
+	}()
+
+	update := start("1", now)
+	updates.PublishUpdate(context.Background(), update)
+	updates.Close()
+	<-tracked
+
+	if syncs := tracker.SyncsOf(userID); len(syncs) != 1 || syncs[0].Syncs[0].SyncID != update.SyncID {
+		t.Errorf("Expected the sync to be tracked, got %+v", syncs)
+	}
+}
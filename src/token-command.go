@@ -0,0 +1,58 @@
+package main
+
+import (
+	"app/auth"
+	"app/primitives"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// authKeyFromEnv reads the key that tokens are signed with from AUTH_KEY, as hex of at least 32 bytes
+func authKeyFromEnv() ([]byte, error) {
+	encoded, ok := os.LookupEnv("AUTH_KEY")
+	if !ok {
+		return nil, fmt.Errorf("AUTH_KEY is needed to sign and verify tokens, generate one with: openssl rand -hex 32")
+	}
+	key, err := hex.DecodeString(encoded)
+	if err != nil || len(key) < 32 {
+		return nil, fmt.Errorf("AUTH_KEY should be the hex of at least 32 bytes")
+	}
+	return key, nil
+}
+
+// runTokenCommand prints a token for a user, signed with the key in AUTH_KEY
+func runTokenCommand(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	userID := flags.String("user", "", "id of the user to create a token for")
+	validity := flags.Duration("valid", 24*time.Hour, "how long the token is valid")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(*userID)
+	if err != nil {
+		return fmt.Errorf("a user is needed to create a token")
+	}
+
+	key, err := authKeyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(auth.NewSigner(key).Sign(primitives.UserID(id), time.Now().Add(*validity)))
+	return nil
+}
+
+// authorized adds the token in AUTH_TOKEN to a request to a running server
+func authorized(req *http.Request) *http.Request {
+	if token := os.Getenv("AUTH_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
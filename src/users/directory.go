@@ -0,0 +1,108 @@
+package users
+
+import (
+	"app/primitives"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+)
+
+// User is the read model of a signed up user
+type User struct {
+	ID       primitives.UserID
+	Profile  Profile
+	Status   Status
+	SignedUp time.Time
+}
+
+// Directory keeps the signed up users, to look them up by id or email
+type Directory struct {
+	mu    *sync.RWMutex
+	users map[primitives.UserID]User
+}
+
+// NewDirectory creates an empty Directory
+func NewDirectory() Directory {
+	res := new(Directory)
+	res.mu = new(sync.RWMutex)
+	res.users = make(map[primitives.UserID]User)
+	return *res
+}
+
+// Matcher matches all events of users
+func (directory Directory) Matcher() eh.EventMatcher {
+	return eh.MatchAnyEventOf(
+		EhUserSignedUp,
+		EhUserProfileUpdated,
+		EhUserDeactivated,
+		EhUserReactivated,
+	)
+}
+
+// HandlerType implements the HandlerType method of the eventhorizon.EventHandler interface.
+func (directory Directory) HandlerType() eh.EventHandlerType {
+	return "user-directory"
+}
+
+// HandleEvent implements the HandleEvent method of the eventhorizon.EventHandler interface.
+func (directory Directory) HandleEvent(ctx context.Context, event eh.Event) error {
+	directory.mu.Lock()
+	defer directory.mu.Unlock()
+
+	switch data := event.Data().(type) {
+	case *UserSignedUp:
+		directory.users[data.ID] = User{
+			ID:       data.ID,
+			Profile:  Profile{Name: data.Name, Email: data.Email},
+			Status:   Active,
+			SignedUp: data.SignedUp,
+		}
+	case *UserProfileUpdated:
+		if user, ok := directory.users[data.ID]; ok {
+			user.Profile = Profile{Name: data.Name, Email: data.Email}
+			directory.users[data.ID] = user
+		}
+	case *UserDeactivated:
+		directory.setStatus(data.ID, Deactivated)
+	case *UserReactivated:
+		directory.setStatus(data.ID, Active)
+	}
+	return nil
+}
+
+func (directory Directory) setStatus(userID primitives.UserID, status Status) {
+	if user, ok := directory.users[userID]; ok {
+		user.Status = status
+		directory.users[userID] = user
+	}
+}
+
+// ActiveUserIDs are the ids of the users that are signed up and not deactivated
+func (directory Directory) ActiveUserIDs() []primitives.UserID {
+	directory.mu.RLock()
+	defer directory.mu.RUnlock()
+
+	var res []primitives.UserID
+	for userID, user := range directory.users {
+		if user.Status == Active {
+			res = append(res, userID)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return uuid.UUID(res[i]).String() < uuid.UUID(res[j]).String()
+	})
+	return res
+}
+
+// UserOf returns the signed up user
+func (directory Directory) UserOf(userID primitives.UserID) (User, bool) {
+	directory.mu.RLock()
+	defer directory.mu.RUnlock()
+
+	user, ok := directory.users[userID]
+	return user, ok
+}
@@ -0,0 +1,207 @@
+package users
+
+import (
+	"app/primitives"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status of a user, only active users are refreshed
+type Status string
+
+// Status enum
+const (
+	Active      Status = "Active"
+	Deactivated Status = "Deactivated"
+)
+
+// maxNameLength is the longest name a user can have
+const maxNameLength = 100
+
+// Profile is what a user tells about itself
+type Profile struct {
+	Name  string
+	Email string
+}
+
+// NormalisedEmail is the email address that invitations are matched on
+func NormalisedEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateProfile(name string, email string) error {
+	if len(name) > maxNameLength {
+		return primitives.NewValidationError("Name", fmt.Sprintf("longer than %d characters", maxNameLength))
+	}
+	at := strings.Index(email, "@")
+	if at <= 0 || at != strings.LastIndex(email, "@") || at == len(email)-1 || strings.ContainsAny(email, " \t\n") {
+		return primitives.NewValidationError("Email", fmt.Sprintf("%s is not an email address", email))
+	}
+	return nil
+}
+
+type userState struct {
+	ID          primitives.UserID
+	initialized bool
+	profile     Profile
+	status      Status
+}
+
+func (state *userState) copy() *userState {
+	res := *state
+	return &res
+}
+
+type UserEvent interface {
+	appliedTo(state *userState) *userState
+}
+
+type UserCommand interface {
+	applyTo(state *userState) ([]UserEvent, error)
+}
+
+// SignUpCommand signs up the user with its profile
+type SignUpCommand struct {
+	UserID   primitives.UserID
+	Name     string
+	Email    string
+	SignedUp time.Time
+}
+
+func (cmd SignUpCommand) applyTo(state *userState) ([]UserEvent, error) {
+	if state != nil && state.initialized {
+		return nil, primitives.NewValidationError("UserID", "already signed up")
+	}
+	if err := validateProfile(cmd.Name, cmd.Email); err != nil {
+		return nil, err
+	}
+	return []UserEvent{newUserSignedUp(cmd)}, nil
+}
+
+// UpdateProfileCommand changes the profile of a signed up user
+type UpdateProfileCommand struct {
+	UserID primitives.UserID
+	Name   string
+	Email  string
+}
+
+func (cmd UpdateProfileCommand) applyTo(state *userState) ([]UserEvent, error) {
+	if state == nil || !state.initialized {
+		return nil, primitives.NewValidationError("UserID", "not signed up")
+	}
+	if err := validateProfile(cmd.Name, cmd.Email); err != nil {
+		return nil, err
+	}
+	if state.profile == (Profile{Name: cmd.Name, Email: cmd.Email}) {
+		return nil, nil
+	}
+	return []UserEvent{newUserProfileUpdated(cmd)}, nil
+}
+
+// DeactivateUserCommand deactivates a user, the connections of the user are no longer refreshed
+type DeactivateUserCommand struct {
+	UserID primitives.UserID
+}
+
+func (cmd DeactivateUserCommand) applyTo(state *userState) ([]UserEvent, error) {
+	if state == nil || !state.initialized {
+		return nil, primitives.NewValidationError("UserID", "not signed up")
+	}
+	if state.status == Deactivated {
+		return nil, nil
+	}
+	return []UserEvent{newUserDeactivated(cmd.UserID)}, nil
+}
+
+// ReactivateUserCommand activates a deactivated user again
+type ReactivateUserCommand struct {
+	UserID primitives.UserID
+}
+
+func (cmd ReactivateUserCommand) applyTo(state *userState) ([]UserEvent, error) {
+	if state == nil || !state.initialized {
+		return nil, primitives.NewValidationError("UserID", "not signed up")
+	}
+	if state.status == Active {
+		return nil, nil
+	}
+	return []UserEvent{newUserReactivated(cmd.UserID)}, nil
+}
+
+type UserSignedUp struct {
+	ID       primitives.UserID
+	Name     string
+	Email    string
+	SignedUp time.Time
+}
+
+func newUserSignedUp(cmd SignUpCommand) UserSignedUp {
+	res := new(UserSignedUp)
+	res.ID = cmd.UserID
+	res.Name = cmd.Name
+	res.Email = cmd.Email
+	res.SignedUp = cmd.SignedUp
+	return *res
+}
+
+func (event UserSignedUp) appliedTo(state *userState) *userState {
+	res := new(userState)
+	res.ID = event.ID
+	res.initialized = true
+	res.profile = Profile{Name: event.Name, Email: event.Email}
+	res.status = Active
+	return res
+}
+
+type UserProfileUpdated struct {
+	ID    primitives.UserID
+	Name  string
+	Email string
+}
+
+func newUserProfileUpdated(cmd UpdateProfileCommand) UserProfileUpdated {
+	res := new(UserProfileUpdated)
+	res.ID = cmd.UserID
+	res.Name = cmd.Name
+	res.Email = cmd.Email
+	return *res
+}
+
+func (event UserProfileUpdated) appliedTo(state *userState) *userState {
+	res := state.copy()
+	res.profile = Profile{Name: event.Name, Email: event.Email}
+	return res
+}
+
+type UserDeactivated struct {
+	ID primitives.UserID
+}
+
+func newUserDeactivated(id primitives.UserID) UserDeactivated {
+	res := new(UserDeactivated)
+	res.ID = id
+	return *res
+}
+
+func (event UserDeactivated) appliedTo(state *userState) *userState {
+	res := state.copy()
+	res.status = Deactivated
+	return res
+}
+
+type UserReactivated struct {
+	ID primitives.UserID
+}
+
+func newUserReactivated(id primitives.UserID) UserReactivated {
+	res := new(UserReactivated)
+	res.ID = id
+	return *res
+}
+
+func (event UserReactivated) appliedTo(state *userState) *userState {
+	res := state.copy()
+	res.status = Active
+	return res
+}
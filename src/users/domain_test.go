@@ -0,0 +1,48 @@
+package users
+
+import (
+	"app/primitives"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var userID = primitives.UserID(uuid.New())
+
+func signedUp() *userState {
+	return UserSignedUp{ID: userID, Name: "Sam", Email: "sam@example.com", SignedUp: time.Now()}.appliedTo(nil)
+}
+
+func Test_SignUpCommand_ValidatesProfile(t *testing.T) {
+	for _, email := range []string{"", "sam", "@example.com", "sam@", "sam@@example.com", "sam @example.com"} {
+		cmd := SignUpCommand{UserID: userID, Name: "Sam", Email: email, SignedUp: time.Now()}
+		if _, err := cmd.applyTo(nil); err == nil {
+			t.Errorf("Expected %q to be rejected as email", email)
+		}
+	}
+
+	cmd := SignUpCommand{UserID: userID, Name: "Sam", Email: "sam@example.com", SignedUp: time.Now()}
+	events, err := cmd.applyTo(nil)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected the user to sign up, got %v %v", events, err)
+	}
+	if _, err := cmd.applyTo(events[0].appliedTo(nil)); err == nil {
+		t.Errorf("Expected signing up twice to be rejected")
+	}
+}
+
+func Test_DeactivateUserCommand_Deactivates(t *testing.T) {
+	events, err := DeactivateUserCommand{UserID: userID}.applyTo(signedUp())
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected the user to be deactivated, got %v %v", events, err)
+	}
+
+	state := events[0].appliedTo(signedUp())
+	if state.status != Deactivated || state.profile.Name != "Sam" {
+		t.Errorf("Expected only the status to change, got %+v", state)
+	}
+	if events, _ := (DeactivateUserCommand{UserID: userID}).applyTo(state); len(events) != 0 {
+		t.Errorf("Expected deactivating twice to be ignored, got %v", events)
+	}
+}
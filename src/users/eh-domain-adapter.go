@@ -0,0 +1,211 @@
+package users
+
+import (
+	"app/utils"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	eh "github.com/looplab/eventhorizon"
+	"github.com/looplab/eventhorizon/aggregatestore/events"
+	"github.com/looplab/eventhorizon/commandhandler/aggregate"
+)
+
+func SetupDomain(
+	eventStore eh.EventStore,
+	eventBus eh.EventBus,
+) (eh.CommandHandler, error) {
+	aggregateStore, err := events.NewAggregateStore(eventStore, eventBus)
+	if err != nil {
+		return nil, fmt.Errorf("could not create aggregate store: %w", err)
+	}
+
+	commandHandler, err := aggregate.NewCommandHandler(UserAggregateType, aggregateStore)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command handler: %w", err)
+	}
+
+	return commandHandler, nil
+}
+
+// UserAggregateType is the aggregate type for the user
+const UserAggregateType = eh.AggregateType("user")
+
+// Aggregate is an aggregate for a user
+type Aggregate struct {
+	*events.AggregateBase
+	*userState
+}
+
+const EhSignUpCommand = eh.CommandType("user:sign-up")
+const EhUpdateProfileCommand = eh.CommandType("user:update-profile")
+const EhDeactivateUserCommand = eh.CommandType("user:deactivate")
+const EhReactivateUserCommand = eh.CommandType("user:reactivate")
+
+const EhUserSignedUp = eh.EventType("user:signed-up")
+const EhUserProfileUpdated = eh.EventType("user:profile-updated")
+const EhUserDeactivated = eh.EventType("user:deactivated")
+const EhUserReactivated = eh.EventType("user:reactivated")
+
+// CommandTypes are all command types handled by the user aggregate
+func CommandTypes() []eh.CommandType {
+	return []eh.CommandType{
+		EhSignUpCommand,
+		EhUpdateProfileCommand,
+		EhDeactivateUserCommand,
+		EhReactivateUserCommand,
+	}
+}
+
+func (cmd SignUpCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd SignUpCommand) AggregateType() eh.AggregateType {
+	return UserAggregateType
+}
+
+func (cmd SignUpCommand) CommandType() eh.CommandType {
+	return EhSignUpCommand
+}
+
+func (cmd UpdateProfileCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd UpdateProfileCommand) AggregateType() eh.AggregateType {
+	return UserAggregateType
+}
+
+func (cmd UpdateProfileCommand) CommandType() eh.CommandType {
+	return EhUpdateProfileCommand
+}
+
+func (cmd DeactivateUserCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd DeactivateUserCommand) AggregateType() eh.AggregateType {
+	return UserAggregateType
+}
+
+func (cmd DeactivateUserCommand) CommandType() eh.CommandType {
+	return EhDeactivateUserCommand
+}
+
+func (cmd ReactivateUserCommand) AggregateID() uuid.UUID {
+	return uuid.UUID(cmd.UserID)
+}
+
+func (cmd ReactivateUserCommand) AggregateType() eh.AggregateType {
+	return UserAggregateType
+}
+
+func (cmd ReactivateUserCommand) CommandType() eh.CommandType {
+	return EhReactivateUserCommand
+}
+
+func init() {
+	eh.RegisterAggregate(func(id uuid.UUID) eh.Aggregate {
+		return &Aggregate{
+			AggregateBase: events.NewAggregateBase(UserAggregateType, id),
+		}
+	})
+
+	eh.RegisterEventData(EhUserSignedUp, func() eh.EventData {
+		return &UserSignedUp{}
+	})
+
+	eh.RegisterEventData(EhUserProfileUpdated, func() eh.EventData {
+		return &UserProfileUpdated{}
+	})
+
+	eh.RegisterEventData(EhUserDeactivated, func() eh.EventData {
+		return &UserDeactivated{}
+	})
+
+	eh.RegisterEventData(EhUserReactivated, func() eh.EventData {
+		return &UserReactivated{}
+	})
+}
+
+// HandleCommand implements the HandleCommand method of the eventhorizon.CommandHandler interface.
+func (a *Aggregate) HandleCommand(ctx context.Context, cmd eh.Command) error {
+	domainCommand, err := mapToDomainCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	events, err := domainCommand.applyTo(a.userState)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventType, err := mapToEhEventType(event)
+		if err != nil {
+			log.Printf("Could not map event, %s", err)
+		} else {
+			a.AppendEvent(eventType, event, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// ApplyEvent implements the ApplyEvent method of the eventhorizon.Aggregate interface.
+func (a *Aggregate) ApplyEvent(ctx context.Context, event eh.Event) error {
+	eventInDomain, err := mapToDomainEvent(event)
+	if err != nil {
+		return fmt.Errorf("unable to understand evnt %v", event)
+	}
+	a.userState = eventInDomain.appliedTo(a.userState)
+	return nil
+}
+
+func mapToDomainEvent(event eh.Event) (UserEvent, error) {
+	switch event.EventType() {
+	case EhUserSignedUp:
+		return event.Data().(UserEvent), nil
+	case EhUserProfileUpdated:
+		return event.Data().(UserEvent), nil
+	case EhUserDeactivated:
+		return event.Data().(UserEvent), nil
+	case EhUserReactivated:
+		return event.Data().(UserEvent), nil
+	default:
+		return nil, fmt.Errorf("unable to understand evnt %v", event)
+	}
+}
+
+func mapToEhEventType(event UserEvent) (eh.EventType, error) {
+	switch event.(type) {
+	case UserSignedUp:
+		return EhUserSignedUp, nil
+	case UserProfileUpdated:
+		return EhUserProfileUpdated, nil
+	case UserDeactivated:
+		return EhUserDeactivated, nil
+	case UserReactivated:
+		return EhUserReactivated, nil
+	}
+	return "", fmt.Errorf("Could not understand event of type %s", utils.TypeNameOf(event))
+}
+
+func mapToDomainCommand(cmd eh.Command) (UserCommand, error) {
+	switch cmd := cmd.(type) {
+	case SignUpCommand:
+		return cmd, nil
+	case UpdateProfileCommand:
+		return cmd, nil
+	case DeactivateUserCommand:
+		return cmd, nil
+	case ReactivateUserCommand:
+		return cmd, nil
+
+	default:
+		return nil, fmt.Errorf("Could not understand command of type %s", utils.TypeNameOf(cmd))
+	}
+}